@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/0x2e/fusion/pkg/logctx"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newAdminACL restricts management, that is the settings and any change, to
+// clients in allowed. Other clients can still log in and read. Without
+// allowed, everyone can manage.
+func newAdminACL(allowed []*net.IPNet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if len(allowed) == 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			if !isAdminRequest(c.Request()) {
+				return next(c)
+			}
+			ip := net.ParseIP(c.RealIP())
+			for _, n := range allowed {
+				if ip != nil && n.Contains(ip) {
+					return next(c)
+				}
+			}
+			logctx.From(c.Request().Context()).Warn("denied management request from a disallowed network")
+			return echo.NewHTTPError(http.StatusForbidden, "Management is not allowed from this network")
+		}
+	}
+}
+
+func isAdminRequest(req *http.Request) bool {
+	path := req.URL.Path
+	if path == "/settings" || strings.HasPrefix(path, "/settings/") ||
+		path == "/api/system" || strings.HasPrefix(path, "/api/system/") ||
+		path == "/api/jobs" || strings.HasPrefix(path, "/api/jobs/") {
+		return true
+	}
+	return isWriteRequest(req)
+}
+
+// isWriteRequest reports whether req is an API call changing anything.
+// Logging in and out doesn't count.
+func isWriteRequest(req *http.Request) bool {
+	path := req.URL.Path
+	if !strings.HasPrefix(path, "/api/") || path == "/api/sessions" {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminACL(t *testing.T) {
+	_, lan, err := net.ParseCIDR("192.168.1.0/24")
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		description    string
+		allowed        []*net.IPNet
+		method         string
+		path           string
+		remoteAddr     string
+		expectedStatus int
+	}{
+		{
+			description:    "no ACL allows everyone",
+			method:         http.MethodDelete,
+			path:           "/api/feeds/1",
+			remoteAddr:     "198.51.100.7:1234",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "reads are allowed from anywhere",
+			allowed:        []*net.IPNet{lan},
+			method:         http.MethodGet,
+			path:           "/api/items",
+			remoteAddr:     "198.51.100.7:1234",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "login is allowed from anywhere",
+			allowed:        []*net.IPNet{lan},
+			method:         http.MethodPost,
+			path:           "/api/sessions",
+			remoteAddr:     "198.51.100.7:1234",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "writes are denied outside the allowed networks",
+			allowed:        []*net.IPNet{lan},
+			method:         http.MethodDelete,
+			path:           "/api/feeds/1",
+			remoteAddr:     "198.51.100.7:1234",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "settings are denied outside the allowed networks",
+			allowed:        []*net.IPNet{lan},
+			method:         http.MethodGet,
+			path:           "/settings/system",
+			remoteAddr:     "198.51.100.7:1234",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "system status is denied outside the allowed networks",
+			allowed:        []*net.IPNet{lan},
+			method:         http.MethodGet,
+			path:           "/api/system",
+			remoteAddr:     "198.51.100.7:1234",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "jobs are denied outside the allowed networks",
+			allowed:        []*net.IPNet{lan},
+			method:         http.MethodGet,
+			path:           "/api/jobs",
+			remoteAddr:     "198.51.100.7:1234",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "writes are allowed from the allowed networks",
+			allowed:        []*net.IPNet{lan},
+			method:         http.MethodDelete,
+			path:           "/api/feeds/1",
+			remoteAddr:     "192.168.1.20:1234",
+			expectedStatus: http.StatusOK,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			r := echo.New()
+			r.IPExtractor = echo.ExtractIPDirect()
+			r.Use(newAdminACL(tt.allowed))
+			r.Any("/*", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
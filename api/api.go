@@ -4,20 +4,28 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"reflect"
 	"strings"
-	"time"
 
 	"github.com/0x2e/fusion/auth"
 	"github.com/0x2e/fusion/conf"
 	"github.com/0x2e/fusion/frontend"
+	"github.com/0x2e/fusion/pkg/blob"
+	"github.com/0x2e/fusion/pkg/logctx"
 	"github.com/0x2e/fusion/repo"
 	"github.com/0x2e/fusion/server"
+	"github.com/0x2e/fusion/service/job"
+	"github.com/0x2e/fusion/service/opml"
+	"github.com/0x2e/fusion/service/pull"
+	"github.com/0x2e/fusion/service/pull/client"
 
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	en_translations "github.com/go-playground/validator/v10/translations/en"
+	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
 	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
@@ -31,9 +39,62 @@ type Params struct {
 	UseSecureCookie bool
 	TLSCert         string
 	TLSKey          string
+	// AutoTLS params get certificates from Let's Encrypt instead, see
+	// conf.Conf.
+	AutoTLSDomains  []string
+	AutoTLSCacheDir string
+	AutoTLSEmail    string
+	AutoTLSHTTPPort int
+	// TrustedProxies are believed about the client IP, see conf.Conf.
+	TrustedProxies []*net.IPNet
+	// AdminAllowedIPs restrict management, see conf.Conf.
+	AdminAllowedIPs []*net.IPNet
+	ReadOnly        bool
+	PageSize        int
+	// LoginChallengeAfter is the number of failed logins after which a client
+	// must solve challenges, see conf.Conf.
+	LoginChallengeAfter int
+	// LoginChallengeDifficulty is the number of leading zero bits of the
+	// solutions, see conf.Conf. 0 uses the default.
+	LoginChallengeDifficulty int
+	// FaviconHosts are told to the frontend, see conf.Conf.
+	FaviconHosts map[string]string
+	// Blob keeps the cached favicons. Nil leaves out the favicon API.
+	Blob blob.Store
+	// FlareSolverrURL is where blocked requests are retried, see conf.Conf.
+	FlareSolverrURL string
+	// DB is the path of the database file, and Settings the configuration,
+	// both shown on the system status page.
+	DB       string
+	Settings []conf.Setting
+	// SessionSecrets sign the session cookies, see auth.SessionKeys. Without
+	// any, a random secret is used, and sessions end with the process.
+	SessionSecrets [][]byte
 }
 
 func Run(params Params) {
+	r := NewServer(params)
+
+	var err error
+	addr := fmt.Sprintf("%s:%d", params.Host, params.Port)
+	slog.Info("starting http server", "addr", addr, "tls", params.TLSCert != "" || len(params.AutoTLSDomains) > 0)
+	switch {
+	case len(params.AutoTLSDomains) > 0:
+		err = startAutoTLS(r, addr, params)
+	case params.TLSCert != "":
+		err = r.StartTLS(addr, params.TLSCert, params.TLSKey)
+	default:
+		err = r.Start(addr)
+	}
+	if err != nil {
+		slog.Error(err.Error())
+		return
+	}
+}
+
+// NewServer builds the HTTP handler serving both the API and the frontend,
+// backed by repo.DB. Host, Port and the TLS params are only used by Run.
+func NewServer(params Params) *echo.Echo {
 	r := echo.New()
 
 	if conf.Debug {
@@ -47,33 +108,46 @@ func Run(params Params) {
 	}
 
 	r.HideBanner = true
+	r.HidePort = true
+	useSlog(r)
+	r.IPExtractor = newIPExtractor(params.TrustedProxies)
 	r.HTTPErrorHandler = errorHandler
 	r.Validator = newCustomValidator()
-	r.Use(middleware.Recover())
+	r.Use(recoverPanics)
+	r.Use(middleware.RequestID())
+	r.Use(withRequestLogger)
 	r.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 		LogStatus:   true,
 		LogURI:      true,
+		LogMethod:   true,
 		LogError:    true,
 		HandleError: true, // forwards error to the global error handler, so it can decide appropriate status code
 		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
 			if !strings.HasPrefix(v.URI, "/api") {
 				return nil
 			}
+			logger := logctx.From(c.Request().Context())
 			if v.Error == nil {
-				slog.Info("REQUEST", "uri", v.URI, "status", v.Status)
+				logger.Info("REQUEST", "method", v.Method, "uri", v.URI, "status", v.Status)
 			} else {
-				slog.Error(v.Error.Error(), "uri", v.URI, "status", v.Status)
+				logger.Error(v.Error.Error(), "method", v.Method, "uri", v.URI, "status", v.Status)
 			}
 			return nil
 		},
 	}))
-	r.Use(middleware.TimeoutWithConfig(middleware.TimeoutConfig{
-		Timeout: 30 * time.Second,
-	}))
+	r.Use(newTimeoutMiddleware(defaultRequestTimeout, routeTimeouts))
 	if params.PasswordHash != nil {
-		r.Use(session.Middleware(sessions.NewCookieStore(params.PasswordHash.Bytes())))
+		secrets := params.SessionSecrets
+		if len(secrets) == 0 {
+			secrets = [][]byte{securecookie.GenerateRandomKey(32)}
+		}
+		r.Use(session.Middleware(sessions.NewCookieStore(auth.SessionKeys(secrets)...)))
 	}
 	r.Pre(middleware.RemoveTrailingSlash())
+	r.Use(newAdminACL(params.AdminAllowedIPs))
+	if params.ReadOnly {
+		r.Use(rejectWrites)
+	}
 	r.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			if strings.HasPrefix(c.Request().URL.Path, "/_app/") {
@@ -83,6 +157,10 @@ func Run(params Params) {
 		}
 	})
 	r.Use(middleware.StaticWithConfig(middleware.StaticConfig{
+		// API clients expect a JSON 404 rather than the frontend's index.html.
+		Skipper: func(c echo.Context) bool {
+			return strings.HasPrefix(c.Request().URL.Path, "/api/")
+		},
 		HTML5:      true,
 		Index:      "index.html",
 		Filesystem: http.FS(frontend.Content),
@@ -95,8 +173,11 @@ func Run(params Params) {
 		loginAPI := Session{
 			PasswordHash:    *params.PasswordHash,
 			UseSecureCookie: params.UseSecureCookie,
+			Versions:        repo.NewAuthState(repo.DB),
+			Guard:           newLoginGuard(params.LoginChallengeAfter, params.LoginChallengeDifficulty),
 		}
 		r.POST("/api/sessions", loginAPI.Create)
+		r.GET("/api/sessions/challenge", loginAPI.Challenge)
 
 		authed.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 			return func(c echo.Context) error {
@@ -108,58 +189,169 @@ func Run(params Params) {
 		})
 
 		authed.DELETE("/sessions", loginAPI.Delete)
+		authed.DELETE("/sessions/all", loginAPI.DeleteAll)
 	}
 
+	// The public groups are browsable without logging in.
+	publicAPIHandler := newPublicAPI(server.NewPublic(repo.NewGroup(repo.DB), repo.NewItem(repo.DB), params.PageSize))
+	r.GET("/api/public/groups", publicAPIHandler.Groups)
+	r.GET("/api/public/groups/:id/items", publicAPIHandler.ListItems)
+	r.GET("/api/public/items/:id", publicAPIHandler.GetItem)
+
+	searchFeedAPIHandler := newSearchFeedAPI(server.NewSearchFeed(repo.NewItem(repo.DB), params.PasswordHash))
+	r.GET("/api/search-feed", searchFeedAPIHandler.Get)
+	authed.GET("/search-feed/link", searchFeedAPIHandler.Link)
+
+	metricsAPIHandler := newMetricsAPI(server.NewMetrics(repo.NewFeed(repo.DB), params.PasswordHash))
+	r.GET("/api/metrics", metricsAPIHandler.Get)
+	authed.GET("/metrics/link", metricsAPIHandler.Link)
+
+	noteAPIHandler := newNoteAPI(server.NewNote(repo.NewFeed(repo.DB), repo.NewItem(repo.DB), params.PasswordHash))
+	r.POST("/api/notes", noteAPIHandler.Create)
+	authed.GET("/notes/link", noteAPIHandler.Link)
+
+	// Jobs are only enqueued here, and run by the queue started with the
+	// server.
+	jobQueue := job.NewQueue(repo.NewJob(repo.DB))
+
 	feeds := authed.Group("/feeds")
-	feedAPIHandler := newFeedAPI(server.NewFeed(repo.NewFeed(repo.DB)))
+	feedClient := client.NewFeedClient(params.FlareSolverrURL)
+	feedAPIHandler := newFeedAPI(server.NewFeed(
+		repo.NewFeed(repo.DB),
+		repo.NewGroup(repo.DB),
+		repo.NewItem(repo.DB),
+		pull.NewPuller(repo.NewFeed(repo.DB), repo.NewItem(repo.DB), feedClient),
+		jobQueue,
+		feedClient,
+	))
 	feeds.GET("", feedAPIHandler.List)
 	feeds.GET("/:id", feedAPIHandler.Get)
 	feeds.POST("", feedAPIHandler.Create)
+	feeds.POST("/bulk", feedAPIHandler.BulkCreate)
 	feeds.POST("/validation", feedAPIHandler.CheckValidity)
 	feeds.PATCH("/:id", feedAPIHandler.Update)
+	feeds.PUT("/:id/settings", feedAPIHandler.UpdateSettings)
 	feeds.DELETE("/:id", feedAPIHandler.Delete)
+	feeds.POST("/:id/merge", feedAPIHandler.Merge)
+	feeds.POST("/:id/dedupe", feedAPIHandler.Dedupe)
 	feeds.POST("/refresh", feedAPIHandler.Refresh)
+	feeds.GET("/refresh", feedAPIHandler.RefreshStatus)
 
 	groups := authed.Group("/groups")
 	groupAPIHandler := newGroupAPI(server.NewGroup(repo.NewGroup(repo.DB)))
 	groups.GET("", groupAPIHandler.All)
 	groups.POST("", groupAPIHandler.Create)
 	groups.PATCH("/:id", groupAPIHandler.Update)
+	groups.PUT("/:id/settings", groupAPIHandler.UpdateSettings)
 	groups.DELETE("/:id", groupAPIHandler.Delete)
+	groups.GET("/rules", groupAPIHandler.AllRules)
+	groups.POST("/rules", groupAPIHandler.CreateRule)
+	groups.DELETE("/rules/:id", groupAPIHandler.DeleteRule)
 
 	items := authed.Group("/items")
-	itemAPIHandler := newItemAPI(server.NewItem(repo.NewItem(repo.DB)))
+	itemAPIHandler := newItemAPI(server.NewItem(repo.NewItem(repo.DB), repo.NewScoreKeyword(repo.DB), params.PageSize))
 	items.GET("", itemAPIHandler.List)
+	items.GET("/highlights", itemAPIHandler.Highlights)
+	items.GET("/surprise", itemAPIHandler.Surprise)
+	items.GET("/digest", itemAPIHandler.Digest)
 	items.GET("/:id", itemAPIHandler.Get)
+	items.POST("/:id/open", itemAPIHandler.Open)
 	items.PATCH("/:id/bookmark", itemAPIHandler.UpdateBookmark)
+	// POST rather than PATCH, as it's sent with navigator.sendBeacon.
+	items.POST("/:id/playback", itemAPIHandler.UpdatePlayback)
+	items.POST("/:id/unread/toggle", itemAPIHandler.ToggleUnread)
+	items.POST("/:id/bookmark/toggle", itemAPIHandler.ToggleBookmark)
 	items.PATCH("/-/unread", itemAPIHandler.UpdateUnread)
+	items.PATCH("/-/bookmark", itemAPIHandler.BatchUpdateBookmark)
+	items.PATCH("/-/hidden", itemAPIHandler.BatchUpdateHidden)
 	items.DELETE("/:id", itemAPIHandler.Delete)
+	items.DELETE("/-", itemAPIHandler.BatchDelete)
 
-	var err error
-	addr := fmt.Sprintf("%s:%d", params.Host, params.Port)
-	if params.TLSCert != "" {
-		err = r.StartTLS(addr, params.TLSCert, params.TLSKey)
-	} else {
-		err = r.Start(addr)
-	}
-	if err != nil {
-		slog.Error(err.Error())
-		return
+	scoreKeywords := authed.Group("/score-keywords")
+	scoreKeywordAPIHandler := newScoreKeywordAPI(server.NewScoreKeyword(repo.NewScoreKeyword(repo.DB)))
+	scoreKeywords.GET("", scoreKeywordAPIHandler.All)
+	scoreKeywords.POST("", scoreKeywordAPIHandler.Create)
+	scoreKeywords.DELETE("/:id", scoreKeywordAPIHandler.Delete)
+
+	bridges := authed.Group("/bridges")
+	bridgeAPIHandler := newBridgeAPI(server.NewBridge(repo.NewBridge(repo.DB)))
+	bridges.GET("", bridgeAPIHandler.All)
+	bridges.POST("", bridgeAPIHandler.Create)
+	bridges.DELETE("/:id", bridgeAPIHandler.Delete)
+
+	if params.Blob != nil {
+		faviconAPIHandler := newFaviconAPI(server.NewFavicon(params.Blob, repo.NewFeed(repo.DB), params.FaviconHosts))
+		authed.GET("/favicons/:host", faviconAPIHandler.Get)
 	}
+
+	authed.GET("/events", streamEvents)
+	authed.GET("/config", newConfigAPI(params).Get)
+
+	discoverAPIHandler := newDiscoverAPI(server.NewDiscover(repo.NewFeed(repo.DB)))
+	authed.GET("/discover", discoverAPIHandler.Get)
+
+	opmlSubscriptions := authed.Group("/opml-subscriptions")
+	opmlSubscriptionAPIHandler := newOPMLSubscriptionAPI(server.NewOPMLSubscription(
+		repo.NewOPMLSubscription(repo.DB),
+		repo.NewGroup(repo.DB),
+		opml.NewSyncer(repo.NewOPMLSubscription(repo.DB), repo.NewFeed(repo.DB), repo.NewGroup(repo.DB)),
+		jobQueue,
+	))
+	opmlSubscriptions.GET("", opmlSubscriptionAPIHandler.List)
+	opmlSubscriptions.POST("", opmlSubscriptionAPIHandler.Create)
+	opmlSubscriptions.DELETE("/:id", opmlSubscriptionAPIHandler.Delete)
+	opmlSubscriptions.POST("/:id/sync", opmlSubscriptionAPIHandler.Sync)
+
+	system := authed.Group("/system")
+	systemAPIHandler := newSystemAPI(server.NewSystem(repo.NewFeed(repo.DB), repo.NewItem(repo.DB), repo.NewSchema(repo.DB), server.SystemParams{
+		DBPath:           params.DB,
+		Settings:         params.Settings,
+		LatestReleaseURL: server.LatestReleaseURL,
+	}))
+	system.GET("", systemAPIHandler.Get)
+	system.GET("/latest-release", systemAPIHandler.LatestRelease)
+	integrityAPIHandler := newIntegrityAPI(server.NewIntegrity(repo.NewIntegrity(repo.DB)))
+	system.GET("/integrity", integrityAPIHandler.Check)
+	system.POST("/integrity", integrityAPIHandler.Repair)
+
+	jobs := authed.Group("/jobs")
+	jobAPIHandler := newJobAPI(server.NewJob(repo.NewJob(repo.DB)))
+	jobs.GET("", jobAPIHandler.List)
+	jobs.POST("/:id/retry", jobAPIHandler.Retry)
+	jobs.DELETE("/:id", jobAPIHandler.Delete)
+
+	return r
 }
 
 func errorHandler(err error, c echo.Context) {
 	if errors.Is(err, repo.ErrNotFound) {
 		err = echo.NewHTTPError(http.StatusNotFound, "Resource not exists")
+	} else if errors.Is(err, repo.ErrBusy) {
+		err = echo.NewHTTPError(http.StatusServiceUnavailable, "Database is busy, please try again")
 	} else {
 		if bizerr, ok := err.(server.BizError); ok {
-			err = echo.NewHTTPError(int(bizerr.HTTPCode), bizerr.FEMessage)
+			if bizerr.Field != "" {
+				err = echo.NewHTTPError(int(bizerr.HTTPCode), validationError{
+					Message: bizerr.FEMessage,
+					Fields:  map[string]string{bizerr.Field: bizerr.FEMessage},
+				})
+			} else {
+				err = echo.NewHTTPError(int(bizerr.HTTPCode), bizerr.FEMessage)
+			}
 		}
 	}
 
 	c.Echo().DefaultHTTPErrorHandler(err, c)
 }
 
+// validationError is the body of responses to invalid requests. Fields maps
+// the JSON name of each invalid field to its error message. Nested fields are
+// named like "feeds[0].link".
+type validationError struct {
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields"`
+}
+
 type CustomValidator struct {
 	handler *validator.Validate
 	trans   ut.Translator
@@ -170,6 +362,19 @@ func newCustomValidator() *CustomValidator {
 	uni := ut.New(en, en)
 	trans, _ := uni.GetTranslator("en")
 	validate := validator.New()
+	// Name fields as the client sends them, so it can match errors to inputs.
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query", "param"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
 	en_translations.RegisterDefaultTranslations(validate, trans)
 	return &CustomValidator{
 		handler: validate,
@@ -182,11 +387,19 @@ func (v *CustomValidator) Validate(i interface{}) error {
 	if err != nil {
 		errs := err.(validator.ValidationErrors)
 		msg := strings.Builder{}
-		for _, content := range errs.Translate(v.trans) {
+		fields := make(map[string]string, len(errs))
+		for _, e := range errs {
+			content := e.Translate(v.trans)
 			msg.WriteString(content)
 			msg.WriteString(".")
+			// Drop the name of the request struct.
+			_, field, _ := strings.Cut(e.Namespace(), ".")
+			fields[field] = content
 		}
-		err = echo.NewHTTPError(http.StatusBadRequest, msg.String())
+		err = echo.NewHTTPError(http.StatusBadRequest, validationError{
+			Message: msg.String(),
+			Fields:  fields,
+		})
 	}
 	return err
 }
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// startAutoTLS serves r over HTTPS on addr, with certificates for
+// params.AutoTLSDomains from Let's Encrypt. The HTTP-01 challenges are
+// answered on params.AutoTLSHTTPPort, which redirects any other request to
+// HTTPS.
+func startAutoTLS(r *echo.Echo, addr string, params Params) error {
+	r.AutoTLSManager.Prompt = autocert.AcceptTOS
+	r.AutoTLSManager.HostPolicy = autocert.HostWhitelist(params.AutoTLSDomains...)
+	r.AutoTLSManager.Cache = autocert.DirCache(params.AutoTLSCacheDir)
+	r.AutoTLSManager.Email = params.AutoTLSEmail
+
+	challengeAddr := fmt.Sprintf("%s:%d", params.Host, params.AutoTLSHTTPPort)
+	challengeServer := &http.Server{
+		Addr:              challengeAddr,
+		Handler:           r.AutoTLSManager.HTTPHandler(nil),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		slog.Info("starting acme challenge server", "addr", challengeAddr, "domains", params.AutoTLSDomains)
+		if err := challengeServer.ListenAndServe(); err != nil {
+			slog.Error("acme challenge server", "error", err)
+		}
+	}()
+
+	return r.StartAutoTLS(addr)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type bridgeAPI struct {
+	srv *server.Bridge
+}
+
+func newBridgeAPI(srv *server.Bridge) *bridgeAPI {
+	return &bridgeAPI{
+		srv: srv,
+	}
+}
+
+func (s bridgeAPI) All(c echo.Context) error {
+	resp, err := s.srv.All(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (s bridgeAPI) Create(c echo.Context) error {
+	var req server.ReqBridgeCreate
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := s.srv.Create(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (s bridgeAPI) Delete(c echo.Context) error {
+	var req server.ReqBridgeDelete
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := s.srv.Delete(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// defaultLoginChallengeDifficulty is how many leading zero bits the
+	// SHA-256 of a challenge followed by its solution must have, unless
+	// configured otherwise. Browsers find one in about a second.
+	defaultLoginChallengeDifficulty = 16
+	// loginChallengeTTL is how long a challenge can be solved.
+	loginChallengeTTL = 10 * time.Minute
+	// loginFailureWindow is how long failed logins are remembered.
+	loginFailureWindow = time.Hour
+	// maxLoginGuardEntries caps the clients and the challenges remembered, as
+	// anyone can add to them.
+	maxLoginGuardEntries = 10000
+	// maxChallengesPerIP caps the pending challenges of a client, so that a
+	// single one can't use up maxLoginGuardEntries.
+	maxChallengesPerIP = 5
+)
+
+// loginGuard asks clients that failed to log in too often to solve a proof of
+// work challenge before each further attempt. Guessing the password gets
+// slow, yet the user is never locked out.
+type loginGuard struct {
+	// after is the number of failed logins from an IP after which it gets
+	// challenges. 0 disables them.
+	after int
+	// difficulty is the number of leading zero bits of solutions.
+	difficulty int
+	now        func() time.Time
+
+	mu sync.Mutex
+	// failures are by client IP.
+	failures map[string]loginFailures
+	// challenges map those issued to who they were issued to.
+	challenges map[string]issuedChallenge
+	// pending lists the challenges of each client IP, oldest first.
+	pending map[string][]string
+}
+
+type loginFailures struct {
+	count int
+	last  time.Time
+}
+
+type issuedChallenge struct {
+	ip     string
+	expiry time.Time
+}
+
+// errTooManyChallenges is returned when challenges are asked for faster than
+// they expire.
+var errTooManyChallenges = echo.NewHTTPError(http.StatusServiceUnavailable, "Too many login attempts, please try again later")
+
+func newLoginGuard(after, difficulty int) *loginGuard {
+	if difficulty <= 0 {
+		difficulty = defaultLoginChallengeDifficulty
+	}
+	return &loginGuard{
+		after:      after,
+		difficulty: difficulty,
+		now:        time.Now,
+		failures:   make(map[string]loginFailures),
+		challenges: make(map[string]issuedChallenge),
+		pending:    make(map[string][]string),
+	}
+}
+
+// required tells whether the client at ip must solve a challenge to log in.
+func (g *loginGuard) required(ip string) bool {
+	if g.after == 0 {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	f := g.failures[ip]
+	return f.count >= g.after && g.now().Sub(f.last) < loginFailureWindow
+}
+
+// fail records a failed login from ip.
+func (g *loginGuard) fail(ip string) {
+	if g.after == 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	f := g.failures[ip]
+	if now.Sub(f.last) >= loginFailureWindow {
+		f.count = 0
+	}
+	f.count++
+	f.last = now
+	if _, ok := g.failures[ip]; !ok && len(g.failures) >= maxLoginGuardEntries {
+		g.prune(now)
+	}
+	g.failures[ip] = f
+}
+
+// succeed forgets the failed logins from ip.
+func (g *loginGuard) succeed(ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.failures, ip)
+}
+
+// issue returns a new challenge for the client at ip, to be solved within
+// loginChallengeTTL. The oldest challenge of the client is dropped if it has
+// too many.
+func (g *loginGuard) issue(ip string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	challenge := hex.EncodeToString(b)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	if pending := g.pending[ip]; len(pending) >= maxChallengesPerIP {
+		g.forget(pending[0])
+	}
+	if len(g.challenges) >= maxLoginGuardEntries {
+		g.prune(now)
+		if len(g.challenges) >= maxLoginGuardEntries {
+			return "", errTooManyChallenges
+		}
+	}
+	g.challenges[challenge] = issuedChallenge{ip: ip, expiry: now.Add(loginChallengeTTL)}
+	g.pending[ip] = append(g.pending[ip], challenge)
+	return challenge, nil
+}
+
+// verify tells whether solution solves challenge, which must have been issued
+// to the client at ip. Challenges are only good for one attempt, solved or
+// not.
+func (g *loginGuard) verify(ip, challenge, solution string) bool {
+	g.mu.Lock()
+	issued, ok := g.challenges[challenge]
+	g.forget(challenge)
+	now := g.now()
+	g.mu.Unlock()
+	if !ok || issued.ip != ip || now.After(issued.expiry) {
+		return false
+	}
+	return solvesChallenge(challenge, solution, g.difficulty)
+}
+
+// prune forgets the expired challenges and failures. It must be called with
+// mu held.
+func (g *loginGuard) prune(now time.Time) {
+	for c, issued := range g.challenges {
+		if now.After(issued.expiry) {
+			g.forget(c)
+		}
+	}
+	for ip, f := range g.failures {
+		if now.Sub(f.last) >= loginFailureWindow {
+			delete(g.failures, ip)
+		}
+	}
+}
+
+// forget drops challenge. It must be called with mu held.
+func (g *loginGuard) forget(challenge string) {
+	issued, ok := g.challenges[challenge]
+	if !ok {
+		return
+	}
+	delete(g.challenges, challenge)
+	pending := slices.DeleteFunc(g.pending[issued.ip], func(c string) bool { return c == challenge })
+	if len(pending) == 0 {
+		delete(g.pending, issued.ip)
+	} else {
+		g.pending[issued.ip] = pending
+	}
+}
+
+// solvesChallenge tells whether the SHA-256 of challenge followed by solution
+// starts with difficulty zero bits.
+func solvesChallenge(challenge, solution string, difficulty int) bool {
+	sum := sha256.Sum256([]byte(challenge + solution))
+	for _, b := range sum {
+		if difficulty <= 0 {
+			return true
+		}
+		if difficulty < 8 {
+			return b>>(8-difficulty) == 0
+		}
+		if b != 0 {
+			return false
+		}
+		difficulty -= 8
+	}
+	return difficulty <= 0
+}
+
+// errChallengeRequired tells the client to solve a challenge, see
+// Session.Challenge, and send the solution along with the password.
+var errChallengeRequired = echo.NewHTTPError(http.StatusPreconditionRequired, "Too many failed logins, solve the login challenge first")
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// solve finds a solution to challenge by brute force.
+func solve(challenge string, difficulty int) string {
+	for n := 0; ; n++ {
+		solution := strconv.FormatInt(int64(n), 36)
+		if solvesChallenge(challenge, solution, difficulty) {
+			return solution
+		}
+	}
+}
+
+func TestLoginGuardCapsChallengesPerIP(t *testing.T) {
+	g := newLoginGuard(1, 8)
+
+	first, err := g.issue("192.0.2.1")
+	require.NoError(t, err)
+	for range maxLoginGuardEntries {
+		_, err := g.issue("192.0.2.1")
+		require.NoError(t, err, "a client asking for more challenges must not be refused")
+	}
+	assert.Len(t, g.challenges, maxChallengesPerIP)
+	assert.False(t, g.verify("192.0.2.1", first, solve(first, g.difficulty)), "the oldest challenges must be dropped")
+
+	challenge, err := g.issue("198.51.100.1")
+	require.NoError(t, err, "a client must not use up the challenges of others")
+	assert.True(t, g.verify("198.51.100.1", challenge, solve(challenge, g.difficulty)))
+	assert.Len(t, g.challenges, maxChallengesPerIP)
+	assert.NotContains(t, g.pending, "198.51.100.1", "verified challenges must be forgotten")
+}
+
+func TestLoginGuardVerifiesIP(t *testing.T) {
+	g := newLoginGuard(1, 8)
+
+	challenge, err := g.issue("192.0.2.1")
+	require.NoError(t, err)
+	assert.False(t, g.verify("198.51.100.1", challenge, solve(challenge, g.difficulty)), "challenges must be solved by the client they were issued to")
+	assert.False(t, g.verify("192.0.2.1", challenge, solve(challenge, g.difficulty)), "challenges are good for one attempt")
+
+	challenge, err = g.issue("192.0.2.1")
+	require.NoError(t, err)
+	assert.True(t, g.verify("192.0.2.1", challenge, solve(challenge, g.difficulty)))
+}
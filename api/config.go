@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// configAPI tells the frontend how the server is configured, where it
+// affects the UI.
+type configAPI struct {
+	readOnly     bool
+	faviconHosts map[string]string
+}
+
+func newConfigAPI(params Params) *configAPI {
+	return &configAPI{
+		readOnly:     params.ReadOnly,
+		faviconHosts: params.FaviconHosts,
+	}
+}
+
+type respConfig struct {
+	ReadOnly     bool              `json:"read_only"`
+	FaviconHosts map[string]string `json:"favicon_hosts"`
+}
+
+func (a configAPI) Get(c echo.Context) error {
+	return c.JSON(http.StatusOK, respConfig{
+		ReadOnly:     a.readOnly,
+		FaviconHosts: a.faviconHosts,
+	})
+}
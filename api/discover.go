@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type discoverAPI struct {
+	srv *server.Discover
+}
+
+func newDiscoverAPI(srv *server.Discover) *discoverAPI {
+	return &discoverAPI{
+		srv: srv,
+	}
+}
+
+func (d discoverAPI) Get(c echo.Context) error {
+	resp, err := d.srv.Get(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
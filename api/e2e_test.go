@@ -0,0 +1,473 @@
+package api_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/api"
+	"github.com/0x2e/fusion/auth"
+	"github.com/0x2e/fusion/pkg/feedtest"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/server"
+)
+
+const testPassword = "correct horse battery staple"
+
+var testFeed = feedtest.Feed{
+	Title:   "Test Feed",
+	SiteURL: "https://example.com",
+	Items: []feedtest.Item{
+		{
+			Title:   "First post",
+			Link:    "https://example.com/first",
+			GUID:    "first",
+			Content: "The first post of the test feed.",
+		},
+		{
+			Title:   "Second post",
+			Link:    "https://example.com/second",
+			GUID:    "second",
+			Content: "The second post of the test feed.",
+		},
+	},
+}
+
+// testClient talks to a running app as a logged in browser would.
+type testClient struct {
+	t       *testing.T
+	baseURL string
+	http    *http.Client
+}
+
+func newTestApp(t *testing.T) *testClient {
+	t.Helper()
+
+	return newTestAppWithParams(t, api.Params{})
+}
+
+// newTestAppWithParams is like newTestApp, with the password and page size
+// set on params.
+func newTestAppWithParams(t *testing.T, params api.Params) *testClient {
+	t.Helper()
+
+	repo.DB = repotest.NewDB(t)
+	passwordHash, err := auth.HashPassword(testPassword)
+	require.NoError(t, err)
+
+	params.PasswordHash = &passwordHash
+	params.PageSize = 10
+	app := httptest.NewServer(api.NewServer(params))
+	t.Cleanup(app.Close)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	return &testClient{
+		t:       t,
+		baseURL: app.URL,
+		http:    &http.Client{Jar: jar},
+	}
+}
+
+// do sends a JSON request and decodes the JSON response into resp, if it's
+// not nil and the request succeeded. It returns the response status code.
+func (c *testClient) do(method, path string, req, resp any) int {
+	c.t.Helper()
+
+	return c.send(method, path, req, resp, false)
+}
+
+// doRaw is like do, but decodes the response body whatever the status code.
+func (c *testClient) doRaw(method, path string, req, resp any) int {
+	c.t.Helper()
+
+	return c.send(method, path, req, resp, true)
+}
+
+func (c *testClient) send(method, path string, req, resp any, decodeErrors bool) int {
+	c.t.Helper()
+
+	var body io.Reader
+	if req != nil {
+		b, err := json.Marshal(req)
+		require.NoError(c.t, err)
+		body = bytes.NewReader(b)
+	}
+	r, err := http.NewRequest(method, c.baseURL+path, body)
+	require.NoError(c.t, err)
+	r.Header.Set("Content-Type", "application/json")
+
+	res, err := c.http.Do(r)
+	require.NoError(c.t, err)
+	defer res.Body.Close()
+	if resp != nil && (res.StatusCode < 300 || decodeErrors) {
+		require.NoError(c.t, json.NewDecoder(res.Body).Decode(resp))
+	}
+	return res.StatusCode
+}
+
+func (c *testClient) listUnread() *server.RespItemList {
+	c.t.Helper()
+
+	var resp server.RespItemList
+	require.Equal(c.t, http.StatusOK, c.do(http.MethodGet, "/api/items?unread=true", nil, &resp))
+	return &resp
+}
+
+func TestEndToEnd(t *testing.T) {
+	feedServer := feedtest.NewServer(t, testFeed)
+	c := newTestApp(t)
+
+	assert.Equal(t, http.StatusUnauthorized, c.do(http.MethodGet, "/api/items", nil, nil), "API must require a session")
+	assert.Equal(t, http.StatusUnauthorized, c.do(http.MethodPost, "/api/sessions", map[string]string{"password": "wrong"}, nil))
+	require.Equal(t, http.StatusCreated, c.do(http.MethodPost, "/api/sessions", map[string]string{"password": testPassword}, nil))
+
+	var invalid struct {
+		Fields map[string]string `json:"fields"`
+	}
+	assert.Equal(t, http.StatusBadRequest, c.doRaw(http.MethodPost, "/api/feeds", map[string]any{
+		"feeds": []map[string]string{
+			{"name": "Test Feed", "link": feedServer.FeedURL()},
+		},
+		"group_id": 999,
+	}, &invalid))
+	assert.Contains(t, invalid.Fields, "group_id", "unknown groups must be reported on the group field")
+
+	var created server.RespFeedCreate
+	require.Equal(t, http.StatusCreated, c.do(http.MethodPost, "/api/feeds", map[string]any{
+		"feeds": []map[string]string{
+			{"name": "Test Feed", "link": feedServer.FeedURL()},
+		},
+	}, &created))
+	require.Len(t, created.IDs, 1)
+
+	unread := c.listUnread()
+	require.NotNil(t, unread.Total)
+	assert.Equal(t, 2, *unread.Total)
+	require.Len(t, unread.Items, 2)
+	titles := []string{*unread.Items[0].Title, *unread.Items[1].Title}
+	assert.ElementsMatch(t, []string{"First post", "Second post"}, titles)
+	for _, item := range unread.Items {
+		assert.Equal(t, created.IDs[0], item.Feed.ID)
+		assert.Equal(t, "Test Feed", *item.Feed.Name)
+	}
+
+	first := unread.Items[0]
+	var detail server.RespItemGet
+	require.Equal(t, http.StatusOK, c.do(http.MethodGet, fmt.Sprintf("/api/items/%d", first.ID), nil, &detail))
+	assert.Equal(t, first.Title, detail.Title)
+	assert.Equal(t, first.Link, detail.Link)
+	require.NotNil(t, detail.Content)
+	assert.Contains(t, *detail.Content, "test feed")
+	assert.True(t, *detail.Unread)
+
+	var updated server.RespItemUpdateUnread
+	require.Equal(t, http.StatusOK, c.do(http.MethodPatch, "/api/items/-/unread", map[string]any{
+		"ids":    []uint{first.ID},
+		"unread": false,
+	}, &updated))
+	assert.Equal(t, map[uint]int{created.IDs[0]: 1}, updated.UnreadCounts)
+	unread = c.listUnread()
+	assert.Equal(t, 1, *unread.Total)
+	require.Len(t, unread.Items, 1)
+	assert.NotEqual(t, first.ID, unread.Items[0].ID)
+
+	var toggled server.RespItemToggle
+	require.Equal(t, http.StatusOK, c.do(http.MethodPost, fmt.Sprintf("/api/items/%d/unread/toggle", first.ID), nil, &toggled))
+	assert.True(t, *toggled.Unread)
+	if assert.NotNil(t, toggled.Feed.UnreadCount) {
+		assert.Equal(t, 2, *toggled.Feed.UnreadCount, "toggle must report the feed's new unread count")
+	}
+	assert.Equal(t, 2, *c.listUnread().Total)
+
+	r, err := http.NewRequest(http.MethodGet, c.baseURL+"/api/items?unread=true", nil)
+	require.NoError(t, err)
+	r.Header.Set("Accept", "application/x-ndjson")
+	res, err := c.http.Do(r)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+	lines := bufio.NewScanner(res.Body)
+	require.True(t, lines.Scan())
+	var header server.RespItemList
+	require.NoError(t, json.Unmarshal(lines.Bytes(), &header))
+	assert.Equal(t, 2, *header.Total, "the first line must carry the total")
+	var streamed []string
+	for lines.Scan() {
+		var item server.ItemForm
+		require.NoError(t, json.Unmarshal(lines.Bytes(), &item))
+		streamed = append(streamed, *item.Title)
+	}
+	assert.ElementsMatch(t, []string{"First post", "Second post"}, streamed, "each item must be on its own line")
+
+	assert.Equal(t, http.StatusMethodNotAllowed, c.do(http.MethodGet, fmt.Sprintf("/api/items/%d/open", first.ID), nil, nil), "opening marks the item read, so it must not be a GET")
+	var opened server.RespItemOpen
+	require.Equal(t, http.StatusOK, c.do(http.MethodPost, fmt.Sprintf("/api/items/%d/open", first.ID), nil, &opened))
+	assert.Equal(t, *first.Link, opened.Link)
+	assert.Equal(t, 1, *c.listUnread().Total)
+	require.Equal(t, http.StatusOK, c.do(http.MethodPost, fmt.Sprintf("/api/items/%d/open", first.ID), nil, nil), "opening a read item again must succeed")
+
+	assert.Equal(t, http.StatusNotFound, c.do(http.MethodGet, "/api/items/9999", nil, nil))
+	assert.Equal(t, http.StatusNotFound, c.do(http.MethodGet, "/api/no-such-route", nil, nil), "unknown API routes must not fall back to the frontend")
+
+	require.Equal(t, http.StatusNoContent, c.do(http.MethodDelete, "/api/sessions", nil, nil))
+	assert.Equal(t, http.StatusUnauthorized, c.do(http.MethodGet, "/api/items", nil, nil), "logging out must end the session")
+}
+
+func TestEndToEndEvents(t *testing.T) {
+	feedServer := feedtest.NewServer(t, testFeed)
+	c := newTestApp(t)
+	require.Equal(t, http.StatusCreated, c.do(http.MethodPost, "/api/sessions", map[string]string{"password": testPassword}, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/events", nil)
+	require.NoError(t, err)
+	res, err := c.http.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	var created server.RespFeedCreate
+	require.Equal(t, http.StatusCreated, c.do(http.MethodPost, "/api/feeds", map[string]any{
+		"feeds": []map[string]string{
+			{"name": "Test Feed", "link": feedServer.FeedURL()},
+		},
+	}, &created))
+	require.Len(t, created.IDs, 1)
+
+	stream := bufio.NewReader(res.Body)
+	var lines []string
+	for len(lines) < 2 {
+		line, err := stream.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, []string{
+		"event: new_items",
+		fmt.Sprintf(`data: {"feed_id":%d,"new_items":2}`, created.IDs[0]),
+	}, lines)
+}
+
+func TestEndToEndRefreshAll(t *testing.T) {
+	feedServer := feedtest.NewServer(t, testFeed)
+	brokenFeedServer := feedtest.NewServer(t, testFeed)
+	c := newTestApp(t)
+	require.Equal(t, http.StatusCreated, c.do(http.MethodPost, "/api/sessions", map[string]string{"password": testPassword}, nil))
+
+	for _, link := range []string{feedServer.FeedURL(), brokenFeedServer.FeedURL()} {
+		require.Equal(t, http.StatusCreated, c.do(http.MethodPost, "/api/feeds", map[string]any{
+			"feeds": []map[string]string{{"name": link, "link": link}},
+		}, nil))
+	}
+	brokenFeedServer.SetStatus(http.StatusInternalServerError)
+
+	var before server.RespFeedRefreshStatus
+	require.Equal(t, http.StatusOK, c.do(http.MethodGet, "/api/feeds/refresh", nil, &before))
+	require.Equal(t, http.StatusNoContent, c.do(http.MethodPost, "/api/feeds/refresh", map[string]any{"all": true}, nil))
+
+	var status server.RespFeedRefreshStatus
+	require.Eventually(t, func() bool {
+		require.Equal(t, http.StatusOK, c.do(http.MethodGet, "/api/feeds/refresh", nil, &status))
+		return status.ID > before.ID && !status.Running
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, status.Total)
+	assert.Equal(t, 2, status.Completed)
+	assert.Equal(t, 1, status.Failed)
+	assert.NotNil(t, status.FinishedAt)
+}
+
+func TestEndToEndServesFrontend(t *testing.T) {
+	c := newTestApp(t)
+
+	for _, path := range []string{"/", "/feeds/1"} {
+		res, err := c.http.Get(c.baseURL + path)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode, path)
+		assert.Contains(t, res.Header.Get("Content-Type"), "text/html", path)
+	}
+}
+
+func TestEndToEndReadOnly(t *testing.T) {
+	c := newTestAppWithParams(t, api.Params{ReadOnly: true})
+	require.Equal(t, http.StatusCreated, c.do(http.MethodPost, "/api/sessions", map[string]string{"password": testPassword}, nil))
+
+	var config struct {
+		ReadOnly bool `json:"read_only"`
+	}
+	require.Equal(t, http.StatusOK, c.do(http.MethodGet, "/api/config", nil, &config))
+	assert.True(t, config.ReadOnly)
+	c.listUnread()
+
+	var errResp struct {
+		Message string `json:"message"`
+	}
+	status := c.doRaw(http.MethodPost, "/api/groups", map[string]string{"name": "New"}, &errResp)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, errResp.Message, "read-only")
+}
+
+func TestEndToEndPublicGroups(t *testing.T) {
+	feedServer := feedtest.NewServer(t, testFeed)
+	c := newTestApp(t)
+	visitor := &testClient{t: t, baseURL: c.baseURL, http: &http.Client{}}
+	require.Equal(t, http.StatusCreated, c.do(http.MethodPost, "/api/sessions", map[string]string{"password": testPassword}, nil))
+
+	var group server.RespGroupCreate
+	require.Equal(t, http.StatusCreated, c.do(http.MethodPost, "/api/groups", map[string]any{"name": "Blogroll"}, &group))
+	require.Equal(t, http.StatusCreated, c.do(http.MethodPost, "/api/feeds", map[string]any{
+		"group_id": group.ID,
+		"feeds":    []map[string]string{{"name": "Test Feed", "link": feedServer.FeedURL()}},
+	}, nil))
+	path := fmt.Sprintf("/api/public/groups/%d/items", group.ID)
+	assert.Equal(t, http.StatusNotFound, visitor.do(http.MethodGet, path, nil, nil), "private groups must not be visible")
+
+	require.Equal(t, http.StatusNoContent, c.do(http.MethodPatch, fmt.Sprintf("/api/groups/%d", group.ID), map[string]any{"name": "Blogroll", "public": true}, nil))
+	var list server.RespPublicItemList
+	require.Equal(t, http.StatusOK, visitor.do(http.MethodGet, path, nil, &list))
+	require.Len(t, list.Items, 2)
+	var item server.RespPublicItemGet
+	require.Equal(t, http.StatusOK, visitor.do(http.MethodGet, fmt.Sprintf("/api/public/items/%d", list.Items[0].ID), nil, &item))
+	assert.NotEmpty(t, item.Content)
+	assert.Equal(t, http.StatusUnauthorized, visitor.do(http.MethodGet, "/api/items", nil, nil), "other items must still require a login")
+}
+
+func TestEndToEndSessionSecrets(t *testing.T) {
+	repo.DB = repotest.NewDB(t)
+	passwordHash, err := auth.HashPassword(testPassword)
+	require.NoError(t, err)
+	// Cookie jars ignore ports, so the instances below share the cookies.
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	startInstance := func(secrets ...string) *testClient {
+		keys := make([][]byte, 0, len(secrets))
+		for _, s := range secrets {
+			keys = append(keys, []byte(s))
+		}
+		app := httptest.NewServer(api.NewServer(api.Params{
+			PasswordHash:   &passwordHash,
+			PageSize:       10,
+			SessionSecrets: keys,
+		}))
+		t.Cleanup(app.Close)
+		return &testClient{t: t, baseURL: app.URL, http: &http.Client{Jar: jar}}
+	}
+	oldSecret, newSecret := strings.Repeat("a", 32), strings.Repeat("b", 32)
+	c := startInstance(oldSecret)
+	require.Equal(t, http.StatusCreated, c.do(http.MethodPost, "/api/sessions", map[string]string{"password": testPassword}, nil))
+
+	for _, tt := range []struct {
+		description    string
+		secrets        []string
+		expectedStatus int
+	}{
+		{
+			description:    "sessions survive restarts",
+			secrets:        []string{oldSecret},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "sessions survive rotating the secret",
+			secrets:        []string{newSecret, oldSecret},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "sessions end when their secret is dropped",
+			secrets:        []string{newSecret},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	} {
+		assert.Equal(t, tt.expectedStatus, startInstance(tt.secrets...).do(http.MethodGet, "/api/groups", nil, nil), tt.description)
+	}
+}
+
+func TestEndToEndSignOutEverywhere(t *testing.T) {
+	c := newTestApp(t)
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	other := &testClient{t: t, baseURL: c.baseURL, http: &http.Client{Jar: jar}}
+	for _, client := range []*testClient{c, other} {
+		require.Equal(t, http.StatusCreated, client.do(http.MethodPost, "/api/sessions", map[string]string{"password": testPassword}, nil))
+	}
+
+	require.Equal(t, http.StatusNoContent, c.do(http.MethodDelete, "/api/sessions/all", nil, nil))
+
+	assert.Equal(t, http.StatusUnauthorized, c.do(http.MethodGet, "/api/groups", nil, nil))
+	assert.Equal(t, http.StatusUnauthorized, other.do(http.MethodGet, "/api/groups", nil, nil), "other sessions must end too")
+	require.Equal(t, http.StatusCreated, other.do(http.MethodPost, "/api/sessions", map[string]string{"password": testPassword}, nil))
+	assert.Equal(t, http.StatusOK, other.do(http.MethodGet, "/api/groups", nil, nil), "new sessions must work")
+}
+
+func TestEndToEndLoginChallenge(t *testing.T) {
+	c := newTestAppWithParams(t, api.Params{LoginChallengeAfter: 2})
+	login := func(req map[string]string) int {
+		return c.do(http.MethodPost, "/api/sessions", req, nil)
+	}
+	for range 2 {
+		require.Equal(t, http.StatusUnauthorized, login(map[string]string{"password": "wrong"}))
+	}
+	assert.Equal(t, http.StatusPreconditionRequired, login(map[string]string{"password": testPassword}), "failed too often")
+
+	var challenge struct {
+		Challenge  string `json:"challenge"`
+		Difficulty int    `json:"difficulty"`
+	}
+	require.Equal(t, http.StatusOK, c.do(http.MethodGet, "/api/sessions/challenge", nil, &challenge))
+	solution := solveChallenge(challenge.Challenge, challenge.Difficulty)
+	assert.Equal(t, http.StatusPreconditionRequired, login(map[string]string{
+		"password": testPassword, "challenge": challenge.Challenge, "solution": solution + "x",
+	}), "wrong solution")
+	assert.Equal(t, http.StatusPreconditionRequired, login(map[string]string{
+		"password": testPassword, "challenge": challenge.Challenge, "solution": solution,
+	}), "challenges are good for one attempt")
+
+	require.Equal(t, http.StatusOK, c.do(http.MethodGet, "/api/sessions/challenge", nil, &challenge))
+	require.Equal(t, http.StatusCreated, login(map[string]string{
+		"password":  testPassword,
+		"challenge": challenge.Challenge,
+		"solution":  solveChallenge(challenge.Challenge, challenge.Difficulty),
+	}))
+	assert.Equal(t, http.StatusCreated, login(map[string]string{"password": testPassword}), "logging in forgets the failures")
+}
+
+// solveChallenge finds a solution to a login challenge as the frontend does.
+func solveChallenge(challenge string, difficulty int) string {
+	for n := 0; ; n++ {
+		solution := strconv.FormatInt(int64(n), 36)
+		sum := sha256.Sum256([]byte(challenge + solution))
+		if leadingZeroBits(sum[:]) >= difficulty {
+			return solution
+		}
+	}
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, v := range b {
+		if v != 0 {
+			return n + bits.LeadingZeros8(v)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/0x2e/fusion/service/pull"
+
+	"github.com/labstack/echo/v4"
+)
+
+// eventsHeartbeatInterval is how often an idle event stream sends a comment,
+// so proxies don't close it.
+const eventsHeartbeatInterval = 30 * time.Second
+
+// streamEvents sends each pull.NewItemsEvent to the client as a server-sent
+// event named "new_items", until the client disconnects.
+func streamEvents(c echo.Context) error {
+	events, unsubscribe := pull.Events.Subscribe()
+	defer unsubscribe()
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	// Keep reverse proxies like nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "event: new_items\ndata: %s\n\n", data); err != nil {
+				return nil
+			}
+		}
+		w.Flush()
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type faviconAPI struct {
+	srv *server.Favicon
+}
+
+func newFaviconAPI(srv *server.Favicon) *faviconAPI {
+	return &faviconAPI{
+		srv: srv,
+	}
+}
+
+func (f faviconAPI) Get(c echo.Context) error {
+	var req server.ReqFaviconGet
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	data, err := f.srv.Get(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("Cache-Control", "private, max-age=86400")
+	return c.Blob(http.StatusOK, http.DetectContentType(data), data)
+}
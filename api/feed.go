@@ -60,6 +60,20 @@ func (f feedAPI) Create(c echo.Context) error {
 	return c.JSON(http.StatusCreated, resp)
 }
 
+func (f feedAPI) BulkCreate(c echo.Context) error {
+	var req server.ReqFeedBulkCreate
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := f.srv.BulkCreate(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
 func (f feedAPI) CheckValidity(c echo.Context) error {
 	var req server.ReqFeedCheckValidity
 	if err := bindAndValidate(&req, c); err != nil {
@@ -88,6 +102,19 @@ func (f feedAPI) Update(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+func (f feedAPI) UpdateSettings(c echo.Context) error {
+	var req server.ReqFeedUpdateSettings
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := f.srv.UpdateSettings(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (f feedAPI) Delete(c echo.Context) error {
 	var req server.ReqFeedDelete
 	if err := bindAndValidate(&req, c); err != nil {
@@ -101,15 +128,55 @@ func (f feedAPI) Delete(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+func (f feedAPI) Merge(c echo.Context) error {
+	var req server.ReqFeedMerge
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := f.srv.Merge(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (f feedAPI) Dedupe(c echo.Context) error {
+	var req server.ReqFeedDedupe
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := f.srv.Dedupe(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
 func (f feedAPI) Refresh(c echo.Context) error {
 	var req server.ReqFeedRefresh
 	if err := bindAndValidate(&req, c); err != nil {
 		return err
 	}
 
-	if err := f.srv.Refresh(c.Request().Context(), &req); err != nil {
+	resp, err := f.srv.Refresh(c.Request().Context(), &req)
+	if err != nil {
 		return err
 	}
+	if resp == nil {
+		return c.NoContent(http.StatusNoContent)
+	}
 
-	return c.NoContent(http.StatusNoContent)
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (f feedAPI) RefreshStatus(c echo.Context) error {
+	resp, err := f.srv.RefreshStatus(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
 }
@@ -55,6 +55,19 @@ func (f groupAPI) Update(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+func (f groupAPI) UpdateSettings(c echo.Context) error {
+	var req server.ReqGroupUpdateSettings
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := f.srv.UpdateSettings(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (f groupAPI) Delete(c echo.Context) error {
 	var req server.ReqGroupDelete
 	if err := bindAndValidate(&req, c); err != nil {
@@ -67,3 +80,39 @@ func (f groupAPI) Delete(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+func (f groupAPI) AllRules(c echo.Context) error {
+	resp, err := f.srv.AllRules(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (f groupAPI) CreateRule(c echo.Context) error {
+	var req server.ReqGroupRuleCreate
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := f.srv.CreateRule(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (f groupAPI) DeleteRule(c echo.Context) error {
+	var req server.ReqGroupRuleDelete
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := f.srv.DeleteRule(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
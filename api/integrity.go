@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type integrityAPI struct {
+	srv *server.Integrity
+}
+
+func newIntegrityAPI(srv *server.Integrity) *integrityAPI {
+	return &integrityAPI{
+		srv: srv,
+	}
+}
+
+func (i integrityAPI) Check(c echo.Context) error {
+	resp, err := i.srv.Check(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (i integrityAPI) Repair(c echo.Context) error {
+	resp, err := i.srv.Repair(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
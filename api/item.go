@@ -1,7 +1,9 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/0x2e/fusion/server"
 
@@ -18,6 +20,48 @@ func newItemAPI(srv *server.Item) *itemAPI {
 	}
 }
 
+func (i itemAPI) Highlights(c echo.Context) error {
+	var req server.ReqItemHighlights
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := i.srv.Highlights(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (i itemAPI) Surprise(c echo.Context) error {
+	var req server.ReqItemSurprise
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := i.srv.Surprise(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (i itemAPI) Digest(c echo.Context) error {
+	var req server.ReqItemDigest
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := i.srv.Digest(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
 func (i itemAPI) List(c echo.Context) error {
 	var req server.ReqItemList
 	if err := bindAndValidate(&req, c); err != nil {
@@ -29,9 +73,53 @@ func (i itemAPI) List(c echo.Context) error {
 		return err
 	}
 
+	if strings.Contains(c.Request().Header.Get(echo.HeaderAccept), ndjsonMIME) {
+		return streamItemList(c, resp)
+	}
 	return c.JSON(http.StatusOK, resp)
 }
 
+// ndjsonMIME is the media type of newline-delimited JSON, in which item lists
+// are streamed on request.
+const ndjsonMIME = "application/x-ndjson"
+
+// streamChunkSize is the number of items sent at once when streaming a list.
+const streamChunkSize = 10
+
+// itemListHeader is the first line of a streamed item list.
+type itemListHeader struct {
+	Total    *int `json:"total"`
+	PageSize int  `json:"page_size"`
+}
+
+// streamItemList sends resp as NDJSON: a line with the total and the page
+// size, then a line per item. It flushes every streamChunkSize items, so that
+// clients on slow connections can show the first rows before the others
+// arrive.
+func streamItemList(c echo.Context, resp *server.RespItemList) error {
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, ndjsonMIME)
+	// Keep reverse proxies like nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(itemListHeader{Total: resp.Total, PageSize: resp.PageSize}); err != nil {
+		return nil
+	}
+	for n, item := range resp.Items {
+		if err := enc.Encode(item); err != nil {
+			// The client is gone.
+			return nil
+		}
+		if (n+1)%streamChunkSize == 0 {
+			w.Flush()
+		}
+	}
+	w.Flush()
+	return nil
+}
+
 func (i itemAPI) Get(c echo.Context) error {
 	var req server.ReqItemGet
 	if err := bindAndValidate(&req, c); err != nil {
@@ -59,17 +147,65 @@ func (i itemAPI) Delete(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+func (i itemAPI) BatchDelete(c echo.Context) error {
+	var req server.ReqItemBatchDelete
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := i.srv.BatchDelete(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (i itemAPI) BatchUpdateHidden(c echo.Context) error {
+	var req server.ReqItemBatchUpdateHidden
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := i.srv.BatchUpdateHidden(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
 func (i itemAPI) UpdateUnread(c echo.Context) error {
 	var req server.ReqItemUpdateUnread
 	if err := bindAndValidate(&req, c); err != nil {
 		return err
 	}
 
-	if err := i.srv.UpdateUnread(c.Request().Context(), &req); err != nil {
+	resp, err := i.srv.UpdateUnread(c.Request().Context(), &req)
+	if err != nil {
 		return err
 	}
 
-	return c.NoContent(http.StatusNoContent)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Open marks the item as read and returns its original link, for the client
+// to open. It's a POST so it's subject to the same checks as other changes,
+// and can't be triggered by links from other sites.
+func (i itemAPI) Open(c echo.Context) error {
+	var req server.ReqItemOpen
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := i.srv.Open(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+	if resp.Link == "" {
+		return echo.NewHTTPError(http.StatusNotFound, "Item has no link")
+	}
+
+	return c.JSON(http.StatusOK, resp)
 }
 
 func (i itemAPI) UpdateBookmark(c echo.Context) error {
@@ -84,3 +220,58 @@ func (i itemAPI) UpdateBookmark(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+func (i itemAPI) UpdatePlayback(c echo.Context) error {
+	var req server.ReqItemUpdatePlayback
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := i.srv.UpdatePlayback(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (i itemAPI) BatchUpdateBookmark(c echo.Context) error {
+	var req server.ReqItemBatchUpdateBookmark
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := i.srv.BatchUpdateBookmark(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (i itemAPI) ToggleUnread(c echo.Context) error {
+	var req server.ReqItemToggle
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := i.srv.ToggleUnread(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (i itemAPI) ToggleBookmark(c echo.Context) error {
+	var req server.ReqItemToggle
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := i.srv.ToggleBookmark(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type jobAPI struct {
+	srv *server.Job
+}
+
+func newJobAPI(srv *server.Job) *jobAPI {
+	return &jobAPI{
+		srv: srv,
+	}
+}
+
+func (j jobAPI) List(c echo.Context) error {
+	var req server.ReqJobList
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := j.srv.List(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (j jobAPI) Retry(c echo.Context) error {
+	var req server.ReqJobRetry
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := j.srv.Retry(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (j jobAPI) Delete(c echo.Context) error {
+	var req server.ReqJobDelete
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := j.srv.Delete(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
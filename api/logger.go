@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/0x2e/fusion/pkg/logctx"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+var echoLogLevels = map[string]slog.Level{
+	"DEBUG": slog.LevelDebug,
+	"INFO":  slog.LevelInfo,
+	"WARN":  slog.LevelWarn,
+	"ERROR": slog.LevelError,
+}
+
+// echoLogWriter forwards the lines echo's logger writes to the default slog
+// logger, so they share its level, format and output. Each line is expected
+// to start with the level, as written with the "${level}" header.
+type echoLogWriter struct{}
+
+func (echoLogWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSpace(string(p))
+	level := slog.LevelInfo
+	if prefix, msg, ok := strings.Cut(line, " "); ok {
+		if l, known := echoLogLevels[prefix]; known {
+			level, line = l, msg
+		}
+	}
+	slog.Log(context.Background(), level, line)
+	return len(p), nil
+}
+
+// useSlog routes everything echo logs to the default slog logger, leaving the
+// filtering by level to it.
+func useSlog(r *echo.Echo) {
+	r.Logger.SetHeader("${level}")
+	r.Logger.SetOutput(echoLogWriter{})
+	r.Logger.SetLevel(log.DEBUG)
+	r.StdLogger = slog.NewLogLogger(slog.Default().Handler(), slog.LevelError)
+}
+
+// withRequestLogger puts a logger tagging everything with the request ID set
+// by middleware.RequestID and the client IP in the request context, for
+// logctx.From.
+func withRequestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Response().Header().Get(echo.HeaderXRequestID)
+		req := c.Request()
+		logger := slog.Default().With("request_id", id, "remote_ip", c.RealIP())
+		c.SetRequest(req.WithContext(logctx.With(req.Context(), logger)))
+		return next(c)
+	}
+}
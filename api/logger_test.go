@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x2e/fusion/pkg/logctx"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseSlog(t *testing.T) {
+	var out bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	r := echo.New()
+	useSlog(r)
+	r.Logger.Debug("filtered out by slog")
+	r.Logger.Warn("something odd")
+	r.Logger.Errorf("failed: %d", 42)
+
+	var records []map[string]any
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var record map[string]any
+		require.NoError(t, dec.Decode(&record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, "something odd", records[0]["msg"])
+	assert.Equal(t, "ERROR", records[1]["level"])
+	assert.Equal(t, "failed: 42", records[1]["msg"])
+}
+
+func TestWithRequestLogger(t *testing.T) {
+	var out bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&out, nil)))
+
+	r := echo.New()
+	r.IPExtractor = echo.ExtractIPDirect()
+	r.Use(middleware.RequestID())
+	r.Use(withRequestLogger)
+	r.GET("/", func(c echo.Context) error {
+		logctx.From(c.Request().Context()).Info("handling")
+		return c.NoContent(http.StatusNoContent)
+	})
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "198.51.100.7:1234"
+	r.ServeHTTP(rec, req)
+
+	var record map[string]any
+	require.NoError(t, json.NewDecoder(&out).Decode(&record))
+	assert.Equal(t, "handling", record["msg"])
+	assert.NotEmpty(t, record["request_id"])
+	assert.Equal(t, rec.Header().Get(echo.HeaderXRequestID), record["request_id"])
+	assert.Equal(t, "198.51.100.7", record["remote_ip"])
+}
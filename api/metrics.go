@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type metricsAPI struct {
+	srv *server.Metrics
+}
+
+func newMetricsAPI(srv *server.Metrics) *metricsAPI {
+	return &metricsAPI{
+		srv: srv,
+	}
+}
+
+func (m metricsAPI) Link(c echo.Context) error {
+	resp, err := m.srv.Link(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Get serves the metrics. Like the search feeds, it's not behind the session
+// check, as scrapers authenticate with the token in the link instead.
+func (m metricsAPI) Get(c echo.Context) error {
+	var req server.ReqMetrics
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := m.srv.Get(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(resp))
+}
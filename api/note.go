@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type noteAPI struct {
+	srv *server.Note
+}
+
+func newNoteAPI(srv *server.Note) *noteAPI {
+	return &noteAPI{
+		srv: srv,
+	}
+}
+
+func (n noteAPI) Link(c echo.Context) error {
+	resp, err := n.srv.Link(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Create adds a note. Like the search feeds, it's not behind the session
+// check, as shortcuts and share sheets authenticate with the token in the
+// link instead.
+func (n noteAPI) Create(c echo.Context) error {
+	var req server.ReqNoteCreate
+	// Bind only reads the query of GET and DELETE requests.
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &req); err != nil {
+		return err
+	}
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := n.srv.Create(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type opmlSubscriptionAPI struct {
+	srv *server.OPMLSubscription
+}
+
+func newOPMLSubscriptionAPI(srv *server.OPMLSubscription) *opmlSubscriptionAPI {
+	return &opmlSubscriptionAPI{
+		srv: srv,
+	}
+}
+
+func (o opmlSubscriptionAPI) List(c echo.Context) error {
+	resp, err := o.srv.List(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (o opmlSubscriptionAPI) Create(c echo.Context) error {
+	var req server.ReqOPMLSubscriptionCreate
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := o.srv.Create(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (o opmlSubscriptionAPI) Delete(c echo.Context) error {
+	var req server.ReqOPMLSubscriptionDelete
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := o.srv.Delete(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (o opmlSubscriptionAPI) Sync(c echo.Context) error {
+	var req server.ReqOPMLSubscriptionSync
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := o.srv.Sync(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type publicAPI struct {
+	srv *server.Public
+}
+
+func newPublicAPI(srv *server.Public) *publicAPI {
+	return &publicAPI{
+		srv: srv,
+	}
+}
+
+func (p publicAPI) Groups(c echo.Context) error {
+	resp, err := p.srv.Groups(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (p publicAPI) ListItems(c echo.Context) error {
+	var req server.ReqPublicItemList
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := p.srv.ListItems(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (p publicAPI) GetItem(c echo.Context) error {
+	var req server.ReqPublicItemGet
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := p.srv.GetItem(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// rejectWrites turns away any change in read-only mode, while browsing keeps
+// working.
+func rejectWrites(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if isWriteRequest(c.Request()) {
+			return echo.NewHTTPError(http.StatusForbidden, "This instance is read-only, changes are disabled")
+		}
+		return next(c)
+	}
+}
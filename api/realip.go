@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newIPExtractor finds the client IP for c.RealIP. The X-Forwarded-For and
+// X-Real-IP headers are only believed when the request comes through
+// trustedProxies, so that clients can't spoof them. Without trustedProxies,
+// echo's defaults of loopback, link-local and private addresses are trusted,
+// as in a reverse proxy on the same host or network.
+func newIPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	var options []echo.TrustOption
+	if len(trustedProxies) > 0 {
+		options = append(options,
+			echo.TrustLoopback(false),
+			echo.TrustLinkLocal(false),
+			echo.TrustPrivateNet(false),
+		)
+		for _, p := range trustedProxies {
+			options = append(options, echo.TrustIPRange(p))
+		}
+	}
+
+	fromXFF := echo.ExtractIPFromXFFHeader(options...)
+	fromRealIP := echo.ExtractIPFromRealIPHeader(options...)
+	return func(req *http.Request) string {
+		if req.Header.Get(echo.HeaderXForwardedFor) != "" {
+			return fromXFF(req)
+		}
+		return fromRealIP(req)
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPExtractor(t *testing.T) {
+	_, proxyNet, err := net.ParseCIDR("203.0.113.0/24")
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		description    string
+		trustedProxies []*net.IPNet
+		remoteAddr     string
+		headers        map[string]string
+		expected       string
+	}{
+		{
+			description: "direct connection",
+			remoteAddr:  "198.51.100.7:1234",
+			expected:    "198.51.100.7",
+		},
+		{
+			description: "private proxy is trusted by default",
+			remoteAddr:  "127.0.0.1:1234",
+			headers:     map[string]string{echo.HeaderXForwardedFor: "198.51.100.7"},
+			expected:    "198.51.100.7",
+		},
+		{
+			description: "public client can't spoof its IP by default",
+			remoteAddr:  "198.51.100.7:1234",
+			headers:     map[string]string{echo.HeaderXForwardedFor: "192.0.2.1"},
+			expected:    "198.51.100.7",
+		},
+		{
+			description:    "configured proxy is trusted",
+			trustedProxies: []*net.IPNet{proxyNet},
+			remoteAddr:     "203.0.113.5:1234",
+			headers:        map[string]string{echo.HeaderXForwardedFor: "192.0.2.1, 198.51.100.7"},
+			expected:       "198.51.100.7",
+		},
+		{
+			description:    "configured proxies replace the defaults",
+			trustedProxies: []*net.IPNet{proxyNet},
+			remoteAddr:     "127.0.0.1:1234",
+			headers:        map[string]string{echo.HeaderXForwardedFor: "198.51.100.7"},
+			expected:       "127.0.0.1",
+		},
+		{
+			description:    "X-Real-IP is used without X-Forwarded-For",
+			trustedProxies: []*net.IPNet{proxyNet},
+			remoteAddr:     "203.0.113.5:1234",
+			headers:        map[string]string{echo.HeaderXRealIP: "198.51.100.7"},
+			expected:       "198.51.100.7",
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tt.expected, newIPExtractor(tt.trustedProxies)(req))
+		})
+	}
+}
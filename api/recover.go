@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/pkg/errreport"
+
+	"github.com/labstack/echo/v4"
+)
+
+// recoverPanics turns a panic of a handler into a 500 response, and reports
+// it along with the request.
+func recoverPanics(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) (err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			// The handler wants the connection aborted, see http.Handler.
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+			req := c.Request()
+			errreport.CapturePanic(req.Context(), r,
+				"method", req.Method,
+				"uri", req.RequestURI,
+				"request_id", c.Response().Header().Get(echo.HeaderXRequestID))
+			err = echo.NewHTTPError(http.StatusInternalServerError)
+		}()
+		return next(c)
+	}
+}
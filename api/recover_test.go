@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverPanics(t *testing.T) {
+	r := echo.New()
+	r.Use(recoverPanics)
+	r.GET("/api/panic", func(c echo.Context) error {
+		panic("dummy panic")
+	})
+
+	rec := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/panic", nil))
+	})
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
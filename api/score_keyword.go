@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type scoreKeywordAPI struct {
+	srv *server.ScoreKeyword
+}
+
+func newScoreKeywordAPI(srv *server.ScoreKeyword) *scoreKeywordAPI {
+	return &scoreKeywordAPI{
+		srv: srv,
+	}
+}
+
+func (s scoreKeywordAPI) All(c echo.Context) error {
+	resp, err := s.srv.All(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (s scoreKeywordAPI) Create(c echo.Context) error {
+	var req server.ReqScoreKeywordCreate
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := s.srv.Create(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (s scoreKeywordAPI) Delete(c echo.Context) error {
+	var req server.ReqScoreKeywordDelete
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	if err := s.srv.Delete(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
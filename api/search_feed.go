@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type searchFeedAPI struct {
+	srv *server.SearchFeed
+}
+
+func newSearchFeedAPI(srv *server.SearchFeed) *searchFeedAPI {
+	return &searchFeedAPI{
+		srv: srv,
+	}
+}
+
+func (s searchFeedAPI) Link(c echo.Context) error {
+	var req server.ReqSearchFeedLink
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	resp, err := s.srv.Link(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Get serves the RSS feed. It's not behind the session check, as feed readers
+// authenticate with the token in the link instead.
+func (s searchFeedAPI) Get(c echo.Context) error {
+	var req server.ReqSearchFeed
+	if err := bindAndValidate(&req, c); err != nil {
+		return err
+	}
+
+	selfLink := c.Scheme() + "://" + c.Request().Host
+	resp, err := s.srv.Get(c.Request().Context(), &req, selfLink)
+	if err != nil {
+		return err
+	}
+
+	data, err := xml.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, "application/rss+xml; charset=utf-8", append([]byte(xml.Header), data...))
+}
@@ -5,41 +5,71 @@ import (
 	"net/http"
 
 	"github.com/0x2e/fusion/auth"
+	"github.com/0x2e/fusion/pkg/logctx"
 	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
 )
 
+// SessionVersions tracks the version that sessions must carry to be valid,
+// see repo.AuthState.
+type SessionVersions interface {
+	SessionVersion() (int, error)
+	BumpSessionVersion() (int, error)
+}
+
 type Session struct {
 	PasswordHash    auth.HashedPassword
 	UseSecureCookie bool
+	Versions        SessionVersions
+	Guard           *loginGuard
 }
 
 // sessionKeyName is the name of the key in the session store, and it's also the
 // client-visible name of the HTTP cookie for the session.
 const sessionKeyName = "session-token"
 
+// sessionVersionKey holds the session version a session was created with.
+const sessionVersionKey = "version"
+
 func (s Session) Create(c echo.Context) error {
 	var req struct {
 		Password string `json:"password" validate:"required"`
+		// Challenge and Solution are only needed after failed logins, see
+		// loginGuard.
+		Challenge string `json:"challenge"`
+		Solution  string `json:"solution"`
 	}
 
 	if err := bindAndValidate(&req, c); err != nil {
 		return err
 	}
 
+	ip := c.RealIP()
+	if s.Guard.required(ip) && !s.Guard.verify(ip, req.Challenge, req.Solution) {
+		return errChallengeRequired
+	}
+
 	attemptedPasswordHash, err := auth.HashPassword(req.Password)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid password")
 	}
 
 	if correctPasswordHash := s.PasswordHash; !attemptedPasswordHash.Equals(correctPasswordHash) {
+		logctx.From(c.Request().Context()).Warn("failed login attempt")
+		s.Guard.fail(ip)
 		return echo.NewHTTPError(http.StatusUnauthorized, "Wrong password")
 	}
+	s.Guard.succeed(ip)
 
 	sess, err := session.Get(sessionKeyName, c)
 	if err != nil {
 		return err
 	}
+	version, err := s.Versions.SessionVersion()
+	if err != nil {
+		return err
+	}
+	sess.Values[sessionVersionKey] = version
 
 	if !s.UseSecureCookie {
 		sess.Options.Secure = false
@@ -50,9 +80,23 @@ func (s Session) Create(c echo.Context) error {
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
+	logctx.From(c.Request().Context()).Info("logged in")
 	return c.NoContent(http.StatusCreated)
 }
 
+// Challenge returns a challenge to solve before logging in, for clients that
+// failed to log in too often.
+func (s Session) Challenge(c echo.Context) error {
+	challenge, err := s.Guard.issue(c.RealIP())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, struct {
+		Challenge  string `json:"challenge"`
+		Difficulty int    `json:"difficulty"`
+	}{challenge, s.Guard.difficulty})
+}
+
 func (s Session) Check(c echo.Context) error {
 	sess, err := session.Get(sessionKeyName, c)
 	if err != nil {
@@ -71,6 +115,16 @@ func (s Session) Check(c echo.Context) error {
 		return errors.New("invalid session")
 	}
 
+	current, err := s.Versions.SessionVersion()
+	if err != nil {
+		return err
+	}
+	if version, ok := sess.Values[sessionVersionKey].(int); !ok || version != current {
+		sess.Options.MaxAge = -1
+		sess.Save(c.Request(), c.Response())
+		return errors.New("session has ended")
+	}
+
 	return nil
 }
 
@@ -86,3 +140,12 @@ func (s Session) Delete(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+// DeleteAll ends every session, including the current one.
+func (s Session) DeleteAll(c echo.Context) error {
+	if _, err := s.Versions.BumpSessionVersion(); err != nil {
+		return err
+	}
+	logctx.From(c.Request().Context()).Info("signed out all sessions")
+	return s.Delete(c)
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/0x2e/fusion/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type systemAPI struct {
+	srv *server.System
+}
+
+func newSystemAPI(srv *server.System) *systemAPI {
+	return &systemAPI{
+		srv: srv,
+	}
+}
+
+func (s systemAPI) Get(c echo.Context) error {
+	resp, err := s.srv.Get(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (s systemAPI) LatestRelease(c echo.Context) error {
+	resp, err := s.srv.LatestRelease(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+const (
+	defaultRequestTimeout = 30 * time.Second
+	// longRequestTimeout is the budget of requests that fetch remote feeds
+	// before responding.
+	longRequestTimeout = 3 * time.Minute
+)
+
+// routeTimeouts overrides defaultRequestTimeout for the routes that import
+// or refresh feeds, and for streamed responses, which the timeout would
+// buffer. The keys are the method and the route path. A zero duration
+// disables the timeout.
+var routeTimeouts = map[string]time.Duration{
+	"GET /api/events":                       0,
+	"GET /api/items":                        0,
+	"POST /api/feeds":                       longRequestTimeout,
+	"POST /api/feeds/bulk":                  longRequestTimeout,
+	"POST /api/feeds/validation":            longRequestTimeout,
+	"POST /api/feeds/refresh":               longRequestTimeout,
+	"POST /api/opml-subscriptions":          longRequestTimeout,
+	"POST /api/opml-subscriptions/:id/sync": longRequestTimeout,
+}
+
+// timeoutMessage is sent with the 503 response of timed out requests. It's
+// JSON so that the frontend shows it like any other API error.
+const timeoutMessage = `{"message":"The request took too long. It may still complete in the background, please check again later."}`
+
+// newTimeoutMiddleware aborts requests that run longer than their budget: the
+// one in overrides for their route, or fallback otherwise. Routes overridden
+// with zero are never aborted.
+func newTimeoutMiddleware(fallback time.Duration, overrides map[string]time.Duration) echo.MiddlewareFunc {
+	timeout := func(d time.Duration) echo.MiddlewareFunc {
+		return middleware.TimeoutWithConfig(middleware.TimeoutConfig{
+			Timeout:      d,
+			ErrorMessage: timeoutMessage,
+		})
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		fallbackHandler := timeout(fallback)(next)
+		handlers := make(map[string]echo.HandlerFunc, len(overrides))
+		for route, d := range overrides {
+			if d == 0 {
+				handlers[route] = next
+				continue
+			}
+			handlers[route] = timeout(d)(next)
+		}
+
+		return func(c echo.Context) error {
+			if h, ok := handlers[c.Request().Method+" "+c.Path()]; ok {
+				return h(c)
+			}
+			return fallbackHandler(c)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	slowHandler := func(c echo.Context) error {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			return c.NoContent(http.StatusNoContent)
+		case <-c.Request().Context().Done():
+			return c.Request().Context().Err()
+		}
+	}
+
+	r := echo.New()
+	r.Use(newTimeoutMiddleware(10*time.Millisecond, map[string]time.Duration{
+		"POST /api/feeds/:id/refresh": time.Second,
+		"GET /api/events":             0,
+	}))
+	r.GET("/api/feeds/:id", slowHandler)
+	r.POST("/api/feeds/:id/refresh", slowHandler)
+	r.GET("/api/events", slowHandler)
+
+	for _, tt := range []struct {
+		description        string
+		method             string
+		path               string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			description:        "route without override times out",
+			method:             http.MethodGet,
+			path:               "/api/feeds/1",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       timeoutMessage,
+		},
+		{
+			description:        "route with override gets the longer budget",
+			method:             http.MethodPost,
+			path:               "/api/feeds/1/refresh",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			description:        "route with zero override never times out",
+			method:             http.MethodGet,
+			path:               "/api/events",
+			expectedStatusCode: http.StatusNoContent,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.expectedStatusCode, rec.Code)
+			assert.Equal(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestRouteTimeoutsMatchRoutes(t *testing.T) {
+	routes := make(map[string]bool)
+	for _, route := range NewServer(Params{}).Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+
+	for route := range routeTimeouts {
+		assert.True(t, routes[route], "%s is not a registered route", route)
+	}
+}
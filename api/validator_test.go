@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/server"
+)
+
+func TestCustomValidatorFieldErrors(t *testing.T) {
+	newFeedCreate := func(name, link string) *server.ReqFeedCreate {
+		req := &server.ReqFeedCreate{}
+		body := fmt.Sprintf(`{"feeds":[{"name":%q,"link":%q}]}`, name, link)
+		if err := json.Unmarshal([]byte(body), req); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	for _, tt := range []struct {
+		description    string
+		req            any
+		expectedFields []string
+	}{
+		{
+			description:    "valid feed",
+			req:            newFeedCreate("Example", "https://example.com/feed.xml"),
+			expectedFields: nil,
+		},
+		{
+			description:    "feed link is not a URL",
+			req:            newFeedCreate("Example", "example"),
+			expectedFields: []string{"feeds[0].link"},
+		},
+		{
+			description:    "feed name is empty",
+			req:            newFeedCreate("", "https://example.com/feed.xml"),
+			expectedFields: []string{"feeds[0].name"},
+		},
+		{
+			description:    "no feeds",
+			req:            &server.ReqFeedCreate{},
+			expectedFields: []string{"feeds"},
+		},
+		{
+			description:    "partial feed update",
+			req:            &server.ReqFeedUpdate{ID: 1, Suspended: ptr.To(true)},
+			expectedFields: nil,
+		},
+		{
+			description: "feed update with invalid values",
+			req: &server.ReqFeedUpdate{
+				ID:   1,
+				Name: ptr.To(""),
+				Link: ptr.To("not a url"),
+			},
+			expectedFields: []string{"name", "link"},
+		},
+		{
+			description:    "feed label removed",
+			req:            &server.ReqFeedUpdate{ID: 1, Color: ptr.To(""), Emoji: ptr.To("")},
+			expectedFields: nil,
+		},
+		{
+			description:    "group label is not a color",
+			req:            &server.ReqGroupUpdate{ID: 1, Name: ptr.To("News"), Color: ptr.To("red"), Emoji: ptr.To("📰")},
+			expectedFields: []string{"color"},
+		},
+		{
+			description:    "group name too long",
+			req:            &server.ReqGroupCreate{Name: ptr.To(string(make([]byte, 101)))},
+			expectedFields: []string{"name"},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			err := newCustomValidator().Validate(tt.req)
+
+			if tt.expectedFields == nil {
+				require.NoError(t, err)
+				return
+			}
+			var httpErr *echo.HTTPError
+			require.ErrorAs(t, err, &httpErr)
+			assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+			body, ok := httpErr.Message.(validationError)
+			require.True(t, ok)
+			assert.NotEmpty(t, body.Message)
+			fields := make([]string, 0, len(body.Fields))
+			for field, msg := range body.Fields {
+				fields = append(fields, field)
+				assert.NotEmpty(t, msg)
+			}
+			assert.ElementsMatch(t, tt.expectedFields, fields)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// SessionKeys returns the key pairs of a gorilla/sessions store from secrets.
+// The first secret signs new sessions, and all of them verify existing ones,
+// so that a secret can be rotated without logging everyone out.
+func SessionKeys(secrets [][]byte) [][]byte {
+	keys := make([][]byte, 0, 2*len(secrets))
+	for _, s := range secrets {
+		// Sessions are signed, not encrypted, so there's no block key.
+		keys = append(keys, s, nil)
+	}
+	return keys
+}
+
+// Fingerprint identifies the password without revealing its hash, keyed with
+// a secret. It tells whether the password changed since the last start.
+func (hp HashedPassword) Fingerprint(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(hp.hash)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,31 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/0x2e/fusion/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionKeys(t *testing.T) {
+	current, previous := []byte("current secret"), []byte("previous secret")
+
+	keys := auth.SessionKeys([][]byte{current, previous})
+
+	assert.Equal(t, [][]byte{current, nil, previous, nil}, keys, "each secret must give a hash and block key pair")
+}
+
+func TestFingerprint(t *testing.T) {
+	password, err := auth.HashPassword("correct horse battery staple")
+	require.NoError(t, err)
+	otherPassword, err := auth.HashPassword("hunter2")
+	require.NoError(t, err)
+	key := []byte("fingerprint key")
+
+	assert.Equal(t, password.Fingerprint(key), password.Fingerprint(key))
+	assert.NotEqual(t, password.Fingerprint(key), otherPassword.Fingerprint(key))
+	assert.NotEqual(t, password.Fingerprint(key), password.Fingerprint([]byte("other key")))
+	assert.NotEqual(t, password.Bytes(), password.Fingerprint(key), "the hash must not be stored as is")
+}
@@ -0,0 +1,60 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/0x2e/fusion/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	hash, err := auth.HashPassword("mypassword")
+	require.NoError(t, err)
+	otherHash, err := auth.HashPassword("otherpassword")
+	require.NoError(t, err)
+
+	signature := hash.Sign("golang")
+
+	for _, tt := range []struct {
+		explanation string
+		hash        auth.HashedPassword
+		message     string
+		signature   string
+		want        bool
+	}{
+		{
+			explanation: "signature of the same message is valid",
+			hash:        hash,
+			message:     "golang",
+			signature:   signature,
+			want:        true,
+		},
+		{
+			explanation: "signature of another message is invalid",
+			hash:        hash,
+			message:     "rust",
+			signature:   signature,
+			want:        false,
+		},
+		{
+			explanation: "signature is invalid after the password changes",
+			hash:        otherHash,
+			message:     "golang",
+			signature:   signature,
+			want:        false,
+		},
+		{
+			explanation: "malformed signature is invalid",
+			hash:        hash,
+			message:     "golang",
+			signature:   "not-hex",
+			want:        false,
+		},
+	} {
+		t.Run(tt.explanation, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.hash.Verify(tt.message, tt.signature))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"io"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
@@ -8,9 +9,19 @@ import (
 	"log/slog"
 
 	"github.com/0x2e/fusion/api"
+	"github.com/0x2e/fusion/auth"
 	"github.com/0x2e/fusion/conf"
+	"github.com/0x2e/fusion/pkg/blob"
+	"github.com/0x2e/fusion/pkg/errreport"
+	"github.com/0x2e/fusion/pkg/logfile"
 	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/server"
+	"github.com/0x2e/fusion/service/integrity"
+	"github.com/0x2e/fusion/service/job"
+	"github.com/0x2e/fusion/service/opml"
 	"github.com/0x2e/fusion/service/pull"
+	"github.com/0x2e/fusion/service/pull/client"
+	"github.com/0x2e/fusion/service/tombstone"
 )
 
 func main() {
@@ -38,16 +49,138 @@ func main() {
 		slog.Error("failed to load configuration", "error", err)
 		return
 	}
-	repo.Init(config.DB)
+	var logOutput io.Writer = os.Stdout
+	if config.LogFile != "" {
+		f, err := logfile.Open(config.LogFile, config.LogFileMaxSize, config.LogFileMaxBackups)
+		if err != nil {
+			slog.Error("failed to open log file", "error", err)
+			return
+		}
+		defer f.Close()
+		logOutput = io.MultiWriter(os.Stdout, f)
+	}
+	slog.SetDefault(newLogger(logOutput, config.LogFormat, config.LogLevel))
+	if config.ErrorReporting.DSN != "" {
+		reporter, err := errreport.NewReporter(config.ErrorReporting)
+		if err != nil {
+			slog.Error("failed to set up error reporting", "error", err)
+			return
+		}
+		errreport.SetReporter(reporter)
+	}
 
-	go pull.NewPuller(repo.NewFeed(repo.DB), repo.NewItem(repo.DB)).Run()
+	repo.Init(config.DB, repo.Pragmas{
+		JournalMode: config.DBJournalMode,
+		BusyTimeout: config.DBBusyTimeout,
+		Synchronous: config.DBSynchronous,
+		ForeignKeys: config.DBForeignKeys,
+	})
+	sessionSecrets, err := loadSessionSecrets(config)
+	if err != nil {
+		slog.Error("failed to load the session secret", "error", err)
+		return
+	}
+	if config.PasswordHash != nil {
+		if err := syncPassword(*config.PasswordHash); err != nil {
+			slog.Error("failed to check for a password change", "error", err)
+			return
+		}
+	}
+	pull.SetUnwantedLanguages(config.UnwantedLanguages)
+	pull.SetSnippetLength(config.SnippetLength)
+	pull.SetOutageThreshold(config.PullOutageThreshold)
+
+	blobStore, err := blob.Open(config.Blob)
+	if err != nil {
+		slog.Error("failed to open blob storage", "error", err)
+		return
+	}
+	// Features storing blobs register as owners here, to have their orphans
+	// collected.
+	go blob.NewCollector(blobStore,
+		server.NewFavicon(blobStore, repo.NewFeed(repo.DB), config.FaviconHosts).Owner(),
+	).Run()
+
+	puller := pull.NewPuller(repo.NewFeed(repo.DB), repo.NewItem(repo.DB), client.NewFeedClient(config.FlareSolverrURL))
+	go puller.Run()
+	jobQueue := job.NewQueue(repo.NewJob(repo.DB))
+	puller.RegisterJobs(jobQueue)
+	go jobQueue.Run()
+	go opml.NewSyncer(repo.NewOPMLSubscription(repo.DB), repo.NewFeed(repo.DB), repo.NewGroup(repo.DB)).Run()
+	go integrity.NewChecker(repo.NewIntegrity(repo.DB)).Run()
+	go tombstone.NewExpirer(repo.NewItem(repo.DB)).Run()
 
 	api.Run(api.Params{
 		Host:            config.Host,
 		Port:            config.Port,
 		PasswordHash:    config.PasswordHash,
+		SessionSecrets:  sessionSecrets,
 		UseSecureCookie: config.SecureCookie,
 		TLSCert:         config.TLSCert,
 		TLSKey:          config.TLSKey,
+		AutoTLSDomains:  config.AutoTLSDomains,
+		AutoTLSCacheDir: config.AutoTLSCacheDir,
+		AutoTLSEmail:    config.AutoTLSEmail,
+		AutoTLSHTTPPort: config.AutoTLSHTTPPort,
+		TrustedProxies:  config.TrustedProxies,
+		AdminAllowedIPs: config.AdminAllowedIPs,
+		ReadOnly:        config.ReadOnly,
+		PageSize:        config.PageSize,
+		FaviconHosts:    config.FaviconHosts,
+		Blob:            blobStore,
+		DB:              config.DB,
+		Settings:        config.Settings(),
+
+		LoginChallengeAfter:      config.LoginChallengeAfter,
+		LoginChallengeDifficulty: config.LoginChallengeDifficulty,
+		FlareSolverrURL:          config.FlareSolverrURL,
 	})
 }
+
+// loadSessionSecrets returns SESSION_SECRET, or the secret generated for
+// sessions in the database if it's unset, followed by SESSION_SECRET_PREVIOUS.
+func loadSessionSecrets(config conf.Conf) ([][]byte, error) {
+	current := []byte(config.SessionSecret)
+	if len(current) == 0 {
+		generated, err := repo.NewSecret(repo.DB).GetOrCreate("session", 32)
+		if err != nil {
+			return nil, err
+		}
+		current = generated
+	}
+	secrets := [][]byte{current}
+	for _, s := range config.SessionSecretsPrevious {
+		secrets = append(secrets, []byte(s))
+	}
+	return secrets, nil
+}
+
+// syncPassword ends every session if the password changed since the last
+// start.
+func syncPassword(password auth.HashedPassword) error {
+	key, err := repo.NewSecret(repo.DB).GetOrCreate("password_fingerprint", 32)
+	if err != nil {
+		return err
+	}
+	changed, err := repo.NewAuthState(repo.DB).SyncPassword(password.Fingerprint(key))
+	if err != nil {
+		return err
+	}
+	if changed {
+		slog.Info("password changed, signed out all sessions")
+	}
+	return nil
+}
+
+// newLogger builds the logger configured by LOG_FORMAT and LOG_LEVEL. Debug
+// builds always log everything as text.
+func newLogger(w io.Writer, format string, level slog.Level) *slog.Logger {
+	if conf.Debug {
+		format, level = "text", slog.LevelDebug
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "text" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
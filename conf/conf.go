@@ -4,9 +4,19 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/0x2e/fusion/auth"
+	"github.com/0x2e/fusion/pkg/blob"
+	"github.com/0x2e/fusion/pkg/errreport"
+	"github.com/0x2e/fusion/pkg/lang"
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
 )
@@ -17,14 +27,161 @@ const (
 	dotEnvFilename = ".env"
 )
 
+// Version is the version of the build. Release builds set it with
+// -ldflags "-X github.com/0x2e/fusion/conf.Version=...".
+var Version = "unknown-version"
+
 type Conf struct {
-	Host         string
-	Port         int
-	PasswordHash *auth.HashedPassword
-	DB           string
-	SecureCookie bool
-	TLSCert      string
-	TLSKey       string
+	Host            string
+	Port            int
+	PasswordHash    *auth.HashedPassword
+	DB              string
+	DBJournalMode   string
+	DBBusyTimeout   time.Duration
+	DBSynchronous   string
+	DBForeignKeys   bool
+	SecureCookie    bool
+	TLSCert         string
+	TLSKey          string
+	FlareSolverrURL string
+	PageSize        int
+	// UnwantedLanguages lists the ISO 639-1 codes of languages whose new items
+	// are marked as read.
+	UnwantedLanguages []string
+	// SnippetLength is the length in characters of the plain-text snippets
+	// stored with new items. 0 stores none.
+	SnippetLength int
+	// PullOutageThreshold is the percentage of failed feeds past which a
+	// refresh of all feeds is reported as an outage. 0 reports none.
+	PullOutageThreshold int
+	// FaviconHosts maps link prefixes, without the scheme, to the sites
+	// whose favicon the feeds with such links get, like those of a
+	// self-hosted bridge.
+	FaviconHosts map[string]string
+	LogLevel     slog.Level
+	// LogFormat is either "json" or "text".
+	LogFormat string
+	// LogFile is where logs are written in addition to stdout. Empty means
+	// stdout only.
+	LogFile string
+	// LogFileMaxSize is the size in bytes past which LogFile is rotated. 0
+	// disables rotation.
+	LogFileMaxSize    int64
+	LogFileMaxBackups int
+	// AutoTLSDomains are the domains to get certificates for from Let's
+	// Encrypt. It's mutually exclusive with TLSCert and TLSKey.
+	AutoTLSDomains []string
+	// AutoTLSCacheDir is where the certificates are kept across restarts.
+	AutoTLSCacheDir string
+	AutoTLSEmail    string
+	// AutoTLSHTTPPort serves the HTTP-01 challenges, and redirects any other
+	// request to HTTPS. Let's Encrypt expects it on port 80.
+	AutoTLSHTTPPort int
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers are believed to find the client IP. Empty trusts
+	// loopback, link-local and private addresses.
+	TrustedProxies []*net.IPNet
+	// AdminAllowedIPs restricts the settings and any change to clients in
+	// these networks, others can only read. Empty allows everyone.
+	AdminAllowedIPs []*net.IPNet
+	// LoginChallengeAfter is the number of failed logins from an IP after
+	// which it must solve a proof of work challenge for each attempt. 0
+	// disables challenges.
+	LoginChallengeAfter int
+	// LoginChallengeDifficulty is the number of leading zero bits the SHA-256
+	// of a challenge and its solution must have. Each bit doubles the work.
+	LoginChallengeDifficulty int
+	// ReadOnly disables any change, for public demos and shared reading
+	// lists. Feeds are still pulled.
+	ReadOnly bool
+	// SessionSecret signs the session cookies. Empty means a secret generated
+	// once and stored in the database.
+	SessionSecret string
+	// SessionSecretsPrevious still verify the cookies signed before
+	// SessionSecret was rotated.
+	SessionSecretsPrevious []string
+	// Blob is where features like snapshots and cached favicons keep their
+	// files.
+	Blob blob.Config
+	// ErrorReporting sends recovered panics to Sentry or GlitchTip.
+	ErrorReporting errreport.Config
+}
+
+// Setting is a configuration value as set by its environment variable.
+type Setting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// maskedSecret replaces secrets in Settings.
+const maskedSecret = "********"
+
+// minSessionSecretLen is the length of a 256-bit key in base64.
+const minSessionSecretLen = 32
+
+func maskIfSet(s string) string {
+	if s == "" {
+		return ""
+	}
+	return maskedSecret
+}
+
+// Settings lists the configuration by environment variable, for display.
+// Secrets are masked.
+func (c Conf) Settings() []Setting {
+	password := ""
+	if c.PasswordHash != nil {
+		password = maskedSecret
+	}
+	flareSolverrURL := c.FlareSolverrURL
+	if u, err := url.Parse(flareSolverrURL); err == nil {
+		flareSolverrURL = u.Redacted()
+	}
+	return []Setting{
+		{"HOST", c.Host},
+		{"PORT", strconv.Itoa(c.Port)},
+		{"PASSWORD", password},
+		{"DB", c.DB},
+		{"DB_JOURNAL_MODE", c.DBJournalMode},
+		{"DB_BUSY_TIMEOUT", strconv.FormatInt(c.DBBusyTimeout.Milliseconds(), 10)},
+		{"DB_SYNCHRONOUS", c.DBSynchronous},
+		{"DB_FOREIGN_KEYS", strconv.FormatBool(c.DBForeignKeys)},
+		{"SECURE_COOKIE", strconv.FormatBool(c.SecureCookie)},
+		{"TLS_CERT", c.TLSCert},
+		{"TLS_KEY", c.TLSKey},
+		{"AUTO_TLS_DOMAIN", strings.Join(c.AutoTLSDomains, ",")},
+		{"AUTO_TLS_CACHE_DIR", c.AutoTLSCacheDir},
+		{"AUTO_TLS_EMAIL", c.AutoTLSEmail},
+		{"AUTO_TLS_HTTP_PORT", strconv.Itoa(c.AutoTLSHTTPPort)},
+		{"TRUSTED_PROXIES", joinIPNets(c.TrustedProxies)},
+		{"ADMIN_ALLOWED_IPS", joinIPNets(c.AdminAllowedIPs)},
+		{"LOGIN_CHALLENGE_AFTER", strconv.Itoa(c.LoginChallengeAfter)},
+		{"LOGIN_CHALLENGE_DIFFICULTY", strconv.Itoa(c.LoginChallengeDifficulty)},
+		{"READ_ONLY", strconv.FormatBool(c.ReadOnly)},
+		{"SESSION_SECRET", maskIfSet(c.SessionSecret)},
+		{"SESSION_SECRET_PREVIOUS", maskIfSet(strings.Join(c.SessionSecretsPrevious, ","))},
+		{"BLOB_STORAGE", c.Blob.Kind},
+		{"BLOB_DIR", c.Blob.Dir},
+		{"S3_ENDPOINT", c.Blob.S3.Endpoint},
+		{"S3_REGION", c.Blob.S3.Region},
+		{"S3_BUCKET", c.Blob.S3.Bucket},
+		{"S3_ACCESS_KEY_ID", c.Blob.S3.AccessKeyID},
+		{"S3_SECRET_ACCESS_KEY", maskIfSet(c.Blob.S3.SecretAccessKey)},
+		{"S3_PATH_STYLE", strconv.FormatBool(c.Blob.S3.PathStyle)},
+		{"SENTRY_DSN", maskIfSet(c.ErrorReporting.DSN)},
+		{"SENTRY_ENVIRONMENT", c.ErrorReporting.Environment},
+		{"FLARESOLVERR_URL", flareSolverrURL},
+		{"PAGE_SIZE", strconv.Itoa(c.PageSize)},
+		{"UNWANTED_LANGUAGES", strings.Join(c.UnwantedLanguages, ",")},
+		{"SNIPPET_LENGTH", strconv.Itoa(c.SnippetLength)},
+		{"PULL_OUTAGE_THRESHOLD", strconv.Itoa(c.PullOutageThreshold)},
+		{"FAVICON_HOSTS", joinFaviconHosts(c.FaviconHosts)},
+		{"LOG_LEVEL", strings.ToLower(c.LogLevel.String())},
+		{"LOG_FORMAT", c.LogFormat},
+		{"LOG_FILE", c.LogFile},
+		{"LOG_FILE_MAX_SIZE_MB", strconv.FormatInt(c.LogFileMaxSize>>20, 10)},
+		{"LOG_FILE_MAX_BACKUPS", strconv.Itoa(c.LogFileMaxBackups)},
+	}
 }
 
 func Load() (Conf, error) {
@@ -32,18 +189,64 @@ func Load() (Conf, error) {
 		if !os.IsNotExist(err) {
 			return Conf{}, err
 		}
-		slog.Warn(fmt.Sprintf("no configuration file found at %s", dotEnvFilename))
+		slog.Warn("no configuration file found", "path", dotEnvFilename)
 	} else {
-		slog.Info(fmt.Sprintf("load configuration from %s", dotEnvFilename))
+		slog.Info("load configuration", "path", dotEnvFilename)
 	}
 	var conf struct {
-		Host         string `env:"HOST" envDefault:"0.0.0.0"`
-		Port         int    `env:"PORT" envDefault:"8080"`
-		Password     string `env:"PASSWORD"`
-		DB           string `env:"DB" envDefault:"fusion.db"`
-		SecureCookie bool   `env:"SECURE_COOKIE" envDefault:"false"`
-		TLSCert      string `env:"TLS_CERT"`
-		TLSKey       string `env:"TLS_KEY"`
+		Host            string `env:"HOST" envDefault:"0.0.0.0"`
+		Port            int    `env:"PORT" envDefault:"8080"`
+		Password        string `env:"PASSWORD"`
+		DB              string `env:"DB" envDefault:"fusion.db"`
+		DBJournalMode   string `env:"DB_JOURNAL_MODE" envDefault:"WAL"`
+		DBBusyTimeout   int    `env:"DB_BUSY_TIMEOUT" envDefault:"5000"`
+		DBSynchronous   string `env:"DB_SYNCHRONOUS" envDefault:"NORMAL"`
+		DBForeignKeys   bool   `env:"DB_FOREIGN_KEYS" envDefault:"true"`
+		SecureCookie    bool   `env:"SECURE_COOKIE" envDefault:"false"`
+		TLSCert         string `env:"TLS_CERT"`
+		TLSKey          string `env:"TLS_KEY"`
+		FlareSolverrURL string `env:"FLARESOLVERR_URL"`
+		PageSize        int    `env:"PAGE_SIZE" envDefault:"10"`
+
+		UnwantedLanguages []string `env:"UNWANTED_LANGUAGES"`
+		SnippetLength     int      `env:"SNIPPET_LENGTH" envDefault:"200"`
+		// PullOutageThreshold is a percentage.
+		PullOutageThreshold int `env:"PULL_OUTAGE_THRESHOLD" envDefault:"50"`
+		// FaviconHosts are prefix=host pairs.
+		FaviconHosts []string `env:"FAVICON_HOSTS"`
+
+		AutoTLSDomains  []string `env:"AUTO_TLS_DOMAIN"`
+		AutoTLSCacheDir string   `env:"AUTO_TLS_CACHE_DIR" envDefault:"autocert"`
+		AutoTLSEmail    string   `env:"AUTO_TLS_EMAIL"`
+		AutoTLSHTTPPort int      `env:"AUTO_TLS_HTTP_PORT" envDefault:"80"`
+
+		TrustedProxies  []string `env:"TRUSTED_PROXIES"`
+		AdminAllowedIPs []string `env:"ADMIN_ALLOWED_IPS"`
+		ReadOnly        bool     `env:"READ_ONLY" envDefault:"false"`
+
+		LoginChallengeAfter      int `env:"LOGIN_CHALLENGE_AFTER" envDefault:"0"`
+		LoginChallengeDifficulty int `env:"LOGIN_CHALLENGE_DIFFICULTY" envDefault:"16"`
+
+		SessionSecret          string   `env:"SESSION_SECRET"`
+		SessionSecretsPrevious []string `env:"SESSION_SECRET_PREVIOUS"`
+
+		BlobStorage       string `env:"BLOB_STORAGE" envDefault:"local"`
+		BlobDir           string `env:"BLOB_DIR"`
+		S3Endpoint        string `env:"S3_ENDPOINT"`
+		S3Region          string `env:"S3_REGION" envDefault:"us-east-1"`
+		S3Bucket          string `env:"S3_BUCKET"`
+		S3AccessKeyID     string `env:"S3_ACCESS_KEY_ID"`
+		S3SecretAccessKey string `env:"S3_SECRET_ACCESS_KEY"`
+		S3PathStyle       bool   `env:"S3_PATH_STYLE" envDefault:"false"`
+
+		SentryDSN         string `env:"SENTRY_DSN"`
+		SentryEnvironment string `env:"SENTRY_ENVIRONMENT" envDefault:"production"`
+
+		LogLevel          string `env:"LOG_LEVEL" envDefault:"info"`
+		LogFormat         string `env:"LOG_FORMAT" envDefault:"json"`
+		LogFile           string `env:"LOG_FILE"`
+		LogFileMaxSizeMB  int    `env:"LOG_FILE_MAX_SIZE_MB" envDefault:"100"`
+		LogFileMaxBackups int    `env:"LOG_FILE_MAX_BACKUPS" envDefault:"3"`
 	}
 	if err := env.Parse(&conf); err != nil {
 		return Conf{}, err
@@ -62,17 +265,236 @@ func Load() (Conf, error) {
 	if (conf.TLSCert == "") != (conf.TLSKey == "") {
 		return Conf{}, errors.New("missing TLS cert or key file")
 	}
-	if conf.TLSCert != "" {
+	if conf.TLSCert != "" && len(conf.AutoTLSDomains) > 0 {
+		return Conf{}, errors.New("AUTO_TLS_DOMAIN can't be used with TLS_CERT and TLS_KEY")
+	}
+	for i, d := range conf.AutoTLSDomains {
+		conf.AutoTLSDomains[i] = strings.ToLower(strings.TrimSpace(d))
+		if conf.AutoTLSDomains[i] == "" {
+			return Conf{}, fmt.Errorf("invalid AUTO_TLS_DOMAIN %q", strings.Join(conf.AutoTLSDomains, ","))
+		}
+	}
+	if len(conf.AutoTLSDomains) > 0 && conf.AutoTLSCacheDir == "" {
+		return Conf{}, errors.New("AUTO_TLS_CACHE_DIR is required with AUTO_TLS_DOMAIN")
+	}
+	if conf.TLSCert != "" || len(conf.AutoTLSDomains) > 0 {
 		conf.SecureCookie = true
 	}
 
+	trustedProxies, err := parseIPNets(conf.TrustedProxies)
+	if err != nil {
+		return Conf{}, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	adminAllowedIPs, err := parseIPNets(conf.AdminAllowedIPs)
+	if err != nil {
+		return Conf{}, fmt.Errorf("invalid ADMIN_ALLOWED_IPS: %w", err)
+	}
+
+	if conf.LoginChallengeAfter < 0 {
+		return Conf{}, errors.New("LOGIN_CHALLENGE_AFTER must not be negative")
+	}
+	if conf.LoginChallengeDifficulty < 1 || conf.LoginChallengeDifficulty > 32 {
+		return Conf{}, errors.New("LOGIN_CHALLENGE_DIFFICULTY must be between 1 and 32")
+	}
+
+	for _, s := range append([]string{conf.SessionSecret}, conf.SessionSecretsPrevious...) {
+		if s != "" && len(s) < minSessionSecretLen {
+			return Conf{}, fmt.Errorf("SESSION_SECRET and SESSION_SECRET_PREVIOUS must be at least %d characters long", minSessionSecretLen)
+		}
+	}
+
+	conf.BlobStorage = strings.ToLower(conf.BlobStorage)
+	switch conf.BlobStorage {
+	case "local":
+	case "s3":
+		if conf.S3Endpoint == "" || conf.S3Bucket == "" || conf.S3AccessKeyID == "" || conf.S3SecretAccessKey == "" {
+			return Conf{}, errors.New("BLOB_STORAGE=s3 requires S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY")
+		}
+	default:
+		return Conf{}, fmt.Errorf("invalid BLOB_STORAGE %q", conf.BlobStorage)
+	}
+	if conf.BlobDir == "" {
+		// Keep the blobs next to the database rather than in the working
+		// directory.
+		conf.BlobDir = filepath.Join(filepath.Dir(conf.DB), "blobs")
+	}
+
+	if conf.SentryDSN != "" {
+		if err := errreport.ValidateDSN(conf.SentryDSN); err != nil {
+			return Conf{}, fmt.Errorf("invalid SENTRY_DSN: %w", err)
+		}
+	}
+
+	if conf.PageSize < 1 {
+		return Conf{}, errors.New("PAGE_SIZE must be a positive number")
+	}
+
+	conf.DBJournalMode = strings.ToUpper(conf.DBJournalMode)
+	if !slices.Contains([]string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}, conf.DBJournalMode) {
+		return Conf{}, fmt.Errorf("invalid DB_JOURNAL_MODE %q", conf.DBJournalMode)
+	}
+	conf.DBSynchronous = strings.ToUpper(conf.DBSynchronous)
+	if !slices.Contains([]string{"OFF", "NORMAL", "FULL", "EXTRA"}, conf.DBSynchronous) {
+		return Conf{}, fmt.Errorf("invalid DB_SYNCHRONOUS %q", conf.DBSynchronous)
+	}
+	if conf.DBBusyTimeout < 0 {
+		return Conf{}, errors.New("DB_BUSY_TIMEOUT must not be negative")
+	}
+
+	for i, l := range conf.UnwantedLanguages {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if !slices.Contains(lang.Supported, l) {
+			return Conf{}, fmt.Errorf("unsupported language %q in UNWANTED_LANGUAGES, supported: %s", l, strings.Join(lang.Supported, ","))
+		}
+		conf.UnwantedLanguages[i] = l
+	}
+
+	if conf.SnippetLength < 0 {
+		return Conf{}, errors.New("SNIPPET_LENGTH must not be negative")
+	}
+	if conf.PullOutageThreshold < 0 || conf.PullOutageThreshold > 100 {
+		return Conf{}, errors.New("PULL_OUTAGE_THRESHOLD must be between 0 and 100")
+	}
+
+	faviconHosts, err := parseFaviconHosts(conf.FaviconHosts)
+	if err != nil {
+		return Conf{}, fmt.Errorf("invalid FAVICON_HOSTS: %w", err)
+	}
+
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(conf.LogLevel)); err != nil {
+		return Conf{}, fmt.Errorf("invalid LOG_LEVEL %q", conf.LogLevel)
+	}
+	conf.LogFormat = strings.ToLower(conf.LogFormat)
+	if !slices.Contains([]string{"json", "text"}, conf.LogFormat) {
+		return Conf{}, fmt.Errorf("invalid LOG_FORMAT %q", conf.LogFormat)
+	}
+	if conf.LogFileMaxSizeMB < 0 {
+		return Conf{}, errors.New("LOG_FILE_MAX_SIZE_MB must not be negative")
+	}
+	if conf.LogFileMaxBackups < 0 {
+		return Conf{}, errors.New("LOG_FILE_MAX_BACKUPS must not be negative")
+	}
+
 	return Conf{
-		Host:         conf.Host,
-		Port:         conf.Port,
-		PasswordHash: pwHash,
-		DB:           conf.DB,
-		SecureCookie: conf.SecureCookie,
-		TLSCert:      conf.TLSCert,
-		TLSKey:       conf.TLSKey,
+		Host:            conf.Host,
+		Port:            conf.Port,
+		PasswordHash:    pwHash,
+		DB:              conf.DB,
+		DBJournalMode:   conf.DBJournalMode,
+		DBBusyTimeout:   time.Duration(conf.DBBusyTimeout) * time.Millisecond,
+		DBSynchronous:   conf.DBSynchronous,
+		DBForeignKeys:   conf.DBForeignKeys,
+		SecureCookie:    conf.SecureCookie,
+		TLSCert:         conf.TLSCert,
+		TLSKey:          conf.TLSKey,
+		AutoTLSDomains:  conf.AutoTLSDomains,
+		AutoTLSCacheDir: conf.AutoTLSCacheDir,
+		AutoTLSEmail:    conf.AutoTLSEmail,
+		AutoTLSHTTPPort: conf.AutoTLSHTTPPort,
+		TrustedProxies:  trustedProxies,
+		AdminAllowedIPs: adminAllowedIPs,
+		ReadOnly:        conf.ReadOnly,
+		FlareSolverrURL: conf.FlareSolverrURL,
+		PageSize:        conf.PageSize,
+
+		UnwantedLanguages:        conf.UnwantedLanguages,
+		SnippetLength:            conf.SnippetLength,
+		PullOutageThreshold:      conf.PullOutageThreshold,
+		LoginChallengeAfter:      conf.LoginChallengeAfter,
+		LoginChallengeDifficulty: conf.LoginChallengeDifficulty,
+		FaviconHosts:             faviconHosts,
+		LogLevel:                 logLevel,
+		LogFormat:                conf.LogFormat,
+		LogFile:                  conf.LogFile,
+		LogFileMaxSize:           int64(conf.LogFileMaxSizeMB) << 20,
+		LogFileMaxBackups:        conf.LogFileMaxBackups,
+
+		SessionSecret:          conf.SessionSecret,
+		SessionSecretsPrevious: conf.SessionSecretsPrevious,
+		Blob: blob.Config{
+			Kind: conf.BlobStorage,
+			Dir:  conf.BlobDir,
+			S3: blob.S3Config{
+				Endpoint:        conf.S3Endpoint,
+				Region:          conf.S3Region,
+				Bucket:          conf.S3Bucket,
+				AccessKeyID:     conf.S3AccessKeyID,
+				SecretAccessKey: conf.S3SecretAccessKey,
+				PathStyle:       conf.S3PathStyle,
+			},
+		},
+		ErrorReporting: errreport.Config{
+			DSN:         conf.SentryDSN,
+			Environment: conf.SentryEnvironment,
+			Release:     Version,
+		},
 	}, nil
 }
+
+// parseFaviconHosts parses prefix=host pairs. Prefixes are links without
+// their scheme, like rsshub.example.com/github.
+func parseFaviconHosts(values []string) (map[string]string, error) {
+	res := make(map[string]string, len(values))
+	for _, v := range values {
+		// Prefixes may have queries, so the host is after the last =.
+		i := strings.LastIndex(v, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%q must be like rsshub.example.com/github=github.com", v)
+		}
+		prefix := strings.TrimSpace(v[:i])
+		host := strings.ToLower(strings.TrimSpace(v[i+1:]))
+		if prefix == "" || host == "" || strings.Contains(prefix, "://") || strings.ContainsAny(host, "/ ") {
+			return nil, fmt.Errorf("%q must be like rsshub.example.com/github=github.com", v)
+		}
+		res[prefix] = host
+	}
+	return res, nil
+}
+
+func joinFaviconHosts(hosts map[string]string) string {
+	res := make([]string, 0, len(hosts))
+	for prefix, host := range hosts {
+		res = append(res, prefix+"="+host)
+	}
+	slices.Sort(res)
+	return strings.Join(res, ",")
+}
+
+func parseIPNets(values []string) ([]*net.IPNet, error) {
+	res := make([]*net.IPNet, 0, len(values))
+	for _, v := range values {
+		ipNet, err := parseIPNet(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", v, err)
+		}
+		res = append(res, ipNet)
+	}
+	return res, nil
+}
+
+func joinIPNets(ipNets []*net.IPNet) string {
+	res := make([]string, 0, len(ipNets))
+	for _, n := range ipNets {
+		res = append(res, n.String())
+	}
+	return strings.Join(res, ",")
+}
+
+// parseIPNet parses either a CIDR, or a single IP as the network of only that
+// address.
+func parseIPNet(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, ipNet, err := net.ParseCIDR(s)
+		return ipNet, err
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, errors.New("not an IP address or CIDR")
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
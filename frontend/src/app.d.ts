@@ -5,7 +5,11 @@ declare global {
 		// interface Error {}
 		// interface Locals {}
 		// interface PageData {}
-		// interface PageState {}
+		interface PageState {
+			// listScrollY is where the item list was scrolled to when an item
+			// was opened from it.
+			listScrollY?: number;
+		}
 		// interface Platform {}
 	}
 }
@@ -1,5 +1,6 @@
 import { goto } from '$app/navigation';
-import ky from 'ky';
+import { error } from '@sveltejs/kit';
+import ky, { HTTPError } from 'ky';
 
 export const api = ky.create({
 	prefixUrl: '/api',
@@ -18,6 +19,7 @@ export const api = ky.create({
 						try {
 							const data = await response.json();
 							error.message = data.message;
+							Object.assign(error, { fields: data.fields ?? {} });
 						} catch (e) {
 							console.log(e);
 						}
@@ -27,3 +29,20 @@ export const api = ky.create({
 		]
 	}
 });
+
+export type FieldErrors = Record<string, string>;
+
+// fieldErrors returns the messages the server attached to the invalid fields
+// of a rejected form, keyed by the JSON name of the field.
+export function fieldErrors(e: unknown): FieldErrors {
+	return (e as { fields?: FieldErrors }).fields ?? {};
+}
+
+// toPageError turns a failed API call in a page loader into a SvelteKit error,
+// so the error page can tell a missing resource apart from a server failure.
+export function toPageError(e: unknown): never {
+	if (e instanceof HTTPError) {
+		error(e.response.status, e.message);
+	}
+	throw e;
+}
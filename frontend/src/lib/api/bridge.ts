@@ -0,0 +1,19 @@
+import { api } from './api';
+import type { Bridge, BridgeKind } from './model';
+
+export async function allBridges() {
+	const resp = await api.get('bridges').json<{ bridges: Bridge[] }>();
+	return resp.bridges;
+}
+
+export async function createBridge(kind: BridgeKind, baseURL: string) {
+	return await api
+		.post('bridges', {
+			json: { kind: kind, base_url: baseURL }
+		})
+		.json<{ id: number }>();
+}
+
+export async function deleteBridge(id: number) {
+	return await api.delete('bridges/' + id);
+}
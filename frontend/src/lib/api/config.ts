@@ -0,0 +1,12 @@
+import { api } from './api';
+
+export type ServerConfig = {
+	read_only: boolean;
+	// favicon_hosts maps link prefixes, without the scheme, to the sites whose
+	// favicon the feeds get.
+	favicon_hosts?: Record<string, string>;
+};
+
+export async function getConfig() {
+	return await api.get('config').json<ServerConfig>();
+}
@@ -0,0 +1,20 @@
+import { api } from './api';
+
+export type DiscoverFeed = {
+	name: string;
+	link: string;
+	site_url: string;
+	subscribed: boolean;
+};
+
+export type DiscoverBundle = {
+	id: string;
+	name: string;
+	feeds: DiscoverFeed[];
+};
+
+export async function getDiscover() {
+	return await api
+		.get('discover')
+		.json<{ bundles: DiscoverBundle[]; suggestions: DiscoverFeed[] }>();
+}
@@ -0,0 +1,15 @@
+export type NewItemsEvent = {
+	feed_id: number;
+	new_items: number;
+};
+
+// subscribeNewItems calls onNewItems each time the server stores new items of
+// a feed. The browser reconnects on its own if the connection drops. It
+// returns a function closing the subscription.
+export function subscribeNewItems(onNewItems: (e: NewItemsEvent) => void) {
+	const source = new EventSource('/api/events');
+	source.addEventListener('new_items', (e) => {
+		onNewItems(JSON.parse((e as MessageEvent).data));
+	});
+	return () => source.close();
+}
@@ -1,33 +1,48 @@
-/**
- *  RSSHub paths mapped to their respective hostname.
- *  Sorted by hostname first then by RSSHub path.
- */
-const rssHubMap = {
-	'/papers/category/arxiv': 'arxiv.org',
-	'/trendingpapers/papers': 'arxiv.org',
-	'/github': 'github.com',
-	'/google': 'google.com',
-	'/dockerhub': 'hub.docker.com',
-	'/imdb': 'imdb.com',
-	'/hackernews': 'news.ycombinator.com',
-	'/phoronix': 'phoronix.com',
-	'/rsshub': 'rsshub.app',
-	'/twitch': 'twitch.tv',
-	'/youtube': 'youtube.com'
-};
+import { bridgedHost, findBridge } from '$lib/bridge';
+import { globalState } from '$lib/state.svelte';
+import type { Feed } from './model';
 
-export function getFavicon(feedLink: string): string {
-	const url = new URL(feedLink);
-	let hostname = url.hostname;
+// feedServices make and host feeds of other sites.
+const feedServices = ['feeds.feedburner.com', 'feedpress.me', 'fetchrss.com', 'rss.app'];
 
-	if (hostname.includes('rsshub')) {
-		for (const prefix in rssHubMap) {
-			if (url.pathname.startsWith(prefix)) {
-				hostname = rssHubMap[prefix as keyof typeof rssHubMap];
-				break;
-			}
+// getFavicon returns the link of the favicon of the feed, which the server
+// fetches once and keeps.
+export function getFavicon(feed: Pick<Feed, 'link' | 'site_url'>): string {
+	return '/api/favicons/' + encodeURIComponent(faviconHost(feed));
+}
+
+// faviconHost returns the site whose favicon the feed gets: the one set for
+// its link in FAVICON_HOSTS, then the one its bridge route comes from. Feeds
+// that aren't served by the site they're about, like those of bridges and
+// fediverse accounts, fall back to the site they declare.
+function faviconHost(feed: Pick<Feed, 'link' | 'site_url'>) {
+	const url = new URL(feed.link);
+	const link = feed.link.replace(/^[a-z]+:(\/\/)?/i, '');
+	let prefix = '';
+	for (const p in globalState.faviconHosts) {
+		if (link.startsWith(p) && p.length > prefix.length) {
+			prefix = p;
 		}
 	}
+	if (prefix) {
+		return globalState.faviconHosts[prefix];
+	}
 
-	return 'https://www.google.com/s2/favicons?sz=32&domain=' + hostname;
+	const bridged = bridgedHost(url, globalState.bridges);
+	if (bridged) {
+		return bridged;
+	}
+	const hosted =
+		!url.protocol.startsWith('http') ||
+		url.hostname.includes('rsshub') ||
+		feedServices.includes(url.hostname) ||
+		findBridge(url, globalState.bridges) !== undefined;
+	if (hosted && feed.site_url) {
+		try {
+			return new URL(feed.site_url).hostname;
+		} catch {
+			// Fall back to the host of the link.
+		}
+	}
+	return url.hostname;
 }
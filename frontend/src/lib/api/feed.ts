@@ -1,5 +1,5 @@
 import { api } from './api';
-import type { Feed } from './model';
+import type { DisplayMode, Feed, Settings } from './model';
 
 export type FeedListFiler = {
 	have_unread?: boolean;
@@ -38,10 +38,14 @@ export async function checkValidity(link: string, options: FeedRequestOptions) {
 }
 
 export type FeedCreateForm = {
-	group_id: number;
+	// omit or use 0 to let the server pick a group by the group rules
+	group_id?: number;
 	feeds: {
 		name: string;
 		link: string;
+		site_url?: string;
+		monitor_only?: boolean;
+		notes?: string;
 		request_options: FeedRequestOptions;
 	}[];
 };
@@ -55,12 +59,44 @@ export async function createFeed(data: FeedCreateForm) {
 		.json<{ ids: number[] }>();
 }
 
+export type BulkCreateResult = {
+	link: string;
+	feed_link?: string;
+	status: 'created' | 'duplicate' | 'invalid';
+	feed_id?: number;
+	error?: string;
+};
+
+// bulkCreateFeeds subscribes to a newline-separated list of links and reports
+// the result of each one.
+export async function bulkCreateFeeds(links: string, groupID?: number) {
+	const resp = await api
+		.post('feeds/bulk', {
+			timeout: 60000,
+			json: { links: links, group_id: groupID }
+		})
+		.json<{ results: BulkCreateResult[] }>();
+	return resp.results;
+}
+
 export type FeedUpdateForm = {
 	name?: string;
 	link?: string;
 	suspended?: boolean;
+	monitor_only?: boolean;
+	weight?: number;
+	embed_videos?: boolean;
+	order_by_position?: boolean;
+	// display_mode is removed when set to ''.
+	display_mode?: DisplayMode | '';
 	req_proxy?: string;
+	// group_id 0 moves the feed to Uncategorized.
 	group_id?: number;
+	capture_response?: boolean;
+	notes?: string;
+	// color and emoji are removed when set to ''.
+	color?: string;
+	emoji?: string;
 };
 
 export async function updateFeed(id: number, data: FeedUpdateForm) {
@@ -69,10 +105,81 @@ export async function updateFeed(id: number, data: FeedUpdateForm) {
 	});
 }
 
+// updateFeedSettings replaces all the settings the feed overrides, unset ones
+// are left to its group.
+export async function updateFeedSettings(id: number, settings: Settings) {
+	return await api.put('feeds/' + id + '/settings', { json: settings });
+}
+
 export async function deleteFeed(id: number) {
 	return await api.delete('feeds/' + id);
 }
 
+// dedupeFeed removes the items of the feed that duplicate older ones, and
+// returns how many it removed.
+export async function dedupeFeed(id: number) {
+	const resp = await api.post('feeds/' + id + '/dedupe').json<{ removed: number }>();
+	return resp.removed;
+}
+
+// mergeFeed moves the items of the feed to another subscription to the same
+// feed, and deletes it.
+export async function mergeFeed(id: number, into: number) {
+	return await api.post('feeds/' + id + '/merge', {
+		json: { into }
+	});
+}
+
+export type RefreshStatus = {
+	id: number;
+	running: boolean;
+	total: number;
+	completed: number;
+	// fetched is the number of feeds the refresh tried to fetch, the others
+	// were skipped.
+	fetched: number;
+	failed: number;
+	pending: number;
+	started_at?: Date;
+	finished_at?: Date;
+	next_run_at?: Date;
+	// outage is set when the last finished refresh failed to fetch most feeds.
+	outage: boolean;
+};
+
+// getRefreshStatus returns the progress of the latest refresh of all feeds,
+// and when the next scheduled one starts.
+export async function getRefreshStatus() {
+	return await api.get('feeds/refresh').json<RefreshStatus>();
+}
+
+export type RefreshSkipReason =
+	| 'suspended'
+	| 'virtual'
+	| 'cooling_off'
+	| 'too_soon'
+	| 'host_unavailable';
+
+export type RefreshResult = {
+	new_items: number;
+	skip_reason?: RefreshSkipReason;
+	next_fetch_at?: Date;
+	duration_ms: number;
+	status_code: number;
+	failure?: string;
+};
+
+// refreshFeed pulls a feed and reports the outcome. Unless force is set, the
+// server skips feeds fetched recently or cooling off after failures.
+export async function refreshFeed(id: number, force = false) {
+	return await api
+		.post('feeds/refresh', {
+			timeout: 20000,
+			json: { id: id, force: force }
+		})
+		.json<RefreshResult>();
+}
+
 export async function refreshFeeds(options: { id?: number; all?: boolean }) {
 	return await api.post('feeds/refresh', {
 		timeout: 20000,
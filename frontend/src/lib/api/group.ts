@@ -1,5 +1,5 @@
 import { api } from './api';
-import type { Group } from './model';
+import type { Group, GroupRule, Label, Settings } from './model';
 
 export async function allGroups() {
 	const resp = await api.get('groups').json<{ groups: Group[] }>();
@@ -16,14 +16,54 @@ export async function createGroup(name: string) {
 		.json<{ id: number }>();
 }
 
-export async function updateGroup(id: number, name: string) {
+export async function updateGroup(
+	id: number,
+	name: string,
+	isDefault?: boolean,
+	isPublic?: boolean,
+	label?: Label
+) {
 	return await api.patch('groups/' + id, {
 		json: {
-			name: name
+			name: name,
+			is_default: isDefault,
+			public: isPublic,
+			color: label?.color,
+			emoji: label?.emoji
 		}
 	});
 }
 
-export async function deleteGroup(id: number) {
-	return await api.delete('groups/' + id);
+// updateGroupSettings replaces all the settings of the group, unset ones are
+// left to the defaults.
+export async function updateGroupSettings(id: number, settings: Settings) {
+	return await api.put('groups/' + id + '/settings', { json: settings });
+}
+
+// deleteGroup deletes the group and moves its feeds to the group moveTo,
+// which is the default group if omitted.
+export async function deleteGroup(id: number, moveTo?: number) {
+	return await api.delete('groups/' + id, {
+		searchParams: moveTo !== undefined ? { move_to: moveTo } : undefined
+	});
+}
+
+export async function allGroupRules() {
+	const resp = await api.get('groups/rules').json<{ rules: GroupRule[] }>();
+	return resp.rules;
+}
+
+export async function createGroupRule(pattern: string, groupID: number) {
+	return await api
+		.post('groups/rules', {
+			json: {
+				pattern: pattern,
+				group_id: groupID
+			}
+		})
+		.json<{ id: number }>();
+}
+
+export async function deleteGroupRule(id: number) {
+	return await api.delete('groups/rules/' + id);
 }
@@ -1,6 +1,6 @@
-import { defaultPageSize } from '$lib/consts';
 import type { URL } from 'url';
 import { api } from './api';
+import { pageSizeState } from '$lib/state.svelte';
 import type { Item } from './model';
 
 export type ListFilter = {
@@ -11,8 +11,40 @@ export type ListFilter = {
 	group_id?: number;
 	unread?: boolean;
 	bookmark?: boolean;
+	language?: string;
+	max_reading_time?: number;
+	media?: ItemMedia;
+	// published_after is an ISO 8601 time, see the Today view.
+	published_after?: string;
 };
 
+export type ItemMedia = 'video' | 'audio' | 'gallery';
+export const itemMediaTypes: ItemMedia[] = ['video', 'audio', 'gallery'];
+
+// shortReadMinutes is the reading time limit of the "short reads" filter.
+export const shortReadMinutes = 3;
+
+// itemLanguages are the ISO 639-1 codes the server detects items in.
+export const itemLanguages = [
+	'ar',
+	'de',
+	'el',
+	'en',
+	'es',
+	'fr',
+	'he',
+	'hi',
+	'it',
+	'ja',
+	'ko',
+	'nl',
+	'pt',
+	'ru',
+	'th',
+	'uk',
+	'zh'
+];
+
 export async function listItems(options?: ListFilter) {
 	if (options) {
 		// trip undefinded fields: https://github.com/sindresorhus/ky/issues/293
@@ -22,14 +54,129 @@ export async function listItems(options?: ListFilter) {
 		.get('items', {
 			searchParams: options
 		})
-		.json<{ total: number; items: Item[] }>();
+		.json<{ total: number; page_size: number; items: Item[] }>();
+}
+
+// ItemPage is a page of items. Pages from streamItems only hold the first rows
+// in items, and rest resolves with the others once they have all arrived.
+export type ItemPage = {
+	total: number;
+	page_size: number;
+	items: Item[];
+	rest?: Promise<Item[]>;
+};
+
+// streamItems lists items like listItems, but resolves as soon as the first
+// rows arrive, so that they can be shown while the others are loading.
+export async function streamItems(options?: ListFilter): Promise<ItemPage> {
+	if (options) {
+		options = JSON.parse(JSON.stringify(options));
+	}
+	const resp = await api.get('items', {
+		searchParams: options,
+		headers: { Accept: 'application/x-ndjson' }
+	});
+	if (!resp.body) {
+		throw new Error('empty response');
+	}
+
+	// The first line has the total and the page size, then each line is an
+	// item.
+	const lines = readLines(resp.body);
+	let header: { total: number; page_size: number } | undefined;
+	const items: Item[] = [];
+	while (header === undefined || items.length === 0) {
+		const { value, done } = await lines.next();
+		if (done) break;
+		for (const line of value) {
+			if (header === undefined) {
+				header = JSON.parse(line);
+			} else {
+				items.push(JSON.parse(line));
+			}
+		}
+	}
+	if (header === undefined) {
+		throw new Error('empty response');
+	}
+
+	const rest = (async () => {
+		const res: Item[] = [];
+		for await (const value of lines) {
+			res.push(...value.map((line) => JSON.parse(line) as Item));
+		}
+		return res;
+	})();
+	return { total: header.total, page_size: header.page_size, items, rest };
+}
+
+// allItems returns all the items of page, waiting for the rest if it's
+// streamed.
+export async function allItems(page: ItemPage) {
+	return page.rest ? [...page.items, ...(await page.rest)] : page.items;
+}
+
+// readLines yields the non-empty lines of body, in batches as they arrive.
+async function* readLines(body: ReadableStream<Uint8Array>) {
+	const reader = body.pipeThrough(new TextDecoderStream()).getReader();
+	try {
+		let buffer = '';
+		for (;;) {
+			const { value, done } = await reader.read();
+			if (done) break;
+			const lines = (buffer + value).split('\n');
+			buffer = lines.pop()!;
+			yield lines.filter((line) => line !== '');
+		}
+		if (buffer !== '') {
+			yield [buffer];
+		}
+	} finally {
+		reader.releaseLock();
+	}
+}
+
+// listHighlights returns the top-scored unread items.
+export async function listHighlights(limit?: number) {
+	return await api
+		.get('items/highlights', {
+			searchParams: limit ? { limit: limit } : undefined
+		})
+		.json<{ total: number; page_size: number; items: Item[] }>();
+}
+
+// listSurprise returns a random sample of the older unread or bookmarked items.
+export async function listSurprise(limit?: number) {
+	return await api
+		.get('items/surprise', {
+			searchParams: limit ? { limit: limit } : undefined
+		})
+		.json<{ total: number; page_size: number; items: Item[] }>();
+}
+
+export type DigestFeed = {
+	feed: Item['feed'];
+	items: Item[];
+};
+
+// getDigest returns the unread items fetched since the given time, with their
+// content, grouped by feed.
+export async function getDigest(since: Date) {
+	return await api
+		.get('items/digest', {
+			searchParams: { since: since.toISOString() }
+		})
+		.json<{ feeds: DigestFeed[]; truncated: boolean }>();
 }
 
 export function parseURLtoFilter(params: URLSearchParams, override?: ListFilter): ListFilter {
 	const filter: ListFilter = {
-		page: parseInt(params.get('page') || '1'),
-		page_size: parseInt(params.get('page_size') || String(defaultPageSize))
+		page: parseInt(params.get('page') || '1')
 	};
+	// fall back to the user's preference, then to the server default
+	const page_size = params.get('page_size');
+	if (page_size) filter.page_size = parseInt(page_size);
+	else if (pageSizeState.pageSize) filter.page_size = pageSizeState.pageSize;
 	const keyword = params.get('keyword');
 	if (keyword) filter.keyword = keyword;
 	const feed_id = params.get('feed_id');
@@ -38,6 +185,12 @@ export function parseURLtoFilter(params: URLSearchParams, override?: ListFilter)
 	if (unread) filter.unread = unread === 'true';
 	const bookmark = params.get('bookmark');
 	if (bookmark) filter.bookmark = bookmark === 'true';
+	const language = params.get('language');
+	if (language) filter.language = language;
+	const max_reading_time = params.get('max_reading_time');
+	if (max_reading_time) filter.max_reading_time = parseInt(max_reading_time);
+	const media = params.get('media');
+	if (media) filter.media = media as ItemMedia;
 	return { ...filter, ...override };
 }
 
@@ -56,13 +209,34 @@ export async function getItem(id: number) {
 	return api.get('items/' + id).json<Item>();
 }
 
-export async function updateUnread(ids: number[], unread: boolean) {
-	return api.patch('items/-/unread', {
-		json: {
-			ids: ids,
-			unread: unread
+// openItem marks the item as read and opens its original link in a new tab.
+// The tab is opened right away, as browsers block those opened once a request
+// is done, and sent to the link when it's known.
+export async function openItem(id: number) {
+	const tab = window.open('', '_blank');
+	try {
+		const resp = await api.post('items/' + id + '/open').json<{ link: string }>();
+		if (tab) {
+			tab.opener = null;
+			tab.location.href = resp.link;
 		}
-	});
+	} catch (e) {
+		tab?.close();
+		throw e;
+	}
+}
+
+// updateUnread returns the number of items whose state changed, and the new
+// unread counts of the affected feeds, keyed by feed ID.
+export async function updateUnread(ids: number[], unread: boolean) {
+	return api
+		.patch('items/-/unread', {
+			json: {
+				ids: ids,
+				unread: unread
+			}
+		})
+		.json<{ updated: number; unread_counts: Record<number, number> }>();
 }
 
 export async function updateBookmark(id: number, bookmark: boolean) {
@@ -72,3 +246,76 @@ export async function updateBookmark(id: number, bookmark: boolean) {
 		}
 	});
 }
+
+// toggleUnread and toggleBookmark flip the state on the server and return the
+// updated item, so the list can be updated without another request.
+export async function toggleUnread(id: number) {
+	return api.post('items/' + id + '/unread/toggle').json<Item>();
+}
+
+export async function toggleBookmark(id: number) {
+	return api.post('items/' + id + '/bookmark/toggle').json<Item>();
+}
+
+// batchUpdateBookmark and batchUpdateBookmarkByFilter return the number of
+// items updated.
+export async function batchUpdateBookmark(ids: number[], bookmark: boolean) {
+	return api
+		.patch('items/-/bookmark', {
+			json: {
+				ids: ids,
+				bookmark: bookmark
+			}
+		})
+		.json<{ updated: number }>();
+}
+
+export async function batchUpdateBookmarkByFilter(
+	filter: Pick<ListFilter, 'keyword' | 'feed_id' | 'group_id' | 'unread' | 'bookmark'>,
+	bookmark: boolean
+) {
+	return api
+		.patch('items/-/bookmark', {
+			json: {
+				filter: filter,
+				bookmark: bookmark
+			}
+		})
+		.json<{ updated: number }>();
+}
+
+// batchUpdateHidden returns the number of items updated, and the new unread
+// counts of the affected feeds, as hiding items marks them as read.
+export async function batchUpdateHidden(ids: number[], hidden: boolean) {
+	return api
+		.patch('items/-/hidden', {
+			json: {
+				ids: ids,
+				hidden: hidden
+			}
+		})
+		.json<{ updated: number; unread_counts: Record<number, number> }>();
+}
+
+// deleteItems deletes the items for good: they aren't fetched again.
+export async function deleteItems(ids: number[]) {
+	if (ids.length === 1) {
+		return api.delete('items/' + ids[0]);
+	}
+	return api.delete('items/-', { json: { ids: ids } });
+}
+
+// updatePlayback updates the playback state of the enclosure of the item.
+export async function updatePlayback(id: number, data: { position?: number; listened?: boolean }) {
+	return api.post('items/' + id + '/playback', { json: data });
+}
+
+// savePlaybackPosition records where playing the enclosure of the item was
+// left off. It's sent as a beacon, so that it isn't cancelled when the user
+// leaves the page.
+export function savePlaybackPosition(id: number, position: number) {
+	navigator.sendBeacon(
+		'/api/items/' + id + '/playback',
+		new Blob([JSON.stringify({ position: position })], { type: 'application/json' })
+	);
+}
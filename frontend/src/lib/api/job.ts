@@ -0,0 +1,31 @@
+import { api } from './api';
+
+export type JobStatus = 'pending' | 'running' | 'succeeded' | 'failed';
+
+export type Job = {
+	id: number;
+	kind: string;
+	payload: unknown;
+	status: JobStatus;
+	attempts: number;
+	failure: string | null;
+	run_at: Date;
+	created_at: Date;
+	finished_at: Date | null;
+};
+
+export async function listJobs(options: { status?: JobStatus; page?: number; page_size?: number }) {
+	const searchParams = new URLSearchParams();
+	for (const [k, v] of Object.entries(options)) {
+		if (v !== undefined) searchParams.set(k, String(v));
+	}
+	return await api.get('jobs', { searchParams }).json<{ total: number; jobs: Job[] }>();
+}
+
+export async function retryJob(id: number) {
+	return await api.post(`jobs/${id}/retry`);
+}
+
+export async function deleteJob(id: number) {
+	return await api.delete('jobs/' + id);
+}
@@ -1,13 +1,68 @@
+import { sha256 } from '$lib/sha256';
+import { HTTPError } from 'ky';
 import { api } from './api';
 
+// LoginChallenge is asked of clients that failed to log in too often: they
+// must find a solution whose SHA-256, following the challenge, starts with
+// difficulty zero bits.
+type LoginChallenge = {
+	challenge: string;
+	difficulty: number;
+};
+
+// login starts a session, solving a challenge first if the server asks for
+// one.
 export async function login(password: string) {
-	return api.post('sessions', {
-		json: {
-			password: password
+	try {
+		return await api.post('sessions', { json: { password: password } });
+	} catch (e) {
+		if (!(e instanceof HTTPError) || e.response.status !== 428) {
+			throw e;
 		}
+	}
+	const { challenge, difficulty } = await api.get('sessions/challenge').json<LoginChallenge>();
+	const solution = await solveChallenge(challenge, difficulty);
+	return api.post('sessions', {
+		json: { password: password, challenge: challenge, solution: solution }
 	});
 }
 
+async function solveChallenge(challenge: string, difficulty: number) {
+	const encoder = new TextEncoder();
+	for (let n = 0; ; n++) {
+		const solution = n.toString(36);
+		const digest = await hash(encoder.encode(challenge + solution));
+		if (leadingZeroBits(digest) >= difficulty) {
+			return solution;
+		}
+	}
+}
+
+// hash returns the SHA-256 of data. crypto.subtle is missing over plain HTTP,
+// except on localhost, which is how Fusion is often reached on a LAN.
+async function hash(data: Uint8Array) {
+	if (!globalThis.crypto?.subtle) {
+		return sha256(data);
+	}
+	return new Uint8Array(await crypto.subtle.digest('SHA-256', data));
+}
+
+function leadingZeroBits(bytes: Uint8Array) {
+	let n = 0;
+	for (const b of bytes) {
+		if (b !== 0) {
+			return n + Math.clz32(b) - 24;
+		}
+		n += 8;
+	}
+	return n;
+}
+
 export async function logout() {
 	return api.delete('sessions');
 }
+
+// logoutEverywhere ends every session, on all devices.
+export async function logoutEverywhere() {
+	return api.delete('sessions/all');
+}
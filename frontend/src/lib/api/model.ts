@@ -1,28 +1,157 @@
-export type Group = {
+// Label marks a group or feed in lists. color is a CSS hex color.
+export type Label = {
+	color?: string;
+	emoji?: string;
+};
+
+export type Group = Label & {
 	id: number;
 	name: string;
+	is_default?: boolean;
+	public?: boolean;
+	settings?: Settings;
+};
+
+export type Sanitize = 'standard' | 'strict';
+
+// DisplayMode is the layout a feed's items are listed in, whatever the
+// user's own. title_only items open their links rather than their content.
+export type DisplayMode = 'magazine' | 'cards' | 'gallery' | 'title_only';
+
+// Settings are how feeds are fetched and shown. Groups set them for their
+// feeds, and each feed can override them. Unset fields are left to the
+// defaults for groups, and to the group for feeds.
+export type Settings = {
+	req_proxy?: string;
+	// refresh_interval is in minutes, at least 30.
+	refresh_interval?: number;
+	// sanitize 'strict' also drops media and embeds from the content.
+	sanitize?: Sanitize;
+	monitor_only?: boolean;
+};
+
+export type GroupRule = {
+	id: number;
+	pattern: string;
+	group: Group;
 };
 
-export type Feed = {
+export type Feed = Label & {
 	id: number;
 	name: string;
 	link: string;
+	site_url?: string;
+	// canonical_link is the link the feed declares as its own, or where its
+	// link redirects to.
+	canonical_link?: string;
+	// duplicate_of is another subscription to the same feed, which this one
+	// can be merged into.
+	duplicate_of?: number;
+	// description is what the feed says it's about, as plain text.
+	description?: string;
 	failure: string;
+	removed_from_opml?: boolean;
+	blocked: boolean;
 	updated_at: Date;
+	// last_build is when the feed says its content last changed.
+	last_build?: Date;
+	last_fetched_at?: Date;
+	next_fetch_at?: Date;
+	last_item_at?: Date;
 	suspended: boolean;
+	// monitor_only, refresh_interval, sanitize and req_proxy are the settings
+	// the feed overrides, settings are the ones it's fetched and shown with.
+	monitor_only?: boolean;
+	refresh_interval?: number;
+	sanitize?: Sanitize;
+	weight?: number;
+	embed_videos?: boolean;
+	order_by_position?: boolean;
+	display_mode?: DisplayMode;
 	req_proxy: string;
+	// notes is whatever the user wants to remember about the feed.
+	notes?: string;
+	capture_response: boolean;
+	last_response?: string;
 	unread_count: number;
-	group: Group;
+	// group is null for uncategorized feeds.
+	group: Group | null;
+	settings: Settings;
 };
 
 export type Item = {
 	id: number;
 	title: string;
 	link: string;
+	guid: string;
 	content: string;
+	// snippet is the beginning of the content as plain text, unset for items
+	// stored before snippets were.
+	snippet?: string;
 	unread: boolean;
 	bookmark: boolean;
+	// hidden items are left out of all lists, but can still be opened.
+	hidden?: boolean;
 	pub_date: Date;
 	updated_at: Date;
-	feed: Pick<Feed, 'id' | 'name' | 'link'>;
+	language?: string;
+	reading_time?: number;
+	word_count?: number;
+	has_video: boolean;
+	has_audio: boolean;
+	has_gallery: boolean;
+	// image_url is the lead image of the item, if it has one.
+	image_url?: string;
+	// unread_count is only set in responses to actions that change it.
+	feed: Pick<
+		Feed,
+		'id' | 'name' | 'link' | 'site_url' | 'embed_videos' | 'display_mode' | 'color' | 'emoji'
+	> & {
+		unread_count?: number;
+		sanitize: Sanitize;
+	};
+	enclosure?: Enclosure;
+	score?: number;
+	// origin is only set when getting a single item.
+	origin?: ItemOrigin;
+};
+
+// ItemOrigin tells when and how an item arrived. kind and source_url are
+// unset for items stored before they were tracked.
+export type ItemOrigin = {
+	fetched_at: Date;
+	kind?: 'scheduled' | 'refresh' | 'import' | 'api';
+	// source_url is where the feed was fetched from, after redirects.
+	source_url?: string;
+};
+
+// Enclosure is the audio or video file of an item, like a podcast episode.
+export type Enclosure = {
+	url: string;
+	type: string;
+	// playback_position is where, in seconds, playing it was left off.
+	playback_position: number;
+	listened: boolean;
+};
+
+export type ScoreKeyword = {
+	id: number;
+	keyword: string;
+	weight: number;
+};
+
+export type BridgeKind = 'rsshub' | 'rss-bridge' | 'nitter';
+
+export type Bridge = {
+	id: number;
+	kind: BridgeKind;
+	base_url: string;
+};
+
+export type OPMLSubscription = {
+	id: number;
+	link: string;
+	last_sync?: Date;
+	failure: string;
+	group: Group;
 };
@@ -0,0 +1,9 @@
+import { api } from './api';
+
+// getNotesLink returns the absolute URL notes are posted to. Like the search
+// feed links, it carries its own token, so it can be used by browser
+// shortcuts and share sheets.
+export async function getNotesLink() {
+	const resp = await api.get('notes/link').json<{ link: string }>();
+	return new URL(resp.link, window.location.origin).toString();
+}
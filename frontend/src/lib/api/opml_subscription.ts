@@ -0,0 +1,28 @@
+import { api } from './api';
+import type { OPMLSubscription } from './model';
+
+export async function listOPMLSubscriptions() {
+	const resp = await api
+		.get('opml-subscriptions')
+		.json<{ subscriptions: OPMLSubscription[] }>();
+	return resp.subscriptions;
+}
+
+export async function createOPMLSubscription(link: string, groupID: number) {
+	return await api
+		.post('opml-subscriptions', {
+			timeout: 60000,
+			json: { link: link, group_id: groupID }
+		})
+		.json<{ id: number }>();
+}
+
+export async function deleteOPMLSubscription(id: number) {
+	return await api.delete('opml-subscriptions/' + id);
+}
+
+export async function syncOPMLSubscription(id: number) {
+	return await api.post('opml-subscriptions/' + id + '/sync', {
+		timeout: 60000
+	});
+}
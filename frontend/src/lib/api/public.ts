@@ -0,0 +1,41 @@
+import { api } from './api';
+import type { Sanitize } from './model';
+
+// The public API serves the groups marked as public to visitors who aren't
+// logged in. It leaves out the reading state.
+
+export type PublicGroup = {
+	id: number;
+	name: string;
+};
+
+export type PublicItem = {
+	id: number;
+	title: string;
+	link: string;
+	content?: string;
+	pub_date: Date;
+	reading_time?: number;
+	feed: {
+		id: number;
+		name: string;
+		site_url?: string;
+		embed_videos?: boolean;
+		sanitize?: Sanitize;
+	};
+};
+
+export async function listPublicGroups() {
+	const resp = await api.get('public/groups').json<{ groups: PublicGroup[] }>();
+	return resp.groups;
+}
+
+export async function listPublicItems(groupID: number, page: number) {
+	return await api
+		.get(`public/groups/${groupID}/items`, { searchParams: { page: page } })
+		.json<{ group: PublicGroup; total: number; page_size: number; items: PublicItem[] }>();
+}
+
+export async function getPublicItem(id: number) {
+	return await api.get('public/items/' + id).json<PublicItem>();
+}
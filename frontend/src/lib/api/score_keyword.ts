@@ -0,0 +1,19 @@
+import { api } from './api';
+import type { ScoreKeyword } from './model';
+
+export async function allScoreKeywords() {
+	const resp = await api.get('score-keywords').json<{ keywords: ScoreKeyword[] }>();
+	return resp.keywords;
+}
+
+export async function createScoreKeyword(keyword: string, weight: number) {
+	return await api
+		.post('score-keywords', {
+			json: { keyword: keyword, weight: weight }
+		})
+		.json<{ id: number }>();
+}
+
+export async function deleteScoreKeyword(id: number) {
+	return await api.delete('score-keywords/' + id);
+}
@@ -0,0 +1,12 @@
+import { api } from './api';
+
+// getSearchFeedLink returns the absolute URL of the RSS feed of items matching
+// keyword. The URL carries its own token, so it can be used by other tools.
+export async function getSearchFeedLink(keyword: string) {
+	const resp = await api
+		.get('search-feed/link', {
+			searchParams: { keyword: keyword }
+		})
+		.json<{ link: string }>();
+	return new URL(resp.link, window.location.origin).toString();
+}
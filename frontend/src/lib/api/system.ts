@@ -0,0 +1,75 @@
+import { api } from './api';
+import type { RefreshStatus } from './feed';
+
+export type SystemStatus = {
+	version: string;
+	started_at: Date;
+	uptime_seconds: number;
+	db_path: string;
+	db_size: number;
+	schema: {
+		version: number;
+		latest_version: number;
+		pending_migrations: { version: number; description: string }[];
+	};
+	counts: {
+		feeds: number;
+		items: number;
+		unread_items: number;
+		bookmarked_items: number;
+	};
+	runtime: {
+		go_version: string;
+		os: string;
+		arch: string;
+		num_cpu: number;
+		goroutines: number;
+		heap_alloc: number;
+		sys: number;
+		num_gc: number;
+	};
+	last_pull: RefreshStatus;
+	config: { name: string; value: string }[];
+};
+
+export type LatestRelease = {
+	current_version: string;
+	latest_version: string;
+	url: string;
+	published_at: Date;
+	update_available: boolean;
+};
+
+// IntegrityReport counts the rows left referencing rows that are gone.
+export type IntegrityReport = {
+	orphaned_items: number;
+	feeds_without_group: number;
+	group_rules_without_group: number;
+	opml_subscriptions_without_group: number;
+	feeds_without_opml_subscription: number;
+	repaired: boolean;
+};
+
+export async function getSystemStatus() {
+	return await api.get('system').json<SystemStatus>();
+}
+
+// getMetricsLink returns the absolute URL of the Prometheus metrics. Like the
+// search feed links, it carries its own token.
+export async function getMetricsLink() {
+	const resp = await api.get('metrics/link').json<{ link: string }>();
+	return new URL(resp.link, window.location.origin).toString();
+}
+
+// checkLatestRelease asks the server to look up the latest release on GitHub.
+export async function checkLatestRelease() {
+	return await api.get('system/latest-release').json<LatestRelease>();
+}
+
+export async function checkIntegrity() {
+	return await api.get('system/integrity').json<IntegrityReport>();
+}
+
+export async function repairIntegrity() {
+	return await api.post('system/integrity').json<IntegrityReport>();
+}
@@ -0,0 +1,127 @@
+import type { Bridge, BridgeKind } from './api/model';
+
+// BridgeTemplate is a kind of source bridges make feeds of, like the posts
+// of a subreddit. Its links on each kind of bridge are made of a single
+// value, like the name of the subreddit.
+export type BridgeTemplate = {
+	id: 'twitter' | 'instagram' | 'reddit' | 'telegram';
+	// host is the site the feeds come from, whose favicon they get.
+	host: string;
+	// paths are appended to the base URL of the bridges that support the
+	// template.
+	paths: Partial<Record<BridgeKind, (value: string) => string>>;
+};
+
+function rssBridge(bridge: string, params: Record<string, string>) {
+	return '/?' + new URLSearchParams({ action: 'display', bridge, ...params, format: 'Atom' });
+}
+
+export const bridgeTemplates: BridgeTemplate[] = [
+	{
+		id: 'twitter',
+		host: 'x.com',
+		paths: {
+			rsshub: (v) => `/twitter/user/${encodeURIComponent(v)}`,
+			'rss-bridge': (v) => rssBridge('TwitterBridge', { context: 'By username', u: v }),
+			nitter: (v) => `/${encodeURIComponent(v)}/rss`
+		}
+	},
+	{
+		id: 'instagram',
+		host: 'instagram.com',
+		paths: {
+			rsshub: (v) => `/instagram/user/${encodeURIComponent(v)}`,
+			'rss-bridge': (v) => rssBridge('InstagramBridge', { context: 'Username', u: v })
+		}
+	},
+	{
+		id: 'reddit',
+		host: 'reddit.com',
+		paths: {
+			rsshub: (v) => `/reddit/subreddit/${encodeURIComponent(v)}`,
+			'rss-bridge': (v) => rssBridge('RedditBridge', { context: 'single', r: v })
+		}
+	},
+	{
+		id: 'telegram',
+		host: 't.me',
+		paths: {
+			rsshub: (v) => `/telegram/channel/${encodeURIComponent(v)}`,
+			'rss-bridge': (v) => rssBridge('TelegramBridge', { username: v })
+		}
+	}
+];
+
+// expandTemplate returns the link of the feed of value on bridge, or
+// undefined if the bridge doesn't support the template. Leading @ and r/, as
+// in handles and subreddit names, are dropped.
+export function expandTemplate(template: BridgeTemplate, bridge: Bridge, value: string) {
+	const path = template.paths[bridge.kind];
+	const v = value.trim().replace(/^(@|r\/)/, '');
+	if (!path || !v) {
+		return undefined;
+	}
+	return bridge.base_url + path(v);
+}
+
+// rsshubRoutes maps the paths of RSSHub routes to the sites their feeds come
+// from. Sorted by hostname first then by path.
+const rsshubRoutes: Record<string, string> = {
+	'/papers/category/arxiv': 'arxiv.org',
+	'/trendingpapers/papers': 'arxiv.org',
+	'/github': 'github.com',
+	'/google': 'google.com',
+	'/dockerhub': 'hub.docker.com',
+	'/imdb': 'imdb.com',
+	'/instagram': 'instagram.com',
+	'/hackernews': 'news.ycombinator.com',
+	'/phoronix': 'phoronix.com',
+	'/reddit': 'reddit.com',
+	'/rsshub': 'rsshub.app',
+	'/telegram': 't.me',
+	'/twitch': 'twitch.tv',
+	'/twitter': 'x.com',
+	'/youtube': 'youtube.com'
+};
+
+// rssBridgeHosts maps the bridges of RSS-Bridge to the sites their feeds
+// come from.
+const rssBridgeHosts: Record<string, string> = {
+	InstagramBridge: 'instagram.com',
+	RedditBridge: 'reddit.com',
+	TelegramBridge: 't.me',
+	TwitterBridge: 'x.com'
+};
+
+// findBridge returns the bridge of bridges the feed at url is made by.
+export function findBridge(url: URL, bridges: Bridge[]) {
+	return bridges.find((b) => url.href.startsWith(b.base_url + '/'));
+}
+
+// bridgedHost returns the site the feed at url comes from, if it's made by
+// one of bridges, or by an instance of RSSHub with "rsshub" in its hostname.
+export function bridgedHost(url: URL, bridges: Bridge[]) {
+	const bridge = findBridge(url, bridges);
+	let kind = bridge?.kind;
+	if (!kind && url.hostname.includes('rsshub')) {
+		kind = 'rsshub';
+	}
+	switch (kind) {
+		case 'rsshub': {
+			// Instances may be served under a path.
+			const base = bridge ? new URL(bridge.base_url).pathname.replace(/\/$/, '') : '';
+			const path = url.pathname.slice(base.length);
+			for (const prefix in rsshubRoutes) {
+				if (path.startsWith(prefix)) {
+					return rsshubRoutes[prefix];
+				}
+			}
+			return undefined;
+		}
+		case 'rss-bridge':
+			return rssBridgeHosts[url.searchParams.get('bridge') ?? ''];
+		case 'nitter':
+			return 'x.com';
+	}
+	return undefined;
+}
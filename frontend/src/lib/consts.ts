@@ -1 +0,0 @@
-export const defaultPageSize = 12;
@@ -0,0 +1,37 @@
+import hljs from 'highlight.js/lib/common';
+
+// highlight marks up the tokens of the code blocks in item content with
+// highlight.js, so that they're colored with the theme. Its classes are
+// prefixed with hl-, see app.css.
+
+hljs.configure({ classPrefix: 'hl-' });
+
+export function highlight(dom: Document) {
+	dom.querySelectorAll('pre').forEach((pre) => {
+		const code = pre.querySelector('code') ?? pre;
+		// Only the text is highlighted, so leave blocks with line breaks or
+		// links as they are. Other markup lost its classes to sanitizing.
+		if (code.querySelector(':not(span)')) return;
+
+		const lang = (
+			code.getAttribute('data-lang') ||
+			pre.getAttribute('data-lang') ||
+			''
+		).toLowerCase();
+		const text = code.textContent || '';
+		// The output is escaped by highlight.js, so it's as safe as the text.
+		code.innerHTML = hljs.getLanguage(lang)
+			? hljs.highlight(text, { language: lang, ignoreIllegals: true }).value
+			: hljs.highlightAuto(text).value;
+	});
+}
+
+// keepCodeLanguage is a DOMPurify hook keeping the language of code blocks,
+// like "language-go", in data-lang, as their classes are dropped.
+export function keepCodeLanguage(node: Element) {
+	if (node.tagName !== 'CODE' && node.tagName !== 'PRE') return;
+	const lang = node.getAttribute('class')?.match(/(?:^|\s)lang(?:uage)?-([\w+#-]+)/)?.[1];
+	if (lang) {
+		node.setAttribute('data-lang', lang);
+	}
+}
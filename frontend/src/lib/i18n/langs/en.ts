@@ -16,9 +16,11 @@ const lang = {
 	'common.unread': 'Unread',
 	'common.bookmark': 'Bookmark',
 	'common.all': 'All',
+	'common.breadcrumbs': 'Breadcrumbs',
 	'common.feeds': 'Feeds',
 	'common.group': 'Group',
 	'common.groups': 'Groups',
+	'common.uncategorized': 'Uncategorized',
 	'common.settings': 'Settings',
 	'common.name': 'Name',
 	'common.password': 'Password',
@@ -35,26 +37,120 @@ const lang = {
 	'state.no_data': 'No data',
 	'state.no_more_data': 'No more data',
 
+	// error page
+	'error.not_found.title': 'Page not found',
+	'error.not_found.description': 'The page you are looking for does not exist or has been deleted.',
+	'error.failed.title': 'Something went wrong',
+	'error.failed.description': 'Fusion could not load this page. Please try again.',
+	'error.reload': 'Try again',
+	'error.home': 'Back to home',
+
 	// feed
 	'feed.refresh': 'Refresh Feed',
 	'feed.refresh.all': 'Refresh All Feeds',
 	'feed.refresh.all.confirm':
 		'Are you sure you want to refresh all feeds except the suspended ones?',
 	'feed.refresh.all.run_in_background': 'Start refreshing in the background',
+	'feed.refresh.all.progress': 'Refreshing feeds: {completed} of {total} done',
+	'feed.refresh.all.done': 'Refreshed {total} feeds',
+	'feed.refresh.all.done_with_failures': 'Refreshed {total} feeds, {failed} failed',
+	'feed.refresh.new_items': '{count} new items ({seconds}s)',
+	'feed.refresh.skipped.suspended': 'Not refreshed, refreshing is suspended for this feed',
+	'feed.refresh.skipped.virtual': 'Not refreshed, items of this feed are added through the API',
+	'feed.refresh.skipped.too_soon': 'Not refreshed, the feed was fetched recently. Next refresh at {next}',
+	'feed.refresh.skipped.cooling_off':
+		'Not refreshed, the feed failed recently and is retried at {next}',
+	'feed.refresh.skipped.host_unavailable':
+		'Not refreshed, the website is not responding to any of its feeds. It is retried at {next}',
+	'feed.refresh.anyway': 'Refresh anyway',
+	'feed.refresh.failed': 'Failed to refresh: {error}',
 	'feed.refresh.resume': 'Resume refreshing',
 	'feed.refresh.suspend': 'Suspend refreshing',
+	'feed.monitor_only': 'Monitor only',
+	'feed.monitor_only.description':
+		'New items are marked as read automatically, so they skip Unread. They are still searchable and listed on the feed page. Meant for archival feeds, like release notes.',
+	'feed.refresh_interval': 'Refresh interval (minutes)',
+	'feed.refresh_interval.description':
+		'How often the feed is checked for new items, from every 30 minutes to once a week.',
+	'feed.sanitize': 'Content cleanup',
+	'feed.sanitize.standard': 'Standard',
+	'feed.sanitize.strict': 'Strict',
+	'feed.sanitize.description':
+		'Standard removes the styling of items. Strict also removes images, videos and embeds, leaving text and links.',
+	'feed.settings.inheritable': 'Group settings',
+	'feed.settings.inheritable.description':
+		"Unless set here, these follow the settings of the feed's group.",
+	'feed.settings.inherit': 'Same as the group',
+	'feed.weight': 'Weight',
+	'feed.weight.description':
+		'Added to the score of every item of this feed in Highlights. Use a negative value to demote it.',
+	'feed.embed_videos': 'Embed videos',
+	'feed.embed_videos.description':
+		'Play YouTube videos in items, after clicking their thumbnail. Otherwise the thumbnail links to the video.',
+	'feed.order_by_position': 'Keep the order of the feed',
+	'feed.order_by_position.description':
+		'List items in the order the feed lists them instead of by date. For digests and roundups whose items share a date or have none.',
+	'feed.display_mode': 'Layout',
+	'feed.display_mode.default': 'As in the appearance settings',
+	'feed.display_mode.title_only': 'Titles only',
+	'feed.display_mode.description':
+		'How the items are listed on this page. Gallery suits photo blogs. Titles only suits link blogs: items open their links instead of their content, in every list.',
+	'feed.site_url': 'Website',
+	'feed.last_build': 'Last updated: {time}',
+	'feed.fetch_status.last_fetched': 'Fetched {time}',
+	'feed.fetch_status.never_fetched': 'Not fetched yet',
+	'feed.fetch_status.failed': 'Last attempt failed',
+	'feed.fetch_status.next': 'Next refresh {time}',
+	'feed.fetch_status.next_due': 'Next refresh due',
+	'feed.fetch_status.suspended': 'Refreshing suspended',
+	'feed.fetch_status.refresh': 'Refresh now',
+	'label.emoji': 'Emoji',
+	'label.color': 'Color',
+	'label.clear': 'Remove the label',
+	'feed.label': 'Label',
+	'feed.notes': 'Notes',
+	'feed.notes.description': 'Only for you, like why you subscribed. Included in OPML exports.',
+	'feed.last_item_at': 'Last new item: {time}',
+	'feed.last_item_at.never': 'No new items yet',
+	'feed.dedupe': 'Remove duplicates',
+	'feed.dedupe.description':
+		'Remove the items with the same link and title as an older one, keeping its read and bookmark state',
+	'feed.dedupe.done': 'Removed {count} duplicate items',
 	'feed.delete.confirm': 'Are you sure you want to delete this feed?',
 	'feed.banner.suspended': 'This feed has been suspended',
 	'feed.banner.failed': 'Failed to refresh the feed. Error: {error}',
+	'feed.banner.duplicate': 'You are also subscribed to this feed as {name}.',
+	'feed.banner.duplicate.merge': 'Merge into it',
+	'feed.banner.removed_from_opml':
+		'This feed is no longer listed in the OPML it was subscribed from. You may want to delete it.',
 
 	'feed.import.title': 'Add Feeds',
 	'feed.import.manually': 'Manually',
 	'feed.import.manually.link.description':
-		'Either the RSS link or the website link. The server will automatically attempt to locate the RSS feed. The existing feed with the same link will be overridden.',
+		'Either the RSS link, the website link, or a fediverse account like @user@mastodon.social. The server will automatically attempt to locate the RSS feed. The existing feed with the same link will be overridden.',
 	'feed.import.manually.name.description': 'Optional. Leave blank for automatic naming.',
 	'feed.import.manually.no_valid_feed_error':
 		'No valid feed was found. Please check the link, or submit a feed link directly.',
 	'feed.import.manually.link_candidates.label': 'Select a link',
+	'feed.import.manually.bridge': 'From a bridge',
+	'feed.import.manually.bridge.description':
+		'Fills the link in with the feed your bridge makes of the source.',
+	'feed.import.manually.bridge.unsupported': 'None of your bridges supports this source.',
+	'feed.import.manually.bridge.use': 'Use',
+	'feed.import.manually.bridge.twitter': 'Twitter user',
+	'feed.import.manually.bridge.instagram': 'Instagram user',
+	'feed.import.manually.bridge.reddit': 'Subreddit',
+	'feed.import.manually.bridge.telegram': 'Telegram channel',
+	'feed.import.group.auto': 'Auto (by group rules)',
+	'feed.import.bulk': 'Bulk',
+	'feed.import.bulk.links.label': 'Links',
+	'feed.import.bulk.status.created': 'Created',
+	'feed.import.bulk.status.duplicate': 'Duplicate',
+	'feed.import.bulk.status.invalid': 'Invalid',
+	'feed.import.opml_url': 'OPML URL',
+	'feed.import.opml_url.link.description':
+		'The OPML file is synced periodically: new feeds are added, and removed feeds are flagged.',
+	'feed.import.opml_url.group.description': 'The group for feeds that are not in any folder.',
 	'feed.import.opml': 'Import OPML',
 	'feed.import.opml.file.label': 'Pick a OPML file',
 	'feed.import.opml.file.description':
@@ -62,7 +158,7 @@ const lang = {
 	'feed.import.opml.file_read_error': 'Failed to load file content',
 	'feed.import.opml.how_it_works.title': 'How it works?',
 	'feed.import.opml.how_it_works.description.1':
-		'Feeds will be imported into the corresponding group, which will be created automatically if it does not exist.',
+		'Feeds will be imported into the corresponding group, which will be created automatically if it does not exist. Feeds outside of any group are placed by the auto-grouping rules.',
 	'feed.import.opml.how_it_works.description.2':
 		"Multidimensional group will be flattened to a one-dimensional structure, using a naming convention like 'a/b/c'.",
 	'feed.import.opml.how_it_works.description.3':
@@ -71,27 +167,237 @@ const lang = {
 	// item
 	'item.search.placeholder': 'Search in title and content',
 	'item.mark_all_as_read': 'Mark all as read',
+	'item.select': 'Select',
+	'item.show_new_items': 'Show {count} new items',
 	'item.mark_as_read': 'Mark as read',
 	'item.mark_as_unread': 'Mark as unread',
+	'item.unread.updated': 'Updated {count} items',
+	'item.bookmark.updated': 'Updated {count} items',
 	'item.add_to_bookmark': 'Add to bookmark',
 	'item.remove_from_bookmark': 'Remove from bookmark',
 	'item.goto_feed': 'Go to feed',
+	'item.hide': 'Hide',
+	'item.unhide': 'Show in lists again',
+	'item.hidden': 'This item is hidden from all lists.',
+	'item.hidden.updated': 'Hid {count} items',
+	'item.delete': 'Delete',
+	'item.delete.confirm': 'Delete {count} items for good? They will not be fetched again.',
 	'item.visit_the_original': 'Visit original link',
+	'item.open_and_mark_as_read': 'Open original link and mark as read',
+	'item.open_in_full_page': 'Open in full page',
 	'item.share': 'Share',
+	'item.language': 'Language',
+	'item.language.all': 'All languages',
+	'item.reading_time': '{minutes} min',
+	'item.origin': 'Where this item came from',
+	'item.origin.fetched_at': 'Fetched',
+	'item.origin.kind': 'By',
+	'item.origin.kind.scheduled': 'Scheduled refresh',
+	'item.origin.kind.refresh': 'Manual refresh',
+	'item.origin.kind.import': 'First pull after subscribing',
+	'item.origin.kind.api': 'API',
+	'item.origin.source_url': 'From',
+	'item.origin.unknown': 'Unknown',
+	'item.short_reads': 'Under {minutes} min',
+	'item.unread_only': 'Unread only',
+	'item.word_count': '{count} words',
+	'item.media': 'Media',
+	'item.media.all': 'All media',
+	'item.media.video': 'Video',
+	'item.media.audio': 'Podcast',
+	'item.media.gallery': 'Image gallery',
+	'item.grouping': 'Group by',
+	'item.grouping.none': 'No grouping',
+	'item.grouping.day': 'Group by day',
+	'item.grouping.feed': 'Group by feed',
+	'item.grouping.today': 'Today',
+	'item.grouping.yesterday': 'Yesterday',
+	'item.grouping.no_date': 'No date',
+	'item.playback.resume': 'Resume at {time}',
+	'item.playback.listened': 'Listened',
+	'item.playback.mark_listened': 'Mark as listened',
+	'item.playback.mark_unlistened': 'Mark as not listened',
+
+	// smart views
+	'smart_views.today': 'Today',
+	'smart_views.last_24_hours': 'Last 24 hours',
+
+	// search
+	'search.feed_link': 'Copy RSS link of these results',
+	'search.feed_link.copied': 'RSS link copied. Anyone with the link can read the results.',
+
+	// highlights
+	'highlights.title': 'Highlights',
+	'highlights.description':
+		'Unread items ranked by feed weight and keyword matches. The score halves every day.',
+
+	// surprise
+	'surprise.title': 'Surprise me',
+	'surprise.description': 'A random pick of unread and bookmarked items older than a week.',
+	'surprise.shuffle': 'Shuffle again',
+
+	// digest
+	'digest.title': 'Daily digest',
+	'digest.description': 'Unread items fetched today, grouped by feed.',
+	'digest.print': 'Print',
+	'digest.mark_all_above_as_read': 'Mark everything above as read',
+	'digest.truncated': 'Only the latest {count} items are shown.',
+
+	// onboarding
+	'onboarding.title': 'Welcome to Fusion',
+	'onboarding.description': "You don't have any feeds yet. Pick a way to get started.",
+	'onboarding.opml.title': 'Import from another reader',
+	'onboarding.opml.description': 'Upload an OPML file exported from your previous RSS reader.',
+	'onboarding.bundles.title': 'Pick starter bundles',
+	'onboarding.bundles.description': 'Browse curated feeds on news, tech, science and more.',
+	'onboarding.bulk.title': 'Paste links',
+	'onboarding.bulk.description':
+		'One link per line. Website links work too, the feed will be found automatically.',
+
+	// discover
+	'discover.title': 'Discover',
+	'discover.description': 'Find new feeds in curated bundles and subscribe to several at once.',
+	'discover.suggestions': 'Suggested for you',
+	'discover.suggestions.description': 'Feeds often read together with the ones you follow.',
+	'discover.subscribed': 'Subscribed',
+	'discover.select_all': 'Select all',
+	'discover.subscribe': 'Subscribe ({count})',
 
 	// settings
 	'settings.appearance': 'Appearance',
 	'settings.appearance.description': 'These settings are stored in your browser.',
 	'settings.appearance.field.language.label': 'Language',
+	'settings.appearance.field.layout.label': 'Layout',
+	'settings.appearance.field.layout.default': 'Default',
+	'settings.appearance.field.layout.three_pane': 'Three panes',
+	'settings.appearance.field.layout.magazine': 'Magazine',
+	'settings.appearance.field.layout.cards': 'Cards',
+	'settings.appearance.field.layout.gallery': 'Gallery',
+	'settings.appearance.field.layout.description':
+		'Three panes shows the selected item next to the list on large screens. Magazine shows the lead image of each item, cards show it with a snippet in a grid, and gallery shows images only, for photo feeds.',
+	'settings.appearance.field.page_size.label': 'Items per page',
+	'settings.appearance.field.page_size.default': 'Server default',
+	'settings.appearance.field.links.label': 'Links in items',
+	'settings.appearance.field.links.new_tab': 'Open links in a new tab',
+	'settings.appearance.field.links.nofollow': 'Mark links as nofollow and user-generated',
+	'settings.appearance.field.math.label': 'Math',
+	'settings.appearance.field.math.render': 'Render TeX formulas, like $x^2$, in items',
+	'settings.appearance.field.render_cache.label': 'Rendered items kept in memory',
+	'settings.appearance.field.render_cache.off': 'None',
+	'settings.appearance.field.render_cache.description':
+		'Opening an item again reuses its sanitized content instead of processing it anew.',
+
+	'settings.opml_subscriptions': 'OPML subscriptions',
+	'settings.opml_subscriptions.description': 'Remote OPML files that are synced periodically.',
+	'settings.opml_subscriptions.sync': 'Sync now',
+	'settings.opml_subscriptions.delete.confirm':
+		'Are you sure you want to unsubscribe from this OPML? Its feeds will be kept.',
+
+	'settings.score_keywords': 'Highlight keywords',
+	'settings.score_keywords.description':
+		'Items whose title or content contains a keyword get its weight added to their score.',
+	'settings.score_keywords.keyword': 'Keyword',
+	'settings.score_keywords.weight': 'Weight',
+
+	'settings.bridges': 'Bridges',
+	'settings.bridges.description':
+		'Your RSSHub, RSS-Bridge or Nitter instances. Feeds of sites without any can be added from them, and get the favicon of the site.',
+	'settings.bridges.kind.rsshub': 'RSSHub',
+	'settings.bridges.kind.rss-bridge': 'RSS-Bridge',
+	'settings.bridges.kind.nitter': 'Nitter',
+
+	'settings.notes': 'Notes to self',
+	'settings.notes.description':
+		'Send links and thoughts to a "Notes to self" feed from browser shortcuts or phone share sheets.',
+	'settings.notes.usage':
+		'POST a url, a title and a content, as a form or JSON, to the link below. The link carries its own token, keep it private.',
+	'settings.notes.copy': 'Copy notes link',
+	'settings.notes.copied': 'Notes link copied',
 
 	'settings.global_actions': 'Global actions',
 	'settings.global_actions.refresh_all_feeds': 'Refresh all feeds',
 	'settings.global_actions.export_all_feeds': 'Export all feeds',
+	'settings.global_actions.logout_everywhere': 'Log out everywhere',
+	'settings.global_actions.logout_everywhere.confirm':
+		'Log out of every device, including this one?',
+	'settings.scheduler': 'Scheduler',
+	'settings.scheduler.description': 'Feeds are refreshed in the background on a schedule.',
+	'settings.scheduler.state': 'State',
+	'settings.scheduler.idle': 'Idle',
+	'settings.scheduler.running': 'Refreshing, {pending} of {total} feeds pending',
+	'settings.scheduler.last_run': 'Last run',
+	'settings.scheduler.next_run': 'Next run',
+	'settings.system': 'System status',
+	'settings.system.back': 'Back to settings',
+	'settings.system.version': 'Version',
+	'settings.system.uptime': 'Uptime',
+	'settings.system.started_at': 'Started at',
+	'settings.system.check_updates': 'Check for updates',
+	'settings.system.update_available': '{version} is available',
+	'settings.system.up_to_date': 'Up to date, the latest release is {version}',
+	'settings.system.database': 'Database',
+	'settings.system.db_path': 'Path',
+	'settings.system.db_size': 'Size',
+	'settings.system.schema_version': 'Schema version',
+	'settings.system.pending_migrations': 'Pending migrations',
+	'settings.system.pending_migrations.none': 'None',
+	'settings.system.items': 'Items',
+	'settings.system.last_pull': 'Last refresh of all feeds',
+	'settings.system.finished_at': 'Finished at',
+	'settings.system.pulled': 'Result',
+	'settings.system.runtime': 'Runtime',
+	'settings.system.platform': 'Platform',
+	'settings.system.heap': 'Heap in use',
+	'settings.system.memory': 'Memory from OS',
+	'settings.system.metrics': 'Metrics',
+	'settings.system.metrics.description':
+		'Feed health in the Prometheus format, like when each feed last had new items. The link carries its own token, so scrapers can use it without logging in.',
+	'settings.system.metrics.copy': 'Copy metrics link',
+	'settings.system.metrics.copied': 'Metrics link copied',
+	'settings.system.integrity': 'Integrity',
+	'settings.system.integrity.description':
+		'Finds what is left referencing deleted feeds, groups and OPML subscriptions. It is repaired daily as well.',
+	'settings.system.integrity.check': 'Check',
+	'settings.system.integrity.repair': 'Repair',
+	'settings.system.integrity.orphaned_items': 'Items of deleted feeds',
+	'settings.system.integrity.feeds_without_group': 'Feeds in deleted groups',
+	'settings.system.integrity.group_rules_without_group': 'Group rules of deleted groups',
+	'settings.system.integrity.opml_subscriptions_without_group': 'OPML subscriptions to deleted groups',
+	'settings.system.integrity.feeds_without_opml_subscription': 'Feeds of deleted OPML subscriptions',
+	'settings.system.integrity.repaired': 'Repaired',
+	'settings.system.config': 'Configuration',
+	'settings.system.config.description': 'Set through environment variables. Secrets are masked.',
+	'settings.jobs': 'Background jobs',
+	'settings.jobs.kind': 'Job',
+	'settings.jobs.status': 'Status',
+	'settings.jobs.status.pending': 'Pending',
+	'settings.jobs.status.running': 'Running',
+	'settings.jobs.status.succeeded': 'Succeeded',
+	'settings.jobs.status.failed': 'Failed',
+	'settings.jobs.attempts': 'Attempts',
+	'settings.jobs.run_at': 'Due at',
+	'settings.jobs.retry': 'Retry',
+	'settings.jobs.empty': 'No jobs',
+	'settings.jobs.more': 'And {count} older jobs',
 
 	'settings.groups.description': "Group's name should be unique.",
 	'settings.groups.delete.confirm':
-		'Are you sure you want to delete this group? All its feeds will be moved to the default group',
+		'Are you sure you want to delete this group? All its feeds will be moved to the selected group',
+	'settings.groups.delete.move_to': 'Move feeds to',
 	'settings.groups.delete.error.delete_the_default': 'Cannot delete default group',
+	'settings.groups.default': 'Default for new feeds',
+	'settings.groups.public': 'Public',
+	'settings.groups.settings.description':
+		'Applied to every feed of the group, unless the feed sets its own.',
+	'settings.groups.settings.default': 'Default',
+	'settings.value.on': 'On',
+	'settings.value.off': 'Off',
+	'settings.groups.public.description':
+		'Public groups and their items can be browsed by anyone, without logging in, at',
+	'settings.groups.rules': 'Auto-grouping rules',
+	'settings.groups.rules.description':
+		'New feeds without a group go to the group of the first matching domain pattern, e.g. "example.com" or "*.substack.com".',
+	'settings.groups.rules.pattern': 'Domain pattern',
 
 	// auth
 	'auth.logout.confirm': 'Are you sure you want to log out?',
@@ -110,9 +416,23 @@ const lang = {
 	'shortcuts.goto_search_page': 'Go to search',
 	'shortcuts.goto_unread_page': 'Go to unread',
 	'shortcuts.goto_bookmarks_page': 'Go to bookmarks',
+	'shortcuts.goto_highlights_page': 'Go to highlights',
 	'shortcuts.goto_all_items_page': 'Go to all items',
+	'shortcuts.goto_today_page': 'Go to today',
+	'shortcuts.goto_recent_page': 'Go to the last 24 hours',
+	'shortcuts.goto_surprise_page': 'Go to surprise me',
 	'shortcuts.goto_feeds_page': 'Go to feeds',
-	'shortcuts.goto_settings_page': 'Go to settings'
+	'shortcuts.goto_discover_page': 'Go to discover',
+	'shortcuts.goto_settings_page': 'Go to settings',
+
+	'public.title': 'Public groups',
+	'public.no_groups': 'Nothing is shared here yet.',
+	'public.no_items': 'No items yet.',
+	'public.back': 'All public groups',
+
+	'refresh.outage':
+		'{failed} of the {fetched} feeds failed to refresh at {time}. The network or a proxy is likely down, rather than the feeds.',
+	'readonly.notice': 'This is a read-only instance. You can browse, but changes are disabled.'
 } as const;
 
 export default lang;
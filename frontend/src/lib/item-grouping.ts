@@ -0,0 +1,55 @@
+import type { Item } from './api/model';
+import { t } from './i18n';
+import type { ItemGrouping } from './state.svelte';
+
+export const itemGroupings: ItemGrouping[] = ['none', 'day', 'feed'];
+
+// groupKey returns the group of the item, or undefined if items aren't
+// grouped.
+export function groupKey(item: Item, grouping: ItemGrouping): string | undefined {
+	switch (grouping) {
+		case 'day':
+			return item.pub_date ? new Date(item.pub_date).toDateString() : '';
+		case 'feed':
+			return String(item.feed.id);
+	}
+	return undefined;
+}
+
+// groupItems orders the items so that the ones in the same group are next to
+// each other. Groups come in the order of their first item, and items keep
+// their order within a group.
+export function groupItems(items: Item[], grouping: ItemGrouping): Item[] {
+	if (grouping === 'none') return items;
+
+	const groups = new Map<string, Item[]>();
+	for (const item of items) {
+		const key = groupKey(item, grouping) ?? '';
+		const group = groups.get(key);
+		if (group) {
+			group.push(item);
+		} else {
+			groups.set(key, [item]);
+		}
+	}
+	return [...groups.values()].flat();
+}
+
+// groupLabel returns the header of the group the item is in.
+export function groupLabel(item: Item, grouping: ItemGrouping): string {
+	if (grouping === 'feed') return item.feed.name;
+	if (!item.pub_date) return t('item.grouping.no_date');
+
+	const date = new Date(item.pub_date);
+	const today = new Date();
+	const yesterday = new Date();
+	yesterday.setDate(today.getDate() - 1);
+	if (date.toDateString() === today.toDateString()) return t('item.grouping.today');
+	if (date.toDateString() === yesterday.toDateString()) return t('item.grouping.yesterday');
+	return date.toLocaleDateString(undefined, {
+		weekday: 'long',
+		year: 'numeric',
+		month: 'long',
+		day: 'numeric'
+	});
+}
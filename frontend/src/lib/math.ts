@@ -0,0 +1,67 @@
+import katex from 'katex';
+
+// renderMath turns the TeX math in item content, like the abstracts of arXiv
+// feeds, into MathML with KaTeX. Browsers render MathML natively, so KaTeX's
+// CSS and fonts aren't needed. Formulas KaTeX can't parse are left as they are.
+
+// Formulas are delimited by $$...$$ or \[...\] for display math, and by $...$
+// or \(...\) for inline math. Like pandoc, an inline $ formula can't start or
+// end with a space nor be followed by a digit, so that prices aren't taken for
+// math.
+const delimiters =
+	/\$\$([\s\S]+?)\$\$|\\\[([\s\S]+?)\\\]|\\\(([\s\S]+?)\\\)|\$(?=\S)((?:[^$\\\n]|\\.)+?)(?<=\S)\$(?!\d)/g;
+
+// Elements whose text is never math.
+const skippedTags = new Set(['CODE', 'PRE', 'SCRIPT', 'STYLE', 'TEXTAREA', 'math']);
+
+// toMathML returns the MathML of the formula, or undefined if it can't be
+// parsed.
+function toMathML(dom: Document, tex: string, display: boolean): DocumentFragment | undefined {
+	let html: string;
+	try {
+		html = katex.renderToString(tex, {
+			displayMode: display,
+			output: 'mathml',
+			throwOnError: true,
+			strict: 'ignore'
+		});
+	} catch {
+		return undefined;
+	}
+	const template = dom.createElement('template');
+	template.innerHTML = html;
+	return template.content;
+}
+
+export function renderMath(dom: Document) {
+	const walker = dom.createTreeWalker(dom.body, NodeFilter.SHOW_TEXT, {
+		acceptNode: (node) => {
+			for (let el = node.parentElement; el; el = el.parentElement) {
+				if (skippedTags.has(el.tagName)) return NodeFilter.FILTER_REJECT;
+			}
+			return node.textContent?.includes('$') || node.textContent?.includes('\\')
+				? NodeFilter.FILTER_ACCEPT
+				: NodeFilter.FILTER_REJECT;
+		}
+	});
+	const nodes: Text[] = [];
+	while (walker.nextNode()) {
+		nodes.push(walker.currentNode as Text);
+	}
+
+	for (const node of nodes) {
+		const text = node.data;
+		const rendered = dom.createDocumentFragment();
+		let last = 0;
+		for (const m of text.matchAll(delimiters)) {
+			const display = m[1] !== undefined || m[2] !== undefined;
+			const math = toMathML(dom, m[1] ?? m[2] ?? m[3] ?? m[4], display);
+			if (!math || m.index === undefined) continue;
+			rendered.append(text.slice(last, m.index), math);
+			last = m.index + m[0].length;
+		}
+		if (last === 0) continue;
+		rendered.append(text.slice(last));
+		node.replaceWith(rendered);
+	}
+}
@@ -0,0 +1,100 @@
+import { parseURLtoFilter, type ListFilter } from './api/item';
+import { t } from './i18n';
+import { globalState } from './state.svelte';
+
+// Items opened from a list carry the list's path and query in their from
+// parameter. The item page links back to the list and moves through its
+// items with it, even when the item is opened from a bookmark or another
+// tab, where there's no previous page to go by.
+
+// ListContext is the list an item was opened from.
+export type ListContext = {
+	// href is the list's path and query.
+	href: string;
+	label: string;
+	// filter lists the list's items again, if it can be.
+	filter?: ListFilter;
+};
+
+type ListRoute = {
+	pattern: RegExp;
+	label: (m: RegExpMatchArray) => string | undefined;
+	// overrides are applied over the filter in the query, like the route's
+	// page does. Lists that can't be listed again, like random picks, have
+	// none.
+	overrides?: (m: RegExpMatchArray) => ListFilter;
+};
+
+const listRoutes: ListRoute[] = [
+	{
+		pattern: /^\/$/,
+		label: () => t('common.unread'),
+		overrides: () => ({ unread: true, bookmark: undefined, feed_id: undefined })
+	},
+	{
+		pattern: /^\/all$/,
+		label: () => t('common.all'),
+		overrides: () => ({ unread: undefined, bookmark: undefined, feed_id: undefined })
+	},
+	{
+		pattern: /^\/bookmarks$/,
+		label: () => t('common.bookmark'),
+		overrides: () => ({ unread: undefined, bookmark: true, feed_id: undefined })
+	},
+	{
+		pattern: /^\/feeds\/(\d+)$/,
+		label: (m) => globalState.feeds.find((f) => f.id === parseInt(m[1]))?.name,
+		overrides: (m) => ({ bookmark: undefined, feed_id: parseInt(m[1]) })
+	},
+	{
+		pattern: /^\/groups\/(\d+)$/,
+		label: (m) => globalState.groups.find((g) => g.id === parseInt(m[1]))?.name,
+		overrides: (m) => ({ bookmark: undefined, feed_id: undefined, group_id: parseInt(m[1]) })
+	},
+	{ pattern: /^\/search$/, label: () => t('common.search'), overrides: () => ({}) },
+	{ pattern: /^\/today$/, label: () => t('smart_views.today') },
+	{ pattern: /^\/recent$/, label: () => t('smart_views.last_24_hours') },
+	{ pattern: /^\/highlights$/, label: () => t('highlights.title') },
+	{ pattern: /^\/surprise$/, label: () => t('surprise.title') }
+];
+
+// rowAnchor is the fragment of the row of the item with id in lists, which
+// they scroll back to.
+export function rowAnchor(id: number) {
+	return 'row-' + id;
+}
+
+// itemHref links to the item with id as opened from the list at list.
+export function itemHref(id: number, list: URL) {
+	return `/items/${id}?` + new URLSearchParams({ from: list.pathname + list.search });
+}
+
+// parseListContext returns the list in the from parameter of an item page,
+// if it's one of the lists items are opened from. Anything else, like a link
+// to another site, is ignored.
+export function parseListContext(params: URLSearchParams): ListContext | undefined {
+	const from = params.get('from');
+	if (!from?.startsWith('/')) {
+		return undefined;
+	}
+	const base = 'http://fusion.invalid';
+	let url: URL;
+	try {
+		url = new URL(from, base);
+	} catch {
+		return undefined;
+	}
+	if (url.origin !== base) {
+		return undefined;
+	}
+	for (const route of listRoutes) {
+		const m = url.pathname.match(route.pattern);
+		if (!m) continue;
+		return {
+			href: url.pathname + url.search,
+			label: route.label(m) ?? url.pathname,
+			filter: route.overrides && parseURLtoFilter(url.searchParams, route.overrides(m))
+		};
+	}
+	return undefined;
+}
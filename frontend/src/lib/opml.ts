@@ -2,13 +2,17 @@ export function parse(content: string) {
 	type feedT = {
 		name: string;
 		link: string;
+		site_url?: string;
+		notes?: string;
 	};
 	type groupT = {
 		name: string;
 		feeds: feedT[];
+		// ungrouped is set for feeds outside of any folder
+		ungrouped?: boolean;
 	};
 	const groups = new Map<string, groupT>();
-	const defaultGroup = { name: 'Default', feeds: [] };
+	const defaultGroup = { name: 'Default', feeds: [], ungrouped: true };
 	groups.set('Default', defaultGroup);
 
 	function dfs(parentGroup: groupT | null, node: Element) {
@@ -21,7 +25,9 @@ export function parse(content: string) {
 			}
 			parentGroup.feeds.push({
 				name: node.getAttribute('title') || node.getAttribute('text') || '',
-				link: node.getAttribute('xmlUrl') || node.getAttribute('htmlUrl') || ''
+				link: node.getAttribute('xmlUrl') || node.getAttribute('htmlUrl') || '',
+				site_url: node.getAttribute('htmlUrl') || undefined,
+				notes: node.getAttribute('notes') || undefined
 			});
 			return;
 		}
@@ -52,11 +58,20 @@ export function parse(content: string) {
 	return Array.from(groups.values());
 }
 
-export function dump(data: { name: string; feeds: { name: string; link: string }[] }[]) {
+// dump serializes groups into OPML. Group names using the "a/b/c" naming
+// convention produced by parse are exported as nested outlines.
+export function dump(
+	data: {
+		name: string;
+		feeds: { name: string; link: string; site_url?: string; notes?: string }[];
+		// ungrouped feeds are written outside of any folder
+		ungrouped?: boolean;
+	}[]
+) {
 	const doc = document.implementation.createDocument('', '', null);
 
 	const opmlElement = doc.createElement('opml');
-	opmlElement.setAttribute('version', '1.0');
+	opmlElement.setAttribute('version', '2.0');
 
 	const headElement = doc.createElement('head');
 	const titleElement = doc.createElement('title');
@@ -65,20 +80,47 @@ export function dump(data: { name: string; feeds: { name: string; link: string }
 	opmlElement.appendChild(headElement);
 
 	const bodyElement = doc.createElement('body');
+	// outlines of groups by their full path, so nested groups share parents
+	const groupOutlines = new Map<string, Element>();
+	function getGroupOutline(path: string[]): Element {
+		const key = path.join('/');
+		let outline = groupOutlines.get(key);
+		if (outline) {
+			return outline;
+		}
+		const name = path[path.length - 1];
+		outline = doc.createElement('outline');
+		outline.setAttribute('text', name);
+		outline.setAttribute('title', name);
+		const parent = path.length > 1 ? getGroupOutline(path.slice(0, -1)) : bodyElement;
+		parent.appendChild(outline);
+		groupOutlines.set(key, outline);
+		return outline;
+	}
+
 	for (const group of data) {
-		const groupOutlineElement = doc.createElement('outline');
-		groupOutlineElement.setAttribute('text', group.name);
-		groupOutlineElement.setAttribute('title', group.name);
+		const path = group.name.split('/').filter((v) => v !== '');
+		if (path.length === 0) {
+			path.push(group.name);
+		}
+		const groupOutlineElement = group.ungrouped ? bodyElement : getGroupOutline(path);
 		for (const feed of group.feeds) {
 			const outlineElement = doc.createElement('outline');
 			outlineElement.setAttribute('type', 'rss');
 			outlineElement.setAttribute('text', feed.name);
 			outlineElement.setAttribute('title', feed.name);
 			outlineElement.setAttribute('xmlUrl', feed.link);
-			outlineElement.setAttribute('htmlUrl', feed.link);
+			outlineElement.setAttribute('htmlUrl', feed.site_url || feed.link);
+			// not a standard attribute, other readers ignore it
+			if (feed.notes) {
+				outlineElement.setAttribute('notes', feed.notes);
+			}
+			// some readers use the category instead of the outline hierarchy
+			if (!group.ungrouped) {
+				outlineElement.setAttribute('category', '/' + path.join('/'));
+			}
 			groupOutlineElement.appendChild(outlineElement);
 		}
-		bodyElement.appendChild(groupOutlineElement);
 	}
 	opmlElement.appendChild(bodyElement);
 
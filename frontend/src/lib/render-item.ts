@@ -1,7 +1,11 @@
 import DOMPurify from 'dompurify';
+import { highlight, keepCodeLanguage } from './highlight';
+import { renderMath } from './math';
+import { linkState, mathState, renderCacheState } from './state.svelte';
 import { tryAbsURL } from './utils';
+import type { Sanitize } from './api/model';
 
-function sanitize(content: string, baseLink: string) {
+function sanitize(content: string, baseLink: string, options: RenderOptions) {
 	const elements: { tag: string; attrs: string[] }[] = [
 		{ tag: 'a', attrs: ['href'] },
 		{ tag: 'img', attrs: ['src'] }, //TODO: srcset attr and base64 type img
@@ -12,7 +16,12 @@ function sanitize(content: string, baseLink: string) {
 		{ tag: 'object', attrs: ['data'] }
 	];
 
-	const cleaned = DOMPurify.sanitize(content, { FORBID_ATTR: ['class', 'style'] });
+	DOMPurify.addHook('beforeSanitizeAttributes', keepCodeLanguage);
+	const cleaned = DOMPurify.sanitize(content, {
+		FORBID_ATTR: ['class', 'style'],
+		FORBID_TAGS: options.sanitize === 'strict' ? strictlyForbidden : []
+	});
+	DOMPurify.removeHook('beforeSanitizeAttributes');
 
 	const dom = new DOMParser().parseFromString(cleaned, 'text/html');
 	for (const el of elements) {
@@ -25,6 +34,15 @@ function sanitize(content: string, baseLink: string) {
 		});
 	}
 
+	if (options.sanitize !== 'strict') {
+		addVideoThumbnail(dom, baseLink, options.embedVideos ?? false);
+	}
+	applyLinkOptions(dom);
+	highlight(dom);
+	if (mathState.enabled) {
+		renderMath(dom);
+	}
+
 	// prevent table from overflowing
 	// https://github.com/tailwindlabs/tailwindcss-typography/issues/334#issuecomment-1942177668
 	dom.querySelectorAll('table').forEach((v) => {
@@ -44,22 +62,171 @@ function sanitize(content: string, baseLink: string) {
 	return new XMLSerializer().serializeToString(dom);
 }
 
-function embedYouTube(content: string, link: string): string {
-	const youtubeDomains = ['youtube.com', 'youtu.be'];
-	if (youtubeDomains.find((v) => new URL(link).hostname.endsWith(v))) {
-		const videoID = new URL(link).searchParams.get('v');
-		content =
-			`<iframe style="aspect-ratio: 16 / 9; width: 100% !important;" src="http://www.youtube.com/embed/` +
-			videoID +
-			`" title="YouTube video player" frameborder="0" allow="accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture; web-share" referrerpolicy="strict-origin-when-cross-origin" allowfullscreen></iframe>` +
-			content;
+// applyLinkOptions sets the target and rel of the links to web pages as set in
+// linkState.
+function applyLinkOptions(dom: Document) {
+	dom.querySelectorAll('a[href]').forEach((v) => {
+		if (!/^https?:/i.test(v.getAttribute('href') || '')) return;
+
+		const rel = new Set((v.getAttribute('rel') || '').split(/\s+/).filter(Boolean));
+		if (linkState.newTab) {
+			v.setAttribute('target', '_blank');
+			rel.add('noopener');
+		}
+		if (linkState.nofollow) {
+			rel.add('nofollow');
+			rel.add('ugc');
+		}
+		if (rel.size > 0) {
+			v.setAttribute('rel', [...rel].join(' '));
+		}
+	});
+}
+
+// youTubeID returns the ID of the YouTube video at link, which can be like
+// youtube.com/watch?v=ID, youtu.be/ID or youtube.com/shorts/ID.
+function youTubeID(link: string): { id: string; short: boolean } | undefined {
+	let url: URL;
+	try {
+		url = new URL(link);
+	} catch {
+		return undefined;
+	}
+
+	const host = url.hostname.replace(/^(www|m)\./, '');
+	const [, first, second] = url.pathname.split('/');
+	let id: string | null = null;
+	if (host === 'youtu.be') {
+		id = first;
+	} else if (host === 'youtube.com' || host === 'youtube-nocookie.com') {
+		if (first === 'watch') {
+			id = url.searchParams.get('v');
+		} else if (['shorts', 'embed', 'live'].includes(first)) {
+			id = second;
+		}
+	}
+	if (!id || !/^[\w-]{11}$/.test(id)) return undefined;
+	return { id: id, short: first === 'shorts' };
+}
+
+// addVideoThumbnail puts the thumbnail of the YouTube video the item links to
+// at the top of its content. If embed is set, clicking it plays the video in
+// place, see loadEmbeds. Otherwise it links to the video.
+function addVideoThumbnail(dom: Document, link: string, embed: boolean) {
+	const video = youTubeID(link);
+	if (!video) return;
+
+	const thumbnail = dom.createElement(embed ? 'button' : 'a');
+	thumbnail.classList.add('video-thumbnail');
+	if (video.short) {
+		thumbnail.classList.add('video-short');
+	}
+	if (embed) {
+		thumbnail.setAttribute('type', 'button');
+		thumbnail.dataset.youtubeId = video.id;
+	} else {
+		thumbnail.setAttribute('href', `https://www.youtube.com/watch?v=${video.id}`);
 	}
-	return content;
+	const img = dom.createElement('img');
+	img.setAttribute('src', `https://i.ytimg.com/vi/${video.id}/hqdefault.jpg`);
+	img.setAttribute('alt', '');
+	img.setAttribute('loading', 'lazy');
+	thumbnail.append(img);
+	dom.body.prepend(thumbnail);
 }
 
-export function render(content: string, link: string): string {
+// loadEmbeds is an action for the element item content is rendered in. It
+// replaces the video thumbnails with the player when they're clicked, so that
+// nothing is loaded from YouTube until the user asks for it. The player is
+// the privacy-enhanced one, which doesn't set cookies until the video plays.
+export function loadEmbeds(node: HTMLElement) {
+	function onClick(e: MouseEvent) {
+		const thumbnail = (e.target as Element).closest<HTMLElement>('[data-youtube-id]');
+		if (!thumbnail || !node.contains(thumbnail)) return;
+
+		const player = document.createElement('iframe');
+		player.className = thumbnail.className;
+		player.src = `https://www.youtube-nocookie.com/embed/${thumbnail.dataset.youtubeId}?autoplay=1`;
+		player.title = 'YouTube video player';
+		player.allow =
+			'accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture; web-share';
+		player.referrerPolicy = 'strict-origin-when-cross-origin';
+		player.allowFullscreen = true;
+		thumbnail.replaceWith(player);
+	}
+
+	node.addEventListener('click', onClick);
+	return {
+		destroy() {
+			node.removeEventListener('click', onClick);
+		}
+	};
+}
+
+export type RenderOptions = {
+	// embedVideos makes the video thumbnails play the video, see
+	// addVideoThumbnail.
+	embedVideos?: boolean;
+	// sanitize 'strict' also drops media and embeds, see strictlyForbidden.
+	sanitize?: Sanitize;
+};
+
+// strictlyForbidden are the tags the 'strict' sanitize setting drops on top of
+// the usual ones, leaving text and links.
+const strictlyForbidden = [
+	'img',
+	'picture',
+	'svg',
+	'audio',
+	'video',
+	'source',
+	'iframe',
+	'embed',
+	'object'
+];
+
+// rendered is an LRU cache of the output of sanitize, which takes a while for
+// long items, holding at most renderCacheState.size entries. It's keyed by
+// everything the output depends on: an item whose content changed, or a change
+// to the settings, misses the cache. Map keeps the insertion order, so moving
+// hits to the end makes the first key the least recently used one.
+const rendered = new Map<string, string>();
+
+export function render(content: string, link: string, options: RenderOptions = {}): string {
 	link = tryAbsURL(link);
-	content = sanitize(content, link);
-	content = embedYouTube(content, link);
-	return content;
+	const size = renderCacheState.size;
+	if (size <= 0) {
+		rendered.clear();
+		return sanitize(content, link, options);
+	}
+
+	const key = JSON.stringify([
+		link,
+		options.embedVideos ?? false,
+		options.sanitize ?? 'standard',
+		linkState.newTab,
+		linkState.nofollow,
+		mathState.enabled,
+		content
+	]);
+	const html = rendered.get(key) ?? sanitize(content, link, options);
+	rendered.delete(key);
+	rendered.set(key, html);
+	for (const oldest of rendered.keys()) {
+		if (rendered.size <= size) break;
+		rendered.delete(oldest);
+	}
+	return html;
+}
+
+// prerender renders an item ahead of time, once the browser is idle, so that
+// opening it next is a cache hit.
+export function prerender(content: string, link: string, options: RenderOptions = {}) {
+	if (renderCacheState.size <= 0) return;
+	const run = () => render(content, link, options);
+	if ('requestIdleCallback' in window) {
+		requestIdleCallback(run);
+	} else {
+		setTimeout(run);
+	}
 }
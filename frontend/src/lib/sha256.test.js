@@ -0,0 +1,34 @@
+import assert from 'node:assert/strict';
+import { createHash } from 'node:crypto';
+import { test } from 'node:test';
+
+import { sha256 } from './sha256.js';
+
+/** @param {Uint8Array} bytes */
+function hex(bytes) {
+	return Buffer.from(bytes).toString('hex');
+}
+
+test('sha256 matches the known digests', () => {
+	const encoder = new TextEncoder();
+	assert.equal(
+		hex(sha256(new Uint8Array())),
+		'e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855'
+	);
+	assert.equal(
+		hex(sha256(encoder.encode('abc'))),
+		'ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad'
+	);
+});
+
+test('sha256 matches node:crypto around the block boundaries', () => {
+	// 55 and 56 bytes are where the length no longer fits in the last block.
+	for (const length of [1, 55, 56, 63, 64, 65, 119, 120, 1000]) {
+		const data = new Uint8Array(length).map((_, i) => (i * 31 + length) & 0xff);
+		assert.equal(
+			hex(sha256(data)),
+			createHash('sha256').update(data).digest('hex'),
+			`length ${length}`
+		);
+	}
+});
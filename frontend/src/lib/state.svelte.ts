@@ -1,8 +1,11 @@
-import { type Feed, type Group } from './api/model';
+import { browser } from '$app/environment';
+import { type Bridge, type Feed, type Group } from './api/model';
 
 export const globalState = $state({
 	groups: [] as Group[],
-	feeds: [] as Feed[]
+	feeds: [] as Feed[],
+	bridges: [] as Bridge[],
+	faviconHosts: {} as Record<string, string>
 });
 
 export function setGlobalFeeds(feeds: Feed[]) {
@@ -13,9 +16,150 @@ export function setGlobalGroups(groups: Group[]) {
 	globalState.groups = groups;
 }
 
+export function setGlobalBridges(bridges: Bridge[]) {
+	globalState.bridges = bridges;
+}
+
+export function setGlobalFaviconHosts(hosts: Record<string, string>) {
+	globalState.faviconHosts = hosts;
+}
+
 export function updateUnreadCount(feedId: number, change: number) {
 	const feed = globalState.feeds.find((f) => f.id === feedId);
 	if (feed) {
 		feed.unread_count = Math.max(0, (feed.unread_count || 0) + change);
 	}
 }
+
+// setUnreadCounts applies the counts reported by the server, keyed by feed ID.
+export function setUnreadCounts(counts: Record<number, number>) {
+	for (const [feedId, count] of Object.entries(counts)) {
+		setUnreadCount(Number(feedId), count);
+	}
+}
+
+// setUnreadCount replaces a count changed optimistically with the one
+// reported by the server.
+export function setUnreadCount(feedId: number, count: number | undefined) {
+	const feed = globalState.feeds.find((f) => f.id === feedId);
+	if (feed && count !== undefined) {
+		feed.unread_count = count;
+	}
+}
+
+// newItemsState counts the items pulled since the item list was loaded.
+export const newItemsState = $state({
+	count: 0
+});
+
+export function addNewItems(count: number) {
+	newItemsState.count += count;
+}
+
+export function clearNewItems() {
+	newItemsState.count = 0;
+}
+
+// display settings are stored in the browser
+export type Layout = 'default' | 'three_pane' | 'magazine' | 'cards' | 'gallery';
+
+const LAYOUT_STORAGE_KEY = 'app_layout';
+
+export const displayState = $state({
+	layout: ((browser && localStorage.getItem(LAYOUT_STORAGE_KEY)) || 'default') as Layout
+});
+
+export function setLayout(layout: Layout) {
+	displayState.layout = layout;
+	localStorage.setItem(LAYOUT_STORAGE_KEY, layout);
+}
+
+// itemGroupingState sets how item lists are grouped, see groupItems.
+export type ItemGrouping = 'none' | 'day' | 'feed';
+
+const ITEM_GROUPING_STORAGE_KEY = 'app_item_grouping';
+
+export const itemGroupingState = $state({
+	grouping: ((browser && localStorage.getItem(ITEM_GROUPING_STORAGE_KEY)) || 'none') as ItemGrouping
+});
+
+export function setItemGrouping(grouping: ItemGrouping) {
+	itemGroupingState.grouping = grouping;
+	localStorage.setItem(ITEM_GROUPING_STORAGE_KEY, grouping);
+}
+
+// pageSize is the preferred number of items per page. Undefined means using the
+// server default.
+const PAGE_SIZE_STORAGE_KEY = 'app_page_size';
+
+export const pageSizeOptions = [10, 25, 50, 100];
+
+export const pageSizeState = $state({
+	pageSize: (browser && parseInt(localStorage.getItem(PAGE_SIZE_STORAGE_KEY) || '')) || undefined
+});
+
+export function setPageSize(pageSize: number | undefined) {
+	pageSizeState.pageSize = pageSize;
+	if (pageSize) {
+		localStorage.setItem(PAGE_SIZE_STORAGE_KEY, String(pageSize));
+	} else {
+		localStorage.removeItem(PAGE_SIZE_STORAGE_KEY);
+	}
+}
+
+// linkState controls the links in item content. newTab opens them in a new
+// tab, and nofollow marks them as user-generated links search engines
+// shouldn't follow.
+const LINK_NEW_TAB_STORAGE_KEY = 'app_link_new_tab';
+const LINK_NOFOLLOW_STORAGE_KEY = 'app_link_nofollow';
+
+export const linkState = $state({
+	newTab: browser && localStorage.getItem(LINK_NEW_TAB_STORAGE_KEY) === 'true',
+	nofollow: browser && localStorage.getItem(LINK_NOFOLLOW_STORAGE_KEY) === 'true'
+});
+
+export function setLinkNewTab(newTab: boolean) {
+	linkState.newTab = newTab;
+	localStorage.setItem(LINK_NEW_TAB_STORAGE_KEY, String(newTab));
+}
+
+export function setLinkNofollow(nofollow: boolean) {
+	linkState.nofollow = nofollow;
+	localStorage.setItem(LINK_NOFOLLOW_STORAGE_KEY, String(nofollow));
+}
+
+// mathState controls rendering the TeX math in item content. It's off by
+// default, as dollar signs in other content may be taken for math.
+const MATH_STORAGE_KEY = 'app_render_math';
+
+export const mathState = $state({
+	enabled: browser && localStorage.getItem(MATH_STORAGE_KEY) === 'true'
+});
+
+export function setRenderMath(enabled: boolean) {
+	mathState.enabled = enabled;
+	localStorage.setItem(MATH_STORAGE_KEY, String(enabled));
+}
+
+// renderCacheState.size is how many rendered items are kept in memory, so that
+// going back to an item doesn't sanitize its content again. 0 turns the cache
+// off.
+const RENDER_CACHE_SIZE_STORAGE_KEY = 'app_render_cache_size';
+
+export const renderCacheSizeOptions = [0, 20, 50, 200];
+
+const defaultRenderCacheSize = 50;
+
+function storedRenderCacheSize(): number {
+	const size = browser ? parseInt(localStorage.getItem(RENDER_CACHE_SIZE_STORAGE_KEY) || '') : NaN;
+	return Number.isNaN(size) ? defaultRenderCacheSize : size;
+}
+
+export const renderCacheState = $state({
+	size: storedRenderCacheSize()
+});
+
+export function setRenderCacheSize(size: number) {
+	renderCacheState.size = size;
+	localStorage.setItem(RENDER_CACHE_SIZE_STORAGE_KEY, String(size));
+}
@@ -0,0 +1,60 @@
+import type { Action } from 'svelte/action';
+
+// minimum horizontal distance in pixels to be recognized as a swipe
+const threshold = 80;
+
+export type SwipeHandlers = {
+	onSwipeLeft?: () => void;
+	onSwipeRight?: () => void;
+};
+
+// swipe detects horizontal one-finger swipes on touch devices. Mostly vertical
+// movements are ignored so scrolling the list keeps working.
+export const swipe: Action<HTMLElement, SwipeHandlers> = (node, handlers) => {
+	let startX = 0;
+	let startY = 0;
+
+	function handleTouchStart(e: TouchEvent) {
+		if (e.touches.length !== 1) return;
+		startX = e.touches[0].clientX;
+		startY = e.touches[0].clientY;
+		node.style.transition = '';
+	}
+
+	function handleTouchMove(e: TouchEvent) {
+		const dx = e.touches[0].clientX - startX;
+		const dy = e.touches[0].clientY - startY;
+		if (Math.abs(dx) > Math.abs(dy)) {
+			node.style.transform = `translateX(${dx}px)`;
+		}
+	}
+
+	function handleTouchEnd(e: TouchEvent) {
+		const dx = e.changedTouches[0].clientX - startX;
+		const dy = e.changedTouches[0].clientY - startY;
+		node.style.transition = 'transform 0.2s';
+		node.style.transform = '';
+		if (Math.abs(dx) < threshold || Math.abs(dx) < Math.abs(dy) * 2) return;
+
+		if (dx < 0) {
+			handlers.onSwipeLeft?.();
+		} else {
+			handlers.onSwipeRight?.();
+		}
+	}
+
+	node.addEventListener('touchstart', handleTouchStart, { passive: true });
+	node.addEventListener('touchmove', handleTouchMove, { passive: true });
+	node.addEventListener('touchend', handleTouchEnd);
+
+	return {
+		update(newHandlers: SwipeHandlers) {
+			handlers = newHandlers;
+		},
+		destroy() {
+			node.removeEventListener('touchstart', handleTouchStart);
+			node.removeEventListener('touchmove', handleTouchMove);
+			node.removeEventListener('touchend', handleTouchEnd);
+		}
+	};
+};
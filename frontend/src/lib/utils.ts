@@ -38,3 +38,32 @@ export function tryAbsURL(url: string, base?: string): string {
 		return url;
 	}
 }
+
+// formatDuration formats seconds like a media player, e.g. "4:05" or
+// "1:02:09".
+export function formatDuration(seconds: number): string {
+	const h = Math.floor(seconds / 3600);
+	const m = Math.floor((seconds % 3600) / 60);
+	const s = String(Math.floor(seconds % 60)).padStart(2, '0');
+	return h > 0 ? `${h}:${String(m).padStart(2, '0')}:${s}` : `${m}:${s}`;
+}
+
+const relativeTimeFormat = new Intl.RelativeTimeFormat(undefined, { numeric: 'auto' });
+const relativeTimeUnits: [Intl.RelativeTimeFormatUnit, number][] = [
+	['day', 24 * 60 * 60],
+	['hour', 60 * 60],
+	['minute', 60],
+	['second', 1]
+];
+
+// formatRelativeTime formats the time from now to date in the largest unit
+// that fits, e.g. "in 5 minutes" or "2 hours ago".
+export function formatRelativeTime(date: Date, now: Date = new Date()): string {
+	const seconds = Math.round((date.getTime() - now.getTime()) / 1000);
+	for (const [unit, size] of relativeTimeUnits) {
+		if (Math.abs(seconds) >= size || unit === 'second') {
+			return relativeTimeFormat.format(Math.trunc(seconds / size), unit);
+		}
+	}
+	return '';
+}
@@ -1,20 +1,34 @@
+import { allBridges } from '$lib/api/bridge';
+import { getConfig } from '$lib/api/config';
 import { listFeeds } from '$lib/api/feed';
 import { allGroups } from '$lib/api/group';
-import { setGlobalFeeds, setGlobalGroups } from '$lib/state.svelte';
+import {
+	setGlobalBridges,
+	setGlobalFaviconHosts,
+	setGlobalFeeds,
+	setGlobalGroups
+} from '$lib/state.svelte';
 import type { LayoutLoad } from './$types';
 
 export const load: LayoutLoad = async ({ depends }) => {
-	depends('app:feeds', 'app:groups');
+	depends('app:feeds', 'app:groups', 'app:bridges');
 
-	await Promise.all([
+	const [config] = await Promise.all([
+		getConfig().then((config) => {
+			setGlobalFaviconHosts(config.favicon_hosts ?? {});
+			return config;
+		}),
 		allGroups().then((groups) => {
 			groups.sort((a, b) => a.id - b.id);
 			setGlobalGroups(groups);
 		}),
 		listFeeds().then((feeds) => {
 			setGlobalFeeds(feeds);
+		}),
+		allBridges().then((bridges) => {
+			setGlobalBridges(bridges);
 		})
 	]);
 
-	return {};
+	return { config };
 };
@@ -1,4 +1,4 @@
-import { listItems, parseURLtoFilter } from '$lib/api/item';
+import { parseURLtoFilter, streamItems } from '$lib/api/item';
 import type { PageLoad } from './$types';
 
 export const load: PageLoad = async ({ url, depends }) => {
@@ -10,6 +10,6 @@ export const load: PageLoad = async ({ url, depends }) => {
 		feed_id: undefined
 	});
 	return {
-		items: listItems(filter)
+		items: streamItems(filter)
 	};
 };
@@ -0,0 +1,12 @@
+import { getDigest } from '$lib/api/item';
+import type { PageLoad } from './$types';
+
+export const load: PageLoad = async ({ depends }) => {
+	depends('app:page');
+
+	const today = new Date();
+	today.setHours(0, 0, 0, 0);
+	return {
+		digest: getDigest(today)
+	};
+};
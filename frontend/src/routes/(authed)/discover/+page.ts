@@ -0,0 +1,10 @@
+import { getDiscover } from '$lib/api/discover';
+import type { PageLoad } from './$types';
+
+export const load: PageLoad = async ({ depends }) => {
+	depends('app:page');
+
+	return {
+		discover: getDiscover()
+	};
+};
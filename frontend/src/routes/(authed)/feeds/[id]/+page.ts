@@ -1,5 +1,6 @@
+import { toPageError } from '$lib/api/api';
 import { getFeed } from '$lib/api/feed';
-import { listItems, parseURLtoFilter } from '$lib/api/item';
+import { parseURLtoFilter, streamItems } from '$lib/api/item';
 import type { PageLoad } from './$types';
 
 export const prerender = false;
@@ -8,12 +9,12 @@ export const load: PageLoad = async ({ url, params, depends }) => {
 	depends('app:page');
 
 	const id = parseInt(params.id);
-	const feed = getFeed(id);
+	const feed = await getFeed(id).catch(toPageError);
+	// unread is left to the URL, see ItemList's unreadToggle.
 	const filter = parseURLtoFilter(url.searchParams, {
-		unread: undefined,
 		bookmark: undefined,
 		feed_id: id
 	});
-	const items = listItems(filter);
+	const items = streamItems(filter);
 	return { feed: feed, items: items };
 };
@@ -1,5 +1,5 @@
 import { allGroups } from '$lib/api/group';
-import { listItems, parseURLtoFilter } from '$lib/api/item';
+import { parseURLtoFilter, streamItems } from '$lib/api/item';
 import { error } from '@sveltejs/kit';
 import type { PageLoad } from './$types';
 
@@ -9,19 +9,16 @@ export const load: PageLoad = async ({ url, params, depends }) => {
 	depends('app:page');
 
 	const id = parseInt(params.id);
-	const group = allGroups().then((groups) => {
-		const group = groups.find((g) => g.id === id);
-		if (!group) {
-			error(404, 'Group not found');
-		}
-		return group;
-	});
+	const group = (await allGroups()).find((g) => g.id === id);
+	if (!group) {
+		error(404, 'Group not found');
+	}
+	// unread is left to the URL, see ItemList's unreadToggle.
 	const filter = parseURLtoFilter(url.searchParams, {
-		unread: undefined,
 		bookmark: undefined,
 		feed_id: undefined,
 		group_id: id
 	});
-	const items = listItems(filter);
+	const items = streamItems(filter);
 	return { group, items: items };
 };
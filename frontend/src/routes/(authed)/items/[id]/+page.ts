@@ -1,3 +1,4 @@
+import { toPageError } from '$lib/api/api';
 import { getItem } from '$lib/api/item';
 import { error } from '@sveltejs/kit';
 import type { PageLoad } from './$types';
@@ -11,5 +12,5 @@ export const load: PageLoad = ({ params, depends }) => {
 	if (id < 1) {
 		error(404, 'wrong id');
 	}
-	return getItem(id);
+	return getItem(id).catch(toPageError);
 };
@@ -0,0 +1,17 @@
+import { parseURLtoFilter, streamItems } from '$lib/api/item';
+import type { PageLoad } from './$types';
+
+export const load: PageLoad = async ({ url, depends }) => {
+	depends('app:page');
+
+	const since = new Date(Date.now() - 24 * 60 * 60 * 1000);
+	const filter = parseURLtoFilter(url.searchParams, {
+		unread: undefined,
+		bookmark: undefined,
+		feed_id: undefined,
+		published_after: since.toISOString()
+	});
+	return {
+		items: streamItems(filter)
+	};
+};
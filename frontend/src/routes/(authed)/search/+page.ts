@@ -1,4 +1,4 @@
-import { listItems, parseURLtoFilter } from '$lib/api/item';
+import { parseURLtoFilter, streamItems } from '$lib/api/item';
 import type { PageLoad } from './$types';
 
 export const load: PageLoad = async ({ url, depends }) => {
@@ -7,6 +7,6 @@ export const load: PageLoad = async ({ url, depends }) => {
 	const filter = parseURLtoFilter(url.searchParams);
 	return {
 		filter,
-		items: listItems(filter)
+		items: streamItems(filter)
 	};
 };
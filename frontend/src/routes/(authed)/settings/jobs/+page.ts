@@ -0,0 +1,12 @@
+import { listJobs, type JobStatus } from '$lib/api/job';
+import type { PageLoad } from './$types';
+
+export const load: PageLoad = async ({ depends, url }) => {
+	depends('app:page');
+
+	const status = (url.searchParams.get('status') || undefined) as JobStatus | undefined;
+	return {
+		status,
+		jobs: listJobs({ status })
+	};
+};
@@ -0,0 +1,10 @@
+import { getSystemStatus } from '$lib/api/system';
+import type { PageLoad } from './$types';
+
+export const load: PageLoad = async ({ depends }) => {
+	depends('app:page');
+
+	return {
+		system: getSystemStatus()
+	};
+};
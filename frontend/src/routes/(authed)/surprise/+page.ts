@@ -0,0 +1,10 @@
+import { listSurprise } from '$lib/api/item';
+import type { PageLoad } from './$types';
+
+export const load: PageLoad = async ({ depends }) => {
+	depends('app:page');
+
+	return {
+		items: listSurprise()
+	};
+};
@@ -0,0 +1,18 @@
+import { parseURLtoFilter, streamItems } from '$lib/api/item';
+import type { PageLoad } from './$types';
+
+export const load: PageLoad = async ({ url, depends }) => {
+	depends('app:page');
+
+	const today = new Date();
+	today.setHours(0, 0, 0, 0);
+	const filter = parseURLtoFilter(url.searchParams, {
+		unread: undefined,
+		bookmark: undefined,
+		feed_id: undefined,
+		published_after: today.toISOString()
+	});
+	return {
+		items: streamItems(filter)
+	};
+};
@@ -0,0 +1,8 @@
+import { toPageError } from '$lib/api/api';
+import { listPublicGroups } from '$lib/api/public';
+import type { PageLoad } from './$types';
+
+export const load: PageLoad = async () => {
+	const groups = await listPublicGroups().catch(toPageError);
+	return { groups };
+};
@@ -0,0 +1,17 @@
+import { toPageError } from '$lib/api/api';
+import { listPublicItems } from '$lib/api/public';
+import { error } from '@sveltejs/kit';
+import type { PageLoad } from './$types';
+
+export const prerender = false;
+
+export const load: PageLoad = ({ params, url }) => {
+	const id = parseInt(params.id);
+	if (id < 1) {
+		error(404, 'wrong id');
+	}
+	const page = Math.max(1, parseInt(url.searchParams.get('page') ?? '1') || 1);
+	return listPublicItems(id, page)
+		.then((resp) => ({ ...resp, page }))
+		.catch(toPageError);
+};
@@ -0,0 +1,14 @@
+import { toPageError } from '$lib/api/api';
+import { getPublicItem } from '$lib/api/public';
+import { error } from '@sveltejs/kit';
+import type { PageLoad } from './$types';
+
+export const prerender = false;
+
+export const load: PageLoad = ({ params }) => {
+	const id = parseInt(params.id);
+	if (id < 1) {
+		error(404, 'wrong id');
+	}
+	return getPublicItem(id).catch(toPageError);
+};
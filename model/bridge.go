@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+// Bridge kinds, the services that make feeds of sites that have none.
+const (
+	BridgeRSSHub    = "rsshub"
+	BridgeRSSBridge = "rss-bridge"
+	BridgeNitter    = "nitter"
+)
+
+// Bridge is an instance of RSSHub, RSS-Bridge or Nitter. The import form
+// expands source templates, like a subreddit, to links on it.
+type Bridge struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt soft_delete.DeletedAt `gorm:"uniqueIndex:idx_base_url"`
+
+	Kind *string `gorm:"kind;not null"`
+	// BaseURL is where the instance is served, without a trailing slash.
+	BaseURL *string `gorm:"base_url;not null;uniqueIndex:idx_base_url"`
+}
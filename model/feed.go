@@ -8,10 +8,21 @@ import (
 
 type FeedRequestOptions struct {
 	ReqProxy *string `gorm:"req_proxy"`
+	// CaptureResponse enables saving the raw response of the most recent fetch
+	// for debugging.
+	CaptureResponse *bool `gorm:"capture_response;default:false"`
 
 	// TODO: headers, cookie, etc.
 }
 
+func (o FeedRequestOptions) IsCapturingResponse() bool {
+	return o.CaptureResponse != nil && *o.CaptureResponse
+}
+
+// NotesFeedLink is the link of the Notes to self feed, whose items are added
+// through the API rather than fetched.
+const NotesFeedLink = "fusion:notes"
+
 type Feed struct {
 	ID        uint `gorm:"primarykey"`
 	CreatedAt time.Time
@@ -20,24 +31,132 @@ type Feed struct {
 
 	Name *string `gorm:"name;not null"`
 	Link *string `gorm:"link;not null;uniqueIndex:idx_link"`
+	// SiteURL is the link to the website the feed belongs to, as declared in
+	// the feed content.
+	SiteURL *string `gorm:"site_url"`
+	// CanonicalLink is the link the feed goes by: the one it declares as its
+	// own, or where fetching Link ends up after redirects. Subscriptions with
+	// the same one are the same feed.
+	CanonicalLink *string `gorm:"canonical_link"`
+	// Description is what the feed says it's about, as plain text.
+	Description *string `gorm:"description"`
 	// LastBuild is the last time the content of the feed changed
 	LastBuild *time.Time `gorm:"last_build"`
+	// LastFetchedAt is the last time the feed was fetched successfully. Unlike
+	// UpdatedAt, it doesn't change when the user edits the feed.
+	LastFetchedAt *time.Time `gorm:"last_fetched_at"`
+	// NextFetchAt is the earliest time the scheduler fetches the feed again,
+	// which is later after failed fetches. Like LastFetchedAt, it's only set
+	// by the puller.
+	NextFetchAt *time.Time `gorm:"next_fetch_at"`
+	// LastItemAt is the last time a fetch found new items, to tell the feeds
+	// that have gone quiet.
+	LastItemAt *time.Time `gorm:"last_item_at"`
 	// Failure is the error message for the last fetch.
 	Failure *string `gorm:"failure;default:''"`
+	// Blocked indicates that the last fetch was stopped by an anti-bot
+	// challenge page rather than failing for another reason.
+	Blocked *bool `gorm:"blocked;default:false"`
 	// ConsecutiveFailures is the number of consecutive times we've failed to
 	// retrieve this feed.
 	ConsecutiveFailures uint `gorm:"consecutive_failures;default:0"`
 
 	Suspended *bool `gorm:"suspended;default:false"`
+	// MonitorOnly keeps new items out of Unread: the puller stores them as
+	// read. They are still searchable and listed on the feed page. It's meant
+	// for high-volume or archival feeds kept for reference, like changelogs.
+	// Nil leaves it to the group, like RefreshInterval and Sanitize, see
+	// Settings.
+	MonitorOnly     *bool   `gorm:"monitor_only"`
+	RefreshInterval *int    `gorm:"refresh_interval"`
+	Sanitize        *string `gorm:"sanitize"`
+	// Weight boosts (or buries, if negative) the feed's items in the
+	// Highlights view.
+	Weight *int `gorm:"weight;default:0"`
+	// EmbedVideos lets the videos items link to, like YouTube's, be played in
+	// the item. Otherwise only their thumbnail is shown.
+	EmbedVideos *bool `gorm:"embed_videos;default:false"`
+	// OrderByPosition lists the feed's items as the feed lists them, see
+	// Item.Position, rather than by date. It suits link roundups and other
+	// feeds whose dates don't tell their order.
+	OrderByPosition *bool `gorm:"order_by_position;default:false"`
+	// DisplayMode is the layout the feed's items are listed in on its page,
+	// one of the DisplayMode constants, whatever the user's own. Nil or ""
+	// leaves it to the user.
+	DisplayMode *string `gorm:"display_mode"`
+	// Notes is whatever the user wants to remember about the feed, like why
+	// they subscribed to it.
+	Notes *string `gorm:"notes"`
+	// LastResponse is the raw response of the most recent fetch. It's only
+	// recorded when CaptureResponse is enabled.
+	LastResponse *string `gorm:"last_response"`
 
 	FeedRequestOptions
+	Label
 
-	GroupID uint
+	// GroupID is nil for the feeds that aren't in any group, which are listed
+	// as Uncategorized.
+	GroupID *uint
 	Group   Group
 
+	// OPMLSubscriptionID is set when the feed was added by syncing a remote
+	// OPML file.
+	OPMLSubscriptionID *uint `gorm:"opml_subscription_id"`
+	// RemovedFromOPML indicates that the feed is no longer listed in the OPML
+	// file it was added from. We keep the feed and leave it to the user.
+	RemovedFromOPML *bool `gorm:"removed_from_opml;default:false"`
+
 	UnreadCount int `gorm:"-:all"`
 }
 
+// IsVirtual tells whether the feed's items are added through the API, so
+// there's nothing to fetch.
+func (f Feed) IsVirtual() bool {
+	return f.Link != nil && *f.Link == NotesFeedLink
+}
+
 func (f Feed) IsSuspended() bool {
 	return f.Suspended != nil && *f.Suspended
 }
+
+// Settings returns the settings the feed is fetched and shown with: its own
+// where set, its group's otherwise. An empty ReqProxy is not set. Group must
+// be loaded.
+func (f Feed) Settings() Settings {
+	var s Settings
+	if f.GroupID != nil {
+		s = f.Group.Settings
+	}
+	if f.ReqProxy != nil && *f.ReqProxy != "" {
+		s.ReqProxy = f.ReqProxy
+	}
+	if f.RefreshInterval != nil {
+		s.RefreshInterval = f.RefreshInterval
+	}
+	if f.Sanitize != nil {
+		s.Sanitize = f.Sanitize
+	}
+	if f.MonitorOnly != nil {
+		s.MonitorOnly = f.MonitorOnly
+	}
+	return s
+}
+
+func (f Feed) EmbedsVideos() bool {
+	return f.EmbedVideos != nil && *f.EmbedVideos
+}
+
+// Display modes of feeds, see Feed.DisplayMode.
+const (
+	DisplayModeMagazine = "magazine"
+	DisplayModeCards    = "cards"
+	DisplayModeGallery  = "gallery"
+	// DisplayModeTitleOnly lists items by their titles alone, and opens their
+	// links rather than their content. It suits link blogs, whose items say
+	// little more than their titles.
+	DisplayModeTitleOnly = "title_only"
+)
+
+func (f Feed) OrdersByPosition() bool {
+	return f.OrderByPosition != nil && *f.OrderByPosition
+}
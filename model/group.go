@@ -13,4 +13,62 @@ type Group struct {
 	DeletedAt soft_delete.DeletedAt `gorm:"uniqueIndex:idx_name"`
 
 	Name *string `gorm:"name;not null;uniqueIndex:idx_name"`
+	// IsDefault marks the group new subscriptions land in when no group is
+	// specified and no GroupRule matches. At most one group is the default.
+	IsDefault *bool `gorm:"is_default;default:false"`
+	// Public groups can be browsed without logging in, along with their
+	// feeds and items.
+	Public *bool `gorm:"public;default:false"`
+
+	Label
+	Settings
+}
+
+const (
+	// SanitizeStandard drops the styling of item contents and keeps the rest
+	// of their markup.
+	SanitizeStandard = "standard"
+	// SanitizeStrict also drops media and embeds, leaving text and links.
+	SanitizeStrict = "strict"
+)
+
+// Settings are how the feeds of a group are fetched and shown. Each feed can
+// override them, see Feed.Settings. Nil fields are left to the defaults.
+type Settings struct {
+	// ReqProxy is the proxy feeds are fetched through.
+	ReqProxy *string `gorm:"req_proxy"`
+	// RefreshInterval is the number of minutes between fetches of a feed.
+	RefreshInterval *int `gorm:"refresh_interval"`
+	// Sanitize is how much of the markup of items is kept when they are
+	// shown, SanitizeStandard or SanitizeStrict.
+	Sanitize *string `gorm:"sanitize"`
+	// MonitorOnly stores new items as read, see Feed.MonitorOnly.
+	MonitorOnly *bool `gorm:"monitor_only"`
+}
+
+func (s Settings) IsMonitorOnly() bool {
+	return s.MonitorOnly != nil && *s.MonitorOnly
+}
+
+// Label marks a group or feed in lists, to tell it apart at a glance.
+type Label struct {
+	// Color is a CSS hex color, like "#f59e0b".
+	Color *string `gorm:"color"`
+	// Emoji is shown before the name.
+	Emoji *string `gorm:"emoji"`
+}
+
+// GroupRule assigns new subscriptions whose link matches Pattern to a group.
+type GroupRule struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt soft_delete.DeletedAt `gorm:"uniqueIndex:idx_pattern"`
+
+	// Pattern is a domain pattern, e.g. "example.com" or "*.substack.com".
+	// "example.com" also matches its subdomains.
+	Pattern *string `gorm:"pattern;not null;uniqueIndex:idx_pattern"`
+
+	GroupID uint
+	Group   Group
 }
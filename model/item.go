@@ -15,11 +15,78 @@ type Item struct {
 	Title    *string    `gorm:"title"`
 	GUID     *string    `gorm:"guid;uniqueIndex:idx_guid"`
 	Link     *string    `gorm:"link"`
-	Content  *string    `gorm:"content"`
+	Content  *string    `gorm:"content;serializer:compress"`
 	PubDate  *time.Time `gorm:"pub_date"`
 	Unread   *bool      `gorm:"unread;default:true;index"`
 	Bookmark *bool      `gorm:"bookmark;default:false;index"`
+	// Hidden items are left out of all lists, without unsubscribing from
+	// their feed. Hiding an item also marks it as read.
+	Hidden *bool `gorm:"hidden;default:false"`
+	// Language is the detected ISO 639-1 code, nil if unknown.
+	Language *string `gorm:"language;index"`
+	// WordCount is the number of words in Content, nil for items stored before
+	// it was tracked.
+	WordCount *int `gorm:"word_count"`
+	// Snippet is the beginning of Content as plain text, for lists that don't
+	// load the content. It's nil for items stored before it was tracked.
+	Snippet *string `gorm:"snippet"`
+	// HasVideo, HasAudio and HasGallery flag the kind of media the item
+	// carries, e.g. a video embed, a podcast episode or a set of images.
+	HasVideo   bool `gorm:"has_video;default:false"`
+	HasAudio   bool `gorm:"has_audio;default:false"`
+	HasGallery bool `gorm:"has_gallery;default:false"`
+	// ImageURL is the lead image of the item, shown with it in lists. It's
+	// nil if the item has none, or was stored before they were.
+	ImageURL *string `gorm:"image_url"`
+
+	// EnclosureURL and EnclosureType are the audio or video file attached to
+	// the item, like a podcast episode.
+	EnclosureURL  *string `gorm:"enclosure_url"`
+	EnclosureType *string `gorm:"enclosure_type"`
+	// PlaybackPosition is where, in seconds, playing the enclosure was left
+	// off.
+	PlaybackPosition int `gorm:"playback_position;default:0"`
+	// Listened is to the enclosure what Unread is to the content: it's set
+	// once the enclosure was played to the end.
+	Listened *bool `gorm:"listened;default:false"`
+
+	// Origin is how the item arrived, one of the ItemOrigin values, and
+	// SourceURL where it was fetched from, after redirects. They're nil for
+	// items stored before they were tracked. The item arrived at CreatedAt.
+	Origin    *string `gorm:"origin"`
+	SourceURL *string `gorm:"source_url"`
+	// Position is the index of the item in the feed it was fetched from, 0
+	// for the first one. It's nil for items stored before it was recorded and
+	// for those that weren't pulled, like notes.
+	Position *int `gorm:"position"`
 
 	FeedID uint `gorm:"feed_id;uniqueIndex:idx_guid"`
 	Feed   Feed
 }
+
+// Origins of items, see Item.Origin.
+const (
+	// ItemOriginScheduled items were found by the periodic refresh of all
+	// feeds.
+	ItemOriginScheduled = "scheduled"
+	// ItemOriginRefresh items were found by a refresh someone asked for.
+	ItemOriginRefresh = "refresh"
+	// ItemOriginImport items were found by the first pull of a feed, after
+	// subscribing to it or importing it.
+	ItemOriginImport = "import"
+	// ItemOriginAPI items were added through the API, like notes.
+	ItemOriginAPI = "api"
+)
+
+// ItemTombstone remembers a deleted item, so that it isn't stored again while
+// its feed still lists it.
+type ItemTombstone struct {
+	FeedID uint `gorm:"feed_id;primaryKey;autoIncrement:false"`
+	// GUIDHash is the hash of the GUID of the item, which is all it takes to
+	// recognize it.
+	GUIDHash  int64     `gorm:"guid_hash;primaryKey;autoIncrement:false"`
+	DeletedAt time.Time `gorm:"deleted_at;not null"`
+	// LastSeenAt is the last time a pull found the item in its feed. Tombstones
+	// of items that feeds stopped listing expire.
+	LastSeenAt time.Time `gorm:"last_seen_at;not null;index"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Job statuses. A job is pending until a worker claims it, and goes back to
+// pending if it fails and may be retried.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is a unit of background work. Jobs are stored so that they survive
+// restarts, see the job service.
+type Job struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Kind selects the handler running the job.
+	Kind string `gorm:"kind;not null"`
+	// Payload is the JSON encoded input of the handler.
+	Payload string `gorm:"payload;not null"`
+	Status  string `gorm:"status;not null;index:idx_jobs_due"`
+	// RunAt is when the job is due, later than its creation for retries.
+	RunAt time.Time `gorm:"run_at;not null;index:idx_jobs_due"`
+	// Attempts is the number of times the job was started.
+	Attempts int `gorm:"attempts;default:0"`
+	// Failure is the error of the last attempt, if it failed.
+	Failure    *string    `gorm:"failure"`
+	FinishedAt *time.Time `gorm:"finished_at"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+// OPMLSubscription is a remote OPML file that is fetched periodically to keep
+// the subscribed feeds in sync with it.
+type OPMLSubscription struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt soft_delete.DeletedAt `gorm:"uniqueIndex:idx_opml_link"`
+
+	Link *string `gorm:"link;not null;uniqueIndex:idx_opml_link"`
+	// LastSync is the last time the OPML file was synced successfully.
+	LastSync *time.Time `gorm:"last_sync"`
+	// Failure is the error message for the last sync.
+	Failure *string `gorm:"failure;default:''"`
+
+	// GroupID is the group that receives feeds outside of any OPML folder.
+	GroupID uint
+	Group   Group
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/plugin/soft_delete"
+)
+
+// ScoreKeyword boosts or buries items mentioning Keyword in the Highlights
+// view.
+type ScoreKeyword struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt soft_delete.DeletedAt `gorm:"uniqueIndex:idx_keyword"`
+
+	Keyword *string `gorm:"keyword;not null;uniqueIndex:idx_keyword"`
+	Weight  int     `gorm:"weight;default:0"`
+}
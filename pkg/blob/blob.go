@@ -0,0 +1,64 @@
+// Package blob stores binary objects by key, such as item snapshots and
+// cached favicons, either in a local directory or in an S3 bucket.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs by key. Keys are slash-separated paths, like
+// "favicons/example.com", whose first segment names the feature owning them.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns ErrNotFound if there is no blob with the key.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete succeeds if there is no blob with the key.
+	Delete(ctx context.Context, key string) error
+	// List returns the blobs whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]Info, error)
+}
+
+type Info struct {
+	Key     string
+	ModTime time.Time
+}
+
+// Config selects and configures a Store.
+type Config struct {
+	// Kind is either "local" or "s3".
+	Kind string
+	// Dir is the directory of the local store.
+	Dir string
+	S3  S3Config
+}
+
+func Open(c Config) (Store, error) {
+	switch c.Kind {
+	case "local":
+		return NewLocal(c.Dir)
+	case "s3":
+		return NewS3(c.S3)
+	default:
+		return nil, fmt.Errorf("unknown blob storage %q", c.Kind)
+	}
+}
+
+// validateKey rejects keys that could escape the store, or that stores
+// disagree about.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, "\\\x00") {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
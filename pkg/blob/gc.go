@@ -0,0 +1,92 @@
+package blob
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/0x2e/fusion/pkg/logctx"
+)
+
+const (
+	gcInterval = 24 * time.Hour
+	// gcGracePeriod spares new blobs, as they're stored before whatever
+	// references them.
+	gcGracePeriod = time.Hour
+)
+
+// Owner is a feature storing blobs under Prefix.
+type Owner struct {
+	Prefix string
+	// Referenced returns which of keys are still in use.
+	Referenced func(ctx context.Context, keys []string) (map[string]bool, error)
+}
+
+// Collector deletes the blobs their owner doesn't reference anymore. Blobs
+// outside the prefixes of the owners are left alone.
+type Collector struct {
+	store       Store
+	owners      []Owner
+	gracePeriod time.Duration
+}
+
+func NewCollector(store Store, owners ...Owner) *Collector {
+	return &Collector{
+		store:       store,
+		owners:      owners,
+		gracePeriod: gcGracePeriod,
+	}
+}
+
+func (c *Collector) Run() {
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.Collect(context.Background()); err != nil {
+			slog.Error("failed to collect orphaned blobs", "error", err)
+		}
+
+		<-ticker.C
+	}
+}
+
+// Collect deletes the orphaned blobs, and returns how many it deleted.
+func (c *Collector) Collect(ctx context.Context) (int, error) {
+	deleted := 0
+	for _, o := range c.owners {
+		blobs, err := c.store.List(ctx, o.Prefix)
+		if err != nil {
+			return deleted, err
+		}
+		keys := make([]string, 0, len(blobs))
+		for _, b := range blobs {
+			if time.Since(b.ModTime) >= c.gracePeriod {
+				keys = append(keys, b.Key)
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		referenced, err := o.Referenced(ctx, keys)
+		if err != nil {
+			return deleted, err
+		}
+		n := 0
+		for _, key := range keys {
+			if referenced[key] {
+				continue
+			}
+			if err := c.store.Delete(ctx, key); err != nil {
+				return deleted, err
+			}
+			n++
+		}
+		deleted += n
+		if n > 0 {
+			logctx.From(ctx).Info("deleted orphaned blobs", "prefix", o.Prefix, "count", n)
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,40 @@
+package blob
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollect(t *testing.T) {
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+	for _, key := range []string{"snapshots/1", "snapshots/2", "other/1"} {
+		require.NoError(t, store.Put(ctx, key, []byte("data")))
+	}
+	c := NewCollector(store, Owner{
+		Prefix: "snapshots/",
+		Referenced: func(ctx context.Context, keys []string) (map[string]bool, error) {
+			return map[string]bool{"snapshots/1": true}, nil
+		},
+	})
+
+	deleted, err := c.Collect(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, deleted, "new blobs must be spared")
+
+	c.gracePeriod = 0
+	deleted, err = c.Collect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	blobs, err := store.List(ctx, "")
+	require.NoError(t, err)
+	keys := make([]string, 0, len(blobs))
+	for _, b := range blobs {
+		keys = append(keys, b.Key)
+	}
+	assert.ElementsMatch(t, []string{"snapshots/1", "other/1"}, keys, "only unreferenced blobs of owners must be deleted")
+}
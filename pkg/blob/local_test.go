@@ -0,0 +1,41 @@
+package blob_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/pkg/blob"
+)
+
+func TestLocal(t *testing.T) {
+	store, err := blob.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = store.Get(ctx, "favicons/example.com")
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+
+	require.NoError(t, store.Put(ctx, "favicons/example.com", []byte("icon")))
+	require.NoError(t, store.Put(ctx, "favicons/example.com", []byte("new icon")))
+	require.NoError(t, store.Put(ctx, "snapshots/1", []byte("snapshot")))
+	data, err := store.Get(ctx, "favicons/example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "new icon", string(data), "Put must overwrite")
+
+	blobs, err := store.List(ctx, "favicons/")
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+	assert.Equal(t, "favicons/example.com", blobs[0].Key)
+
+	require.NoError(t, store.Delete(ctx, "favicons/example.com"))
+	require.NoError(t, store.Delete(ctx, "favicons/example.com"), "deleting a missing blob must succeed")
+	_, err = store.Get(ctx, "favicons/example.com")
+	assert.ErrorIs(t, err, blob.ErrNotFound)
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "favicons/../../outside", "favicons/", "a//b"} {
+		assert.Error(t, store.Put(ctx, key, []byte("x")), "key %q must be rejected", key)
+	}
+}
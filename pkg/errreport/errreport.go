@@ -0,0 +1,279 @@
+// Package errreport logs recovered panics and reports them to a
+// Sentry-compatible service, like Sentry or GlitchTip, so that crashes of
+// self-hosted instances can be diagnosed.
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/0x2e/fusion/pkg/logctx"
+)
+
+// Config configures the Reporter.
+type Config struct {
+	// DSN is the client key of the project, as shown by the service. Empty
+	// disables reporting.
+	DSN         string
+	Environment string
+	Release     string
+}
+
+// dsn is a parsed DSN, in the form "https://<key>@<host>[/<path>]/<project>".
+type dsn struct {
+	raw       string
+	publicKey string
+	// envelopeURL is where events are sent.
+	envelopeURL string
+}
+
+func parseDSN(s string) (*dsn, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, errors.New("missing public key")
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	if i < 0 || path[i+1:] == "" {
+		return nil, errors.New("missing project ID")
+	}
+	endpoint := url.URL{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   path[:i] + "/api/" + path[i+1:] + "/envelope/",
+	}
+	return &dsn{
+		raw:         s,
+		publicKey:   u.User.Username(),
+		envelopeURL: endpoint.String(),
+	}, nil
+}
+
+// ValidateDSN reports whether s is a DSN the Reporter can send events to.
+func ValidateDSN(s string) error {
+	_, err := parseDSN(s)
+	return err
+}
+
+// Reporter sends events to a Sentry-compatible service.
+type Reporter struct {
+	dsn         *dsn
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+}
+
+func NewReporter(c Config) (*Reporter, error) {
+	d, err := parseDSN(c.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSN: %w", err)
+	}
+	serverName, _ := os.Hostname()
+	return &Reporter{
+		dsn:         d,
+		environment: c.Environment,
+		release:     c.Release,
+		serverName:  serverName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+var reporter atomic.Pointer[Reporter]
+
+// SetReporter sets where recovered panics are reported. Without one, they're
+// only logged.
+func SetReporter(r *Reporter) {
+	reporter.Store(r)
+}
+
+// Recover recovers from a panic, and logs and reports it with the given
+// attributes. It must be deferred directly, like
+//
+//	defer errreport.Recover(ctx, "feed_id", id)
+func Recover(ctx context.Context, attrs ...any) {
+	if r := recover(); r != nil {
+		CapturePanic(ctx, r, attrs...)
+	}
+}
+
+// CapturePanic logs and reports the value recovered from a panic. attrs are
+// key-value pairs, as for slog, that end up as tags of the event.
+func CapturePanic(ctx context.Context, recovered any, attrs ...any) {
+	logctx.From(ctx).Error("recovered from panic",
+		append([]any{"panic", fmt.Sprint(recovered), "stack", string(debug.Stack())}, attrs...)...)
+
+	r := reporter.Load()
+	if r == nil {
+		return
+	}
+	e := r.newEvent(recovered, panicFrames(), attrs)
+	// Don't hold up the recovering goroutine, or fail it, over the report.
+	go func() {
+		if err := r.send(e); err != nil {
+			logctx.From(ctx).Error("failed to report panic", "error", err)
+		}
+	}()
+}
+
+type event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Exception   struct {
+		Values []exception `json:"values"`
+	} `json:"exception"`
+}
+
+type exception struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace struct {
+		Frames []frame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// maxTagLen is the length past which services truncate or drop tag values.
+const maxTagLen = 200
+
+func (r *Reporter) newEvent(recovered any, frames []frame, attrs []any) *event {
+	id := make([]byte, 16)
+	rand.Read(id)
+	e := &event{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   time.Now().UTC(),
+		Platform:    "go",
+		Level:       "fatal",
+		Logger:      "fusion",
+		Release:     r.release,
+		Environment: r.environment,
+		ServerName:  r.serverName,
+		Tags:        make(map[string]string, len(attrs)/2),
+	}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		value := fmt.Sprint(attrs[i+1])
+		if len(value) > maxTagLen {
+			value = value[:maxTagLen]
+		}
+		e.Tags[fmt.Sprint(attrs[i])] = value
+	}
+	ex := exception{Type: "panic", Value: fmt.Sprint(recovered)}
+	if err, ok := recovered.(error); ok {
+		ex.Type = fmt.Sprintf("%T", err)
+	}
+	ex.Stacktrace.Frames = frames
+	e.Exception.Values = []exception{ex}
+	return e
+}
+
+// panicFrames returns the stack of the panicking goroutine from the caller of
+// panic, oldest first as the services expect.
+func panicFrames() []frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(1, pcs)
+	callers := runtime.CallersFrames(pcs[:n])
+	var frames []frame
+	for {
+		f, more := callers.Next()
+		if f.Function == "runtime.gopanic" {
+			// Everything so far is the recovery.
+			frames = frames[:0]
+		} else {
+			frames = append(frames, newFrame(f))
+		}
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+func newFrame(f runtime.Frame) frame {
+	// Functions are named like "github.com/0x2e/fusion/service/pull.(*Puller).do".
+	module, function := "", f.Function
+	slash := strings.LastIndex(f.Function, "/")
+	if dot := strings.Index(f.Function[slash+1:], "."); dot >= 0 {
+		module = f.Function[:slash+1+dot]
+		function = f.Function[slash+1+dot+1:]
+	}
+	return frame{
+		Function: function,
+		Module:   module,
+		AbsPath:  f.File,
+		Lineno:   f.Line,
+		InApp:    strings.HasPrefix(module, "github.com/0x2e/fusion"),
+	}
+}
+
+// send posts the event in an envelope, the format current services accept.
+func (r *Reporter) send(e *event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	header, err := json.Marshal(map[string]any{
+		"event_id": e.EventID,
+		"dsn":      r.dsn.raw,
+		"sent_at":  time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	body.Write(header)
+	fmt.Fprintf(&body, "\n{\"type\":\"event\",\"length\":%d}\n", len(payload))
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequest(http.MethodPost, r.dsn.envelopeURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=fusion/%s, sentry_key=%s", r.release, r.dsn.publicKey))
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package errreport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDSN(t *testing.T) {
+	for _, tt := range []struct {
+		description         string
+		dsn                 string
+		expectedEnvelopeURL string
+		expectedKey         string
+		expectErr           bool
+	}{
+		{
+			description:         "Sentry DSN",
+			dsn:                 "https://abc123@o42.ingest.sentry.io/1234",
+			expectedEnvelopeURL: "https://o42.ingest.sentry.io/api/1234/envelope/",
+			expectedKey:         "abc123",
+		},
+		{
+			description:         "self-hosted under a path",
+			dsn:                 "http://abc123@glitchtip.lan:8000/errors/7",
+			expectedEnvelopeURL: "http://glitchtip.lan:8000/errors/api/7/envelope/",
+			expectedKey:         "abc123",
+		},
+		{
+			description: "missing key",
+			dsn:         "https://o42.ingest.sentry.io/1234",
+			expectErr:   true,
+		},
+		{
+			description: "missing project",
+			dsn:         "https://abc123@o42.ingest.sentry.io/",
+			expectErr:   true,
+		},
+		{
+			description: "unsupported scheme",
+			dsn:         "ftp://abc123@o42.ingest.sentry.io/1234",
+			expectErr:   true,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			d, err := parseDSN(tt.dsn)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedEnvelopeURL, d.envelopeURL)
+			assert.Equal(t, tt.expectedKey, d.publicKey)
+		})
+	}
+}
+
+func panicky() {
+	panic("dummy panic")
+}
+
+func TestRecoverReportsPanics(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	events := make(chan event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(nil, 1<<20)
+		// The envelope header, the item header, then the event.
+		for range 3 {
+			scanner.Scan()
+		}
+		var e event
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		received <- r
+		events <- e
+	}))
+	defer srv.Close()
+	r, err := NewReporter(Config{
+		DSN:         strings.Replace(srv.URL, "://", "://abc123@", 1) + "/1",
+		Environment: "test",
+		Release:     "v1.2.3",
+	})
+	require.NoError(t, err)
+	SetReporter(r)
+	defer SetReporter(nil)
+
+	func() {
+		defer Recover(context.Background(), "feed_id", 42)
+		panicky()
+	}()
+
+	var req *http.Request
+	var e event
+	select {
+	case req = <-received:
+		e = <-events
+	case <-time.After(time.Second):
+		require.Fail(t, "the panic must be reported")
+	}
+	assert.Equal(t, "/api/1/envelope/", req.URL.Path)
+	assert.Contains(t, req.Header.Get("X-Sentry-Auth"), "sentry_key=abc123")
+	assert.Equal(t, "fatal", e.Level)
+	assert.Equal(t, "v1.2.3", e.Release)
+	assert.Equal(t, "test", e.Environment)
+	assert.Equal(t, map[string]string{"feed_id": "42"}, e.Tags)
+	require.Len(t, e.Exception.Values, 1)
+	ex := e.Exception.Values[0]
+	assert.Equal(t, "dummy panic", ex.Value)
+	require.NotEmpty(t, ex.Stacktrace.Frames)
+	last := ex.Stacktrace.Frames[len(ex.Stacktrace.Frames)-1]
+	assert.Equal(t, "panicky", last.Function, "the stack must end where panic was called")
+	assert.Equal(t, "github.com/0x2e/fusion/pkg/errreport", last.Module)
+	assert.True(t, last.InApp)
+}
+
+func TestRecoverWithoutReporter(t *testing.T) {
+	assert.NotPanics(t, func() {
+		defer Recover(context.Background())
+		panicky()
+	})
+}
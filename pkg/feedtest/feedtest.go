@@ -0,0 +1,355 @@
+// Package feedtest provides a local feed server for tests. It serves a
+// configurable feed as RSS, Atom or JSON Feed, and can simulate conditional
+// requests, slow responses and errors.
+package feedtest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatRSS  Format = "rss"
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json"
+)
+
+type Item struct {
+	Title     string
+	Link      string
+	GUID      string
+	Content   string
+	Published time.Time
+}
+
+type Feed struct {
+	Title       string
+	SiteURL     string
+	Description string
+	Updated     time.Time
+	Items       []Item
+}
+
+// Server is an HTTP server serving a single feed on every path. It's safe to
+// change its behavior while requests are in flight.
+type Server struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	feed         Feed
+	format       Format
+	etag         string
+	lastModified time.Time
+	status       int
+	body         *string
+	delay        time.Duration
+	requests     []*http.Request
+}
+
+// NewServer starts a server serving feed as RSS. The server is closed when
+// the test ends.
+func NewServer(t testing.TB, feed Feed) *Server {
+	t.Helper()
+
+	s := &Server{
+		feed:   feed,
+		format: FormatRSS,
+		status: http.StatusOK,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// FeedURL returns the URL of the feed.
+func (s *Server) FeedURL() string {
+	return s.URL + "/feed"
+}
+
+func (s *Server) SetFeed(feed Feed) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feed = feed
+}
+
+func (s *Server) SetFormat(format Format) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.format = format
+}
+
+// SetETag sets the ETag of the feed. Requests with a matching If-None-Match
+// header get a 304 response. An empty etag disables the header.
+func (s *Server) SetETag(etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.etag = etag
+}
+
+// SetLastModified sets the Last-Modified time of the feed. Requests with an
+// If-Modified-Since header at or after it get a 304 response. The zero time
+// disables the header.
+func (s *Server) SetLastModified(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastModified = t
+}
+
+// SetStatus makes the server answer every request with the given status
+// code. Only 200 responses carry the feed.
+func (s *Server) SetStatus(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = code
+}
+
+// SetBody makes the server send body instead of the rendered feed, e.g. to
+// serve a malformed feed.
+func (s *Server) SetBody(body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = &body
+}
+
+// SetDelay makes the server wait before responding. The wait ends early if
+// the client gives up.
+func (s *Server) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*http.Request(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Clone(r.Context()))
+	feed, format, etag, lastModified := s.feed, s.format, s.etag, s.lastModified
+	status, body, delay := s.status, s.body, s.delay
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if body != nil {
+		w.Write([]byte(*body))
+		return
+	}
+	data, contentType, err := render(feed, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if etag != "" && r.Header.Get("If-None-Match") == etag {
+		return true
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+func render(feed Feed, format Format) ([]byte, string, error) {
+	switch format {
+	case FormatAtom:
+		data, err := renderAtom(feed)
+		return data, "application/atom+xml", err
+	case FormatJSON:
+		data, err := renderJSON(feed)
+		return data, "application/feed+json", err
+	default:
+		data, err := renderRSS(feed)
+		return data, "application/rss+xml", err
+	}
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link,omitempty"`
+	Description   string    `xml:"description,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link,omitempty"`
+	GUID        string `xml:"guid,omitempty"`
+	Description string `xml:"description,omitempty"`
+	PubDate     string `xml:"pubDate,omitempty"`
+}
+
+func renderRSS(feed Feed) ([]byte, error) {
+	doc := rss{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         feed.Title,
+			Link:          feed.SiteURL,
+			Description:   feed.Description,
+			LastBuildDate: formatTime(feed.Updated, time.RFC1123Z),
+		},
+	}
+	for _, v := range feed.Items {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       v.Title,
+			Link:        v.Link,
+			GUID:        v.GUID,
+			Description: v.Content,
+			PubDate:     formatTime(v.Published, time.RFC1123Z),
+		})
+	}
+	return marshalXML(doc)
+}
+
+type atom struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Link     *atomLink   `xml:"link,omitempty"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated,omitempty"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title     string       `xml:"title"`
+	Link      *atomLink    `xml:"link,omitempty"`
+	ID        string       `xml:"id,omitempty"`
+	Content   *atomContent `xml:"content,omitempty"`
+	Published string       `xml:"published,omitempty"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func renderAtom(feed Feed) ([]byte, error) {
+	doc := atom{
+		Title:    feed.Title,
+		Link:     newAtomLink(feed.SiteURL),
+		Subtitle: feed.Description,
+		Updated:  formatTime(feed.Updated, time.RFC3339),
+	}
+	for _, v := range feed.Items {
+		entry := atomEntry{
+			Title:     v.Title,
+			Link:      newAtomLink(v.Link),
+			ID:        v.GUID,
+			Published: formatTime(v.Published, time.RFC3339),
+		}
+		if v.Content != "" {
+			entry.Content = &atomContent{Type: "html", Body: v.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshalXML(doc)
+}
+
+func newAtomLink(href string) *atomLink {
+	if href == "" {
+		return nil
+	}
+	return &atomLink{Href: href}
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url,omitempty"`
+	Title         string `json:"title"`
+	ContentHTML   string `json:"content_html,omitempty"`
+	DatePublished string `json:"date_published,omitempty"`
+}
+
+func renderJSON(feed Feed) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.SiteURL,
+		Description: feed.Description,
+		Items:       []jsonFeedItem{},
+	}
+	for _, v := range feed.Items {
+		doc.Items = append(doc.Items, jsonFeedItem{
+			ID:            v.GUID,
+			URL:           v.Link,
+			Title:         v.Title,
+			ContentHTML:   v.Content,
+			DatePublished: formatTime(v.Published, time.RFC3339),
+		})
+	}
+	return json.Marshal(doc)
+}
+
+func marshalXML(v any) ([]byte, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func formatTime(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
@@ -0,0 +1,139 @@
+package feedtest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/pkg/feedtest"
+)
+
+var testFeed = feedtest.Feed{
+	Title:   "Test Feed",
+	SiteURL: "https://example.com",
+	Updated: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	Items: []feedtest.Item{
+		{
+			Title:     "First post",
+			Link:      "https://example.com/first",
+			GUID:      "first",
+			Content:   "<p>Hello</p>",
+			Published: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	},
+}
+
+func TestServerFormats(t *testing.T) {
+	for _, format := range []feedtest.Format{feedtest.FormatRSS, feedtest.FormatAtom, feedtest.FormatJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			s := feedtest.NewServer(t, testFeed)
+			s.SetFormat(format)
+
+			feed, err := gofeed.NewParser().ParseURL(s.FeedURL())
+			require.NoError(t, err)
+
+			assert.Equal(t, string(format), feed.FeedType)
+			assert.Equal(t, "Test Feed", feed.Title)
+			assert.Equal(t, "https://example.com", feed.Link)
+			require.Len(t, feed.Items, 1)
+			item := feed.Items[0]
+			assert.Equal(t, "First post", item.Title)
+			assert.Equal(t, "https://example.com/first", item.Link)
+			assert.Equal(t, "first", item.GUID)
+			assert.Contains(t, item.Description+item.Content, "<p>Hello</p>")
+			require.NotNil(t, item.PublishedParsed)
+			assert.True(t, testFeed.Items[0].Published.Equal(*item.PublishedParsed))
+		})
+	}
+}
+
+func TestServerConditionalRequests(t *testing.T) {
+	lastModified := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tt := range []struct {
+		description    string
+		etag           string
+		lastModified   time.Time
+		header         http.Header
+		expectedStatus int
+	}{
+		{
+			description:    "unconditional request gets the feed",
+			etag:           `"v1"`,
+			lastModified:   lastModified,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "matching ETag gets 304",
+			etag:           `"v1"`,
+			header:         http.Header{"If-None-Match": {`"v1"`}},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			description:    "stale ETag gets the feed",
+			etag:           `"v2"`,
+			header:         http.Header{"If-None-Match": {`"v1"`}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "If-Modified-Since at Last-Modified gets 304",
+			lastModified:   lastModified,
+			header:         http.Header{"If-Modified-Since": {lastModified.Format(http.TimeFormat)}},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			description:    "If-Modified-Since before Last-Modified gets the feed",
+			lastModified:   lastModified,
+			header:         http.Header{"If-Modified-Since": {lastModified.Add(-time.Hour).Format(http.TimeFormat)}},
+			expectedStatus: http.StatusOK,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			s := feedtest.NewServer(t, testFeed)
+			s.SetETag(tt.etag)
+			s.SetLastModified(tt.lastModified)
+
+			req, err := http.NewRequest(http.MethodGet, s.FeedURL(), nil)
+			require.NoError(t, err)
+			req.Header = tt.header.Clone()
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tt.etag, resp.Header.Get("ETag"))
+			require.Len(t, s.Requests(), 1)
+		})
+	}
+}
+
+func TestServerStatus(t *testing.T) {
+	s := feedtest.NewServer(t, testFeed)
+	s.SetStatus(http.StatusInternalServerError)
+
+	resp, err := http.Get(s.FeedURL())
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestServerDelay(t *testing.T) {
+	s := feedtest.NewServer(t, testFeed)
+	s.SetDelay(time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.FeedURL(), nil)
+	require.NoError(t, err)
+
+	_, err = http.DefaultClient.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
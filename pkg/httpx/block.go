@@ -0,0 +1,63 @@
+package httpx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrBlocked indicates that the server answered with an anti-bot challenge
+// page instead of the requested content.
+var ErrBlocked = errors.New("blocked by anti-bot protection, try setting a proxy or configuring FlareSolverr")
+
+// maxSniffedBodySize is the number of bytes we inspect when looking for
+// challenge page markers.
+const maxSniffedBodySize = 64 * 1024
+
+// challengeMarkers are strings that appear in the body of well-known anti-bot
+// challenge pages.
+var challengeMarkers = []string{
+	"<title>Just a moment...</title>",
+	"<title>Attention Required! | Cloudflare</title>",
+	"cf-browser-verification",
+	"_cf_chl_opt",
+	"/cdn-cgi/challenge-platform/",
+	"DDoS-Guard",
+}
+
+// IsBlocked reports whether resp is an anti-bot challenge page. It peeks at
+// the beginning of the body and restores it, so the caller can still read the
+// full response afterwards.
+func IsBlocked(resp *http.Response) bool {
+	if resp.Header.Get("cf-mitigated") == "challenge" {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+	default:
+		return false
+	}
+
+	if resp.Body == nil {
+		return false
+	}
+	head, err := io.ReadAll(io.LimitReader(resp.Body, maxSniffedBodySize))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+
+	body := string(head)
+	for _, marker := range challengeMarkers {
+		if strings.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
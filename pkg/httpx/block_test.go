@@ -0,0 +1,80 @@
+package httpx_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/0x2e/fusion/pkg/httpx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBlocked(t *testing.T) {
+	for _, tt := range []struct {
+		description     string
+		statusCode      int
+		header          http.Header
+		body            string
+		expectedBlocked bool
+	}{
+		{
+			description:     "regular feed is not blocked",
+			statusCode:      http.StatusOK,
+			header:          http.Header{},
+			body:            `<?xml version="1.0"?><rss version="2.0"></rss>`,
+			expectedBlocked: false,
+		},
+		{
+			description:     "cloudflare challenge header is blocked",
+			statusCode:      http.StatusOK,
+			header:          http.Header{"Cf-Mitigated": []string{"challenge"}},
+			body:            "<html></html>",
+			expectedBlocked: true,
+		},
+		{
+			description:     "cloudflare interstitial page is blocked",
+			statusCode:      http.StatusServiceUnavailable,
+			header:          http.Header{"Server": []string{"cloudflare"}},
+			body:            "<html><head><title>Just a moment...</title></head></html>",
+			expectedBlocked: true,
+		},
+		{
+			description:     "cloudflare challenge script is blocked",
+			statusCode:      http.StatusForbidden,
+			header:          http.Header{},
+			body:            "<html><script>window._cf_chl_opt={};</script></html>",
+			expectedBlocked: true,
+		},
+		{
+			description:     "plain 403 is not blocked",
+			statusCode:      http.StatusForbidden,
+			header:          http.Header{},
+			body:            "<html><title>Forbidden</title></html>",
+			expectedBlocked: false,
+		},
+		{
+			description:     "challenge markers with 200 status are not blocked",
+			statusCode:      http.StatusOK,
+			header:          http.Header{},
+			body:            "<p>An article about _cf_chl_opt</p>",
+			expectedBlocked: false,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.statusCode,
+				Header:     tt.header,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+
+			assert.Equal(t, tt.expectedBlocked, httpx.IsBlocked(resp))
+
+			// The body must still be readable after sniffing.
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
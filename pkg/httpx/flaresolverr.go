@@ -0,0 +1,85 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/0x2e/fusion/model"
+)
+
+type flareSolverrRequest struct {
+	Cmd        string `json:"cmd"`
+	URL        string `json:"url"`
+	MaxTimeout int    `json:"maxTimeout"`
+	Proxy      *struct {
+		URL string `json:"url"`
+	} `json:"proxy,omitempty"`
+}
+
+type flareSolverrResponse struct {
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	Solution struct {
+		Status   int               `json:"status"`
+		Headers  map[string]string `json:"headers"`
+		Response string            `json:"response"`
+	} `json:"solution"`
+}
+
+// requestWithFlareSolverr fetches link through FlareSolverr and converts the
+// solution into a regular HTTP response.
+func requestWithFlareSolverr(ctx context.Context, sendRequest SendHTTPRequestFn, endpoint string, link string, options model.FeedRequestOptions) (*http.Response, error) {
+	payload := flareSolverrRequest{
+		Cmd:        "request.get",
+		URL:        link,
+		MaxTimeout: 60000,
+	}
+	if options.ReqProxy != nil && *options.ReqProxy != "" {
+		payload.Proxy = &struct {
+			URL string `json:"url"`
+		}{URL: *options.ReqProxy}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint+"/v1", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := sendRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("flaresolverr: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result flareSolverrResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("flaresolverr: %w", err)
+	}
+	if result.Status != "ok" {
+		return nil, fmt.Errorf("flaresolverr: %s", result.Message)
+	}
+
+	header := http.Header{}
+	for k, v := range result.Solution.Headers {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		Proto:         "HTTP/1.1",
+		StatusCode:    result.Solution.Status,
+		Status:        http.StatusText(result.Solution.Status),
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(result.Solution.Response)),
+		ContentLength: int64(len(result.Solution.Response)),
+		Request:       req,
+	}, nil
+}
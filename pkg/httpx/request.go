@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/0x2e/fusion/model"
 )
@@ -12,12 +13,35 @@ const UserAgentString = "fusion/1.0"
 
 var globalClient = newClient()
 
+type acceptKey struct{}
+
+// WithAccept returns a copy of ctx with which requests ask for the given
+// media types, for the sources that aren't served as feeds by default, like
+// ActivityPub actors.
+func WithAccept(ctx context.Context, accept string) context.Context {
+	return context.WithValue(ctx, acceptKey{}, accept)
+}
+
 // SendHTTPRequestFn is a function type for sending HTTP requests, matching
 // http.Client's Do method.
 type SendHTTPRequestFn func(req *http.Request) (*http.Response, error)
 
 // FusionRequest makes an HTTP request using the global client.
 func FusionRequest(ctx context.Context, link string, options model.FeedRequestOptions) (*http.Response, error) {
+	return fusionRequest(ctx, link, options, "")
+}
+
+// FusionRequestWithFlareSolverr returns a function that makes requests like
+// FusionRequest, and retries those blocked by an anti-bot challenge through
+// the FlareSolverr instance at endpoint. An empty endpoint disables retries.
+func FusionRequestWithFlareSolverr(endpoint string) func(ctx context.Context, link string, options model.FeedRequestOptions) (*http.Response, error) {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	return func(ctx context.Context, link string, options model.FeedRequestOptions) (*http.Response, error) {
+		return fusionRequest(ctx, link, options, endpoint)
+	}
+}
+
+func fusionRequest(ctx context.Context, link string, options model.FeedRequestOptions, flareSolverrEndpoint string) (*http.Response, error) {
 	client := globalClient
 
 	if options.ReqProxy != nil && *options.ReqProxy != "" {
@@ -30,7 +54,17 @@ func FusionRequest(ctx context.Context, link string, options model.FeedRequestOp
 		})
 	}
 
-	return FusionRequestWithRequestSender(ctx, client.Do, link, options)
+	resp, err := FusionRequestWithRequestSender(ctx, client.Do, link, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if flareSolverrEndpoint != "" && IsBlocked(resp) {
+		resp.Body.Close()
+		return requestWithFlareSolverr(ctx, globalClient.Do, flareSolverrEndpoint, link, options)
+	}
+
+	return resp, nil
 }
 
 // FusionRequestWithRequestSender makes an HTTP request using the provided
@@ -42,6 +76,9 @@ func FusionRequestWithRequestSender(ctx context.Context, sendRequest SendHTTPReq
 	}
 	req.Close = true
 	req.Header.Add("User-Agent", UserAgentString)
+	if accept, ok := ctx.Value(acceptKey{}).(string); ok {
+		req.Header.Set("Accept", accept)
+	}
 
 	return sendRequest(req)
 }
@@ -3,7 +3,9 @@ package httpx_test
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/0x2e/fusion/model"
@@ -92,3 +94,30 @@ func TestFusionRequestWithRequestSender(t *testing.T) {
 		})
 	}
 }
+
+func TestFusionRequestWithFlareSolverr(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("cf-mitigated", "challenge")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer site.Close()
+	solver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1", r.URL.Path)
+		w.Write([]byte(`{"status": "ok", "solution": {"status": 200, "response": "<rss></rss>"}}`))
+	}))
+	defer solver.Close()
+
+	resp, err := httpx.FusionRequest(context.Background(), site.URL, model.FeedRequestOptions{})
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "requests are only retried when FlareSolverr is configured")
+
+	request := httpx.FusionRequestWithFlareSolverr(solver.URL + "/")
+	resp, err = request(context.Background(), site.URL, model.FeedRequestOptions{})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "<rss></rss>", string(body))
+}
@@ -0,0 +1,230 @@
+// Package lang guesses the natural language of a piece of text.
+//
+// It's a lightweight heuristic rather than a statistical model: scripts that
+// are (mostly) used by a single language are recognized by their characters,
+// and languages written in the Latin script are told apart by counting common
+// function words.
+package lang
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxSampleRunes caps the amount of text that is inspected, as the first few
+// paragraphs are enough to tell the language.
+const maxSampleRunes = 4000
+
+// minStopwordHits is the number of function words a Latin-script text must
+// contain before we trust the guess.
+const minStopwordHits = 3
+
+// Supported lists the ISO 639-1 codes that Detect may return.
+var Supported = []string{
+	"ar", "de", "el", "en", "es", "fr", "he", "hi", "it", "ja", "ko", "nl",
+	"pt", "ru", "th", "uk", "zh",
+}
+
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "is", "that", "with", "was", "this", "are", "you", "for", "have", "it", "to"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "mit", "sich", "auf", "für", "von", "dem", "auch", "ich"},
+	"fr": {"le", "les", "et", "est", "des", "une", "du", "dans", "pour", "pas", "qui", "sur", "avec", "au", "ce"},
+	"es": {"el", "los", "las", "y", "es", "una", "por", "con", "para", "del", "se", "lo", "como", "más", "pero"},
+	"it": {"il", "di", "che", "è", "per", "non", "sono", "della", "gli", "si", "anche", "come", "più"},
+	"pt": {"o", "os", "e", "um", "uma", "para", "com", "não", "do", "da", "em", "são", "mais", "mas"},
+	"nl": {"het", "een", "en", "van", "dat", "niet", "op", "te", "met", "zijn", "voor", "ook", "maar", "dit", "wordt"},
+}
+
+// stopwordLanguages maps every stop word to the languages it belongs to.
+var stopwordLanguages = func() map[string][]string {
+	m := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+// Detect returns the ISO 639-1 code of the language text is written in, or an
+// empty string if it can't tell. HTML tags in text are ignored.
+func Detect(text string) string {
+	text = sample(stripTags(text))
+
+	var letters, han, kana, hangul, cyrillic, ukrainian, arabic, hebrew, greek, thai, devanagari int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				ukrainian++
+			}
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Hebrew, r):
+			hebrew++
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		case unicode.Is(unicode.Devanagari, r):
+			devanagari++
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	// Japanese mixes kana with Han characters, so any meaningful amount of
+	// kana wins over Chinese.
+	if kana > 0 && kana*10 >= kana+han {
+		return "ja"
+	}
+	for _, s := range []struct {
+		lang  string
+		count int
+	}{
+		{"zh", han},
+		{"ko", hangul},
+		{"ar", arabic},
+		{"he", hebrew},
+		{"el", greek},
+		{"th", thai},
+		{"hi", devanagari},
+	} {
+		if s.count*2 > letters {
+			return s.lang
+		}
+	}
+	if cyrillic*2 > letters {
+		if ukrainian > 0 {
+			return "uk"
+		}
+		return "ru"
+	}
+
+	return detectLatin(text)
+}
+
+func detectLatin(text string) string {
+	hits := make(map[string]int)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		for _, lang := range stopwordLanguages[w] {
+			hits[lang]++
+		}
+	}
+
+	best, bestHits, secondHits := "", 0, 0
+	for lang, n := range hits {
+		switch {
+		case n > bestHits:
+			secondHits = max(secondHits, bestHits)
+			best, bestHits = lang, n
+		case n > secondHits:
+			secondHits = n
+		}
+	}
+	if bestHits < minStopwordHits || bestHits == secondHits {
+		return ""
+	}
+	return best
+}
+
+// WordCount returns the number of words in text, ignoring HTML tags. Han and
+// kana characters are counted one by one, as those scripts don't separate
+// words with spaces.
+func WordCount(text string) int {
+	n := 0
+	for _, field := range strings.Fields(stripTags(text)) {
+		inWord := false
+		for _, r := range field {
+			switch {
+			case unicode.Is(unicode.Han, r), unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+				n++
+				inWord = false
+			case unicode.IsLetter(r) || unicode.IsDigit(r):
+				if !inWord {
+					n++
+					inWord = true
+				}
+			}
+		}
+	}
+	return n
+}
+
+// Snippet returns the beginning of text as plain text: HTML tags are removed,
+// entities decoded and whitespace collapsed. It's cut at a word boundary to at
+// most maxRunes runes, followed by an ellipsis if anything was left out.
+func Snippet(text string, maxRunes int) string {
+	if maxRunes <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	n := 0
+	for _, word := range strings.Fields(html.UnescapeString(stripTags(text))) {
+		sep := 0
+		if n > 0 {
+			sep = 1
+		}
+		wordRunes := utf8.RuneCountInString(word)
+		if n+sep+wordRunes > maxRunes {
+			// A single word longer than the limit, like text in a script
+			// that doesn't separate words, is cut anywhere.
+			if n == 0 {
+				b.WriteString(string([]rune(word)[:maxRunes]))
+			}
+			b.WriteString("…")
+			return b.String()
+		}
+		if sep > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(word)
+		n += sep + wordRunes
+	}
+	return b.String()
+}
+
+// stripTags removes HTML tags so that markup doesn't count as text.
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+			b.WriteRune(' ')
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func sample(s string) string {
+	n := 0
+	for i := range s {
+		if n == maxSampleRunes {
+			return s[:i]
+		}
+		n++
+	}
+	return s
+}
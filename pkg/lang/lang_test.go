@@ -0,0 +1,159 @@
+package lang_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/0x2e/fusion/pkg/lang"
+)
+
+func TestDetect(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		text        string
+		expected    string
+	}{
+		{
+			description: "english",
+			text:        "<p>This is the story of a city that was built with the help of its people.</p>",
+			expected:    "en",
+		},
+		{
+			description: "german",
+			text:        "Die Regierung hat sich auf einen Plan geeinigt, der auch von der Opposition unterstützt wird.",
+			expected:    "de",
+		},
+		{
+			description: "french",
+			text:        "Le gouvernement est dans une situation difficile pour les mois qui viennent, avec des élections.",
+			expected:    "fr",
+		},
+		{
+			description: "spanish",
+			text:        "El presidente habló con los ministros para preparar una respuesta a la crisis del sector.",
+			expected:    "es",
+		},
+		{
+			description: "japanese mixes kana and kanji",
+			text:        "今日は東京で新しい技術についての会議が開かれました。",
+			expected:    "ja",
+		},
+		{
+			description: "chinese",
+			text:        "今天在北京举行了关于新技术的会议。",
+			expected:    "zh",
+		},
+		{
+			description: "russian",
+			text:        "Сегодня в Москве прошла конференция о новых технологиях.",
+			expected:    "ru",
+		},
+		{
+			description: "ukrainian has distinct letters",
+			text:        "Сьогодні в Києві відбулася конференція про нові технології.",
+			expected:    "uk",
+		},
+		{
+			description: "markup is ignored",
+			text:        `<img src="the-and-of-is.png"><a href="/the/and/of">Hallo</a>`,
+			expected:    "",
+		},
+		{
+			description: "too little text",
+			text:        "Release v1.2.3",
+			expected:    "",
+		},
+		{
+			description: "empty",
+			text:        "",
+			expected:    "",
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.Equal(t, tt.expected, lang.Detect(tt.text))
+		})
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		text        string
+		expected    int
+	}{
+		{
+			description: "plain text",
+			text:        "The quick brown fox jumps over the lazy dog.",
+			expected:    9,
+		},
+		{
+			description: "markup is ignored",
+			text:        `<p>Hello <a href="https://example.com/some/long/path">world</a></p><img src="a.png">`,
+			expected:    2,
+		},
+		{
+			description: "punctuation is not a word",
+			text:        "Wait — what?! -- ...",
+			expected:    2,
+		},
+		{
+			description: "han and kana are counted per character",
+			text:        "今日は東京",
+			expected:    5,
+		},
+		{
+			description: "empty",
+			text:        "",
+			expected:    0,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.Equal(t, tt.expected, lang.WordCount(tt.text))
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		text        string
+		maxRunes    int
+		expected    string
+	}{
+		{
+			description: "short text is kept whole",
+			text:        "The quick brown fox.",
+			maxRunes:    200,
+			expected:    "The quick brown fox.",
+		},
+		{
+			description: "markup and entities become plain text",
+			text:        "<p>Fish &amp; chips</p>\n\n<p>are   <b>great</b></p>",
+			maxRunes:    200,
+			expected:    "Fish & chips are great",
+		},
+		{
+			description: "long text is cut at a word boundary",
+			text:        "The quick brown fox jumps over the lazy dog.",
+			maxRunes:    18,
+			expected:    "The quick brown…",
+		},
+		{
+			description: "a word longer than the limit is cut anywhere",
+			text:        "今日は東京で雨が降っています",
+			maxRunes:    5,
+			expected:    "今日は東京…",
+		},
+		{
+			description: "zero length",
+			text:        "The quick brown fox.",
+			maxRunes:    0,
+			expected:    "",
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.Equal(t, tt.expected, lang.Snippet(tt.text, tt.maxRunes))
+		})
+	}
+}
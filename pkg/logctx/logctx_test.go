@@ -0,0 +1,20 @@
+package logctx_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/0x2e/fusion/pkg/logctx"
+)
+
+func TestFrom(t *testing.T) {
+	assert.Same(t, slog.Default(), logctx.From(context.Background()), "contexts without a logger must use the default one")
+
+	l := slog.Default().With("request_id", "abc")
+	ctx := logctx.With(context.Background(), l)
+	assert.Same(t, l, logctx.From(ctx))
+	assert.Same(t, l, logctx.From(context.WithoutCancel(ctx)), "the logger must survive detaching from cancellation")
+}
@@ -0,0 +1,69 @@
+package logfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/pkg/logfile"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestFileRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fusion.log")
+	f, err := logfile.Open(path, 10, 2)
+	require.NoError(t, err)
+	defer f.Close()
+
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "fifth\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "fifth\n", readFile(t, path))
+	assert.Equal(t, "four\n", readFile(t, path+".1"))
+	assert.Equal(t, "three\n", readFile(t, path+".2"))
+	assert.NoFileExists(t, path+".3", "only maxBackups backups must be kept")
+}
+
+func TestFileWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fusion.log")
+	f, err := logfile.Open(path, 10, 0)
+	require.NoError(t, err)
+	defer f.Close()
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "three\n", readFile(t, path))
+	assert.NoFileExists(t, path+".1")
+}
+
+func TestFileWithoutRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fusion.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+	f, err := logfile.Open(path, 0, 2)
+	require.NoError(t, err)
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, "old\none\ntwo\nthree\n", readFile(t, path), "existing content must be appended to")
+	_, err = f.Write([]byte("four\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
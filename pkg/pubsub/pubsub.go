@@ -0,0 +1,52 @@
+// Package pubsub fans out in-process events to any number of subscribers.
+package pubsub
+
+import "sync"
+
+// subscriberBuffer is the number of events a subscriber can fall behind
+// before new events are dropped for it.
+const subscriberBuffer = 16
+
+// Broker delivers every published event to all current subscribers. Publish
+// never blocks: a subscriber that doesn't keep up misses events.
+type Broker[T any] struct {
+	mu   sync.Mutex
+	subs map[chan T]struct{}
+}
+
+func NewBroker[T any]() *Broker[T] {
+	return &Broker[T]{
+		subs: make(map[chan T]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving the events published from now on, and
+// a function that ends the subscription and closes the channel.
+func (b *Broker[T]) Subscribe() (<-chan T, func()) {
+	ch := make(chan T, subscriberBuffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (b *Broker[T]) Publish(event T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
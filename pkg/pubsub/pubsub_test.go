@@ -0,0 +1,42 @@
+package pubsub_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/0x2e/fusion/pkg/pubsub"
+)
+
+func TestBroker(t *testing.T) {
+	b := pubsub.NewBroker[int]()
+	b.Publish(0) // no subscribers yet
+
+	ch1, cancel1 := b.Subscribe()
+	ch2, cancel2 := b.Subscribe()
+	b.Publish(1)
+	assert.Equal(t, 1, <-ch1)
+	assert.Equal(t, 1, <-ch2)
+
+	cancel1()
+	cancel1() // canceling twice is harmless
+	_, open := <-ch1
+	assert.False(t, open, "canceled subscriptions must be closed")
+
+	b.Publish(2)
+	assert.Equal(t, 2, <-ch2)
+	cancel2()
+}
+
+func TestBrokerDropsEventsForSlowSubscribers(t *testing.T) {
+	b := pubsub.NewBroker[int]()
+	ch, cancel := b.Subscribe()
+	defer cancel()
+
+	for i := 0; i < 100; i++ {
+		b.Publish(i)
+	}
+
+	assert.Equal(t, 0, <-ch, "buffered events must be kept in order")
+	assert.Less(t, len(ch), 100)
+}
@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"bytes"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+func NewAuthState(db *gorm.DB) *AuthState {
+	return &AuthState{
+		db: db,
+	}
+}
+
+// AuthState tracks which sessions are valid. Every session carries the
+// session version it was created with, and bumping the version ends them all.
+type AuthState struct {
+	db *gorm.DB
+}
+
+type authState struct {
+	ID                  uint `gorm:"primaryKey"`
+	SessionVersion      int
+	PasswordFingerprint []byte
+}
+
+func (authState) TableName() string {
+	return "auth_state"
+}
+
+// SessionVersion returns the version that sessions must carry to be valid.
+func (a AuthState) SessionVersion() (int, error) {
+	var res authState
+	err := a.db.Take(&res, 1).Error
+	if errors.Is(err, ErrNotFound) {
+		return 1, nil
+	}
+	return res.SessionVersion, err
+}
+
+// BumpSessionVersion ends every session, and returns the new version.
+func (a AuthState) BumpSessionVersion() (int, error) {
+	err := a.db.Exec("INSERT INTO `auth_state` (`id`, `session_version`) VALUES (1, 2) " +
+		"ON CONFLICT (`id`) DO UPDATE SET `session_version` = `session_version` + 1").Error
+	if err != nil {
+		return 0, err
+	}
+	return a.SessionVersion()
+}
+
+// SyncPassword bumps the session version if the password changed since the
+// last call, as told by its fingerprint. It reports whether it did.
+func (a AuthState) SyncPassword(fingerprint []byte) (bool, error) {
+	changed := false
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		var res authState
+		err := tx.Take(&res, 1).Error
+		if errors.Is(err, ErrNotFound) {
+			return tx.Create(&authState{ID: 1, SessionVersion: 1, PasswordFingerprint: fingerprint}).Error
+		}
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(res.PasswordFingerprint, fingerprint) {
+			return nil
+		}
+		changed = true
+		return tx.Model(&authState{}).Where("id = ?", 1).Updates(map[string]any{
+			"session_version":      gorm.Expr("session_version + 1"),
+			"password_fingerprint": fingerprint,
+		}).Error
+	})
+	return changed, err
+}
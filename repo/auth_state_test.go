@@ -0,0 +1,42 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+)
+
+func TestAuthStateSessionVersion(t *testing.T) {
+	state := repo.NewAuthState(repotest.NewDB(t))
+	version := func() int {
+		t.Helper()
+		v, err := state.SessionVersion()
+		require.NoError(t, err)
+		return v
+	}
+	require.Equal(t, 1, version())
+
+	changed, err := state.SyncPassword([]byte("first"))
+	require.NoError(t, err)
+	assert.False(t, changed, "the first password isn't a change")
+	assert.Equal(t, 1, version())
+
+	changed, err = state.SyncPassword([]byte("first"))
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, 1, version(), "restarting with the same password must keep sessions")
+
+	changed, err = state.SyncPassword([]byte("second"))
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 2, version(), "changing the password must end sessions")
+
+	bumped, err := state.BumpSessionVersion()
+	require.NoError(t, err)
+	assert.Equal(t, 3, bumped)
+	assert.Equal(t, 3, version())
+}
@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"github.com/0x2e/fusion/model"
+
+	"gorm.io/gorm"
+)
+
+func NewBridge(db *gorm.DB) *Bridge {
+	return &Bridge{
+		db: db,
+	}
+}
+
+type Bridge struct {
+	db *gorm.DB
+}
+
+func (b Bridge) All() ([]*model.Bridge, error) {
+	var res []*model.Bridge
+	err := b.db.Order("id").Find(&res).Error
+	return res, err
+}
+
+func (b Bridge) Create(bridge *model.Bridge) error {
+	return b.db.Create(bridge).Error
+}
+
+func (b Bridge) Delete(id uint) error {
+	return b.db.Delete(&model.Bridge{}, id).Error
+}
@@ -0,0 +1,140 @@
+package repo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+
+	gosqlite "github.com/glebarez/go-sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Item content is stored gzipped, as it makes up most of the database and
+// compresses well. Rows stored before it was compressed keep their plain
+// text, so both are read back, told apart by the gzip magic number.
+func init() {
+	schema.RegisterSerializer("compress", compressSerializer{})
+
+	// Lets queries match against the content, e.g.
+	// "decompress(content) LIKE ?". It must be registered before the first
+	// connection is opened.
+	gosqlite.MustRegisterDeterministicScalarFunction("decompress", 1, func(ctx *gosqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		switch v := args[0].(type) {
+		case []byte:
+			return decompress(v)
+		default:
+			return v, nil
+		}
+	})
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compress returns s gzipped, or s itself if compressing doesn't make it
+// smaller, which is the case for short content.
+func compress(s string) (any, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(w, s); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if buf.Len() >= len(s) {
+		return s, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress returns the text stored in b, which is either gzipped or plain.
+func decompress(b []byte) (string, error) {
+	if !bytes.HasPrefix(b, gzipMagic) {
+		return string(b), nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	res, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+// compressSerializer stores *string fields with compress.
+type compressSerializer struct{}
+
+func (compressSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var text *string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		text = &v
+	case []byte:
+		s, err := decompress(v)
+		if err != nil {
+			return fmt.Errorf("decompress %s: %w", field.Name, err)
+		}
+		text = &s
+	default:
+		return fmt.Errorf("unexpected type %T for compressed field %s", dbValue, field.Name)
+	}
+	field.ReflectValueOf(ctx, dst).Set(reflect.ValueOf(text))
+	return nil
+}
+
+func (compressSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue any) (any, error) {
+	text, ok := fieldValue.(*string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T for compressed field %s", fieldValue, field.Name)
+	}
+	if text == nil {
+		return nil, nil
+	}
+	return compress(*text)
+}
+
+// compressItemContentBatch is the number of items compressItemContent loads
+// at once.
+const compressItemContentBatch = 500
+
+// compressItemContent compresses the content of the items stored before it
+// was compressed.
+func compressItemContent(tx *gorm.DB) error {
+	var lastID uint
+	for {
+		var rows []struct {
+			ID      uint
+			Content string
+		}
+		err := tx.Raw("SELECT `id`, `content` FROM `items` WHERE `id` > ? AND typeof(`content`) = 'text' ORDER BY `id` LIMIT ?",
+			lastID, compressItemContentBatch).Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		for _, row := range rows {
+			lastID = row.ID
+			value, err := compress(row.Content)
+			if err != nil {
+				return err
+			}
+			if _, ok := value.(string); ok {
+				continue
+			}
+			if err := tx.Exec("UPDATE `items` SET `content` = ? WHERE `id` = ?", value, row.ID).Error; err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package repo_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+
+	"gorm.io/gorm"
+)
+
+var longContent = "<p>" + strings.Repeat("The quick brown fox jumps over the lazy dog. ", 50) + "</p>"
+
+func createFeed(t *testing.T, db *gorm.DB) uint {
+	t.Helper()
+
+	feed := &model.Feed{Name: ptr.To("Feed"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}
+	require.NoError(t, repo.NewFeed(db).Create([]*model.Feed{feed}))
+	return feed.ID
+}
+
+// storedContent returns the content of an item as stored in the database.
+func storedContent(t *testing.T, db *gorm.DB, id uint) any {
+	t.Helper()
+
+	var content any
+	require.NoError(t, db.Raw("SELECT `content` FROM `items` WHERE `id` = ?", id).Row().Scan(&content))
+	return content
+}
+
+func TestItemContentIsCompressed(t *testing.T) {
+	db := repotest.NewDB(t)
+	items := repo.NewItem(db)
+	feedID := createFeed(t, db)
+	_, err := items.Insert([]*model.Item{
+		{GUID: ptr.To("long"), Title: ptr.To("Long"), Content: ptr.To(longContent), FeedID: feedID},
+		{GUID: ptr.To("short"), Title: ptr.To("Short"), Content: ptr.To("<p>Hi</p>"), FeedID: feedID},
+		{GUID: ptr.To("empty"), Title: ptr.To("Empty"), FeedID: feedID},
+	})
+	require.NoError(t, err)
+
+	list, _, err := items.List(repo.ItemFilter{}, 1, 10)
+	require.NoError(t, err)
+	byGUID := map[string]*model.Item{}
+	for _, v := range list {
+		byGUID[*v.GUID] = v
+	}
+	require.Len(t, byGUID, 3)
+
+	long := byGUID["long"]
+	assert.Equal(t, longContent, ptr.From(long.Content))
+	stored, ok := storedContent(t, db, long.ID).([]byte)
+	require.True(t, ok, "long content must be stored compressed")
+	assert.Less(t, len(stored), len(longContent))
+
+	assert.Equal(t, "<p>Hi</p>", ptr.From(byGUID["short"].Content))
+	assert.Equal(t, "<p>Hi</p>", storedContent(t, db, byGUID["short"].ID), "content that doesn't shrink must be stored as is")
+	assert.Nil(t, byGUID["empty"].Content)
+
+	require.NoError(t, items.Update(long.ID, &model.Item{Content: ptr.To(longContent + "<p>Updated</p>")}))
+	got, err := items.Get(long.ID)
+	require.NoError(t, err)
+	assert.Equal(t, longContent+"<p>Updated</p>", ptr.From(got.Content))
+
+	found, total, err := items.List(repo.ItemFilter{Keyword: ptr.To("lazy dog")}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total, "keyword search must match compressed content")
+	require.Len(t, found, 1)
+	assert.Equal(t, long.ID, found[0].ID)
+}
+
+func TestMigrateCompressesItemContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fusion.db")
+	db, err := openFile(t, path)
+	require.NoError(t, err)
+	feedID := createFeed(t, db)
+	// Store the content the way it was before it was compressed, and forget
+	// the migrations from then on.
+	require.NoError(t, db.Exec("INSERT INTO `items` (`id`, `deleted_at`, `guid`, `content`, `feed_id`) VALUES (1, 0, 'legacy', ?, ?)", longContent, feedID).Error)
+	require.NoError(t, db.Exec("DELETE FROM `schema_version` WHERE `version` >= 5").Error)
+
+	got, err := repo.NewItem(db).Get(1)
+	require.NoError(t, err)
+	assert.Equal(t, longContent, ptr.From(got.Content), "plain content must still be read")
+	closeDB(t, db)
+
+	db, err = openFile(t, path)
+	require.NoError(t, err)
+	_, ok := storedContent(t, db, 1).([]byte)
+	assert.True(t, ok, "existing content must be compressed")
+	got, err = repo.NewItem(db).Get(1)
+	require.NoError(t, err)
+	assert.Equal(t, longContent, ptr.From(got.Content))
+}
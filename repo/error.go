@@ -5,4 +5,7 @@ import "errors"
 var (
 	ErrNotFound      = errors.New("resource not exists")
 	ErrDuplicatedKey = errors.New("exists duplicated key(s)")
+	// ErrBusy means the database stayed locked by other writers after all
+	// retries.
+	ErrBusy = errors.New("database is busy")
 )
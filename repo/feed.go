@@ -43,43 +43,52 @@ func (f Feed) List(filter *FeedListFilter) ([]*model.Feed, error) {
 		return nil, err
 	}
 
-	// count unread items of each feed.
-	// yeah this is stupid, but I don't know how to do it in a single query using GORM.
 	ids := make([]uint, 0, len(res))
 	for _, feed := range res {
 		ids = append(ids, feed.ID)
 	}
-	var itemUnreadCount []struct {
-		FeedID uint  `gorm:"feed_id"`
-		Count  int64 `gorm:"count"`
-	}
-	err = f.db.Model(&model.Item{}).
-		Select("feed_id, count(*) as count").
-		Where("feed_id in ?", ids).
-		Where("unread = true").
-		Group("feed_id").
-		Find(&itemUnreadCount).Error
+	counts, err := unreadCacheFor(f.db).get(ids, func(feedIDs []uint) (map[uint]int, error) {
+		return countUnread(f.db, feedIDs)
+	})
 	if err != nil {
 		return nil, err
 	}
 	for _, feed := range res {
-		for _, count := range itemUnreadCount {
-			if feed.ID == count.FeedID {
-				feed.UnreadCount = int(count.Count)
-				break
-			}
-		}
+		feed.UnreadCount = counts[feed.ID]
 	}
 
 	return res, nil
 }
 
+// DeletedOPMLLinks returns the links of the deleted feeds that were added by
+// the OPML subscription.
+func (f Feed) DeletedOPMLLinks(opmlSubscriptionID uint) ([]string, error) {
+	var links []string
+	err := f.db.Unscoped().Model(&model.Feed{}).
+		Where("opml_subscription_id = ? AND deleted_at != 0", opmlSubscriptionID).
+		Pluck("link", &links).Error
+	return links, err
+}
+
+func (f Feed) Count() (int, error) {
+	var count int64
+	err := f.db.Model(&model.Feed{}).Count(&count).Error
+	return int(count), err
+}
+
 func (f Feed) Get(id uint) (*model.Feed, error) {
 	var res model.Feed
 	err := f.db.Model(&model.Feed{}).Joins("Group").First(&res, id).Error
 	return &res, err
 }
 
+// GetByLink returns the feed subscribed to at link.
+func (f Feed) GetByLink(link string) (*model.Feed, error) {
+	var res model.Feed
+	err := f.db.Model(&model.Feed{}).Joins("Group").Where("feeds.link = ?", link).First(&res).Error
+	return &res, err
+}
+
 func (f Feed) Create(data []*model.Feed) error {
 	return f.db.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "link"}, {Name: "deleted_at"}},
@@ -88,10 +97,36 @@ func (f Feed) Create(data []*model.Feed) error {
 }
 
 func (f Feed) Update(id uint, feed *model.Feed) error {
-	return f.db.Model(&model.Feed{}).Where("id = ?", id).Updates(feed).Error
+	return withRetry(func() error {
+		return f.db.Model(&model.Feed{}).Where("id = ?", id).Updates(feed).Error
+	})
+}
+
+// Ungroup moves the feed out of its group, to Uncategorized.
+func (f Feed) Ungroup(id uint) error {
+	return withRetry(func() error {
+		return f.db.Model(&model.Feed{}).Where("id = ?", id).Update("group_id", nil).Error
+	})
+}
+
+// UpdateSettings replaces the settings the feed overrides, see
+// model.Feed.Settings. Nil fields are left to its group.
+func (f Feed) UpdateSettings(id uint, settings model.Settings) error {
+	return withRetry(func() error {
+		return f.db.Model(&model.Feed{}).Where("id = ?", id).Select(settingsColumns).
+			Updates(&model.Feed{
+				MonitorOnly:        settings.MonitorOnly,
+				RefreshInterval:    settings.RefreshInterval,
+				Sanitize:           settings.Sanitize,
+				FeedRequestOptions: model.FeedRequestOptions{ReqProxy: settings.ReqProxy},
+			}).Error
+	})
 }
 
 func (f Feed) Delete(id uint) error {
+	cache := unreadCacheFor(f.db)
+	cache.beginWrite()
+	defer cache.endWrite(nil, id)
 	return f.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Model(&model.Item{}).Where("feed_id = ?", id).Delete(&model.Item{}).Error; err != nil && !errors.Is(err, ErrNotFound) {
 			return err
@@ -99,3 +134,25 @@ func (f Feed) Delete(id uint) error {
 		return tx.Delete(&model.Feed{}, id).Error
 	})
 }
+
+// Merge moves the items of the feed from to the feed into, except those into
+// has already, by GUID or by link and title, and deletes from.
+func (f Feed) Merge(from, into uint) error {
+	cache := unreadCacheFor(f.db)
+	cache.beginWrite()
+	defer cache.endWrite(nil, from, into)
+	return f.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("UPDATE items SET feed_id = ? WHERE feed_id = ? AND deleted_at = 0 "+
+			"AND (guid IS NULL OR guid NOT IN (SELECT guid FROM items WHERE feed_id = ? AND guid IS NOT NULL)) "+
+			"AND NOT EXISTS (SELECT 1 FROM items d WHERE d.feed_id = ? AND d.deleted_at = 0 "+
+			"AND d.link = items.link AND d.title IS items.title)",
+			into, from, into, into).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Item{}).Where("feed_id = ?", from).Delete(&model.Item{}).Error; err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return tx.Delete(&model.Feed{}, from).Error
+	})
+}
@@ -0,0 +1,47 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+)
+
+func TestFeedMerge(t *testing.T) {
+	db := repotest.NewDB(t)
+	feedRepo := repo.NewFeed(db)
+	feeds := []*model.Feed{
+		{Name: ptr.To("Old"), Link: ptr.To("http://example.com/feed"), GroupID: ptr.To(uint(1))},
+		{Name: ptr.To("New"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))},
+	}
+	require.NoError(t, feedRepo.Create(feeds))
+	from, into := feeds[0].ID, feeds[1].ID
+	itemRepo := repo.NewItem(db)
+
+	_, err := itemRepo.Insert([]*model.Item{
+		{GUID: ptr.To("a"), Link: ptr.To("https://example.com/a"), Title: ptr.To("A"), Unread: ptr.To(true), FeedID: from},
+		{GUID: ptr.To("b"), Link: ptr.To("https://example.com/b"), Title: ptr.To("B"), Unread: ptr.To(true), FeedID: from},
+		{GUID: ptr.To("old-c"), Link: ptr.To("https://example.com/c"), Title: ptr.To("C"), Unread: ptr.To(true), FeedID: from},
+		{GUID: ptr.To("a"), Link: ptr.To("https://example.com/a"), Title: ptr.To("A"), Unread: ptr.To(true), FeedID: into},
+		{GUID: ptr.To("new-c"), Link: ptr.To("https://example.com/c"), Title: ptr.To("C"), Unread: ptr.To(true), FeedID: into},
+	})
+	require.NoError(t, err)
+	counts, err := itemRepo.UnreadCounts([]uint{from, into})
+	require.NoError(t, err)
+	require.Equal(t, map[uint]int{from: 3, into: 2}, counts)
+
+	require.NoError(t, feedRepo.Merge(from, into))
+
+	assert.Equal(t, []string{"a", "b", "new-c"}, storedGUIDs(t, itemRepo, into))
+	_, err = feedRepo.Get(from)
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+	counts, err = itemRepo.UnreadCounts([]uint{from, into})
+	require.NoError(t, err)
+	assert.Equal(t, 3, counts[into])
+	assert.Zero(t, counts[from])
+}
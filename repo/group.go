@@ -34,16 +34,75 @@ func (g Group) Create(group *model.Group) error {
 	return g.db.Create(group).Error
 }
 
+// ListPublic returns the groups that can be browsed without logging in.
+func (g Group) ListPublic() ([]*model.Group, error) {
+	var res []*model.Group
+	err := g.db.Where("public = ?", true).Order("id").Find(&res).Error
+	return res, err
+}
+
+// GetDefault returns the group marked as default for new subscriptions.
+func (g Group) GetDefault() (*model.Group, error) {
+	var res model.Group
+	err := g.db.Where("is_default = ?", true).First(&res).Error
+	return &res, err
+}
+
 func (g Group) Update(id uint, group *model.Group) error {
-	return g.db.Model(&model.Group{}).Where("id = ?", id).Updates(group).Error
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if group.IsDefault != nil && *group.IsDefault {
+			// only one group can be the default
+			if err := tx.Model(&model.Group{}).Where("id != ? AND is_default = ?", id, true).
+				Update("is_default", false).Error; err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+		}
+
+		return tx.Model(&model.Group{}).Where("id = ?", id).Updates(group).Error
+	})
+}
+
+// settingsColumns are the columns of model.Settings, which UpdateSettings
+// replace together.
+var settingsColumns = []string{"req_proxy", "refresh_interval", "sanitize", "monitor_only"}
+
+// UpdateSettings replaces the settings of the group. Nil fields are cleared.
+func (g Group) UpdateSettings(id uint, settings model.Settings) error {
+	return g.db.Model(&model.Group{}).Where("id = ?", id).Select(settingsColumns).
+		Updates(&model.Group{Settings: settings}).Error
 }
 
-func (g Group) Delete(id uint) error {
+// Delete deletes the group and moves its feeds to the group moveTo.
+func (g Group) Delete(id uint, moveTo uint) error {
 	return g.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&model.Feed{}).Where("group_id = ?", id).Update("group_id", 1).Error; err != nil && !errors.Is(err, ErrNotFound) {
+		if err := tx.First(&model.Group{}, moveTo).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.Feed{}).Where("group_id = ?", id).Update("group_id", moveTo).Error; err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err := tx.Model(&model.GroupRule{}).Where("group_id = ?", id).Update("group_id", moveTo).Error; err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err := tx.Model(&model.OPMLSubscription{}).Where("group_id = ?", id).Update("group_id", moveTo).Error; err != nil && !errors.Is(err, ErrNotFound) {
 			return err
 		}
 
 		return tx.Delete(&model.Group{}, id).Error
 	})
 }
+
+func (g Group) ListRules() ([]*model.GroupRule, error) {
+	var res []*model.GroupRule
+	err := g.db.Joins("Group").Order("group_rules.id").Find(&res).Error
+	return res, err
+}
+
+func (g Group) CreateRule(rule *model.GroupRule) error {
+	return g.db.Create(rule).Error
+}
+
+func (g Group) DeleteRule(id uint) error {
+	return g.db.Delete(&model.GroupRule{}, id).Error
+}
@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"github.com/0x2e/fusion/model"
+
+	"gorm.io/gorm"
+)
+
+func NewIntegrity(db *gorm.DB) *Integrity {
+	return &Integrity{
+		db: db,
+	}
+}
+
+// Integrity finds the rows left referencing rows that are gone, which deletes
+// made by older versions, or interrupted, leave behind.
+type Integrity struct {
+	db *gorm.DB
+}
+
+// IntegrityReport counts the rows referencing rows that are gone.
+type IntegrityReport struct {
+	// OrphanedItems belong to a deleted feed.
+	OrphanedItems int
+	// FeedsWithoutGroup, GroupRulesWithoutGroup and
+	// OPMLSubscriptionsWithoutGroup point to a deleted group.
+	FeedsWithoutGroup             int
+	GroupRulesWithoutGroup        int
+	OPMLSubscriptionsWithoutGroup int
+	// FeedsWithoutOPMLSubscription are linked to a deleted OPML subscription.
+	FeedsWithoutOPMLSubscription int
+}
+
+// Total is the number of rows found.
+func (r IntegrityReport) Total() int {
+	return r.OrphanedItems + r.FeedsWithoutGroup + r.GroupRulesWithoutGroup +
+		r.OPMLSubscriptionsWithoutGroup + r.FeedsWithoutOPMLSubscription
+}
+
+const (
+	withoutFeed             = "feed_id NOT IN (SELECT id FROM feeds WHERE deleted_at = 0)"
+	withoutGroup            = "group_id NOT IN (SELECT id FROM groups WHERE deleted_at = 0)"
+	withoutOPMLSubscription = "opml_subscription_id IS NOT NULL AND " +
+		"opml_subscription_id NOT IN (SELECT id FROM opml_subscriptions WHERE deleted_at = 0)"
+)
+
+// Check finds the rows referencing rows that are gone. With repair, it also
+// fixes them in the same transaction: orphaned items are deleted, what points
+// to a deleted group is moved to the default group, and feeds are unlinked
+// from deleted OPML subscriptions.
+func (i Integrity) Check(repair bool) (*IntegrityReport, error) {
+	var report IntegrityReport
+	check := func(tx *gorm.DB) error {
+		var defaultGroup *uint
+		moveToDefaultGroup := func(db *gorm.DB) error {
+			if defaultGroup == nil {
+				var id uint
+				// Like new subscriptions, see Group.GetDefault.
+				if err := tx.Model(&model.Group{}).Select("id").Order("is_default DESC, id").
+					Limit(1).Scan(&id).Error; err != nil {
+					return err
+				}
+				if id == 0 {
+					return ErrNotFound
+				}
+				defaultGroup = &id
+			}
+			return db.Update("group_id", *defaultGroup).Error
+		}
+
+		for _, c := range []struct {
+			count *int
+			model any
+			where string
+			fix   func(db *gorm.DB) error
+		}{
+			{&report.OrphanedItems, &model.Item{}, withoutFeed, func(db *gorm.DB) error {
+				return db.Delete(&model.Item{}).Error
+			}},
+			{&report.FeedsWithoutGroup, &model.Feed{}, withoutGroup, moveToDefaultGroup},
+			{&report.GroupRulesWithoutGroup, &model.GroupRule{}, withoutGroup, moveToDefaultGroup},
+			{&report.OPMLSubscriptionsWithoutGroup, &model.OPMLSubscription{}, withoutGroup, moveToDefaultGroup},
+			{&report.FeedsWithoutOPMLSubscription, &model.Feed{}, withoutOPMLSubscription, func(db *gorm.DB) error {
+				return db.Updates(map[string]any{
+					"opml_subscription_id": nil,
+					"removed_from_opml":    false,
+				}).Error
+			}},
+		} {
+			var n int64
+			if err := tx.Model(c.model).Where(c.where).Count(&n).Error; err != nil {
+				return err
+			}
+			*c.count = int(n)
+			if repair && n > 0 {
+				if err := c.fix(tx.Model(c.model).Where(c.where)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	return &report, i.db.Transaction(check)
+}
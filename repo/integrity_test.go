@@ -0,0 +1,61 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+)
+
+func TestIntegrityCheck(t *testing.T) {
+	db := repotest.NewDB(t)
+	group := &model.Group{Name: ptr.To("Gone")}
+	require.NoError(t, repo.NewGroup(db).Create(group))
+	sub := &model.OPMLSubscription{Link: ptr.To("https://example.com/feeds.opml"), GroupID: 1}
+	require.NoError(t, repo.NewOPMLSubscription(db).Create(sub))
+	feeds := []*model.Feed{
+		{Name: ptr.To("Kept"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))},
+		{Name: ptr.To("Gone"), Link: ptr.To("https://example.org/feed"), GroupID: ptr.To(uint(1))},
+		{Name: ptr.To("Grouped"), Link: ptr.To("https://example.net/feed"), GroupID: &group.ID, OPMLSubscriptionID: &sub.ID},
+	}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	itemRepo := repo.NewItem(db)
+	_, err := itemRepo.Insert([]*model.Item{
+		{GUID: ptr.To("kept"), FeedID: feeds[0].ID},
+		{GUID: ptr.To("orphan-1"), FeedID: feeds[1].ID},
+		{GUID: ptr.To("orphan-2"), FeedID: feeds[1].ID},
+	})
+	require.NoError(t, err)
+	// Deleted without what references them, as older versions could.
+	require.NoError(t, db.Delete(&model.Feed{}, feeds[1].ID).Error)
+	require.NoError(t, db.Delete(&model.Group{}, group.ID).Error)
+	require.NoError(t, db.Delete(&model.OPMLSubscription{}, sub.ID).Error)
+
+	integrity := repo.NewIntegrity(db)
+	expected := &repo.IntegrityReport{
+		OrphanedItems:                2,
+		FeedsWithoutGroup:            1,
+		FeedsWithoutOPMLSubscription: 1,
+	}
+	report, err := integrity.Check(false)
+	require.NoError(t, err)
+	assert.Equal(t, expected, report)
+
+	report, err = integrity.Check(true)
+	require.NoError(t, err)
+	assert.Equal(t, expected, report)
+
+	report, err = integrity.Check(false)
+	require.NoError(t, err)
+	assert.Zero(t, report.Total())
+	feed, err := repo.NewFeed(db).Get(feeds[2].ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, ptr.From(feed.GroupID))
+	assert.Nil(t, feed.OPMLSubscriptionID)
+	assert.Equal(t, []string{"kept"}, storedGUIDs(t, itemRepo, feeds[0].ID))
+}
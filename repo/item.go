@@ -1,9 +1,13 @@
 package repo
 
 import (
+	"errors"
+	"hash/fnv"
+	"slices"
 	"time"
 
 	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -25,15 +29,75 @@ type ItemFilter struct {
 	GroupID  *uint
 	Unread   *bool
 	Bookmark *bool
+	Language *string
+	// MaxWordCount only matches items with fewer words.
+	MaxWordCount *int
+	// Media is one of "video", "audio" or "gallery".
+	Media *string
+	// CreatedAfter only matches items fetched after this time.
+	CreatedAfter *time.Time
+	// PublishedAfter and PublishedBefore only match items published at or
+	// after, and before, these times. Items without a publication date are
+	// matched by when they were fetched.
+	PublishedAfter  *time.Time
+	PublishedBefore *time.Time
+	// Backlog only matches the items left to read: unread or bookmarked ones.
+	Backlog bool
 }
 
 func (i Item) List(filter ItemFilter, page, pageSize int) ([]*model.Item, int, error) {
 	var total int64
 	var res []*model.Item
-	db := i.db.Model(&model.Item{}).Joins("JOIN feeds ON feeds.id = items.feed_id")
+	db := i.filtered(filter)
+	err := db.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order := "items.pub_date desc, items.created_at desc"
+	if filter.FeedID != nil {
+		byPosition, err := i.ordersByPosition(*filter.FeedID)
+		if err != nil {
+			return nil, 0, err
+		}
+		// Each pull stores its items at once, so they share created_at.
+		if byPosition {
+			order = "items.created_at desc"
+		}
+	}
+	// Items of a pull often share their dates too. Without a unique key last,
+	// they'd come in any order, so pages could repeat or skip some of them.
+	// Items are stored in the order of the feed, which ids follow for those
+	// stored before positions were recorded.
+	order += ", items.position, items.id"
+	err = db.Preload("Feed.Group").Order(order).
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&res).Error
+	return res, int(total), err
+}
+
+// ordersByPosition tells whether the items of a feed are listed as the feed
+// lists them, see model.Feed.OrderByPosition.
+func (i Item) ordersByPosition(feedID uint) (bool, error) {
+	var feeds []*model.Feed
+	if err := i.db.Select("order_by_position").Where("id = ?", feedID).Limit(1).Find(&feeds).Error; err != nil {
+		return false, err
+	}
+	return len(feeds) > 0 && feeds[0].OrdersByPosition(), nil
+}
+
+// Sample returns up to n items matching filter, picked at random.
+func (i Item) Sample(filter ItemFilter, n int) ([]*model.Item, error) {
+	var res []*model.Item
+	err := i.filtered(filter).Preload("Feed.Group").Order("RANDOM()").Limit(n).Find(&res).Error
+	return res, err
+}
+
+func (i Item) filtered(filter ItemFilter) *gorm.DB {
+	db := i.db.Model(&model.Item{}).Joins("JOIN feeds ON feeds.id = items.feed_id").
+		Where("items.hidden = ?", false)
 	if filter.Keyword != nil {
 		expr := "%" + *filter.Keyword + "%"
-		db = db.Where("title LIKE ? OR content LIKE ?", expr, expr)
+		db = db.Where("title LIKE ? OR decompress(content) LIKE ?", expr, expr)
 	}
 	if filter.FeedID != nil {
 		db = db.Where("feed_id = ?", *filter.FeedID)
@@ -47,46 +111,474 @@ func (i Item) List(filter ItemFilter, page, pageSize int) ([]*model.Item, int, e
 	if filter.Bookmark != nil {
 		db = db.Where("bookmark = ?", *filter.Bookmark)
 	}
-	err := db.Count(&total).Error
-	if err != nil {
-		return nil, 0, err
+	if filter.Language != nil {
+		db = db.Where("language = ?", *filter.Language)
+	}
+	if filter.MaxWordCount != nil {
+		db = db.Where("word_count < ?", *filter.MaxWordCount)
+	}
+	if filter.CreatedAfter != nil {
+		db = db.Where("items.created_at > ?", *filter.CreatedAfter)
+	}
+	if filter.PublishedAfter != nil {
+		// datetime() compares the times in UTC, whatever their offset.
+		db = db.Where("datetime(COALESCE(items.pub_date, items.created_at)) >= datetime(?)", *filter.PublishedAfter)
+	}
+	if filter.PublishedBefore != nil {
+		db = db.Where("datetime(COALESCE(items.pub_date, items.created_at)) < datetime(?)", *filter.PublishedBefore)
+	}
+	if filter.Backlog {
+		db = db.Where("unread = ? OR bookmark = ?", true, true)
 	}
+	if filter.Media != nil {
+		switch *filter.Media {
+		case "video":
+			db = db.Where("has_video = ?", true)
+		case "audio":
+			db = db.Where("has_audio = ?", true)
+		case "gallery":
+			db = db.Where("has_gallery = ?", true)
+		}
+	}
+	return db
+}
 
-	err = db.Preload("Feed").Order("items.pub_date desc, items.created_at desc").
-		Offset((page - 1) * pageSize).Limit(pageSize).Find(&res).Error
-	return res, int(total), err
+// ItemCounts summarizes the stored items.
+type ItemCounts struct {
+	Total      int
+	Unread     int
+	Bookmarked int
+}
+
+func (i Item) Counts() (*ItemCounts, error) {
+	var counts struct {
+		Total      int64
+		Unread     int64
+		Bookmarked int64
+	}
+	err := i.db.Model(&model.Item{}).
+		Select("count(*) AS total, " +
+			"count(CASE WHEN unread THEN 1 END) AS unread, " +
+			"count(CASE WHEN bookmark THEN 1 END) AS bookmarked").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ItemCounts{
+		Total:      int(counts.Total),
+		Unread:     int(counts.Unread),
+		Bookmarked: int(counts.Bookmarked),
+	}, nil
 }
 
 func (i Item) Get(id uint) (*model.Item, error) {
 	var res model.Item
-	err := i.db.Joins("Feed").First(&res, id).Error
+	err := i.db.Preload("Feed.Group").First(&res, id).Error
 	return &res, err
 }
 
-func (i Item) Insert(items []*model.Item) error {
+// Insert stores the items that aren't stored yet, and returns how many it
+// stored.
+func (i Item) Insert(items []*model.Item) (int, error) {
 	// limit batchSize to fix 'too many SQL variable' error
 	now := time.Now()
 	for _, i := range items {
 		i.CreatedAt = now
 		i.UpdatedAt = now
 	}
-	return i.db.Clauses(clause.OnConflict{
-		DoNothing: true,
-	}).CreateInBatches(items, 5).Error
+	items, err := i.withoutRenamed(items)
+	if err != nil || len(items) == 0 {
+		return 0, err
+	}
+	items, err = i.withoutTombstoned(items)
+	if err != nil || len(items) == 0 {
+		return 0, err
+	}
+	var inserted int64
+	cache := unreadCacheFor(i.db)
+	cache.beginWrite()
+	err = withRetry(func() error {
+		res := i.db.Clauses(clause.OnConflict{
+			DoNothing: true,
+		}).CreateInBatches(items, 5)
+		inserted = res.RowsAffected
+		return res.Error
+	})
+	// Which of the items were new isn't known, so their feeds are counted
+	// again.
+	var stale []uint
+	if inserted > 0 || err != nil {
+		for _, item := range items {
+			if !slices.Contains(stale, item.FeedID) {
+				stale = append(stale, item.FeedID)
+			}
+		}
+	}
+	cache.endWrite(nil, stale...)
+	return int(inserted), err
+}
+
+// withoutTombstoned leaves out the items that were deleted, so that they don't
+// come back with the next pull. Their tombstones are kept alive meanwhile, see
+// ExpireTombstones.
+func (i Item) withoutTombstoned(items []*model.Item) ([]*model.Item, error) {
+	var feedIDs []uint
+	var hashes []int64
+	for _, item := range items {
+		if item.GUID == nil {
+			continue
+		}
+		if !slices.Contains(feedIDs, item.FeedID) {
+			feedIDs = append(feedIDs, item.FeedID)
+		}
+		hashes = append(hashes, guidHash(*item.GUID))
+	}
+	if len(hashes) == 0 {
+		return items, nil
+	}
+
+	var tombstones []*model.ItemTombstone
+	err := i.db.Where("feed_id IN ? AND guid_hash IN ?", feedIDs, hashes).Find(&tombstones).Error
+	if err != nil || len(tombstones) == 0 {
+		return items, err
+	}
+	type key struct {
+		feedID uint
+		hash   int64
+	}
+	gone := make(map[key]bool, len(tombstones))
+	for _, v := range tombstones {
+		gone[key{v.FeedID, v.GUIDHash}] = true
+	}
+
+	res := make([]*model.Item, 0, len(items))
+	seen := make(map[key]bool)
+	for _, item := range items {
+		if item.GUID != nil {
+			k := key{item.FeedID, guidHash(*item.GUID)}
+			if gone[k] {
+				seen[k] = true
+				continue
+			}
+		}
+		res = append(res, item)
+	}
+	now := time.Now()
+	for k := range seen {
+		if err := i.db.Model(&model.ItemTombstone{}).Where("feed_id = ? AND guid_hash = ?", k.feedID, k.hash).
+			Update("last_seen_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// guidHash is the hash of the GUID of an item, which tombstones keep instead
+// of the GUID.
+func guidHash(guid string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(guid))
+	return int64(h.Sum64())
+}
+
+// withoutRenamed leaves out the items that are stored already under another
+// GUID: items of the same feed with the same link and title. It keeps feeds
+// whose GUIDs the parser replaced with their link, as they were missing or
+// opaque, from duplicating the items stored under the old GUIDs. Items with
+// GUIDs of their own are kept, like the daily posts of a page whose link and
+// title never change, or notes.
+func (i Item) withoutRenamed(items []*model.Item) ([]*model.Item, error) {
+	renameable := func(item *model.Item) bool {
+		return ptr.From(item.Link) != "" && ptr.From(item.GUID) == *item.Link
+	}
+	var feedIDs []uint
+	var links []string
+	for _, item := range items {
+		if !renameable(item) {
+			continue
+		}
+		if !slices.Contains(feedIDs, item.FeedID) {
+			feedIDs = append(feedIDs, item.FeedID)
+		}
+		links = append(links, *item.Link)
+	}
+	if len(links) == 0 {
+		return items, nil
+	}
+
+	var stored []struct {
+		FeedID uint
+		Link   string
+		Title  *string
+	}
+	err := i.db.Model(&model.Item{}).Select("feed_id, link, title").
+		Where("feed_id IN ? AND link IN ?", feedIDs, links).
+		Scan(&stored).Error
+	if err != nil {
+		return nil, err
+	}
+	type key struct {
+		feedID      uint
+		link, title string
+	}
+	known := make(map[key]bool, len(stored))
+	for _, v := range stored {
+		known[key{v.FeedID, v.Link, ptr.From(v.Title)}] = true
+	}
+
+	res := make([]*model.Item, 0, len(items))
+	for _, item := range items {
+		if renameable(item) && known[key{item.FeedID, *item.Link, ptr.From(item.Title)}] {
+			continue
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
+
+// Dedupe removes the items of the feed that duplicate an older one, with the
+// same link and title, like those stored before the feed changed how it makes
+// GUIDs. The older item is kept, read if any of its copies was read and
+// bookmarked if any was bookmarked. It returns how many items it removed.
+func (i Item) Dedupe(feedID uint) (int, error) {
+	// The oldest item of each link and title.
+	oldest := func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&model.Item{}).Select("MIN(id)").
+			Where("feed_id = ? AND link IS NOT NULL AND link != ''", feedID).
+			Group("link, title")
+	}
+	const copies = "FROM items d WHERE d.feed_id = items.feed_id AND d.link = items.link " +
+		"AND d.title IS items.title AND d.deleted_at = 0"
+
+	var removed int64
+	err := unreadCacheFor(i.db).invalidateAfter(func() error {
+		return withRetry(func() error {
+			return i.db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec("UPDATE items SET "+
+					"unread = (SELECT MIN(d.unread) "+copies+"), "+
+					"bookmark = (SELECT MAX(d.bookmark) "+copies+") "+
+					"WHERE id IN (?)", oldest(tx)).Error; err != nil {
+					return err
+				}
+				res := tx.Where("feed_id = ? AND link IS NOT NULL AND link != '' AND id NOT IN (?)", feedID, oldest(tx)).
+					Delete(&model.Item{})
+				if errors.Is(res.Error, ErrNotFound) {
+					removed = 0
+					return nil
+				}
+				removed = res.RowsAffected
+				return res.Error
+			})
+		})
+	})
+	return int(removed), err
 }
 
 func (i Item) Update(id uint, item *model.Item) error {
-	return i.db.Model(&model.Item{}).Where("id = ?", id).Updates(item).Error
+	update := func() error {
+		return withRetry(func() error {
+			return i.db.Model(&model.Item{}).Where("id = ?", id).Updates(item).Error
+		})
+	}
+	if item.Unread == nil {
+		return update()
+	}
+	return unreadCacheFor(i.db).invalidateAfter(update)
+}
+
+// Delete deletes the items for good. They leave tombstones behind, so that
+// they aren't stored again with the next pull, see withoutTombstoned.
+func (i Item) Delete(ids ...uint) error {
+	return unreadCacheFor(i.db).invalidateAfter(func() error {
+		return withRetry(func() error {
+			return i.db.Transaction(func(tx *gorm.DB) error {
+				var items []*model.Item
+				if err := tx.Select("feed_id, guid").Where("id IN ? AND guid IS NOT NULL", ids).
+					Find(&items).Error; err != nil {
+					return err
+				}
+				now := time.Now()
+				tombstones := make([]*model.ItemTombstone, 0, len(items))
+				for _, item := range items {
+					tombstones = append(tombstones, &model.ItemTombstone{
+						FeedID:     item.FeedID,
+						GUIDHash:   guidHash(*item.GUID),
+						DeletedAt:  now,
+						LastSeenAt: now,
+					})
+				}
+				if len(tombstones) > 0 {
+					if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+						CreateInBatches(tombstones, 100).Error; err != nil {
+						return err
+					}
+				}
+				return tx.Unscoped().Delete(&model.Item{}, ids).Error
+			})
+		})
+	})
+}
+
+// ExpireTombstones deletes the tombstones of the items that their feed hasn't
+// listed since before, and returns how many it deleted.
+func (i Item) ExpireTombstones(before time.Time) (int, error) {
+	res := i.db.Where("last_seen_at < ?", before).Delete(&model.ItemTombstone{})
+	if errors.Is(res.Error, ErrNotFound) {
+		return 0, nil
+	}
+	return int(res.RowsAffected), res.Error
 }
 
-func (i Item) Delete(id uint) error {
-	return i.db.Delete(&model.Item{}, id).Error
+// UpdateHidden hides the items from all lists, or shows them again, and
+// returns the number of items updated. Hidden items are marked as read.
+func (i Item) UpdateHidden(ids []uint, hidden bool) (int, error) {
+	updates := map[string]any{"hidden": hidden}
+	if hidden {
+		updates["unread"] = false
+	}
+	var updated int64
+	err := unreadCacheFor(i.db).invalidateAfter(func() error {
+		return withRetry(func() error {
+			res := i.db.Model(&model.Item{}).Where("id IN ?", ids).Updates(updates)
+			updated = res.RowsAffected
+			return res.Error
+		})
+	})
+	return int(updated), err
+}
+
+// UpdateUnread sets the unread state of the items and returns the number of
+// items whose state changed.
+func (i Item) UpdateUnread(ids []uint, unread *bool) (int, error) {
+	cache := unreadCacheFor(i.db)
+	if unread == nil {
+		var updated int64
+		err := cache.invalidateAfter(func() error {
+			return withRetry(func() error {
+				res := i.db.Model(&model.Item{}).Where("id IN ?", ids).Update("unread", unread)
+				updated = res.RowsAffected
+				return res.Error
+			})
+		})
+		return int(updated), err
+	}
+
+	// Only the items whose state changes are updated, and their feeds are
+	// returned to adjust the unread counts.
+	var changed []*model.Item
+	cache.beginWrite()
+	err := withRetry(func() error {
+		changed = nil
+		return i.db.Model(&changed).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "feed_id"}}}).
+			Where("id IN ? AND unread IS NOT ?", ids, *unread).
+			Update("unread", *unread).Error
+	})
+	delta := make(map[uint]int)
+	for _, item := range changed {
+		if *unread {
+			delta[item.FeedID]++
+		} else {
+			delta[item.FeedID]--
+		}
+	}
+	cache.endWrite(delta)
+	if errors.Is(err, ErrNotFound) {
+		// The items may all be in that state already.
+		var count int64
+		if err := i.db.Model(&model.Item{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+			return 0, err
+		}
+		if count > 0 {
+			return 0, nil
+		}
+	}
+	return len(changed), err
 }
 
-func (i Item) UpdateUnread(ids []uint, unread *bool) error {
-	return i.db.Model(&model.Item{}).Where("id IN ?", ids).Update("unread", unread).Error
+// FeedIDs returns the distinct feeds of the given items.
+func (i Item) FeedIDs(itemIDs []uint) ([]uint, error) {
+	var res []uint
+	err := i.db.Model(&model.Item{}).Where("id IN ?", itemIDs).
+		Distinct().Pluck("feed_id", &res).Error
+	return res, err
+}
+
+// UnreadCounts returns the number of unread items of each of the given feeds.
+// Feeds without unread items map to 0.
+func (i Item) UnreadCounts(feedIDs []uint) (map[uint]int, error) {
+	return unreadCacheFor(i.db).get(feedIDs, func(feedIDs []uint) (map[uint]int, error) {
+		return countUnread(i.db, feedIDs)
+	})
+}
+
+// countUnread counts the unread items of the given feeds, or of all feeds if
+// feedIDs is nil. Feeds without unread items are left out.
+func countUnread(db *gorm.DB, feedIDs []uint) (map[uint]int, error) {
+	var counts []struct {
+		FeedID uint  `gorm:"feed_id"`
+		Count  int64 `gorm:"count"`
+	}
+	query := db.Model(&model.Item{}).
+		Select("feed_id, count(*) as count").
+		Where("unread = true").
+		Group("feed_id")
+	if feedIDs != nil {
+		query = query.Where("feed_id in ?", feedIDs)
+	}
+	if err := query.Find(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	res := make(map[uint]int, len(counts))
+	for _, v := range counts {
+		res[v.FeedID] = int(v.Count)
+	}
+	return res, nil
+}
+
+// UpdatePlayback records where playing the enclosure of the item was left
+// off, and whether it was listened to. Marking it listened restarts it from
+// the beginning.
+func (i Item) UpdatePlayback(id uint, position *int, listened *bool) error {
+	updates := map[string]any{}
+	if position != nil {
+		updates["playback_position"] = *position
+	}
+	if listened != nil {
+		updates["listened"] = *listened
+		if *listened {
+			updates["playback_position"] = 0
+		}
+	}
+	return withRetry(func() error {
+		return i.db.Model(&model.Item{}).Where("id = ?", id).Updates(updates).Error
+	})
+}
+
+// UpdateBookmark sets the bookmark state of the items and returns the number
+// of items updated.
+func (i Item) UpdateBookmark(ids []uint, bookmark *bool) (int, error) {
+	return i.updateBookmark(bookmark, "id IN ?", ids)
+}
+
+// UpdateBookmarkByFilter sets the bookmark state of the items matching filter
+// and returns the number of items updated, which may be 0.
+func (i Item) UpdateBookmarkByFilter(filter ItemFilter, bookmark *bool) (int, error) {
+	// SQLite can't join in an UPDATE, so the items are picked in a subquery.
+	updated, err := i.updateBookmark(bookmark, "id IN (?)", i.filtered(filter).Select("items.id"))
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
+	return updated, err
 }
 
-func (i Item) UpdateBookmark(id uint, bookmark *bool) error {
-	return i.db.Model(&model.Item{}).Where("id = ?", id).Update("bookmark", bookmark).Error
+func (i Item) updateBookmark(bookmark *bool, query any, args ...any) (int, error) {
+	var updated int64
+	err := withRetry(func() error {
+		res := i.db.Model(&model.Item{}).Where(query, args...).Update("bookmark", bookmark)
+		updated = res.RowsAffected
+		return res.Error
+	})
+	return int(updated), err
 }
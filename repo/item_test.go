@@ -0,0 +1,265 @@
+package repo_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+)
+
+// storedGUIDs lists the GUIDs of the items of the feed, sorted.
+func storedGUIDs(t *testing.T, itemRepo *repo.Item, feedID uint) []string {
+	t.Helper()
+	items, _, err := itemRepo.List(repo.ItemFilter{FeedID: &feedID}, 1, 100)
+	require.NoError(t, err)
+	guids := make([]string, 0, len(items))
+	for _, item := range items {
+		guids = append(guids, *item.GUID)
+	}
+	slices.Sort(guids)
+	return guids
+}
+
+func TestItemInsertSkipsRenamedItems(t *testing.T) {
+	db := repotest.NewDB(t)
+	feeds := []*model.Feed{
+		{Name: ptr.To("First"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))},
+		{Name: ptr.To("Second"), Link: ptr.To("https://example.org/feed"), GroupID: ptr.To(uint(1))},
+	}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	first, second := feeds[0].ID, feeds[1].ID
+	itemRepo := repo.NewItem(db)
+
+	inserted, err := itemRepo.Insert([]*model.Item{
+		{GUID: ptr.To("hash-1"), Link: ptr.To("https://example.com/a"), Title: ptr.To("A"), FeedID: first},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, inserted)
+
+	inserted, err = itemRepo.Insert([]*model.Item{
+		// The same item under a new GUID.
+		{GUID: ptr.To("https://example.com/a"), Link: ptr.To("https://example.com/a"), Title: ptr.To("A"), FeedID: first},
+		// Another episode linking to the same page.
+		{GUID: ptr.To("episode-2"), Link: ptr.To("https://example.com/a"), Title: ptr.To("B"), FeedID: first},
+		// The same link in another feed.
+		{GUID: ptr.To("other"), Link: ptr.To("https://example.com/a"), Title: ptr.To("A"), FeedID: second},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, inserted)
+	assert.Equal(t, []string{"episode-2", "hash-1"}, storedGUIDs(t, itemRepo, first))
+	assert.Equal(t, []string{"other"}, storedGUIDs(t, itemRepo, second))
+}
+
+func TestItemInsertKeepsItemsWithStableLinks(t *testing.T) {
+	db := repotest.NewDB(t)
+	feed := &model.Feed{Name: ptr.To("Changelog"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}
+	require.NoError(t, repo.NewFeed(db).Create([]*model.Feed{feed}))
+	itemRepo := repo.NewItem(db)
+
+	for _, guid := range []string{"changelog-1", "changelog-2"} {
+		inserted, err := itemRepo.Insert([]*model.Item{
+			{GUID: ptr.To(guid), Link: ptr.To("https://example.com/changelog"), Title: ptr.To("Changelog"), FeedID: feed.ID},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, inserted, "items with new GUIDs must be kept, even with a known link and title")
+	}
+	assert.Equal(t, []string{"changelog-1", "changelog-2"}, storedGUIDs(t, itemRepo, feed.ID))
+}
+
+func TestItemDedupe(t *testing.T) {
+	db := repotest.NewDB(t)
+	feeds := []*model.Feed{
+		{Name: ptr.To("First"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))},
+		{Name: ptr.To("Second"), Link: ptr.To("https://example.org/feed"), GroupID: ptr.To(uint(1))},
+	}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	first, second := feeds[0].ID, feeds[1].ID
+	itemRepo := repo.NewItem(db)
+
+	_, err := itemRepo.Insert([]*model.Item{
+		{GUID: ptr.To("old-a"), Link: ptr.To("https://example.com/a"), Title: ptr.To("A"), Unread: ptr.To(true), Bookmark: ptr.To(false), FeedID: first},
+		{GUID: ptr.To("old-b"), Link: ptr.To("https://example.com/b"), Title: ptr.To("B"), Unread: ptr.To(true), Bookmark: ptr.To(true), FeedID: first},
+		{GUID: ptr.To("no-link"), Title: ptr.To("C"), Unread: ptr.To(true), FeedID: first},
+		{GUID: ptr.To("other"), Link: ptr.To("https://example.com/a"), Title: ptr.To("A"), FeedID: second},
+	})
+	require.NoError(t, err)
+	// Copies stored under new GUIDs before Insert skipped them.
+	for _, item := range []*model.Item{
+		{GUID: ptr.To("new-a"), Link: ptr.To("https://example.com/a"), Title: ptr.To("A"), Unread: ptr.To(false), Bookmark: ptr.To(true), FeedID: first},
+		{GUID: ptr.To("new-b"), Link: ptr.To("https://example.com/b"), Title: ptr.To("B"), Unread: ptr.To(true), Bookmark: ptr.To(false), FeedID: first},
+		{GUID: ptr.To("no-link-2"), Title: ptr.To("C"), Unread: ptr.To(true), FeedID: first},
+	} {
+		require.NoError(t, db.Create(item).Error)
+	}
+
+	removed, err := itemRepo.Dedupe(first)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, []string{"no-link", "no-link-2", "old-a", "old-b"}, storedGUIDs(t, itemRepo, first), "items without a link must be kept")
+	assert.Equal(t, []string{"other"}, storedGUIDs(t, itemRepo, second))
+
+	items, _, err := itemRepo.List(repo.ItemFilter{FeedID: &first}, 1, 100)
+	require.NoError(t, err)
+	state := make(map[string][2]bool)
+	for _, item := range items {
+		state[*item.GUID] = [2]bool{*item.Unread, *item.Bookmark}
+	}
+	assert.Equal(t, [2]bool{false, true}, state["old-a"], "read and bookmarked, like its copy")
+	assert.Equal(t, [2]bool{true, true}, state["old-b"])
+
+	counts, err := itemRepo.UnreadCounts([]uint{first})
+	require.NoError(t, err)
+	assert.Equal(t, 3, counts[first])
+
+	removed, err = itemRepo.Dedupe(first)
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
+
+func TestItemDeleteAndHide(t *testing.T) {
+	db := repotest.NewDB(t)
+	feeds := []*model.Feed{{Name: ptr.To("First"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	feedID := feeds[0].ID
+	itemRepo := repo.NewItem(db)
+	pulled := func() []*model.Item {
+		return []*model.Item{
+			{GUID: ptr.To("deleted"), Content: ptr.To("Deleted"), FeedID: feedID},
+			{GUID: ptr.To("hidden"), Content: ptr.To("Hidden"), FeedID: feedID},
+			{GUID: ptr.To("kept"), Content: ptr.To("Kept"), FeedID: feedID},
+		}
+	}
+	_, err := itemRepo.Insert(pulled())
+	require.NoError(t, err)
+	items, _, err := itemRepo.List(repo.ItemFilter{FeedID: &feedID}, 1, 100)
+	require.NoError(t, err)
+	ids := make(map[string]uint, len(items))
+	for _, item := range items {
+		ids[*item.GUID] = item.ID
+	}
+
+	require.NoError(t, itemRepo.Delete(ids["deleted"]))
+	updated, err := itemRepo.UpdateHidden([]uint{ids["hidden"]}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+	assert.Equal(t, []string{"kept"}, storedGUIDs(t, itemRepo, feedID))
+
+	inserted, err := itemRepo.Insert(pulled())
+	require.NoError(t, err)
+	assert.Zero(t, inserted, "deleted items must not be inserted again")
+	assert.Equal(t, []string{"kept"}, storedGUIDs(t, itemRepo, feedID))
+
+	hidden, err := itemRepo.Get(ids["hidden"])
+	require.NoError(t, err, "hidden items must still be found by ID")
+	assert.False(t, *hidden.Unread, "hidden items must be marked as read")
+	_, err = itemRepo.Get(ids["deleted"])
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+
+	_, err = itemRepo.UpdateHidden([]uint{ids["hidden"]}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hidden", "kept"}, storedGUIDs(t, itemRepo, feedID))
+}
+
+func TestItemTombstonesExpire(t *testing.T) {
+	db := repotest.NewDB(t)
+	feeds := []*model.Feed{{Name: ptr.To("First"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	feedID := feeds[0].ID
+	itemRepo := repo.NewItem(db)
+	pulled := func() []*model.Item {
+		return []*model.Item{{GUID: ptr.To("deleted"), FeedID: feedID}}
+	}
+	_, err := itemRepo.Insert(pulled())
+	require.NoError(t, err)
+	items, _, err := itemRepo.List(repo.ItemFilter{FeedID: &feedID}, 1, 100)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.NoError(t, itemRepo.Delete(items[0].ID))
+
+	expired, err := itemRepo.ExpireTombstones(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, expired, "tombstones of items still listed must be kept")
+	inserted, err := itemRepo.Insert(pulled())
+	require.NoError(t, err)
+	assert.Zero(t, inserted)
+
+	expired, err = itemRepo.ExpireTombstones(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	inserted, err = itemRepo.Insert(pulled())
+	require.NoError(t, err)
+	assert.Equal(t, 1, inserted, "items must be stored again once their tombstone expired")
+}
+
+func TestItemListOrderByPosition(t *testing.T) {
+	db := repotest.NewDB(t)
+	feeds := []*model.Feed{
+		{Name: ptr.To("Roundup"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1)), OrderByPosition: ptr.To(true)},
+		{Name: ptr.To("Blog"), Link: ptr.To("https://example.org/feed"), GroupID: ptr.To(uint(1))},
+	}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	itemRepo := repo.NewItem(db)
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, feed := range feeds {
+		_, err := itemRepo.Insert([]*model.Item{
+			{GUID: ptr.To("b"), PubDate: ptr.To(day), Position: ptr.To(0), FeedID: feed.ID},
+			{GUID: ptr.To("a"), PubDate: ptr.To(day.Add(time.Hour)), Position: ptr.To(1), FeedID: feed.ID},
+			{GUID: ptr.To("c"), PubDate: ptr.To(day), Position: ptr.To(2), FeedID: feed.ID},
+		})
+		require.NoError(t, err)
+	}
+	// A later pull comes first, whatever its dates.
+	_, err := itemRepo.Insert([]*model.Item{
+		{GUID: ptr.To("d"), PubDate: ptr.To(day.Add(-time.Hour)), Position: ptr.To(0), FeedID: feeds[0].ID},
+	})
+	require.NoError(t, err)
+
+	listGUIDs := func(feedID uint) []string {
+		items, _, err := itemRepo.List(repo.ItemFilter{FeedID: &feedID}, 1, 100)
+		require.NoError(t, err)
+		guids := make([]string, 0, len(items))
+		for _, item := range items {
+			guids = append(guids, *item.GUID)
+		}
+		return guids
+	}
+	assert.Equal(t, []string{"d", "b", "a", "c"}, listGUIDs(feeds[0].ID))
+	assert.Equal(t, "a", listGUIDs(feeds[1].ID)[0], "other feeds are ordered by date")
+}
+
+func TestItemListIdenticalDates(t *testing.T) {
+	db := repotest.NewDB(t)
+	feeds := []*model.Feed{
+		{Name: ptr.To("Feed"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))},
+	}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	itemRepo := repo.NewItem(db)
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	expected := []string{"e", "c", "a", "d", "b"}
+	items := make([]*model.Item, 0, len(expected))
+	for _, guid := range expected {
+		// No positions, as stored by older versions.
+		items = append(items, &model.Item{GUID: ptr.To(guid), PubDate: ptr.To(day), FeedID: feeds[0].ID})
+	}
+	_, err := itemRepo.Insert(items)
+	require.NoError(t, err)
+
+	for range 3 {
+		var guids []string
+		for page := 1; page <= len(expected); page++ {
+			res, total, err := itemRepo.List(repo.ItemFilter{FeedID: &feeds[0].ID}, page, 1)
+			require.NoError(t, err)
+			require.Equal(t, len(expected), total)
+			require.Len(t, res, 1)
+			guids = append(guids, *res[0].GUID)
+		}
+		assert.Equal(t, expected, guids)
+	}
+}
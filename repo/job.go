@@ -0,0 +1,144 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/0x2e/fusion/model"
+
+	"gorm.io/gorm"
+)
+
+func NewJob(db *gorm.DB) *Job {
+	return &Job{
+		db: db,
+	}
+}
+
+type Job struct {
+	db *gorm.DB
+}
+
+type JobFilter struct {
+	Status *string
+}
+
+func (j Job) List(filter JobFilter, page, pageSize int) ([]*model.Job, int, error) {
+	var total int64
+	var res []*model.Job
+	db := j.db.Model(&model.Job{})
+	if filter.Status != nil {
+		db = db.Where("status = ?", *filter.Status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := db.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&res).Error
+	return res, int(total), err
+}
+
+func (j Job) Get(id uint) (*model.Job, error) {
+	var res model.Job
+	err := j.db.First(&res, id).Error
+	return &res, err
+}
+
+func (j Job) Create(job *model.Job) error {
+	return withRetry(func() error {
+		return j.db.Create(job).Error
+	})
+}
+
+// Claim marks the next job due at now as running and returns it. It returns
+// ErrNotFound if no job is due.
+func (j Job) Claim(now time.Time) (*model.Job, error) {
+	var res model.Job
+	err := withRetry(func() error {
+		// A single statement, so that concurrent workers never claim the
+		// same job.
+		tx := j.db.Raw("UPDATE `jobs` SET `status` = ?, `attempts` = `attempts` + 1, `updated_at` = ? "+
+			"WHERE `id` = (SELECT `id` FROM `jobs` WHERE `status` = ? AND `run_at` <= ? ORDER BY `run_at`, `id` LIMIT 1) "+
+			"RETURNING *", model.JobRunning, now, model.JobPending, now).Scan(&res)
+		if tx.Error == nil && tx.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return tx.Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Finish records that a running job succeeded.
+func (j Job) Finish(id uint, now time.Time) error {
+	return withRetry(func() error {
+		return j.db.Model(&model.Job{}).Where("id = ?", id).Updates(map[string]any{
+			"status":      model.JobSucceeded,
+			"failure":     nil,
+			"finished_at": now,
+		}).Error
+	})
+}
+
+// Fail records that a running job failed. It's retried at retryAt, or never
+// if retryAt is nil.
+func (j Job) Fail(id uint, failure string, now time.Time, retryAt *time.Time) error {
+	values := map[string]any{
+		"status":      model.JobFailed,
+		"failure":     failure,
+		"finished_at": now,
+	}
+	if retryAt != nil {
+		values["status"] = model.JobPending
+		values["run_at"] = *retryAt
+		values["finished_at"] = nil
+	}
+	return withRetry(func() error {
+		return j.db.Model(&model.Job{}).Where("id = ?", id).Updates(values).Error
+	})
+}
+
+// Retry makes a job due again at now with a fresh set of attempts.
+func (j Job) Retry(id uint, now time.Time) error {
+	return withRetry(func() error {
+		return j.db.Model(&model.Job{}).Where("id = ?", id).Updates(map[string]any{
+			"status":      model.JobPending,
+			"run_at":      now,
+			"attempts":    0,
+			"failure":     nil,
+			"finished_at": nil,
+		}).Error
+	})
+}
+
+// ResetRunning makes the jobs left running by a crash or restart pending
+// again, and returns how many there were.
+func (j Job) ResetRunning() (int, error) {
+	var reset int64
+	err := withRetry(func() error {
+		res := j.db.Model(&model.Job{}).Where("status = ?", model.JobRunning).
+			Update("status", model.JobPending)
+		reset = res.RowsAffected
+		if errors.Is(res.Error, ErrNotFound) {
+			return nil
+		}
+		return res.Error
+	})
+	return int(reset), err
+}
+
+func (j Job) Delete(id uint) error {
+	return j.db.Delete(&model.Job{}, id).Error
+}
+
+// DeleteSucceeded deletes the jobs that succeeded before the given time.
+func (j Job) DeleteSucceeded(before time.Time) error {
+	err := j.db.Where("status = ? AND finished_at < ?", model.JobSucceeded, before).
+		Delete(&model.Job{}).Error
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,492 @@
+package repo
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/0x2e/fusion/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Migration is a schema change. Migrations run in version order, each in its
+// own transaction, and are recorded in the schema_version table so that each
+// runs once per database.
+//
+// The models are never auto-migrated: a change to them must come with a new
+// migration appended to migrations.
+type Migration struct {
+	Version     int
+	Description string
+	up          func(tx *gorm.DB) error
+}
+
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create the initial schema",
+		up:          migrateBaseline,
+	},
+	{
+		Version:     2,
+		Description: "add public groups",
+		up: func(tx *gorm.DB) error {
+			return addColumn(tx, "groups", "public", "numeric DEFAULT false")
+		},
+	},
+	{
+		Version:     3,
+		Description: "store generated secrets",
+		up: func(tx *gorm.DB) error {
+			return tx.Exec("CREATE TABLE IF NOT EXISTS `secrets` (" +
+				"`name` text PRIMARY KEY," +
+				"`value` blob NOT NULL," +
+				"`created_at` datetime NOT NULL)").Error
+		},
+	},
+	{
+		Version:     4,
+		Description: "track session versions",
+		up: func(tx *gorm.DB) error {
+			return tx.Exec("CREATE TABLE IF NOT EXISTS `auth_state` (" +
+				"`id` integer PRIMARY KEY," +
+				"`session_version` integer NOT NULL DEFAULT 1," +
+				"`password_fingerprint` blob)").Error
+		},
+	},
+	{
+		Version:     5,
+		Description: "compress item content",
+		up:          compressItemContent,
+	},
+	{
+		Version:     6,
+		Description: "add the job queue",
+		up: func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE IF NOT EXISTS `jobs` (" +
+				"`id` integer PRIMARY KEY AUTOINCREMENT," +
+				"`created_at` datetime," +
+				"`updated_at` datetime," +
+				"`kind` text NOT NULL," +
+				"`payload` text NOT NULL," +
+				"`status` text NOT NULL," +
+				"`run_at` datetime NOT NULL," +
+				"`attempts` integer DEFAULT 0," +
+				"`failure` text," +
+				"`finished_at` datetime)").Error; err != nil {
+				return err
+			}
+			return tx.Exec("CREATE INDEX IF NOT EXISTS `idx_jobs_due` ON `jobs`(`status`,`run_at`)").Error
+		},
+	},
+	{
+		Version:     7,
+		Description: "add video embedding to feeds",
+		up: func(tx *gorm.DB) error {
+			return addColumn(tx, "feeds", "embed_videos", "numeric DEFAULT false")
+		},
+	},
+	{
+		Version:     8,
+		Description: "add enclosures and their playback state to items",
+		up: func(tx *gorm.DB) error {
+			for _, c := range []struct{ column, definition string }{
+				{"enclosure_url", "text"},
+				{"enclosure_type", "text"},
+				{"playback_position", "integer DEFAULT 0"},
+				{"listened", "numeric DEFAULT false"},
+			} {
+				if err := addColumn(tx, "items", c.column, c.definition); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     9,
+		Description: "track when feeds last had new items",
+		up: func(tx *gorm.DB) error {
+			if err := addColumn(tx, "feeds", "last_item_at", "datetime"); err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE `feeds` SET `last_item_at` = " +
+				"(SELECT MAX(`created_at`) FROM `items` WHERE `items`.`feed_id` = `feeds`.`id`)").Error
+		},
+	},
+	{
+		Version:     10,
+		Description: "add item snippets",
+		up: func(tx *gorm.DB) error {
+			return addColumn(tx, "items", "snippet", "text")
+		},
+	},
+	{
+		Version:     11,
+		Description: "add canonical links to feeds",
+		up: func(tx *gorm.DB) error {
+			return addColumn(tx, "feeds", "canonical_link", "text")
+		},
+	},
+	{
+		Version:     12,
+		Description: "add feed descriptions",
+		up: func(tx *gorm.DB) error {
+			return addColumn(tx, "feeds", "description", "text")
+		},
+	},
+	{
+		Version:     13,
+		Description: "add feed notes",
+		up: func(tx *gorm.DB) error {
+			return addColumn(tx, "feeds", "notes", "text")
+		},
+	},
+	{
+		Version:     14,
+		Description: "add labels to groups and feeds",
+		up: func(tx *gorm.DB) error {
+			for _, table := range []string{"groups", "feeds"} {
+				for _, column := range []string{"color", "emoji"} {
+					if err := addColumn(tx, table, column, "text"); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     15,
+		Description: "add hidden items",
+		up: func(tx *gorm.DB) error {
+			return addColumn(tx, "items", "hidden", "numeric DEFAULT false")
+		},
+	},
+	{
+		Version:     16,
+		Description: "keep tombstones of deleted items",
+		up:          addItemTombstones,
+	},
+	{
+		Version:     17,
+		Description: "add group settings that feeds can override",
+		up:          addGroupSettings,
+	},
+	{
+		Version:     18,
+		Description: "add bridges",
+		up: func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE IF NOT EXISTS `bridges` (" +
+				"`id` integer PRIMARY KEY AUTOINCREMENT," +
+				"`created_at` datetime," +
+				"`updated_at` datetime," +
+				"`deleted_at` integer," +
+				"`kind` text NOT NULL," +
+				"`base_url` text NOT NULL)").Error; err != nil {
+				return err
+			}
+			return tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS `idx_base_url` ON `bridges`(`deleted_at`,`base_url`)").Error
+		},
+	},
+	{
+		Version:     19,
+		Description: "track the origin of items",
+		up: func(tx *gorm.DB) error {
+			if err := addColumn(tx, "items", "origin", "text"); err != nil {
+				return err
+			}
+			return addColumn(tx, "items", "source_url", "text")
+		},
+	},
+	{
+		Version:     20,
+		Description: "order items by their position in feeds",
+		up: func(tx *gorm.DB) error {
+			if err := addColumn(tx, "items", "position", "integer"); err != nil {
+				return err
+			}
+			return addColumn(tx, "feeds", "order_by_position", "numeric DEFAULT false")
+		},
+	},
+	{
+		Version:     21,
+		Description: "add lead images to items",
+		up: func(tx *gorm.DB) error {
+			return addColumn(tx, "items", "image_url", "text")
+		},
+	},
+	{
+		Version:     22,
+		Description: "add display modes to feeds",
+		up: func(tx *gorm.DB) error {
+			return addColumn(tx, "feeds", "display_mode", "text")
+		},
+	},
+}
+
+// LatestSchemaVersion is the schema version this build migrates databases to.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+type schemaVersion struct {
+	Version     int `gorm:"primaryKey;autoIncrement:false"`
+	Description string
+	AppliedAt   time.Time
+}
+
+func (schemaVersion) TableName() string {
+	return "schema_version"
+}
+
+func NewSchema(db *gorm.DB) *Schema {
+	return &Schema{
+		db: db,
+	}
+}
+
+type Schema struct {
+	db *gorm.DB
+}
+
+// Version returns the version of the latest migration applied to the
+// database, or 0 if none is.
+func (s Schema) Version() (int, error) {
+	if !s.db.Migrator().HasTable(&schemaVersion{}) {
+		return 0, nil
+	}
+	var version int
+	err := s.db.Model(&schemaVersion{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Pending returns the migrations not applied to the database yet.
+func (s Schema) Pending() ([]Migration, error) {
+	version, err := s.Version()
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]Migration, 0)
+	for _, m := range migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// migrate applies the pending migrations. It refuses to touch a database
+// migrated by a newer build, as this one doesn't know its schema.
+func migrate(db *gorm.DB) error {
+	if err := db.Exec("CREATE TABLE IF NOT EXISTS `schema_version` (" +
+		"`version` integer PRIMARY KEY," +
+		"`description` text NOT NULL," +
+		"`applied_at` datetime NOT NULL)").Error; err != nil {
+		return err
+	}
+
+	schema := NewSchema(db)
+	version, err := schema.Version()
+	if err != nil {
+		return err
+	}
+	if version > LatestSchemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than the latest one supported by this version of fusion, %d: upgrade fusion", version, LatestSchemaVersion())
+	}
+
+	pending, err := schema.Pending()
+	if err != nil {
+		return err
+	}
+	for _, m := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaVersion{
+				Version:     m.Version,
+				Description: m.Description,
+				AppliedAt:   time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migrate database to schema version %d (%s): %w", m.Version, m.Description, err)
+		}
+		slog.Info("migrated database", "schema_version", m.Version, "description", m.Description)
+	}
+	return nil
+}
+
+// baselineSchema is the schema of the models when versioned migrations were
+// introduced.
+var baselineSchema = []string{
+	"CREATE TABLE `groups` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` integer,`name` text NOT NULL,`is_default` numeric DEFAULT false)",
+	"CREATE UNIQUE INDEX `idx_name` ON `groups`(`deleted_at`,`name`)",
+	"CREATE TABLE `feeds` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` integer,`name` text NOT NULL,`link` text NOT NULL,`site_url` text,`last_build` datetime,`last_fetched_at` datetime,`next_fetch_at` datetime,`failure` text DEFAULT \"\",`blocked` numeric DEFAULT false,`consecutive_failures` integer DEFAULT 0,`suspended` numeric DEFAULT false,`monitor_only` numeric DEFAULT false,`weight` integer DEFAULT 0,`last_response` text,`req_proxy` text,`capture_response` numeric DEFAULT false,`group_id` integer,`opml_subscription_id` integer,`removed_from_opml` numeric DEFAULT false,CONSTRAINT `fk_feeds_group` FOREIGN KEY (`group_id`) REFERENCES `groups`(`id`))",
+	"CREATE UNIQUE INDEX `idx_link` ON `feeds`(`deleted_at`,`link`)",
+	"CREATE TABLE `group_rules` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` integer,`pattern` text NOT NULL,`group_id` integer,CONSTRAINT `fk_group_rules_group` FOREIGN KEY (`group_id`) REFERENCES `groups`(`id`))",
+	"CREATE UNIQUE INDEX `idx_pattern` ON `group_rules`(`deleted_at`,`pattern`)",
+	"CREATE TABLE `items` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` integer,`title` text,`guid` text,`link` text,`content` text,`pub_date` datetime,`unread` numeric DEFAULT true,`bookmark` numeric DEFAULT false,`language` text,`word_count` integer,`has_video` numeric DEFAULT false,`has_audio` numeric DEFAULT false,`has_gallery` numeric DEFAULT false,`feed_id` integer,CONSTRAINT `fk_items_feed` FOREIGN KEY (`feed_id`) REFERENCES `feeds`(`id`))",
+	"CREATE INDEX `idx_items_bookmark` ON `items`(`bookmark`)",
+	"CREATE INDEX `idx_items_unread` ON `items`(`unread`)",
+	"CREATE UNIQUE INDEX `idx_guid` ON `items`(`deleted_at`,`guid`,`feed_id`)",
+	"CREATE INDEX `idx_items_language` ON `items`(`language`)",
+	"CREATE TABLE `opml_subscriptions` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` integer,`link` text NOT NULL,`last_sync` datetime,`failure` text DEFAULT \"\",`group_id` integer,CONSTRAINT `fk_opml_subscriptions_group` FOREIGN KEY (`group_id`) REFERENCES `groups`(`id`))",
+	"CREATE UNIQUE INDEX `idx_opml_link` ON `opml_subscriptions`(`deleted_at`,`link`)",
+	"CREATE TABLE `score_keywords` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` integer,`keyword` text NOT NULL,`weight` integer DEFAULT 0)",
+	"CREATE UNIQUE INDEX `idx_keyword` ON `score_keywords`(`deleted_at`,`keyword`)",
+}
+
+// migrateBaseline creates baselineSchema in a new database. Databases created
+// before versioned migrations are brought to it the way they used to be, by
+// auto-migrating the models.
+func migrateBaseline(tx *gorm.DB) error {
+	if tx.Migrator().HasTable(&model.Feed{}) {
+		if err := migrateLegacy(tx); err != nil {
+			return err
+		}
+	} else {
+		for _, stmt := range baselineSchema {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	// Not with model.Group, whose columns may be newer than the baseline.
+	now := time.Now()
+	return tx.Exec("INSERT INTO `groups` (`id`, `created_at`, `updated_at`, `deleted_at`, `name`) "+
+		"SELECT 1, ?, ?, 0, 'Default' WHERE NOT EXISTS (SELECT 1 FROM `groups` WHERE `id` = 1)", now, now).Error
+}
+
+// addColumn adds a column to table. Databases migrated by migrateLegacy may
+// already have it, as it auto-migrates the latest models.
+func addColumn(tx *gorm.DB, table, column, definition string) error {
+	if tx.Migrator().HasColumn(table, column) {
+		return nil
+	}
+	return tx.Exec(fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", table, column, definition)).Error
+}
+
+func migrateLegacy(tx *gorm.DB) error {
+	// The verison after v0.8.7 will add a unique index to Feed.Link.
+	// We must delete any duplicate feeds before AutoMigrate applies the
+	// new unique constraint.
+	if tx.Migrator().HasTable(&model.Item{}) {
+		// query duplicate feeds
+		dupFeeds := make([]model.Feed, 0)
+		err := tx.Model(&model.Feed{}).Where(
+			"link IN (?)",
+			tx.Model(&model.Feed{}).Select("link").Group("link").
+				Having("count(link) > 1"),
+		).Order("link, id").Find(&dupFeeds).Error
+		if err != nil {
+			return err
+		}
+
+		// filter out feeds that will be deleted.
+		// we've queried with order, so the first one is the one we should keep.
+		distinct := map[string]uint{}
+		deleteIDs := make([]uint, 0, len(dupFeeds))
+		for _, f := range dupFeeds {
+			if _, ok := distinct[*f.Link]; !ok {
+				distinct[*f.Link] = f.ID
+				continue
+			}
+			deleteIDs = append(deleteIDs, f.ID)
+			slog.Info("delete duplicate feed", "feed_id", f.ID, "feed_name", *f.Name, "feed_link", *f.Link)
+		}
+
+		if len(deleteIDs) > 0 {
+			// **hard** delete duplicate feeds and their items. The items go
+			// first, as they reference the feeds.
+			err = tx.Where("feed_id IN ?", deleteIDs).Unscoped().Delete(&model.Item{}).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Where("id IN ?", deleteIDs).Unscoped().Delete(&model.Feed{}).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Feeds fetched before LastFetchedAt existed get their last update time,
+	// so they aren't all fetched again at once.
+	backfillLastFetchedAt := !tx.Migrator().HasColumn(&model.Feed{}, "LastFetchedAt")
+
+	// FIX: gorm not auto drop index and change 'not null'
+	if err := tx.AutoMigrate(&model.Feed{}, &model.Group{}, &model.GroupRule{}, &model.Item{}, &model.OPMLSubscription{}, &model.ScoreKeyword{}); err != nil {
+		return err
+	}
+
+	if backfillLastFetchedAt {
+		if err := tx.Exec("UPDATE feeds SET last_fetched_at = updated_at WHERE failure = '' OR failure IS NULL").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addItemTombstones creates the item_tombstones table and fills it with the
+// items deleted so far, which were kept as tombstones themselves.
+func addItemTombstones(tx *gorm.DB) error {
+	if err := tx.Exec("CREATE TABLE IF NOT EXISTS `item_tombstones` (" +
+		"`feed_id` integer," +
+		"`guid_hash` integer," +
+		"`deleted_at` datetime NOT NULL," +
+		"`last_seen_at` datetime NOT NULL," +
+		"PRIMARY KEY (`feed_id`,`guid_hash`))").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("CREATE INDEX IF NOT EXISTS `idx_item_tombstones_last_seen_at` " +
+		"ON `item_tombstones`(`last_seen_at`)").Error; err != nil {
+		return err
+	}
+
+	var deleted []struct {
+		FeedID    uint
+		GUID      string
+		DeletedAt int64
+	}
+	err := tx.Table("items").Select("feed_id, guid, deleted_at").
+		Where("deleted_at != 0 AND guid IS NOT NULL AND feed_id IN (SELECT id FROM feeds WHERE deleted_at = 0)").
+		Scan(&deleted).Error
+	if err != nil || len(deleted) == 0 {
+		return err
+	}
+	now := time.Now()
+	rows := make([]map[string]any, 0, len(deleted))
+	for _, v := range deleted {
+		rows = append(rows, map[string]any{
+			"feed_id":      v.FeedID,
+			"guid_hash":    guidHash(v.GUID),
+			"deleted_at":   time.Unix(v.DeletedAt, 0),
+			"last_seen_at": now,
+		})
+	}
+	return tx.Table("item_tombstones").Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(rows, 100).Error
+}
+
+// addGroupSettings adds model.Settings to groups, and the overrides feeds
+// didn't have yet. Feeds that weren't monitor-only now leave it to their
+// group.
+func addGroupSettings(tx *gorm.DB) error {
+	for _, c := range []struct{ table, column, definition string }{
+		{"groups", "req_proxy", "text"},
+		{"groups", "refresh_interval", "integer"},
+		{"groups", "sanitize", "text"},
+		{"groups", "monitor_only", "numeric"},
+		{"feeds", "refresh_interval", "integer"},
+		{"feeds", "sanitize", "text"},
+	} {
+		if err := addColumn(tx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return tx.Exec("UPDATE `feeds` SET `monitor_only` = NULL WHERE `monitor_only` = false").Error
+}
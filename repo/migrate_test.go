@@ -0,0 +1,138 @@
+package repo_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func openFile(t *testing.T, path string) (*gorm.DB, error) {
+	t.Helper()
+
+	db, err := repo.Open(path, repo.Pragmas{ForeignKeys: true})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB.Close()
+	})
+	return db, nil
+}
+
+func closeDB(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+}
+
+func TestMigrationsMatchModels(t *testing.T) {
+	db := repotest.NewDB(t)
+
+	for _, m := range []any{
+		&model.Bridge{},
+		&model.Feed{},
+		&model.Group{},
+		&model.GroupRule{},
+		&model.Item{},
+		&model.ItemTombstone{},
+		&model.Job{},
+		&model.OPMLSubscription{},
+		&model.ScoreKeyword{},
+	} {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(m))
+		table := stmt.Schema.Table
+		require.True(t, db.Migrator().HasTable(m), "table %s must exist", table)
+		for _, column := range stmt.Schema.DBNames {
+			assert.True(t, db.Migrator().HasColumn(m, column), "column %s.%s must exist, add a migration for it", table, column)
+		}
+		for _, idx := range stmt.Schema.ParseIndexes() {
+			assert.True(t, db.Migrator().HasIndex(m, idx.Name), "index %s on %s must exist, add a migration for it", idx.Name, table)
+		}
+	}
+
+	version, err := repo.NewSchema(db).Version()
+	require.NoError(t, err)
+	assert.Equal(t, repo.LatestSchemaVersion(), version)
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fusion.db")
+	legacy, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		// The schema before LastFetchedAt and the unique index on links.
+		"CREATE TABLE `groups` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` integer,`name` text NOT NULL)",
+		"CREATE TABLE `feeds` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` integer,`name` text NOT NULL,`link` text NOT NULL,`failure` text DEFAULT \"\",`group_id` integer)",
+		"CREATE TABLE `items` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` integer,`title` text,`guid` text,`feed_id` integer,CONSTRAINT `fk_items_feed` FOREIGN KEY (`feed_id`) REFERENCES `feeds`(`id`))",
+		"INSERT INTO `groups` (`id`, `deleted_at`, `name`) VALUES (1, 0, 'Default')",
+		"INSERT INTO `feeds` (`id`, `updated_at`, `deleted_at`, `name`, `link`, `failure`, `group_id`) VALUES " +
+			"(1, '2025-01-01 12:00:00', 0, 'Kept', 'https://example.com/feed', '', 1), " +
+			"(2, '2025-01-01 12:00:00', 0, 'Duplicate', 'https://example.com/feed', '', 1), " +
+			"(3, '2025-01-01 12:00:00', 0, 'Failing', 'https://example.org/feed', 'got status code 500', 1)",
+		"INSERT INTO `items` (`id`, `created_at`, `deleted_at`, `guid`, `feed_id`) VALUES " +
+			"(1, '2025-01-01 12:00:00', 0, 'first', 1), (2, '2025-01-01 12:00:00', 0, 'first', 2)",
+	} {
+		require.NoError(t, legacy.Exec(stmt).Error)
+	}
+	closeDB(t, legacy)
+
+	db, err := openFile(t, path)
+	require.NoError(t, err)
+
+	feeds, err := repo.NewFeed(db).List(nil)
+	require.NoError(t, err)
+	require.Len(t, feeds, 2, "duplicate feeds must be deleted")
+	assert.Equal(t, "Kept", *feeds[0].Name)
+	assert.NotNil(t, feeds[0].LastFetchedAt, "last fetch time must be backfilled")
+	assert.Nil(t, feeds[1].LastFetchedAt, "failing feeds must not be backfilled")
+	assert.NotNil(t, feeds[0].LastItemAt, "last item time must be backfilled")
+	assert.Nil(t, feeds[1].LastItemAt, "feeds without items must not have a last item time")
+	_, err = repo.NewItem(db).Get(2)
+	assert.ErrorIs(t, err, repo.ErrNotFound, "items of duplicate feeds must be deleted")
+
+	pending, err := repo.NewSchema(db).Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fusion.db")
+	db, err := openFile(t, path)
+	require.NoError(t, err)
+	require.NoError(t, repo.NewScoreKeyword(db).Create(&model.ScoreKeyword{Keyword: ptr.To("go"), Weight: 1}))
+	closeDB(t, db)
+
+	db, err = openFile(t, path)
+	require.NoError(t, err)
+	keywords, err := repo.NewScoreKeyword(db).All()
+	require.NoError(t, err)
+	assert.Len(t, keywords, 1, "reopening a database must keep its data")
+}
+
+func TestOpenRejectsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fusion.db")
+	db, err := openFile(t, path)
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("INSERT INTO schema_version (version, description, applied_at) VALUES (?, 'from the future', CURRENT_TIMESTAMP)",
+		repo.LatestSchemaVersion()+1).Error)
+	closeDB(t, db)
+
+	_, err = openFile(t, path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "upgrade fusion")
+}
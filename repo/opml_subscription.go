@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/0x2e/fusion/model"
+
+	"gorm.io/gorm"
+)
+
+func NewOPMLSubscription(db *gorm.DB) *OPMLSubscription {
+	return &OPMLSubscription{
+		db: db,
+	}
+}
+
+type OPMLSubscription struct {
+	db *gorm.DB
+}
+
+func (o OPMLSubscription) List() ([]*model.OPMLSubscription, error) {
+	var res []*model.OPMLSubscription
+	err := o.db.Joins("Group").Order("opml_subscriptions.id").Find(&res).Error
+	return res, err
+}
+
+func (o OPMLSubscription) Get(id uint) (*model.OPMLSubscription, error) {
+	var res model.OPMLSubscription
+	err := o.db.Joins("Group").First(&res, id).Error
+	return &res, err
+}
+
+func (o OPMLSubscription) Create(sub *model.OPMLSubscription) error {
+	return o.db.Create(sub).Error
+}
+
+func (o OPMLSubscription) Update(id uint, sub *model.OPMLSubscription) error {
+	return o.db.Model(&model.OPMLSubscription{}).Where("id = ?", id).Updates(sub).Error
+}
+
+// Delete deletes the subscription. Feeds added by it are kept, but no longer
+// linked to it.
+func (o OPMLSubscription) Delete(id uint) error {
+	return o.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Feed{}).Where("opml_subscription_id = ?", id).Updates(map[string]any{
+			"opml_subscription_id": nil,
+			"removed_from_opml":    false,
+		}).Error; err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return tx.Delete(&model.OPMLSubscription{}, id).Error
+	})
+}
@@ -2,9 +2,11 @@ package repo
 
 import (
 	"errors"
-	"log"
-
-	"github.com/0x2e/fusion/model"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
@@ -12,98 +14,87 @@ import (
 
 var DB *gorm.DB
 
-func Init(dbPath string) {
-	conn, err := gorm.Open(
-		sqlite.Open(dbPath),
-		&gorm.Config{TranslateError: true},
-	)
+// Pragmas tune the SQLite connection.
+type Pragmas struct {
+	JournalMode string
+	// BusyTimeout is how long SQLite waits for a lock before giving up with
+	// "database is locked".
+	BusyTimeout time.Duration
+	Synchronous string
+	ForeignKeys bool
+}
+
+func Init(dbPath string, pragmas Pragmas) {
+	conn, err := Open(dbPath, pragmas)
 	if err != nil {
 		panic(err)
 	}
 	DB = conn
-
-	migrage()
-	registerCallback()
 }
 
-func migrage() {
-	// The verison after v0.8.7 will add a unique index to Feed.Link.
-	// We must delete any duplicate feeds before AutoMigrate applies the
-	// new unique constraint.
-	err := DB.Transaction(func(tx *gorm.DB) error {
-		// skip when it's the first launch
-		if !tx.Migrator().HasTable(&model.Feed{}) || !tx.Migrator().HasTable(&model.Item{}) {
-			return nil
-		}
-
-		// query duplicate feeds
-		dupFeeds := make([]model.Feed, 0)
-		err := tx.Model(&model.Feed{}).Where(
-			"link IN (?)",
-			tx.Model(&model.Feed{}).Select("link").Group("link").
-				Having("count(link) > 1"),
-		).Order("link, id").Find(&dupFeeds).Error
-		if err != nil {
-			return err
-		}
+// Open opens the database at dsn and migrates it to the latest schema.
+func Open(dsn string, pragmas Pragmas) (*gorm.DB, error) {
+	db, err := gorm.Open(
+		sqlite.Open(withPragmas(dsn, pragmas)),
+		&gorm.Config{TranslateError: true},
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		// filter out feeds that will be deleted.
-		// we've queried with order, so the first one is the one we should keep.
-		distinct := map[string]uint{}
-		deleteIDs := make([]uint, 0, len(dupFeeds))
-		for _, f := range dupFeeds {
-			if _, ok := distinct[*f.Link]; !ok {
-				distinct[*f.Link] = f.ID
-				continue
-			}
-			deleteIDs = append(deleteIDs, f.ID)
-			log.Println("delete duplicate feed: ", f.ID, *f.Name, *f.Link)
-		}
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+	if err := registerCallback(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
 
-		if len(deleteIDs) > 0 {
-			// **hard** delete duplicate feeds and their items
-			err = tx.Where("id IN ?", deleteIDs).Unscoped().Delete(&model.Feed{}).Error
-			if err != nil {
-				return err
-			}
-			return tx.Where("feed_id IN ?", deleteIDs).Unscoped().Delete(&model.Item{}).Error
+// withPragmas appends pragmas to the DSN, so that they're applied to every
+// connection in the pool. Pragmas that are already in the DSN are kept.
+func withPragmas(dsn string, pragmas Pragmas) string {
+	query := url.Values{}
+	add := func(name, value string) {
+		if value == "" || strings.Contains(dsn, name) {
+			return
 		}
-		return nil
-	})
-	if err != nil {
-		panic(err)
+		query.Add("_pragma", fmt.Sprintf("%s(%s)", name, value))
 	}
-
-	// FIX: gorm not auto drop index and change 'not null'
-	if err := DB.AutoMigrate(&model.Feed{}, &model.Group{}, &model.Item{}); err != nil {
-		panic(err)
+	// busy_timeout goes first, as the other pragmas may need a lock.
+	add("busy_timeout", strconv.FormatInt(pragmas.BusyTimeout.Milliseconds(), 10))
+	add("journal_mode", pragmas.JournalMode)
+	add("synchronous", pragmas.Synchronous)
+	add("foreign_keys", strconv.FormatBool(pragmas.ForeignKeys))
+	if len(query) == 0 {
+		return dsn
 	}
 
-	defaultGroup := "Default"
-	if err := DB.Model(&model.Group{}).Where("id = ?", 1).
-		FirstOrCreate(&model.Group{ID: 1, Name: &defaultGroup}).Error; err != nil {
-		panic(err)
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
 	}
+	return dsn + sep + query.Encode()
 }
 
-func registerCallback() {
-	if err := DB.Callback().Query().After("*").Register("convert_error", func(db *gorm.DB) {
+func registerCallback(db *gorm.DB) error {
+	if err := db.Callback().Query().After("*").Register("convert_error", func(db *gorm.DB) {
 		if errors.Is(db.Error, gorm.ErrRecordNotFound) {
 			db.Error = ErrNotFound
 		}
 	}); err != nil {
-		panic(err)
+		return err
 	}
 
-	if err := DB.Callback().Create().After("*").Register("convert_error", func(db *gorm.DB) {
+	if err := db.Callback().Create().After("*").Register("convert_error", func(db *gorm.DB) {
 		if errors.Is(db.Error, gorm.ErrDuplicatedKey) {
 			db.Error = ErrDuplicatedKey
 		}
 	}); err != nil {
-		panic(err)
+		return err
 	}
 
-	if err := DB.Callback().Update().After("*").Register("convert_error", func(db *gorm.DB) {
+	if err := db.Callback().Update().After("*").Register("convert_error", func(db *gorm.DB) {
 		if db.Error == nil && db.RowsAffected == 0 {
 			db.Error = ErrNotFound
 		}
@@ -111,10 +102,10 @@ func registerCallback() {
 			db.Error = ErrDuplicatedKey
 		}
 	}); err != nil {
-		panic(err)
+		return err
 	}
 
-	if err := DB.Callback().Delete().After("*").Register("convert_error", func(db *gorm.DB) {
+	if err := db.Callback().Delete().After("*").Register("convert_error", func(db *gorm.DB) {
 		if db.Error == nil && db.RowsAffected == 0 {
 			db.Error = ErrNotFound
 		}
@@ -122,6 +113,7 @@ func registerCallback() {
 			db.Error = ErrDuplicatedKey
 		}
 	}); err != nil {
-		panic(err)
+		return err
 	}
+	return nil
 }
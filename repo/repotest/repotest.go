@@ -0,0 +1,38 @@
+// Package repotest provides real, migrated databases for tests, so that tests
+// exercise the actual queries and constraints instead of mocks.
+package repotest
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0x2e/fusion/repo"
+
+	"gorm.io/gorm"
+)
+
+var dbCount atomic.Uint64
+
+// NewDB returns an empty, migrated in-memory database that is closed when the
+// test ends.
+func NewDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	// Every connection to a plain ":memory:" DSN gets its own database, so
+	// name the database and share it between the connections of the pool.
+	dsn := fmt.Sprintf("file:repotest%d?mode=memory&cache=shared", dbCount.Add(1))
+	db, err := repo.Open(dsn, repo.Pragmas{ForeignKeys: true})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get test database connection: %v", err)
+	}
+	t.Cleanup(func() {
+		sqlDB.Close()
+	})
+	return db
+}
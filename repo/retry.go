@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	writeAttempts   = 5
+	writeRetryDelay = 50 * time.Millisecond
+)
+
+// isBusy reports whether err means that SQLite couldn't get a lock because
+// another connection is writing.
+func isBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked") ||
+		strings.Contains(msg, "SQLITE_BUSY")
+}
+
+// withRetry runs write and retries it with exponential backoff while the
+// database is locked. Concurrent pulls and UI actions share a single SQLite
+// file, so short lock contention is expected and shouldn't fail a write.
+func withRetry(write func() error) error {
+	delay := writeRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if !isBusy(err) {
+			return err
+		}
+		if attempt == writeAttempts {
+			return fmt.Errorf("%w: %w", ErrBusy, err)
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"github.com/0x2e/fusion/model"
+
+	"gorm.io/gorm"
+)
+
+func NewScoreKeyword(db *gorm.DB) *ScoreKeyword {
+	return &ScoreKeyword{
+		db: db,
+	}
+}
+
+type ScoreKeyword struct {
+	db *gorm.DB
+}
+
+func (s ScoreKeyword) All() ([]*model.ScoreKeyword, error) {
+	var res []*model.ScoreKeyword
+	err := s.db.Order("id").Find(&res).Error
+	return res, err
+}
+
+func (s ScoreKeyword) Create(keyword *model.ScoreKeyword) error {
+	return s.db.Create(keyword).Error
+}
+
+func (s ScoreKeyword) Delete(id uint) error {
+	return s.db.Delete(&model.ScoreKeyword{}, id).Error
+}
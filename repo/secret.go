@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"crypto/rand"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func NewSecret(db *gorm.DB) *Secret {
+	return &Secret{
+		db: db,
+	}
+}
+
+// Secret stores the secrets fusion generates for itself, so that they
+// survive restarts.
+type Secret struct {
+	db *gorm.DB
+}
+
+type secret struct {
+	Name      string `gorm:"primaryKey"`
+	Value     []byte
+	CreatedAt time.Time
+}
+
+// GetOrCreate returns the secret called name, generating size random bytes
+// for it if it doesn't exist yet.
+func (s Secret) GetOrCreate(name string, size int) ([]byte, error) {
+	value := make([]byte, size)
+	if _, err := rand.Read(value); err != nil {
+		return nil, err
+	}
+	// Keep whichever secret is stored first, if two instances race.
+	if err := s.db.Exec("INSERT OR IGNORE INTO `secrets` (`name`, `value`, `created_at`) VALUES (?, ?, ?)",
+		name, value, time.Now()).Error; err != nil {
+		return nil, err
+	}
+
+	var res secret
+	if err := s.db.Table("secrets").Where("name = ?", name).First(&res).Error; err != nil {
+		return nil, err
+	}
+	return res.Value, nil
+}
@@ -0,0 +1,27 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+)
+
+func TestSecretGetOrCreate(t *testing.T) {
+	secrets := repo.NewSecret(repotest.NewDB(t))
+
+	first, err := secrets.GetOrCreate("session", 32)
+	require.NoError(t, err)
+	assert.Len(t, first, 32)
+
+	again, err := secrets.GetOrCreate("session", 32)
+	require.NoError(t, err)
+	assert.Equal(t, first, again, "the stored secret must be kept")
+
+	other, err := secrets.GetOrCreate("other", 32)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, other)
+}
@@ -0,0 +1,137 @@
+package repo
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// unreadCaches holds the unreadCache of each database, keyed by its
+// *gorm.Config, which all the sessions of a database share.
+var unreadCaches sync.Map
+
+// unreadCache keeps the number of unread items of each feed, so that listing
+// the feeds doesn't count the items on every page view. It's loaded on first
+// use, then kept up to date by the writes of Item and Feed: they adjust the
+// counts they know the change of, and mark the feeds they don't as stale, to
+// be counted again on next use.
+type unreadCache struct {
+	mu sync.Mutex
+	// counts is nil until loaded. Feeds missing from it have no unread
+	// items.
+	counts map[uint]int
+	stale  map[uint]bool
+	// writing is the number of writes in progress, and version changes with
+	// each write. Counts read from the database while either changes may
+	// already include a change that's also applied to the cache, so they're
+	// not kept.
+	writing int
+	version uint64
+}
+
+func unreadCacheFor(db *gorm.DB) *unreadCache {
+	c, _ := unreadCaches.LoadOrStore(db.Config, &unreadCache{})
+	return c.(*unreadCache)
+}
+
+// get returns the unread counts of feedIDs. count reads from the database
+// the counts of the given feeds, or of all feeds if feedIDs is nil, leaving
+// out those without unread items.
+func (c *unreadCache) get(feedIDs []uint, count func(feedIDs []uint) (map[uint]int, error)) (map[uint]int, error) {
+	c.mu.Lock()
+	full := c.counts == nil
+	missing := make(map[uint]bool)
+	if !full {
+		for _, id := range feedIDs {
+			if c.stale[id] {
+				missing[id] = true
+			}
+		}
+	}
+	version := c.version
+	clean := c.writing == 0
+	c.mu.Unlock()
+
+	var fresh map[uint]int
+	var err error
+	switch {
+	case full:
+		fresh, err = count(nil)
+	case len(missing) > 0:
+		fresh, err = count(slices.Collect(maps.Keys(missing)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if !full && c.counts == nil {
+		c.mu.Unlock()
+		// Invalidated meanwhile, so the counts that weren't read are gone.
+		return c.get(feedIDs, count)
+	}
+	defer c.mu.Unlock()
+	if clean && c.writing == 0 && c.version == version {
+		if full {
+			c.counts = fresh
+			c.stale = make(map[uint]bool)
+		} else {
+			for id := range missing {
+				c.counts[id] = fresh[id]
+				delete(c.stale, id)
+			}
+		}
+	}
+
+	res := make(map[uint]int, len(feedIDs))
+	for _, id := range feedIDs {
+		if full || missing[id] {
+			res[id] = fresh[id]
+		} else {
+			res[id] = c.counts[id]
+		}
+	}
+	return res, nil
+}
+
+// beginWrite must be called before writing to the database anything that
+// changes unread counts, and endWrite once the write is done.
+func (c *unreadCache) beginWrite() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writing++
+	c.version++
+}
+
+// endWrite applies the changes of a write: delta is added to the counts of
+// its feeds, and the counts of stale feeds are read again on next use.
+func (c *unreadCache) endWrite(delta map[uint]int, stale ...uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writing--
+	c.version++
+	if c.counts == nil {
+		return
+	}
+	for id, d := range delta {
+		c.counts[id] += d
+	}
+	for _, id := range stale {
+		c.stale[id] = true
+	}
+}
+
+// invalidateAfter runs write, whose changes to the unread counts are
+// unknown, and drops all the counts.
+func (c *unreadCache) invalidateAfter(write func() error) error {
+	c.beginWrite()
+	err := write()
+	c.mu.Lock()
+	c.counts = nil
+	c.stale = nil
+	c.mu.Unlock()
+	c.endWrite(nil)
+	return err
+}
@@ -0,0 +1,79 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+)
+
+func TestUnreadCountsFollowWrites(t *testing.T) {
+	db := repotest.NewDB(t)
+	feedRepo := repo.NewFeed(db)
+	itemRepo := repo.NewItem(db)
+	feeds := []*model.Feed{
+		{Name: ptr.To("First"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))},
+		{Name: ptr.To("Second"), Link: ptr.To("https://example.org/feed"), GroupID: ptr.To(uint(1))},
+	}
+	require.NoError(t, feedRepo.Create(feeds))
+	first, second := feeds[0].ID, feeds[1].ID
+
+	listCounts := func() map[uint]int {
+		t.Helper()
+		list, err := feedRepo.List(nil)
+		require.NoError(t, err)
+		res := make(map[uint]int, len(list))
+		for _, f := range list {
+			res[f.ID] = f.UnreadCount
+		}
+		return res
+	}
+
+	items := []*model.Item{
+		{GUID: ptr.To("a"), FeedID: first, Unread: ptr.To(true)},
+		{GUID: ptr.To("b"), FeedID: first, Unread: ptr.To(true)},
+		{GUID: ptr.To("c"), FeedID: second, Unread: ptr.To(false)},
+	}
+	_, err := itemRepo.Insert(items)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]int{first: 2, second: 0}, listCounts())
+
+	_, err = itemRepo.Insert([]*model.Item{
+		{GUID: ptr.To("a"), FeedID: first, Unread: ptr.To(true)},
+		{GUID: ptr.To("d"), FeedID: second, Unread: ptr.To(true)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]int{first: 2, second: 1}, listCounts(), "only new items must be counted")
+
+	updated, err := itemRepo.UpdateUnread([]uint{items[0].ID, items[2].ID}, ptr.To(false))
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated, "only the items whose state changes must be counted")
+	assert.Equal(t, map[uint]int{first: 1, second: 1}, listCounts())
+
+	updated, err = itemRepo.UpdateUnread([]uint{items[0].ID}, ptr.To(false))
+	require.NoError(t, err, "marking read items as read must succeed")
+	assert.Zero(t, updated)
+	assert.Equal(t, map[uint]int{first: 1, second: 1}, listCounts())
+	_, err = itemRepo.UpdateUnread([]uint{999}, ptr.To(false))
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+
+	updated, err = itemRepo.UpdateUnread([]uint{items[0].ID, items[2].ID}, ptr.To(true))
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated)
+	counts, err := itemRepo.UnreadCounts([]uint{first, second})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]int{first: 2, second: 2}, counts)
+
+	require.NoError(t, itemRepo.Delete(items[1].ID))
+	assert.Equal(t, map[uint]int{first: 1, second: 2}, listCounts())
+
+	require.NoError(t, feedRepo.Delete(second))
+	counts, err = itemRepo.UnreadCounts([]uint{first, second})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]int{first: 1, second: 0}, counts)
+}
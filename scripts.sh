@@ -11,22 +11,26 @@ test_go() {
   go test ./...
 }
 
+fusion_version() {
+  if [ -n "$FUSION_VERSION" ]; then
+    echo "$FUSION_VERSION"
+  # Try to get version relative to the last git tag.
+  elif git describe --tags --abbrev=0 >/dev/null 2>&1; then
+    git describe --tags --abbrev=0
+  # If repo has no tags, just use the latest commit hash.
+  elif git rev-parse --short HEAD >/dev/null 2>&1; then
+    git rev-parse --short HEAD
+  else
+    echo "unknown-version"
+  fi
+}
+
 build_frontend() {
   echo "building frontend"
   mkdir -p ./build
   root=$(pwd)
 
-  if [ -n "$FUSION_VERSION" ]; then
-    version="$FUSION_VERSION"
-  else
-    # Try to get version relative to the last git tag.
-    if git describe --tags --abbrev=0 >/dev/null 2>&1; then
-      version=$(git describe --tags --abbrev=0)
-    else
-      # If repo has no tags, just use the latest commit hash.
-      version=$(git rev-parse --short HEAD)
-    fi
-  fi
+  version=$(fusion_version)
   echo "Using fusion version string: ${version}"
 
   cd ./frontend
@@ -38,9 +42,10 @@ build_frontend() {
 build_backend() {
   target_os=${1:-$(go env GOOS)}
   target_arch=${2:-$(go env GOARCH)}
-  echo "building backend for OS: ${target_os}, Arch: ${target_arch}"
+  version=$(fusion_version)
+  echo "building backend for OS: ${target_os}, Arch: ${target_arch}, version: ${version}"
   CGO_ENABLED=0 GOOS=${target_os} GOARCH=${target_arch} go build \
-    -ldflags '-extldflags "-static"' \
+    -ldflags "-extldflags \"-static\" -X github.com/0x2e/fusion/conf.Version=${version}" \
     -o ./build/fusion \
     ./cmd/server/*
 }
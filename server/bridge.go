@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/repo"
+)
+
+type BridgeRepo interface {
+	All() ([]*model.Bridge, error)
+	Create(bridge *model.Bridge) error
+	Delete(id uint) error
+}
+
+type Bridge struct {
+	repo BridgeRepo
+}
+
+func NewBridge(repo BridgeRepo) *Bridge {
+	return &Bridge{
+		repo: repo,
+	}
+}
+
+func (b Bridge) All(ctx context.Context) (*RespBridgeAll, error) {
+	data, err := b.repo.All()
+	if err != nil {
+		return nil, err
+	}
+
+	bridges := make([]*BridgeForm, 0, len(data))
+	for _, v := range data {
+		bridges = append(bridges, &BridgeForm{
+			ID:      v.ID,
+			Kind:    v.Kind,
+			BaseURL: v.BaseURL,
+		})
+	}
+	return &RespBridgeAll{
+		Bridges: bridges,
+	}, nil
+}
+
+func (b Bridge) Create(ctx context.Context, req *ReqBridgeCreate) (*RespBridgeCreate, error) {
+	// Templates append paths to it, see BaseURL.
+	baseURL := strings.TrimRight(strings.TrimSpace(*req.BaseURL), "/")
+
+	newBridge := &model.Bridge{
+		Kind:    req.Kind,
+		BaseURL: &baseURL,
+	}
+	if err := b.repo.Create(newBridge); err != nil {
+		if errors.Is(err, repo.ErrDuplicatedKey) {
+			err = NewBizError(err, http.StatusBadRequest, "bridge already exists")
+		}
+		return nil, err
+	}
+	return &RespBridgeCreate{ID: newBridge.ID}, nil
+}
+
+func (b Bridge) Delete(ctx context.Context, req *ReqBridgeDelete) error {
+	return b.repo.Delete(req.ID)
+}
@@ -0,0 +1,24 @@
+package server
+
+type BridgeForm struct {
+	ID      uint    `json:"id"`
+	Kind    *string `json:"kind"`
+	BaseURL *string `json:"base_url"`
+}
+
+type RespBridgeAll struct {
+	Bridges []*BridgeForm `json:"bridges"`
+}
+
+type ReqBridgeCreate struct {
+	Kind    *string `json:"kind" validate:"required,oneof=rsshub rss-bridge nitter"`
+	BaseURL *string `json:"base_url" validate:"required,http_url"`
+}
+
+type RespBridgeCreate struct {
+	ID uint `json:"id"`
+}
+
+type ReqBridgeDelete struct {
+	ID uint `param:"id" validate:"required"`
+}
@@ -0,0 +1,40 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/server"
+)
+
+func TestBridgeCreate(t *testing.T) {
+	bridges := server.NewBridge(repo.NewBridge(repotest.NewDB(t)))
+	ctx := context.Background()
+
+	_, err := bridges.Create(ctx, &server.ReqBridgeCreate{
+		Kind:    ptr.To(model.BridgeRSSHub),
+		BaseURL: ptr.To(" https://rsshub.example.com/ "),
+	})
+	require.NoError(t, err)
+
+	_, err = bridges.Create(ctx, &server.ReqBridgeCreate{
+		Kind:    ptr.To(model.BridgeRSSHub),
+		BaseURL: ptr.To("https://rsshub.example.com"),
+	})
+	var bizErr server.BizError
+	require.ErrorAs(t, err, &bizErr)
+	assert.EqualValues(t, http.StatusBadRequest, bizErr.HTTPCode)
+
+	resp, err := bridges.All(ctx)
+	require.NoError(t, err)
+	require.Len(t, resp.Bridges, 1)
+	assert.Equal(t, "https://rsshub.example.com", *resp.Bridges[0].BaseURL)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/repo"
+)
+
+type DiscoverFeedRepo interface {
+	List(filter *repo.FeedListFilter) ([]*model.Feed, error)
+}
+
+type Discover struct {
+	feedRepo DiscoverFeedRepo
+}
+
+func NewDiscover(feedRepo DiscoverFeedRepo) *Discover {
+	return &Discover{
+		feedRepo: feedRepo,
+	}
+}
+
+func (d Discover) Get(ctx context.Context) (*RespDiscover, error) {
+	feeds, err := d.feedRepo.List(nil)
+	if err != nil {
+		return nil, err
+	}
+	subscribedLinks := make(map[string]bool, len(feeds))
+	subscribedHosts := make(map[string]bool, len(feeds))
+	for _, f := range feeds {
+		subscribedLinks[*f.Link] = true
+		if host := hostOf(*f.Link); host != "" {
+			subscribedHosts[host] = true
+		}
+	}
+	isSubscribed := func(f discoverFeed) bool {
+		return subscribedLinks[f.Link] || subscribedHosts[hostOf(f.Link)] || subscribedHosts[hostOf(f.SiteURL)]
+	}
+
+	bundles := make([]*DiscoverBundleForm, 0, len(discoverCatalog))
+	// score counts how many subscribed feeds share a bundle with the feed
+	score := make(map[string]int)
+	suggestions := make([]*DiscoverFeedForm, 0)
+	for _, b := range discoverCatalog {
+		bundle := &DiscoverBundleForm{
+			ID:    b.ID,
+			Name:  b.Name,
+			Feeds: make([]*DiscoverFeedForm, 0, len(b.Feeds)),
+		}
+		overlap := 0
+		for _, f := range b.Feeds {
+			subscribed := isSubscribed(f)
+			if subscribed {
+				overlap++
+			}
+			bundle.Feeds = append(bundle.Feeds, &DiscoverFeedForm{
+				Name:       f.Name,
+				Link:       f.Link,
+				SiteURL:    f.SiteURL,
+				Subscribed: subscribed,
+			})
+		}
+		bundles = append(bundles, bundle)
+
+		if overlap == 0 {
+			continue
+		}
+		for _, f := range bundle.Feeds {
+			if f.Subscribed {
+				continue
+			}
+			if _, ok := score[f.Link]; !ok {
+				suggestions = append(suggestions, f)
+			}
+			score[f.Link] += overlap
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return score[suggestions[i].Link] > score[suggestions[j].Link]
+	})
+
+	return &RespDiscover{
+		Bundles:     bundles,
+		Suggestions: suggestions,
+	}, nil
+}
+
+func hostOf(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
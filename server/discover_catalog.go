@@ -0,0 +1,61 @@
+package server
+
+// discoverBundle is a curated set of feeds on a topic.
+type discoverBundle struct {
+	ID    string
+	Name  string
+	Feeds []discoverFeed
+}
+
+type discoverFeed struct {
+	Name    string
+	Link    string
+	SiteURL string
+}
+
+// discoverCatalog is the curated feed directory shown on the Discover page.
+// Bundles also drive suggestions: subscribing to some feeds of a bundle
+// suggests the rest of it.
+var discoverCatalog = []discoverBundle{
+	{
+		ID:   "news",
+		Name: "News",
+		Feeds: []discoverFeed{
+			{Name: "BBC News", Link: "https://feeds.bbci.co.uk/news/rss.xml", SiteURL: "https://www.bbc.co.uk/news"},
+			{Name: "NPR News", Link: "https://feeds.npr.org/1001/rss.xml", SiteURL: "https://www.npr.org"},
+			{Name: "The Guardian World", Link: "https://www.theguardian.com/world/rss", SiteURL: "https://www.theguardian.com/world"},
+			{Name: "Al Jazeera", Link: "https://www.aljazeera.com/xml/rss/all.xml", SiteURL: "https://www.aljazeera.com"},
+		},
+	},
+	{
+		ID:   "tech",
+		Name: "Tech",
+		Feeds: []discoverFeed{
+			{Name: "Hacker News", Link: "https://news.ycombinator.com/rss", SiteURL: "https://news.ycombinator.com"},
+			{Name: "Lobsters", Link: "https://lobste.rs/rss", SiteURL: "https://lobste.rs"},
+			{Name: "Ars Technica", Link: "https://feeds.arstechnica.com/arstechnica/index", SiteURL: "https://arstechnica.com"},
+			{Name: "The Verge", Link: "https://www.theverge.com/rss/index.xml", SiteURL: "https://www.theverge.com"},
+			{Name: "TechCrunch", Link: "https://techcrunch.com/feed/", SiteURL: "https://techcrunch.com"},
+		},
+	},
+	{
+		ID:   "programming",
+		Name: "Programming",
+		Feeds: []discoverFeed{
+			{Name: "The Go Blog", Link: "https://go.dev/blog/feed.atom", SiteURL: "https://go.dev/blog"},
+			{Name: "Julia Evans", Link: "https://jvns.ca/atom.xml", SiteURL: "https://jvns.ca"},
+			{Name: "Simon Willison", Link: "https://simonwillison.net/atom/everything/", SiteURL: "https://simonwillison.net"},
+			{Name: "Lobsters", Link: "https://lobste.rs/rss", SiteURL: "https://lobste.rs"},
+		},
+	},
+	{
+		ID:   "science",
+		Name: "Science",
+		Feeds: []discoverFeed{
+			{Name: "Quanta Magazine", Link: "https://www.quantamagazine.org/feed/", SiteURL: "https://www.quantamagazine.org"},
+			{Name: "Nature", Link: "https://www.nature.com/nature.rss", SiteURL: "https://www.nature.com"},
+			{Name: "ScienceDaily", Link: "https://www.sciencedaily.com/rss/all.xml", SiteURL: "https://www.sciencedaily.com"},
+			{Name: "New Scientist", Link: "https://www.newscientist.com/feed/home/", SiteURL: "https://www.newscientist.com"},
+		},
+	},
+}
@@ -0,0 +1,21 @@
+package server
+
+type DiscoverFeedForm struct {
+	Name       string `json:"name"`
+	Link       string `json:"link"`
+	SiteURL    string `json:"site_url"`
+	Subscribed bool   `json:"subscribed"`
+}
+
+type DiscoverBundleForm struct {
+	ID    string              `json:"id"`
+	Name  string              `json:"name"`
+	Feeds []*DiscoverFeedForm `json:"feeds"`
+}
+
+type RespDiscover struct {
+	Bundles []*DiscoverBundleForm `json:"bundles"`
+	// Suggestions are feeds that are often read together with the feeds the
+	// user already subscribes to, most relevant first.
+	Suggestions []*DiscoverFeedForm `json:"suggestions"`
+}
@@ -1,10 +1,15 @@
 package server
 
+import "net/http"
+
 // BizError is the error allowed to show on frontend user side
 type BizError struct {
 	HTTPCode  uint
 	FEMessage string
 	Raw       error
+	// Field is the JSON name of the request field the error is about, if any,
+	// so the frontend can show the message next to the input.
+	Field string
 }
 
 func (e BizError) Error() string {
@@ -18,3 +23,13 @@ func NewBizError(raw error, code uint, message string) BizError {
 		Raw:       raw,
 	}
 }
+
+// NewFieldError returns a 400 BizError about the request field named field.
+func NewFieldError(raw error, field string, message string) BizError {
+	return BizError{
+		HTTPCode:  http.StatusBadRequest,
+		FEMessage: message,
+		Raw:       raw,
+		Field:     field,
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/blob"
+	"github.com/0x2e/fusion/pkg/httpx"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+)
+
+const (
+	// faviconPrefix is where favicons are kept in the blob storage, by host.
+	faviconPrefix = "favicons/"
+	// faviconSource returns the favicon of the host appended to it.
+	faviconSource = "https://www.google.com/s2/favicons?sz=32&domain="
+	// maxFaviconSize caps the favicons fetched.
+	maxFaviconSize = 256 << 10
+)
+
+var faviconHost = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?$`)
+
+type FaviconFeedRepo interface {
+	List(filter *repo.FeedListFilter) ([]*model.Feed, error)
+}
+
+// Favicon serves the favicons of the sites feeds come from. Each one is
+// fetched once and kept in the blob storage, so that browsers don't ask a
+// third party for them on every page.
+type Favicon struct {
+	store    blob.Store
+	feedRepo FaviconFeedRepo
+	// hosts are the FAVICON_HOSTS sites, see conf.Conf.
+	hosts map[string]string
+}
+
+func NewFavicon(store blob.Store, feedRepo FaviconFeedRepo, hosts map[string]string) *Favicon {
+	return &Favicon{
+		store:    store,
+		feedRepo: feedRepo,
+		hosts:    hosts,
+	}
+}
+
+// Get returns the favicon of req.Host, fetching it if it's not stored yet.
+func (f Favicon) Get(ctx context.Context, req *ReqFaviconGet) ([]byte, error) {
+	host := strings.ToLower(req.Host)
+	if !faviconHost.MatchString(host) {
+		return nil, NewFieldError(fmt.Errorf("invalid favicon host %q", req.Host), "host", "invalid host")
+	}
+	key := faviconPrefix + host
+
+	data, err := f.store.Get(ctx, key)
+	if !errors.Is(err, blob.ErrNotFound) {
+		return data, err
+	}
+
+	data, err = fetchFavicon(ctx, host)
+	if err != nil {
+		return nil, NewBizError(err, http.StatusNotFound, "favicon not found")
+	}
+	if err := f.store.Put(ctx, key, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func fetchFavicon(ctx context.Context, host string) ([]byte, error) {
+	resp, err := httpx.FusionRequest(ctx, faviconSource+url.QueryEscape(host), model.FeedRequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The source answers unknown hosts with a 404 and a default icon, which
+	// is kept as well, so that it isn't asked again.
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "image/") {
+		return nil, fmt.Errorf("got status code %d and no image", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFaviconSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxFaviconSize {
+		return nil, errors.New("favicon is too large")
+	}
+	return data, nil
+}
+
+// Owner has the favicons that no feed refers to anymore collected. Favicons
+// of other hosts, like the sites of bridged feeds, are fetched again after
+// being collected.
+func (f Favicon) Owner() blob.Owner {
+	return blob.Owner{
+		Prefix:     faviconPrefix,
+		Referenced: f.referenced,
+	}
+}
+
+func (f Favicon) referenced(ctx context.Context, keys []string) (map[string]bool, error) {
+	feeds, err := f.feedRepo.List(nil)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make(map[string]bool)
+	for _, h := range f.hosts {
+		hosts[strings.ToLower(h)] = true
+	}
+	for _, feed := range feeds {
+		for _, link := range []string{ptr.From(feed.Link), ptr.From(feed.SiteURL)} {
+			if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+				hosts[strings.ToLower(u.Hostname())] = true
+			}
+		}
+	}
+
+	res := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if hosts[strings.TrimPrefix(key, faviconPrefix)] {
+			res[key] = true
+		}
+	}
+	return res, nil
+}
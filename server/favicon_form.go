@@ -0,0 +1,5 @@
+package server
+
+type ReqFaviconGet struct {
+	Host string `param:"host" validate:"required"`
+}
@@ -0,0 +1,46 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/blob"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/server"
+)
+
+func TestFavicon(t *testing.T) {
+	db := repotest.NewDB(t)
+	require.NoError(t, repo.NewFeed(db).Create([]*model.Feed{
+		{Name: ptr.To("Blog"), Link: ptr.To("https://example.com/feed"), SiteURL: ptr.To("https://www.example.com/"), GroupID: ptr.To(uint(1))},
+	}))
+	store, err := blob.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	favicons := server.NewFavicon(store, repo.NewFeed(db), map[string]string{"rss.example.net/": "bridged.example.org"})
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "favicons/example.com", []byte("icon")))
+	data, err := favicons.Get(ctx, &server.ReqFaviconGet{Host: "Example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("icon"), data, "stored favicons must not be fetched again")
+
+	_, err = favicons.Get(ctx, &server.ReqFaviconGet{Host: "../secrets"})
+	var bizErr server.BizError
+	assert.ErrorAs(t, err, &bizErr)
+
+	owner := favicons.Owner()
+	keys := []string{"favicons/example.com", "favicons/www.example.com", "favicons/bridged.example.org", "favicons/gone.example.com"}
+	referenced, err := owner.Referenced(ctx, keys)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{
+		"favicons/example.com":         true,
+		"favicons/www.example.com":     true,
+		"favicons/bridged.example.org": true,
+	}, referenced, "favicons of hosts no feed refers to must be collected")
+}
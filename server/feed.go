@@ -1,14 +1,18 @@
 package server
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/0x2E/feedfinder"
 	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
 	"github.com/0x2e/fusion/repo"
 	"github.com/0x2e/fusion/service/pull"
 	"github.com/0x2e/fusion/service/pull/client"
@@ -19,16 +23,47 @@ type FeedRepo interface {
 	Get(id uint) (*model.Feed, error)
 	Create(feed []*model.Feed) error
 	Update(id uint, feed *model.Feed) error
+	Ungroup(id uint) error
+	UpdateSettings(id uint, settings model.Settings) error
 	Delete(id uint) error
+	Merge(from, into uint) error
+}
+
+// FeedGroupRepo provides what's needed to pick a group for new feeds.
+type FeedGroupRepo interface {
+	Get(id uint) (*model.Group, error)
+	GetDefault() (*model.Group, error)
+	ListRules() ([]*model.GroupRule, error)
+}
+
+// FeedItemRepo provides the maintenance of the items of a feed.
+type FeedItemRepo interface {
+	Dedupe(feedID uint) (int, error)
+}
+
+// FeedPuller fetches feeds and stores their new items.
+type FeedPuller interface {
+	PullOne(ctx context.Context, id uint, force bool) (pull.PullResult, error)
+	PullAll(ctx context.Context, force bool) error
 }
 
 type Feed struct {
-	repo FeedRepo
+	repo       FeedRepo
+	groupRepo  FeedGroupRepo
+	itemRepo   FeedItemRepo
+	puller     FeedPuller
+	jobs       JobEnqueuer
+	feedClient client.FeedClient
 }
 
-func NewFeed(repo FeedRepo) *Feed {
+func NewFeed(repo FeedRepo, groupRepo FeedGroupRepo, itemRepo FeedItemRepo, puller FeedPuller, jobs JobEnqueuer, feedClient client.FeedClient) *Feed {
 	return &Feed{
-		repo: repo,
+		repo:       repo,
+		groupRepo:  groupRepo,
+		itemRepo:   itemRepo,
+		puller:     puller,
+		jobs:       jobs,
+		feedClient: feedClient,
 	}
 }
 
@@ -42,18 +77,41 @@ func (f Feed) List(ctx context.Context, req *ReqFeedList) (*RespFeedList, error)
 		return nil, err
 	}
 
+	duplicates := duplicateFeeds(data)
 	feeds := make([]*FeedForm, 0, len(data))
 	for _, v := range data {
 		feeds = append(feeds, &FeedForm{
-			ID:          v.ID,
-			Name:        v.Name,
-			Link:        v.Link,
-			Failure:     v.Failure,
-			Suspended:   v.Suspended,
-			ReqProxy:    v.ReqProxy,
-			UpdatedAt:   v.UpdatedAt,
-			UnreadCount: v.UnreadCount,
-			Group:       GroupForm{ID: v.GroupID, Name: v.Group.Name},
+			ID:              v.ID,
+			Name:            v.Name,
+			Link:            v.Link,
+			SiteURL:         v.SiteURL,
+			CanonicalLink:   v.CanonicalLink,
+			Description:     v.Description,
+			Failure:         v.Failure,
+			Blocked:         v.Blocked,
+			Suspended:       v.Suspended,
+			MonitorOnly:     v.MonitorOnly,
+			RefreshInterval: v.RefreshInterval,
+			Sanitize:        v.Sanitize,
+			Weight:          v.Weight,
+			EmbedVideos:     v.EmbedVideos,
+			OrderByPosition: v.OrderByPosition,
+			DisplayMode:     v.DisplayMode,
+			ReqProxy:        v.ReqProxy,
+			Notes:           v.Notes,
+			Color:           v.Color,
+			Emoji:           v.Emoji,
+			CaptureResponse: v.CaptureResponse,
+			UpdatedAt:       v.UpdatedAt,
+			LastBuild:       v.LastBuild,
+			LastFetchedAt:   v.LastFetchedAt,
+			NextFetchAt:     v.NextFetchAt,
+			LastItemAt:      v.LastItemAt,
+			UnreadCount:     v.UnreadCount,
+			Group:           newFeedGroup(v),
+			RemovedFromOPML: v.RemovedFromOPML,
+			DuplicateOf:     duplicates[v.ID],
+			Settings:        newSettingsForm(v.Settings()),
 		})
 	}
 	return &RespFeedList{
@@ -66,29 +124,125 @@ func (f Feed) Get(ctx context.Context, req *ReqFeedGet) (*RespFeedGet, error) {
 	if err != nil {
 		return nil, err
 	}
+	all, err := f.repo.List(nil)
+	if err != nil {
+		return nil, err
+	}
 
 	return &RespFeedGet{
-		ID:        data.ID,
-		Name:      data.Name,
-		Link:      data.Link,
-		Failure:   data.Failure,
-		Suspended: data.Suspended,
-		ReqProxy:  data.ReqProxy,
-		UpdatedAt: data.UpdatedAt,
-		Group:     GroupForm{ID: data.GroupID, Name: data.Group.Name},
+		ID:              data.ID,
+		Name:            data.Name,
+		Link:            data.Link,
+		SiteURL:         data.SiteURL,
+		CanonicalLink:   data.CanonicalLink,
+		Description:     data.Description,
+		Failure:         data.Failure,
+		Blocked:         data.Blocked,
+		Suspended:       data.Suspended,
+		MonitorOnly:     data.MonitorOnly,
+		RefreshInterval: data.RefreshInterval,
+		Sanitize:        data.Sanitize,
+		Weight:          data.Weight,
+		EmbedVideos:     data.EmbedVideos,
+		OrderByPosition: data.OrderByPosition,
+		DisplayMode:     data.DisplayMode,
+		ReqProxy:        data.ReqProxy,
+		Notes:           data.Notes,
+		Color:           data.Color,
+		Emoji:           data.Emoji,
+		CaptureResponse: data.CaptureResponse,
+		LastResponse:    data.LastResponse,
+		UpdatedAt:       data.UpdatedAt,
+		LastBuild:       data.LastBuild,
+		LastFetchedAt:   data.LastFetchedAt,
+		NextFetchAt:     data.NextFetchAt,
+		LastItemAt:      data.LastItemAt,
+		Group:           newFeedGroup(data),
+		RemovedFromOPML: data.RemovedFromOPML,
+		DuplicateOf:     duplicateFeeds(all)[data.ID],
+		Settings:        newSettingsForm(data.Settings()),
 	}, nil
 }
 
+// newFeedGroup returns the group of feed, or nil if it's uncategorized.
+func newFeedGroup(feed *model.Feed) *GroupForm {
+	if feed.GroupID == nil {
+		return nil
+	}
+	return &GroupForm{ID: *feed.GroupID, Name: feed.Group.Name}
+}
+
+// duplicateFeeds finds the subscriptions to the same feed, which have the
+// same link or canonical link, compared with aliasKey. Each one is mapped to
+// another one of them, so that it can be merged into it.
+func duplicateFeeds(feeds []*model.Feed) map[uint]*uint {
+	sorted := slices.Clone(feeds)
+	slices.SortFunc(sorted, func(a, b *model.Feed) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	duplicates := make(map[uint]*uint)
+	owners := make(map[string]uint)
+	for _, feed := range sorted {
+		keys := []string{aliasKey(ptr.From(feed.Link))}
+		if feed.CanonicalLink != nil && *feed.CanonicalLink != "" {
+			keys = append(keys, aliasKey(*feed.CanonicalLink))
+		}
+		owner, found := uint(0), false
+		for _, key := range keys {
+			if owner, found = owners[key]; found {
+				break
+			}
+		}
+		if !found {
+			owner = feed.ID
+		} else {
+			duplicates[feed.ID] = ptr.To(owner)
+			if duplicates[owner] == nil {
+				duplicates[owner] = ptr.To(feed.ID)
+			}
+		}
+		for _, key := range keys {
+			if _, ok := owners[key]; !ok {
+				owners[key] = owner
+			}
+		}
+	}
+	return duplicates
+}
+
+// aliasKey normalizes a feed link for comparison: the scheme, a leading www.
+// and a trailing slash don't make another feed.
+func aliasKey(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return link
+	}
+	key := strings.TrimPrefix(strings.ToLower(u.Host), "www.") + strings.TrimSuffix(u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
+
 func (f Feed) Create(ctx context.Context, req *ReqFeedCreate) (*RespFeedCreate, error) {
+	resolveGroup, err := f.groupResolver(req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
 	feeds := make([]*model.Feed, 0, len(req.Feeds))
 	for _, r := range req.Feeds {
 		feeds = append(feeds, &model.Feed{
-			Name: r.Name,
-			Link: r.Link,
+			Name:        r.Name,
+			Link:        r.Link,
+			SiteURL:     r.SiteURL,
+			MonitorOnly: r.MonitorOnly,
+			Notes:       r.Notes,
 			FeedRequestOptions: model.FeedRequestOptions{
 				ReqProxy: r.RequestOptions.Proxy,
 			},
-			GroupID: req.GroupID,
+			GroupID: resolveGroup(*r.Link),
 		})
 	}
 
@@ -106,49 +260,239 @@ func (f Feed) Create(ctx context.Context, req *ReqFeedCreate) (*RespFeedCreate,
 		IDs: ids,
 	}
 
-	puller := pull.NewPuller(repo.NewFeed(repo.DB), repo.NewItem(repo.DB))
+	// A single feed is pulled right away, so that it has items when the client
+	// opens it. The validation guarantees there is at least one feed.
 	if len(feeds) > 1 {
+		return resp, f.pullInBackground(ctx, ids)
+	}
+	_, err = f.puller.PullOne(pull.WithOrigin(ctx, model.ItemOriginImport), feeds[0].ID, false)
+	return resp, err
+}
+
+// pullInBackground queues pulling the given feeds, without blocking the
+// caller.
+func (f Feed) pullInBackground(ctx context.Context, ids []uint) error {
+	for _, id := range ids {
+		if err := f.jobs.Enqueue(ctx, pull.JobPullFeed, pull.PullFeedJob{FeedID: id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bulkCreateConcurrency is the number of links discovered in parallel by
+// BulkCreate.
+const bulkCreateConcurrency = 5
+
+// BulkCreate subscribes to a newline-separated list of links. Each link goes
+// through the same discovery as CheckValidity, and the outcome is reported
+// per link instead of failing the whole request.
+func (f Feed) BulkCreate(ctx context.Context, req *ReqFeedBulkCreate) (*RespFeedBulkCreate, error) {
+	resolveGroup, err := f.groupResolver(req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := f.repo.List(nil)
+	if err != nil {
+		return nil, err
+	}
+	subscribed := &subscribedLinks{links: make(map[string]bool, len(existing))}
+	for _, v := range existing {
+		subscribed.links[*v.Link] = true
+	}
+
+	links := splitLinks(req.Links)
+	results := make([]*BulkCreateResult, len(links))
+	routinePool := make(chan struct{}, bulkCreateConcurrency)
+	defer close(routinePool)
+	wg := sync.WaitGroup{}
+	for i, link := range links {
+		routinePool <- struct{}{}
+		wg.Add(1)
 		go func() {
-			routinePool := make(chan struct{}, 10)
-			defer close(routinePool)
-			wg := sync.WaitGroup{}
-			for _, feed := range feeds {
-				routinePool <- struct{}{}
-				wg.Add(1)
-				go func() {
-					// NOTE: do not use the incoming ctx, as it will be Done() automatically
-					// by api timeout middleware
-					puller.PullOne(context.Background(), feed.ID)
-					<-routinePool
-					wg.Done()
-				}()
-			}
-			wg.Wait()
+			defer func() {
+				wg.Done()
+				<-routinePool
+			}()
+			results[i] = f.bulkCreateOne(ctx, link, subscribed, resolveGroup)
 		}()
-		return resp, nil
 	}
-	return resp, puller.PullOne(ctx, feeds[0].ID)
+	wg.Wait()
+
+	createdIDs := make([]uint, 0, len(links))
+	for _, result := range results {
+		if result.Status == BulkCreateStatusCreated {
+			createdIDs = append(createdIDs, result.FeedID)
+		}
+	}
+
+	if len(createdIDs) > 0 {
+		if err := f.pullInBackground(ctx, createdIDs); err != nil {
+			return nil, err
+		}
+	}
+	return &RespFeedBulkCreate{
+		Results: results,
+	}, nil
+}
+
+// subscribedLinks tracks the feed links that already exist while links are
+// created concurrently.
+type subscribedLinks struct {
+	mu    sync.Mutex
+	links map[string]bool
+}
+
+func (s *subscribedLinks) has(link string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.links[link]
+}
+
+func (f Feed) bulkCreateOne(ctx context.Context, link string, subscribed *subscribedLinks, resolveGroup func(string) *uint) *BulkCreateResult {
+	result := &BulkCreateResult{Link: link}
+	name := link
+	if acct, ok := client.ActivityPubLink(link); ok {
+		link = acct
+	} else {
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			result.Status = BulkCreateStatusInvalid
+			result.Error = "not a valid http(s) URL or fediverse account"
+			return result
+		}
+		name = u.Hostname()
+	}
+	if subscribed.has(link) {
+		result.Status = BulkCreateStatusDuplicate
+		return result
+	}
+
+	candidates, err := f.findFeedLinks(ctx, link, nil)
+	if err != nil || len(candidates) == 0 {
+		result.Status = BulkCreateStatusInvalid
+		result.Error = "no valid feed found"
+		if err != nil {
+			result.Error = err.Error()
+		}
+		return result
+	}
+	feedLink := *candidates[0].Link
+	result.FeedLink = feedLink
+
+	// Several links may lead to the same feed, so checking and creating must
+	// not interleave.
+	subscribed.mu.Lock()
+	defer subscribed.mu.Unlock()
+	if subscribed.links[feedLink] {
+		result.Status = BulkCreateStatusDuplicate
+		return result
+	}
+
+	if title := ptr.From(candidates[0].Title); title != "" {
+		name = title
+	}
+	feed := &model.Feed{
+		Name:    &name,
+		Link:    &feedLink,
+		GroupID: resolveGroup(feedLink),
+	}
+	if err := f.repo.Create([]*model.Feed{feed}); err != nil {
+		result.Status = BulkCreateStatusInvalid
+		result.Error = err.Error()
+		return result
+	}
+	subscribed.links[feedLink] = true
+	result.Status = BulkCreateStatusCreated
+	result.FeedID = feed.ID
+	return result
+}
+
+// splitLinks splits text into unique, non-empty lines.
+func splitLinks(text string) []string {
+	seen := make(map[string]bool)
+	links := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		link := strings.TrimSpace(line)
+		if link == "" || seen[link] {
+			continue
+		}
+		seen[link] = true
+		links = append(links, link)
+	}
+	return links
+}
+
+// groupResolver returns a function that picks the group of a new feed. An
+// explicit groupID always wins. Otherwise the first matching group rule is
+// used, then the default group. Without a default group, the feed is left
+// uncategorized.
+func (f Feed) groupResolver(groupID uint) (func(link string) *uint, error) {
+	if groupID != 0 {
+		if err := checkGroupExists(f.groupRepo, groupID, "group_id"); err != nil {
+			return nil, err
+		}
+		return func(string) *uint { return ptr.To(groupID) }, nil
+	}
+
+	rules, err := f.groupRepo.ListRules()
+	if err != nil {
+		return nil, err
+	}
+	var defaultID *uint
+	defaultGroup, err := f.groupRepo.GetDefault()
+	if err == nil {
+		defaultID = ptr.To(defaultGroup.ID)
+	} else if !errors.Is(err, repo.ErrNotFound) {
+		return nil, err
+	}
+
+	return func(link string) *uint {
+		if id, ok := matchGroupRule(rules, link); ok {
+			return ptr.To(id)
+		}
+		return defaultID
+	}, nil
 }
 
 func (f Feed) CheckValidity(ctx context.Context, req *ReqFeedCheckValidity) (*RespFeedCheckValidity, error) {
-	if title, err := client.NewFeedClient().FetchTitle(ctx, req.Link, model.FeedRequestOptions{ReqProxy: req.RequestOptions.Proxy}); err == nil {
-		return &RespFeedCheckValidity{
-			FeedLinks: []ValidityItem{
-				{
-					Title: &title,
-					Link:  &req.Link,
-				},
+	validLinks, err := f.findFeedLinks(ctx, req.Link, req.RequestOptions.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	return &RespFeedCheckValidity{
+		FeedLinks: validLinks,
+	}, nil
+}
+
+// findFeedLinks returns link itself if it's a feed. Otherwise it returns the
+// feeds found on the web page at link. Fediverse accounts are returned as
+// acct: links.
+func (f Feed) findFeedLinks(ctx context.Context, link string, proxy *string) ([]ValidityItem, error) {
+	acct, isAccount := client.ActivityPubLink(link)
+	if isAccount {
+		link = acct
+	}
+	title, err := f.feedClient.FetchTitle(ctx, link, model.FeedRequestOptions{ReqProxy: proxy})
+	if err == nil {
+		return []ValidityItem{
+			{
+				Title: &title,
+				Link:  &link,
 			},
 		}, nil
 	}
+	if isAccount {
+		return nil, err
+	}
 
 	validLinks := make([]ValidityItem, 0)
-	target, err := url.Parse(req.Link)
+	target, err := url.Parse(link)
 	if err != nil {
 		return nil, err
 	}
 	sniffed, err := feedfinder.Find(ctx, target.String(), &feedfinder.Options{
-		RequestProxy: req.RequestOptions.Proxy,
+		RequestProxy: proxy,
 	})
 	if err != nil {
 		return nil, err
@@ -159,26 +503,50 @@ func (f Feed) CheckValidity(ctx context.Context, req *ReqFeedCheckValidity) (*Re
 			Link:  &l.Link,
 		})
 	}
-	return &RespFeedCheckValidity{
-		FeedLinks: validLinks,
-	}, nil
+	return validLinks, nil
 }
 
 func (f Feed) Update(ctx context.Context, req *ReqFeedUpdate) error {
 	data := &model.Feed{
-		Name:      req.Name,
-		Link:      req.Link,
-		Suspended: req.Suspended,
+		Name:            req.Name,
+		Link:            req.Link,
+		Suspended:       req.Suspended,
+		MonitorOnly:     req.MonitorOnly,
+		Weight:          req.Weight,
+		EmbedVideos:     req.EmbedVideos,
+		OrderByPosition: req.OrderByPosition,
+		DisplayMode:     req.DisplayMode,
+		Notes:           req.Notes,
 		FeedRequestOptions: model.FeedRequestOptions{
-			ReqProxy: req.ReqProxy,
+			ReqProxy:        req.ReqProxy,
+			CaptureResponse: req.CaptureResponse,
 		},
+		Label: model.Label{Color: req.Color, Emoji: req.Emoji},
+	}
+	if req.CaptureResponse != nil && !*req.CaptureResponse {
+		// Drop the stale capture so it doesn't linger after debugging is done.
+		data.LastResponse = ptr.To("")
 	}
-	if req.GroupID != nil {
-		data.GroupID = *req.GroupID
+	if req.GroupID != nil && *req.GroupID != 0 {
+		if err := checkGroupExists(f.groupRepo, *req.GroupID, "group_id"); err != nil {
+			return err
+		}
+		data.GroupID = req.GroupID
 	}
 	err := f.repo.Update(req.ID, data)
+	if err == nil && req.GroupID != nil && *req.GroupID == 0 {
+		err = f.repo.Ungroup(req.ID)
+	}
 	if errors.Is(err, repo.ErrDuplicatedKey) {
-		err = NewBizError(err, http.StatusBadRequest, "link is not allowed to be the same as other feeds")
+		err = NewFieldError(err, "link", "link is not allowed to be the same as other feeds")
+	}
+	return err
+}
+
+func (f Feed) UpdateSettings(ctx context.Context, req *ReqFeedUpdateSettings) error {
+	err := f.repo.UpdateSettings(req.ID, req.SettingsForm.settings())
+	if errors.Is(err, repo.ErrNotFound) {
+		err = NewBizError(err, http.StatusNotFound, "feed not found")
 	}
 	return err
 }
@@ -187,15 +555,85 @@ func (f Feed) Delete(ctx context.Context, req *ReqFeedDelete) error {
 	return f.repo.Delete(req.ID)
 }
 
-func (f Feed) Refresh(ctx context.Context, req *ReqFeedRefresh) error {
-	pull := pull.NewPuller(repo.NewFeed(repo.DB), repo.NewItem(repo.DB))
+// Merge moves the items of a feed to another subscription to the same feed,
+// and deletes it.
+func (f Feed) Merge(ctx context.Context, req *ReqFeedMerge) error {
+	if req.Into == req.ID {
+		msg := "a feed can't be merged into itself"
+		return NewBizError(errors.New(msg), http.StatusBadRequest, msg)
+	}
+	for _, id := range []uint{req.ID, req.Into} {
+		if _, err := f.repo.Get(id); err != nil {
+			return err
+		}
+	}
+	return f.repo.Merge(req.ID, req.Into)
+}
+
+// Dedupe removes the items of a feed that duplicate older ones, which feeds
+// that changed how they make GUIDs leave behind.
+func (f Feed) Dedupe(ctx context.Context, req *ReqFeedDedupe) (*RespFeedDedupe, error) {
+	if _, err := f.repo.Get(req.ID); err != nil {
+		return nil, err
+	}
+	removed, err := f.itemRepo.Dedupe(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &RespFeedDedupe{Removed: removed}, nil
+}
+
+// Refresh pulls a feed and reports the outcome, or starts pulling all feeds
+// in the background, in which case it returns no response.
+func (f Feed) Refresh(ctx context.Context, req *ReqFeedRefresh) (*RespFeedRefresh, error) {
 	if req.ID != nil {
-		return pull.PullOne(ctx, *req.ID)
+		result, err := f.puller.PullOne(pull.WithOrigin(ctx, model.ItemOriginRefresh), *req.ID, ptr.From(req.Force))
+		if err != nil {
+			return nil, err
+		}
+		resp := &RespFeedRefresh{
+			NewItems:    result.NewItems,
+			NextFetchAt: result.NextFetchAt,
+			DurationMS:  result.Duration.Milliseconds(),
+			StatusCode:  result.StatusCode,
+		}
+		if result.SkipReason != nil {
+			resp.SkipReason = ptr.To(result.SkipReason.Code())
+		}
+		if result.FetchErr != nil {
+			resp.Failure = ptr.To(result.FetchErr.Error())
+		}
+		return resp, nil
 	}
 	if req.All != nil && *req.All {
-		// NOTE: do not use the incoming ctx, as it will be Done() automatically
-		// by api timeout middleware
-		go pull.PullAll(context.Background(), true)
+		// NOTE: detach from the incoming ctx, as it will be Done()
+		// automatically by api timeout middleware.
+		// If a refresh is already running, PullAll returns ErrPullAllRunning
+		// right away, and clients follow the running one instead.
+		go f.puller.PullAll(pull.WithOrigin(context.WithoutCancel(ctx), model.ItemOriginRefresh), true)
 	}
-	return nil
+	return nil, nil
+}
+
+func (f Feed) RefreshStatus(ctx context.Context) (*RespFeedRefreshStatus, error) {
+	return newRespFeedRefreshStatus(pull.CurrentProgress()), nil
+}
+
+func newRespFeedRefreshStatus(p pull.Progress) *RespFeedRefreshStatus {
+	resp := &RespFeedRefreshStatus{
+		ID:         p.ID,
+		Running:    p.Running,
+		Total:      p.Total,
+		Completed:  p.Completed,
+		Fetched:    p.Fetched,
+		Failed:     p.Failed,
+		StartedAt:  p.StartedAt,
+		FinishedAt: p.FinishedAt,
+		NextRunAt:  p.NextRunAt,
+		Outage:     p.Outage,
+	}
+	if p.Running {
+		resp.Pending = p.Total - p.Completed
+	}
+	return resp
 }
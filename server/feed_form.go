@@ -3,15 +3,43 @@ package server
 import "time"
 
 type FeedForm struct {
-	ID          uint      `json:"id"`
-	Name        *string   `json:"name"`
-	Link        *string   `json:"link"`
-	Failure     *string   `json:"failure"`
-	Suspended   *bool     `json:"suspended"`
-	ReqProxy    *string   `json:"req_proxy"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	UnreadCount int       `json:"unread_count"`
-	Group       GroupForm `json:"group"`
+	ID              uint       `json:"id"`
+	Name            *string    `json:"name"`
+	Link            *string    `json:"link"`
+	SiteURL         *string    `json:"site_url"`
+	CanonicalLink   *string    `json:"canonical_link"`
+	Description     *string    `json:"description"`
+	Failure         *string    `json:"failure"`
+	Blocked         *bool      `json:"blocked"`
+	Suspended       *bool      `json:"suspended"`
+	MonitorOnly     *bool      `json:"monitor_only"`
+	RefreshInterval *int       `json:"refresh_interval"`
+	Sanitize        *string    `json:"sanitize"`
+	Weight          *int       `json:"weight"`
+	EmbedVideos     *bool      `json:"embed_videos"`
+	OrderByPosition *bool      `json:"order_by_position"`
+	DisplayMode     *string    `json:"display_mode"`
+	ReqProxy        *string    `json:"req_proxy"`
+	Notes           *string    `json:"notes"`
+	Color           *string    `json:"color"`
+	Emoji           *string    `json:"emoji"`
+	CaptureResponse *bool      `json:"capture_response"`
+	LastResponse    *string    `json:"last_response"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	LastBuild       *time.Time `json:"last_build"`
+	LastFetchedAt   *time.Time `json:"last_fetched_at"`
+	NextFetchAt     *time.Time `json:"next_fetch_at"`
+	LastItemAt      *time.Time `json:"last_item_at"`
+	UnreadCount     int        `json:"unread_count"`
+	// Group is nil for uncategorized feeds.
+	Group           *GroupForm `json:"group"`
+	RemovedFromOPML *bool      `json:"removed_from_opml"`
+	// DuplicateOf is another subscription to the same feed, see
+	// duplicateFeeds.
+	DuplicateOf *uint `json:"duplicate_of"`
+	// Settings are the ones the feed is fetched and shown with, its own or
+	// its group's, see model.Feed.Settings.
+	Settings *SettingsForm `json:"settings"`
 }
 
 type ReqFeedList struct {
@@ -34,7 +62,8 @@ type FeedRequestOptions struct {
 }
 
 type ReqFeedCheckValidity struct {
-	Link           string             `json:"link" validate:"required"`
+	// Link is a feed, a web page, or a fediverse account like @user@host.
+	Link           string             `json:"link" validate:"required,http_url|contains=@"`
 	RequestOptions FeedRequestOptions `json:"request_options"`
 }
 
@@ -49,11 +78,17 @@ type RespFeedCheckValidity struct {
 
 type ReqFeedCreate struct {
 	Feeds []struct {
-		Name           *string            `json:"name" validate:"required"`
-		Link           *string            `json:"link" validate:"required"`
+		Name           *string            `json:"name" validate:"required,min=1,max=200"`
+		Link           *string            `json:"link" validate:"required,http_url|startswith=acct:"`
+		SiteURL        *string            `json:"site_url"`
+		MonitorOnly    *bool              `json:"monitor_only"`
+		Notes          *string            `json:"notes" validate:"omitnil,max=10000"`
 		RequestOptions FeedRequestOptions `json:"request_options"`
-	} `json:"feeds" validate:"required"`
-	GroupID uint `json:"group_id" validate:"required"`
+	} `json:"feeds" validate:"required,min=1,dive"`
+	// GroupID is optional. If it's omitted, each feed is assigned by the group
+	// rules, falling back to the default group, or Uncategorized if there's
+	// none.
+	GroupID uint `json:"group_id"`
 }
 
 type RespFeedCreate struct {
@@ -61,19 +96,118 @@ type RespFeedCreate struct {
 }
 
 type ReqFeedUpdate struct {
-	ID        uint    `param:"id" validate:"required"`
-	Name      *string `json:"name"`
-	Link      *string `json:"link"`
-	Suspended *bool   `json:"suspended"`
-	ReqProxy  *string `json:"req_proxy"`
-	GroupID   *uint   `json:"group_id"`
+	ID              uint    `param:"id" validate:"required"`
+	Name            *string `json:"name" validate:"omitnil,min=1,max=200"`
+	Link            *string `json:"link" validate:"omitnil,http_url|startswith=acct:"`
+	Suspended       *bool   `json:"suspended"`
+	MonitorOnly     *bool   `json:"monitor_only"`
+	Weight          *int    `json:"weight"`
+	EmbedVideos     *bool   `json:"embed_videos"`
+	OrderByPosition *bool   `json:"order_by_position"`
+	ReqProxy        *string `json:"req_proxy"`
+	CaptureResponse *bool   `json:"capture_response"`
+	Notes           *string `json:"notes" validate:"omitnil,max=10000"`
+	// DisplayMode is removed when set to "", see model.Feed.
+	DisplayMode *string `json:"display_mode" validate:"omitempty,oneof=magazine cards gallery title_only"`
+	// Color and Emoji are removed when set to "", see model.Label.
+	Color *string `json:"color" validate:"omitempty,hexcolor"`
+	Emoji *string `json:"emoji" validate:"omitempty,max=16"`
+	// GroupID 0 moves the feed to Uncategorized.
+	GroupID *uint `json:"group_id"`
+}
+
+// ReqFeedUpdateSettings replaces all the settings the feed overrides. Nil
+// fields are left to its group.
+type ReqFeedUpdateSettings struct {
+	ID uint `param:"id" validate:"required"`
+	SettingsForm
 }
 
 type ReqFeedDelete struct {
 	ID uint `param:"id" validate:"required"`
 }
 
+type ReqFeedMerge struct {
+	ID uint `param:"id" validate:"required"`
+	// Into is the feed that gets the items and remains.
+	Into uint `json:"into" validate:"required"`
+}
+
+type ReqFeedDedupe struct {
+	ID uint `param:"id" validate:"required"`
+}
+
+type RespFeedDedupe struct {
+	// Removed is the number of duplicate items removed.
+	Removed int `json:"removed"`
+}
+
 type ReqFeedRefresh struct {
 	ID  *uint `json:"id"`
 	All *bool `json:"all"`
+	// Force pulls the feed with ID even if it was fetched recently or is
+	// cooling off after failures. Refreshing all feeds always forces.
+	Force *bool `json:"force"`
+}
+
+// RespFeedRefresh reports the outcome of refreshing a single feed.
+type RespFeedRefresh struct {
+	NewItems int `json:"new_items"`
+	// SkipReason is set if the feed wasn't fetched. It's one of "suspended",
+	// "virtual", "cooling_off" and "too_soon". Only the last two can be
+	// bypassed with Force.
+	SkipReason *string `json:"skip_reason"`
+	// NextFetchAt is when a skipped feed is due.
+	NextFetchAt *time.Time `json:"next_fetch_at"`
+	DurationMS  int64      `json:"duration_ms"`
+	// StatusCode is the HTTP status of the feed response, or 0 if there was
+	// none.
+	StatusCode int `json:"status_code"`
+	// Failure is the error of fetching the feed, if any.
+	Failure *string `json:"failure"`
+}
+
+// RespFeedRefreshStatus reports the progress of the latest refresh of all
+// feeds and the schedule of the next one, see pull.Progress.
+type RespFeedRefreshStatus struct {
+	ID        uint `json:"id"`
+	Running   bool `json:"running"`
+	Total     int  `json:"total"`
+	Completed int  `json:"completed"`
+	Fetched   int  `json:"fetched"`
+	Failed    int  `json:"failed"`
+	// Pending is the number of feeds the running refresh has yet to pull.
+	Pending    int        `json:"pending"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	NextRunAt  *time.Time `json:"next_run_at"`
+	// Outage is set when the last finished refresh failed to fetch most
+	// feeds, which likely comes from the network rather than the feeds.
+	Outage bool `json:"outage"`
+}
+
+type ReqFeedBulkCreate struct {
+	// Links is a newline-separated list of feed or website links.
+	Links string `json:"links" validate:"required"`
+	// GroupID is optional, see ReqFeedCreate.
+	GroupID uint `json:"group_id"`
+}
+
+const (
+	BulkCreateStatusCreated   = "created"
+	BulkCreateStatusDuplicate = "duplicate"
+	BulkCreateStatusInvalid   = "invalid"
+)
+
+type BulkCreateResult struct {
+	Link string `json:"link"`
+	// FeedLink is the discovered feed link, which may differ from Link.
+	FeedLink string `json:"feed_link,omitempty"`
+	Status   string `json:"status"`
+	FeedID   uint   `json:"feed_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type RespFeedBulkCreate struct {
+	Results []*BulkCreateResult `json:"results"`
 }
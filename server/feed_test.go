@@ -0,0 +1,324 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/server"
+	"github.com/0x2e/fusion/service/job"
+	"github.com/0x2e/fusion/service/pull"
+	"github.com/0x2e/fusion/service/pull/client"
+
+	"gorm.io/gorm"
+)
+
+// mockPuller records the pulls it's asked for. pulled receives the feed ID
+// of each PullOne call, and 0 for PullAll calls. force records the force
+// argument of every call.
+type mockPuller struct {
+	result pull.PullResult
+	err    error
+	pulled chan uint
+
+	mu    sync.Mutex
+	force []bool
+}
+
+func newMockPuller(err error) *mockPuller {
+	return &mockPuller{
+		err:    err,
+		pulled: make(chan uint, 10),
+	}
+}
+
+func (m *mockPuller) PullOne(ctx context.Context, id uint, force bool) (pull.PullResult, error) {
+	m.mu.Lock()
+	m.force = append(m.force, force)
+	m.mu.Unlock()
+	m.pulled <- id
+	return m.result, m.err
+}
+
+func (m *mockPuller) PullAll(ctx context.Context, force bool) error {
+	m.mu.Lock()
+	m.force = append(m.force, force)
+	m.mu.Unlock()
+	m.pulled <- 0
+	return m.err
+}
+
+// waitPulled returns the IDs of the next n pulls, in any order.
+func (m *mockPuller) waitPulled(t *testing.T, n int) []uint {
+	t.Helper()
+
+	ids := make([]uint, 0, n)
+	for range n {
+		select {
+		case id := <-m.pulled:
+			ids = append(ids, id)
+		case <-time.After(time.Second):
+			require.Fail(t, "timed out waiting for pulls", "got %v, want %d", ids, n)
+		}
+	}
+	return ids
+}
+
+func newFeedService(t *testing.T, db *gorm.DB, puller server.FeedPuller) *server.Feed {
+	t.Helper()
+
+	return server.NewFeed(repo.NewFeed(db), repo.NewGroup(db), repo.NewItem(db), puller, job.NewQueue(repo.NewJob(db)), client.NewFeedClient(""))
+}
+
+// newReqFeedCreate builds a request to subscribe to links the way the API
+// decodes it.
+func newReqFeedCreate(t *testing.T, links ...string) *server.ReqFeedCreate {
+	t.Helper()
+
+	feeds := make([]map[string]string, 0, len(links))
+	for _, link := range links {
+		feeds = append(feeds, map[string]string{"name": link, "link": link})
+	}
+	body, err := json.Marshal(map[string]any{"feeds": feeds})
+	require.NoError(t, err)
+	var req server.ReqFeedCreate
+	require.NoError(t, json.Unmarshal(body, &req))
+	return &req
+}
+
+func TestFeedCreatePullsSingleFeedRightAway(t *testing.T) {
+	errPull := errors.New("dummy pull error")
+	puller := newMockPuller(errPull)
+	feedService := newFeedService(t, repotest.NewDB(t), puller)
+
+	resp, err := feedService.Create(context.Background(), newReqFeedCreate(t, "https://example.com/feed"))
+
+	assert.ErrorIs(t, err, errPull, "the pull error must be returned")
+	require.NotNil(t, resp)
+	require.Len(t, resp.IDs, 1)
+	select {
+	case id := <-puller.pulled:
+		assert.Equal(t, resp.IDs[0], id)
+	default:
+		assert.Fail(t, "the feed must be pulled before Create returns")
+	}
+}
+
+func TestFeedCreateQueuesPulls(t *testing.T) {
+	db := repotest.NewDB(t)
+	puller := newMockPuller(nil)
+	feedService := newFeedService(t, db, puller)
+
+	resp, err := feedService.Create(context.Background(), newReqFeedCreate(t,
+		"https://example.com/feed",
+		"https://example.org/feed",
+	))
+
+	require.NoError(t, err)
+	require.Len(t, resp.IDs, 2)
+	jobs, _, err := repo.NewJob(db).List(repo.JobFilter{}, 1, 10)
+	require.NoError(t, err)
+	queued := make([]uint, 0, len(jobs))
+	for _, v := range jobs {
+		assert.Equal(t, pull.JobPullFeed, v.Kind)
+		var payload pull.PullFeedJob
+		require.NoError(t, json.Unmarshal([]byte(v.Payload), &payload))
+		queued = append(queued, payload.FeedID)
+	}
+	assert.ElementsMatch(t, resp.IDs, queued, "each feed must be pulled by a job")
+	assert.Empty(t, puller.pulled, "the feeds must not be pulled by Create")
+}
+
+func TestFeedRefresh(t *testing.T) {
+	for _, tt := range []struct {
+		description   string
+		req           server.ReqFeedRefresh
+		pullResult    pull.PullResult
+		expectedResp  *server.RespFeedRefresh
+		expectedPulls []uint
+		expectedForce []bool
+	}{
+		{
+			description: "refreshing a feed reports its new items",
+			req:         server.ReqFeedRefresh{ID: ptr.To(uint(42))},
+			pullResult: pull.PullResult{
+				NewItems:   12,
+				Duration:   1500 * time.Millisecond,
+				StatusCode: http.StatusOK,
+			},
+			expectedResp: &server.RespFeedRefresh{
+				NewItems:   12,
+				DurationMS: 1500,
+				StatusCode: http.StatusOK,
+			},
+			expectedPulls: []uint{42},
+			expectedForce: []bool{false},
+		},
+		{
+			description: "refreshing a feed reports why it was skipped",
+			req:         server.ReqFeedRefresh{ID: ptr.To(uint(42))},
+			pullResult: pull.PullResult{
+				SkipReason:  &pull.SkipReasonTooSoon,
+				NextFetchAt: ptr.To(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)),
+			},
+			expectedResp: &server.RespFeedRefresh{
+				SkipReason:  ptr.To("too_soon"),
+				NextFetchAt: ptr.To(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)),
+			},
+			expectedPulls: []uint{42},
+			expectedForce: []bool{false},
+		},
+		{
+			description:   "refreshing a feed with force bypasses its schedule",
+			req:           server.ReqFeedRefresh{ID: ptr.To(uint(42)), Force: ptr.To(true)},
+			expectedResp:  &server.RespFeedRefresh{},
+			expectedPulls: []uint{42},
+			expectedForce: []bool{true},
+		},
+		{
+			description: "refreshing a feed reports fetch errors",
+			req:         server.ReqFeedRefresh{ID: ptr.To(uint(42))},
+			pullResult: pull.PullResult{
+				StatusCode: http.StatusInternalServerError,
+				FetchErr:   errors.New("got status code 500"),
+			},
+			expectedResp: &server.RespFeedRefresh{
+				StatusCode: http.StatusInternalServerError,
+				Failure:    ptr.To("got status code 500"),
+			},
+			expectedPulls: []uint{42},
+			expectedForce: []bool{false},
+		},
+		{
+			description:   "refreshing all feeds forces pulling them",
+			req:           server.ReqFeedRefresh{All: ptr.To(true)},
+			expectedPulls: []uint{0},
+			expectedForce: []bool{true},
+		},
+		{
+			description:   "empty request pulls nothing",
+			req:           server.ReqFeedRefresh{},
+			expectedPulls: []uint{},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			puller := newMockPuller(nil)
+			puller.result = tt.pullResult
+			feedService := newFeedService(t, repotest.NewDB(t), puller)
+
+			resp, err := feedService.Refresh(context.Background(), &tt.req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedResp, resp)
+			assert.Equal(t, tt.expectedPulls, puller.waitPulled(t, len(tt.expectedPulls)))
+			puller.mu.Lock()
+			defer puller.mu.Unlock()
+			assert.Equal(t, tt.expectedForce, puller.force)
+		})
+	}
+}
+
+func TestFeedDedupe(t *testing.T) {
+	db := repotest.NewDB(t)
+	feedService := newFeedService(t, db, newMockPuller(nil))
+	feed := &model.Feed{Name: ptr.To("Feed"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}
+	require.NoError(t, repo.NewFeed(db).Create([]*model.Feed{feed}))
+	for _, guid := range []string{"old", "new"} {
+		require.NoError(t, db.Create(&model.Item{
+			GUID:   ptr.To(guid),
+			Link:   ptr.To("https://example.com/post"),
+			Title:  ptr.To("Post"),
+			FeedID: feed.ID,
+		}).Error)
+	}
+
+	resp, err := feedService.Dedupe(context.Background(), &server.ReqFeedDedupe{ID: feed.ID})
+	require.NoError(t, err)
+	assert.Equal(t, &server.RespFeedDedupe{Removed: 1}, resp)
+
+	_, err = feedService.Dedupe(context.Background(), &server.ReqFeedDedupe{ID: 999})
+	assert.ErrorIs(t, err, repo.ErrNotFound)
+}
+
+func TestFeedListFlagsDuplicates(t *testing.T) {
+	db := repotest.NewDB(t)
+	feeds := []*model.Feed{
+		{Name: ptr.To("Old"), Link: ptr.To("http://www.example.com/feed/"), GroupID: ptr.To(uint(1))},
+		{Name: ptr.To("Redirected"), Link: ptr.To("https://example.org/rss"), GroupID: ptr.To(uint(1)),
+			CanonicalLink: ptr.To("https://example.com/feed")},
+		{Name: ptr.To("Other"), Link: ptr.To("https://example.net/feed"), GroupID: ptr.To(uint(1))},
+	}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+
+	resp, err := newFeedService(t, db, newMockPuller(nil)).List(context.Background(), &server.ReqFeedList{})
+
+	require.NoError(t, err)
+	duplicates := make(map[uint]*uint)
+	for _, f := range resp.Feeds {
+		duplicates[f.ID] = f.DuplicateOf
+	}
+	assert.Equal(t, map[uint]*uint{
+		feeds[0].ID: ptr.To(feeds[1].ID),
+		feeds[1].ID: ptr.To(feeds[0].ID),
+		feeds[2].ID: nil,
+	}, duplicates)
+}
+
+func TestFeedUpdateNotes(t *testing.T) {
+	db := repotest.NewDB(t)
+	feedService := newFeedService(t, db, newMockPuller(nil))
+	feed := &model.Feed{Name: ptr.To("Feed"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}
+	require.NoError(t, repo.NewFeed(db).Create([]*model.Feed{feed}))
+
+	for _, notes := range []string{"Recommended by a friend", ""} {
+		require.NoError(t, feedService.Update(context.Background(), &server.ReqFeedUpdate{ID: feed.ID, Notes: ptr.To(notes)}))
+
+		resp, err := feedService.Get(context.Background(), &server.ReqFeedGet{ID: feed.ID})
+		require.NoError(t, err)
+		assert.Equal(t, notes, ptr.From(resp.Notes))
+	}
+}
+
+func TestFeedUpdateDisplayMode(t *testing.T) {
+	db := repotest.NewDB(t)
+	feedService := newFeedService(t, db, newMockPuller(nil))
+	feed := &model.Feed{Name: ptr.To("Photos"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}
+	require.NoError(t, repo.NewFeed(db).Create([]*model.Feed{feed}))
+
+	for _, mode := range []string{model.DisplayModeGallery, ""} {
+		require.NoError(t, feedService.Update(context.Background(), &server.ReqFeedUpdate{ID: feed.ID, DisplayMode: ptr.To(mode)}))
+
+		resp, err := feedService.Get(context.Background(), &server.ReqFeedGet{ID: feed.ID})
+		require.NoError(t, err)
+		assert.Equal(t, mode, ptr.From(resp.DisplayMode))
+	}
+}
+
+func TestFeedUpdateUngroups(t *testing.T) {
+	db := repotest.NewDB(t)
+	feedService := newFeedService(t, db, newMockPuller(nil))
+	feed := &model.Feed{Name: ptr.To("Feed"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}
+	require.NoError(t, repo.NewFeed(db).Create([]*model.Feed{feed}))
+
+	require.NoError(t, feedService.Update(context.Background(), &server.ReqFeedUpdate{ID: feed.ID, GroupID: ptr.To(uint(0))}))
+	resp, err := feedService.Get(context.Background(), &server.ReqFeedGet{ID: feed.ID})
+	require.NoError(t, err)
+	assert.Nil(t, resp.Group)
+
+	require.NoError(t, feedService.Update(context.Background(), &server.ReqFeedUpdate{ID: feed.ID, GroupID: ptr.To(uint(1))}))
+	resp, err = feedService.Get(context.Background(), &server.ReqFeedGet{ID: feed.ID})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Group)
+	assert.EqualValues(t, 1, resp.Group.ID)
+}
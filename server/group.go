@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
 
 	"github.com/0x2e/fusion/model"
 	"github.com/0x2e/fusion/repo"
@@ -11,9 +14,15 @@ import (
 
 type GroupRepo interface {
 	All() ([]*model.Group, error)
+	Get(id uint) (*model.Group, error)
 	Create(group *model.Group) error
 	Update(id uint, group *model.Group) error
-	Delete(id uint) error
+	UpdateSettings(id uint, settings model.Settings) error
+	Delete(id uint, moveTo uint) error
+	GetDefault() (*model.Group, error)
+	ListRules() ([]*model.GroupRule, error)
+	CreateRule(rule *model.GroupRule) error
+	DeleteRule(id uint) error
 }
 
 type Group struct {
@@ -35,8 +44,13 @@ func (g Group) All(ctx context.Context) (*RespGroupAll, error) {
 	groups := make([]*GroupForm, 0, len(data))
 	for _, v := range data {
 		groups = append(groups, &GroupForm{
-			ID:   v.ID,
-			Name: v.Name,
+			ID:        v.ID,
+			Name:      v.Name,
+			IsDefault: v.IsDefault,
+			Public:    v.Public,
+			Color:     v.Color,
+			Emoji:     v.Emoji,
+			Settings:  newSettingsForm(v.Settings),
 		})
 	}
 	return &RespGroupAll{
@@ -51,7 +65,7 @@ func (g Group) Create(ctx context.Context, req *ReqGroupCreate) (*RespGroupCreat
 	err := g.repo.Create(newGroup)
 	if err != nil {
 		if errors.Is(err, repo.ErrDuplicatedKey) {
-			err = NewBizError(err, http.StatusBadRequest, "name is not allowed to be the same as other groups")
+			err = NewFieldError(err, "name", "name is not allowed to be the same as other groups")
 		}
 		return nil, err
 	}
@@ -60,17 +74,160 @@ func (g Group) Create(ctx context.Context, req *ReqGroupCreate) (*RespGroupCreat
 
 func (g Group) Update(ctx context.Context, req *ReqGroupUpdate) error {
 	err := g.repo.Update(req.ID, &model.Group{
-		Name: req.Name,
+		Name:      req.Name,
+		IsDefault: req.IsDefault,
+		Public:    req.Public,
+		Label:     model.Label{Color: req.Color, Emoji: req.Emoji},
 	})
 	if errors.Is(err, repo.ErrDuplicatedKey) {
-		err = NewBizError(err, http.StatusBadRequest, "name is not allowed to be the same as other groups")
+		err = NewFieldError(err, "name", "name is not allowed to be the same as other groups")
+	}
+	return err
+}
+
+func (g Group) UpdateSettings(ctx context.Context, req *ReqGroupUpdateSettings) error {
+	err := g.repo.UpdateSettings(req.ID, req.SettingsForm.settings())
+	if errors.Is(err, repo.ErrNotFound) {
+		err = NewBizError(err, http.StatusNotFound, "group not found")
 	}
 	return err
 }
 
 func (g Group) Delete(ctx context.Context, req *ReqGroupDelete) error {
-	if req.ID == 1 {
-		return errors.New("cannot delete the default group")
+	defaultGroup, err := g.repo.GetDefault()
+	hasDefault := err == nil
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return err
+	}
+	if hasDefault && defaultGroup.ID == req.ID {
+		msg := "cannot delete the default group"
+		return NewBizError(errors.New(msg), http.StatusBadRequest, msg)
+	}
+
+	// feeds are moved to the default group unless specified
+	var moveTo uint
+	switch {
+	case req.MoveTo != nil:
+		moveTo = *req.MoveTo
+	case hasDefault:
+		moveTo = defaultGroup.ID
+	default:
+		msg := "there is no default group to move the feeds to"
+		return NewFieldError(errors.New(msg), "move_to", msg)
+	}
+	if moveTo == req.ID {
+		msg := "cannot move feeds to the group being deleted"
+		return NewBizError(errors.New(msg), http.StatusBadRequest, msg)
+	}
+
+	err = g.repo.Delete(req.ID, moveTo)
+	if errors.Is(err, repo.ErrNotFound) {
+		err = NewBizError(err, http.StatusNotFound, "group not found")
+	}
+	return err
+}
+
+func (g Group) AllRules(ctx context.Context) (*RespGroupRuleAll, error) {
+	data, err := g.repo.ListRules()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*GroupRuleForm, 0, len(data))
+	for _, v := range data {
+		rules = append(rules, &GroupRuleForm{
+			ID:      v.ID,
+			Pattern: v.Pattern,
+			Group:   GroupForm{ID: v.GroupID, Name: v.Group.Name},
+		})
+	}
+	return &RespGroupRuleAll{
+		Rules: rules,
+	}, nil
+}
+
+func (g Group) CreateRule(ctx context.Context, req *ReqGroupRuleCreate) (*RespGroupRuleCreate, error) {
+	pattern := strings.ToLower(strings.TrimSpace(*req.Pattern))
+	if _, err := path.Match(pattern, ""); pattern == "" || err != nil {
+		msg := "invalid domain pattern"
+		return nil, NewFieldError(errors.New(msg), "pattern", msg)
+	}
+	if err := checkGroupExists(g.repo, req.GroupID, "group_id"); err != nil {
+		return nil, err
+	}
+
+	rule := &model.GroupRule{
+		Pattern: &pattern,
+		GroupID: req.GroupID,
+	}
+	if err := g.repo.CreateRule(rule); err != nil {
+		if errors.Is(err, repo.ErrDuplicatedKey) {
+			err = NewFieldError(err, "pattern", "a rule with the same pattern already exists")
+		}
+		return nil, err
+	}
+	return &RespGroupRuleCreate{ID: rule.ID}, nil
+}
+
+func (g Group) DeleteRule(ctx context.Context, req *ReqGroupRuleDelete) error {
+	return g.repo.DeleteRule(req.ID)
+}
+
+func newSettingsForm(s model.Settings) *SettingsForm {
+	return &SettingsForm{
+		ReqProxy:        s.ReqProxy,
+		RefreshInterval: s.RefreshInterval,
+		Sanitize:        s.Sanitize,
+		MonitorOnly:     s.MonitorOnly,
+	}
+}
+
+// settings returns the model.Settings of the form. An empty ReqProxy is nil,
+// as model.Feed.Settings takes it for unset.
+func (f SettingsForm) settings() model.Settings {
+	s := model.Settings{
+		ReqProxy:        f.ReqProxy,
+		RefreshInterval: f.RefreshInterval,
+		Sanitize:        f.Sanitize,
+		MonitorOnly:     f.MonitorOnly,
+	}
+	if s.ReqProxy != nil && *s.ReqProxy == "" {
+		s.ReqProxy = nil
+	}
+	return s
+}
+
+// groupGetter is implemented by the repos that can look up a group.
+type groupGetter interface {
+	Get(id uint) (*model.Group, error)
+}
+
+// checkGroupExists returns a field error about field if there is no group
+// with the given ID.
+func checkGroupExists(groupRepo groupGetter, id uint, field string) error {
+	_, err := groupRepo.Get(id)
+	if errors.Is(err, repo.ErrNotFound) {
+		return NewFieldError(err, field, "group does not exist")
+	}
+	return err
+}
+
+// matchGroupRule returns the group of the first rule matching the host of
+// link.
+func matchGroupRule(rules []*model.GroupRule, link string) (uint, bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return 0, false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, r := range rules {
+		pattern := *r.Pattern
+		if matched, _ := path.Match(pattern, host); matched {
+			return r.GroupID, true
+		}
+		if host == pattern || strings.HasSuffix(host, "."+pattern) {
+			return r.GroupID, true
+		}
 	}
-	return g.repo.Delete(req.ID)
+	return 0, false
 }
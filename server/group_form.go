@@ -1,8 +1,25 @@
 package server
 
 type GroupForm struct {
-	ID   uint    `json:"id"`
-	Name *string `json:"name"`
+	ID        uint    `json:"id"`
+	Name      *string `json:"name"`
+	IsDefault *bool   `json:"is_default,omitempty"`
+	Public    *bool   `json:"public,omitempty"`
+	Color     *string `json:"color,omitempty"`
+	Emoji     *string `json:"emoji,omitempty"`
+	// Settings is nil in the forms that only name the group.
+	Settings *SettingsForm `json:"settings,omitempty"`
+}
+
+// SettingsForm is how feeds are fetched and shown, see model.Settings. Nil
+// fields are left to the defaults for groups, and to the group for feeds.
+type SettingsForm struct {
+	ReqProxy *string `json:"req_proxy"`
+	// RefreshInterval is in minutes. Feeds aren't fetched more often than
+	// every 30 minutes.
+	RefreshInterval *int    `json:"refresh_interval" validate:"omitnil,min=30,max=10080"`
+	Sanitize        *string `json:"sanitize" validate:"omitnil,oneof=standard strict"`
+	MonitorOnly     *bool   `json:"monitor_only"`
 }
 
 type RespGroupAll struct {
@@ -10,7 +27,7 @@ type RespGroupAll struct {
 }
 
 type ReqGroupCreate struct {
-	Name *string `json:"name" validate:"required"`
+	Name *string `json:"name" validate:"required,min=1,max=100"`
 }
 
 type RespGroupCreate struct {
@@ -18,10 +35,47 @@ type RespGroupCreate struct {
 }
 
 type ReqGroupUpdate struct {
-	ID   uint    `param:"id" validate:"required"`
-	Name *string `json:"name" validate:"required"`
+	ID        uint    `param:"id" validate:"required"`
+	Name      *string `json:"name" validate:"required,min=1,max=100"`
+	IsDefault *bool   `json:"is_default"`
+	Public    *bool   `json:"public"`
+	// Color and Emoji are removed when set to "", see model.Label.
+	Color *string `json:"color" validate:"omitempty,hexcolor"`
+	Emoji *string `json:"emoji" validate:"omitempty,max=16"`
+}
+
+// ReqGroupUpdateSettings replaces all the settings of the group.
+type ReqGroupUpdateSettings struct {
+	ID uint `param:"id" validate:"required"`
+	SettingsForm
 }
 
 type ReqGroupDelete struct {
 	ID uint `param:"id" validate:"required"`
+	// MoveTo is the group that receives the feeds of the deleted group.
+	// Defaults to the default group.
+	MoveTo *uint `query:"move_to"`
+}
+
+type GroupRuleForm struct {
+	ID      uint      `json:"id"`
+	Pattern *string   `json:"pattern"`
+	Group   GroupForm `json:"group"`
+}
+
+type RespGroupRuleAll struct {
+	Rules []*GroupRuleForm `json:"rules"`
+}
+
+type ReqGroupRuleCreate struct {
+	Pattern *string `json:"pattern" validate:"required"`
+	GroupID uint    `json:"group_id" validate:"required"`
+}
+
+type RespGroupRuleCreate struct {
+	ID uint `json:"id"`
+}
+
+type ReqGroupRuleDelete struct {
+	ID uint `param:"id" validate:"required"`
 }
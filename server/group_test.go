@@ -0,0 +1,43 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/server"
+)
+
+func TestGroupDelete(t *testing.T) {
+	db := repotest.NewDB(t)
+	groupRepo := repo.NewGroup(db)
+	groups := server.NewGroup(groupRepo)
+	ctx := context.Background()
+
+	var bizErr server.BizError
+	err := groups.Delete(ctx, &server.ReqGroupDelete{ID: 1})
+	require.ErrorAs(t, err, &bizErr, "without a default group, feeds need somewhere to go")
+	assert.EqualValues(t, http.StatusBadRequest, bizErr.HTTPCode)
+
+	news := &model.Group{Name: ptr.To("News")}
+	require.NoError(t, groupRepo.Create(news))
+	require.NoError(t, groupRepo.Update(news.ID, &model.Group{IsDefault: ptr.To(true)}))
+	feed := &model.Feed{Name: ptr.To("Feed"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}
+	require.NoError(t, repo.NewFeed(db).Create([]*model.Feed{feed}))
+
+	err = groups.Delete(ctx, &server.ReqGroupDelete{ID: news.ID})
+	require.ErrorAs(t, err, &bizErr)
+	assert.EqualValues(t, http.StatusBadRequest, bizErr.HTTPCode, "the default group must not be deleted")
+
+	require.NoError(t, groups.Delete(ctx, &server.ReqGroupDelete{ID: 1}))
+	moved, err := repo.NewFeed(db).Get(feed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, news.ID, ptr.From(moved.GroupID), "feeds must move to the default group")
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+
+	"github.com/0x2e/fusion/repo"
+)
+
+type IntegrityRepo interface {
+	Check(repair bool) (*repo.IntegrityReport, error)
+}
+
+// Integrity checks the database for rows referencing rows that are gone, on
+// demand. They're also repaired daily, see the integrity service.
+type Integrity struct {
+	repo IntegrityRepo
+}
+
+func NewIntegrity(repo IntegrityRepo) *Integrity {
+	return &Integrity{
+		repo: repo,
+	}
+}
+
+// Check reports what Repair would fix, without changing anything.
+func (i Integrity) Check(ctx context.Context) (*RespIntegrity, error) {
+	return i.run(false)
+}
+
+func (i Integrity) Repair(ctx context.Context) (*RespIntegrity, error) {
+	return i.run(true)
+}
+
+func (i Integrity) run(repair bool) (*RespIntegrity, error) {
+	report, err := i.repo.Check(repair)
+	if err != nil {
+		return nil, err
+	}
+	return &RespIntegrity{
+		OrphanedItems:                 report.OrphanedItems,
+		FeedsWithoutGroup:             report.FeedsWithoutGroup,
+		GroupRulesWithoutGroup:        report.GroupRulesWithoutGroup,
+		OPMLSubscriptionsWithoutGroup: report.OPMLSubscriptionsWithoutGroup,
+		FeedsWithoutOPMLSubscription:  report.FeedsWithoutOPMLSubscription,
+		Repaired:                      repair,
+	}, nil
+}
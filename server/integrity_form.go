@@ -0,0 +1,13 @@
+package server
+
+// RespIntegrity counts the rows referencing rows that are gone, see
+// repo.IntegrityReport.
+type RespIntegrity struct {
+	OrphanedItems                 int `json:"orphaned_items"`
+	FeedsWithoutGroup             int `json:"feeds_without_group"`
+	GroupRulesWithoutGroup        int `json:"group_rules_without_group"`
+	OPMLSubscriptionsWithoutGroup int `json:"opml_subscriptions_without_group"`
+	FeedsWithoutOPMLSubscription  int `json:"feeds_without_opml_subscription"`
+	// Repaired is whether the rows counted were fixed.
+	Repaired bool `json:"repaired"`
+}
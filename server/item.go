@@ -1,28 +1,159 @@
 package server
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
 
 	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
 	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/service/score"
 )
 
 type ItemRepo interface {
 	List(filter repo.ItemFilter, page, pageSize int) ([]*model.Item, int, error)
+	Sample(filter repo.ItemFilter, n int) ([]*model.Item, error)
 	Get(id uint) (*model.Item, error)
-	Delete(id uint) error
-	UpdateUnread(ids []uint, unread *bool) error
-	UpdateBookmark(id uint, bookmark *bool) error
+	Delete(ids ...uint) error
+	UpdateHidden(ids []uint, hidden bool) (int, error)
+	UpdateUnread(ids []uint, unread *bool) (int, error)
+	UpdateBookmark(ids []uint, bookmark *bool) (int, error)
+	UpdateBookmarkByFilter(filter repo.ItemFilter, bookmark *bool) (int, error)
+	UpdatePlayback(id uint, position *int, listened *bool) error
+	UnreadCounts(feedIDs []uint) (map[uint]int, error)
+	FeedIDs(itemIDs []uint) ([]uint, error)
+}
+
+type ScoreKeywordLister interface {
+	All() ([]*model.ScoreKeyword, error)
 }
 
 type Item struct {
-	repo ItemRepo
+	repo        ItemRepo
+	keywordRepo ScoreKeywordLister
+	// defaultPageSize is used when a list request doesn't specify a page size.
+	defaultPageSize int
 }
 
-func NewItem(repo ItemRepo) *Item {
+func NewItem(repo ItemRepo, keywordRepo ScoreKeywordLister, defaultPageSize int) *Item {
 	return &Item{
-		repo: repo,
+		repo:            repo,
+		keywordRepo:     keywordRepo,
+		defaultPageSize: defaultPageSize,
+	}
+}
+
+// highlightsWindow is the number of latest unread items that are scored for
+// the Highlights view.
+const highlightsWindow = 500
+
+// Highlights returns the top-scored unread items.
+func (i Item) Highlights(ctx context.Context, req *ReqItemHighlights) (*RespItemList, error) {
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+	keywords, err := i.keywordRepo.All()
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := i.repo.List(repo.ItemFilter{Unread: ptr.To(true)}, 1, highlightsWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	items := make([]*ItemForm, 0, len(data))
+	for _, v := range data {
+		s := score.Score(v, keywords, now)
+		if s <= 0 {
+			continue
+		}
+		item := newItemRow(v)
+		item.Score = &s
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(a, b int) bool {
+		return *items[a].Score > *items[b].Score
+	})
+	if len(items) > req.Limit {
+		items = items[:req.Limit]
 	}
+
+	total := len(items)
+	return &RespItemList{
+		Total:    &total,
+		PageSize: req.Limit,
+		Items:    items,
+	}, nil
+}
+
+// surpriseMinAge is how old items must be to be picked by Surprise, so that
+// it digs into the backlog rather than showing what's new.
+const surpriseMinAge = 7 * 24 * time.Hour
+
+// Surprise returns a random sample of the older unread or bookmarked items.
+func (i Item) Surprise(ctx context.Context, req *ReqItemSurprise) (*RespItemList, error) {
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+	data, err := i.repo.Sample(repo.ItemFilter{
+		Backlog:         true,
+		PublishedBefore: ptr.To(time.Now().Add(-surpriseMinAge)),
+	}, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*ItemForm, 0, len(data))
+	for _, v := range data {
+		items = append(items, newItemRow(v))
+	}
+	total := len(items)
+	return &RespItemList{
+		Total:    &total,
+		PageSize: req.Limit,
+		Items:    items,
+	}, nil
+}
+
+// digestMaxItems caps the number of items in a digest, which includes their
+// content.
+const digestMaxItems = 300
+
+// Digest returns the unread items fetched since req.Since with their content,
+// grouped by feed.
+func (i Item) Digest(ctx context.Context, req *ReqItemDigest) (*RespItemDigest, error) {
+	data, total, err := i.repo.List(repo.ItemFilter{
+		Unread:       ptr.To(true),
+		CreatedAfter: &req.Since,
+	}, 1, digestMaxItems)
+	if err != nil {
+		return nil, err
+	}
+
+	feeds := make([]*DigestFeed, 0)
+	byFeed := make(map[uint]*DigestFeed)
+	for _, v := range data {
+		f, ok := byFeed[v.FeedID]
+		if !ok {
+			f = &DigestFeed{
+				Feed: newItemFeed(v.Feed),
+			}
+			byFeed[v.FeedID] = f
+			feeds = append(feeds, f)
+		}
+		item := newItemRow(v)
+		item.Content = v.Content
+		f.Items = append(f.Items, item)
+	}
+	return &RespItemDigest{
+		Feeds:     feeds,
+		Truncated: total > len(data),
+	}, nil
 }
 
 func (i Item) List(ctx context.Context, req *ReqItemList) (*RespItemList, error) {
@@ -32,12 +163,19 @@ func (i Item) List(ctx context.Context, req *ReqItemList) (*RespItemList, error)
 		GroupID:  req.GroupID,
 		Unread:   req.Unread,
 		Bookmark: req.Bookmark,
+		Language: req.Language,
+		Media:    req.Media,
+
+		PublishedAfter: req.PublishedAfter,
+	}
+	if req.MaxReadingTime != nil {
+		filter.MaxWordCount = ptr.To(*req.MaxReadingTime * wordsPerMinute)
 	}
 	if req.Page == 0 {
 		req.Page = 1
 	}
 	if req.PageSize == 0 {
-		req.PageSize = 10
+		req.PageSize = i.defaultPageSize
 	}
 	data, total, err := i.repo.List(filter, req.Page, req.PageSize)
 	if err != nil {
@@ -46,28 +184,79 @@ func (i Item) List(ctx context.Context, req *ReqItemList) (*RespItemList, error)
 
 	items := make([]*ItemForm, 0, len(data))
 	for _, v := range data {
-		items = append(items, &ItemForm{
-			ID:        v.ID,
-			GUID:      v.GUID,
-			Title:     v.Title,
-			Link:      v.Link,
-			Unread:    v.Unread,
-			Bookmark:  v.Bookmark,
-			PubDate:   v.PubDate,
-			UpdatedAt: &v.UpdatedAt,
-			Feed: ItemFeed{
-				ID:   v.Feed.ID,
-				Name: v.Feed.Name,
-				Link: v.Feed.Link,
-			},
-		})
+		items = append(items, newItemRow(v))
 	}
 	return &RespItemList{
-		Total: &total,
-		Items: items,
+		Total:    &total,
+		PageSize: req.PageSize,
+		Items:    items,
 	}, nil
 }
 
+// wordsPerMinute is the reading speed used to estimate reading time.
+const wordsPerMinute = 200
+
+// readingTime estimates the reading time of wordCount words in minutes,
+// rounding up so that any content takes at least a minute.
+func readingTime(wordCount *int) *int {
+	if wordCount == nil {
+		return nil
+	}
+	return ptr.To(max(1, (*wordCount+wordsPerMinute-1)/wordsPerMinute))
+}
+
+// newItemRow converts an item to the form used in item lists, which omits the
+// content.
+func newItemRow(v *model.Item) *ItemForm {
+	return &ItemForm{
+		ID:          v.ID,
+		GUID:        v.GUID,
+		Title:       v.Title,
+		Link:        v.Link,
+		Snippet:     v.Snippet,
+		Unread:      v.Unread,
+		Bookmark:    v.Bookmark,
+		Hidden:      v.Hidden,
+		PubDate:     v.PubDate,
+		UpdatedAt:   &v.UpdatedAt,
+		Language:    v.Language,
+		ReadingTime: readingTime(v.WordCount),
+		WordCount:   v.WordCount,
+		HasVideo:    v.HasVideo,
+		HasAudio:    v.HasAudio,
+		HasGallery:  v.HasGallery,
+		ImageURL:    v.ImageURL,
+		Feed:        newItemFeed(v.Feed),
+		Enclosure:   newEnclosureForm(v),
+	}
+}
+
+func newItemFeed(feed model.Feed) ItemFeed {
+	return ItemFeed{
+		ID:          feed.ID,
+		Name:        feed.Name,
+		Link:        feed.Link,
+		SiteURL:     feed.SiteURL,
+		EmbedVideos: feed.EmbedsVideos(),
+		DisplayMode: ptr.From(feed.DisplayMode),
+		Sanitize:    cmp.Or(ptr.From(feed.Settings().Sanitize), model.SanitizeStandard),
+		Color:       feed.Color,
+		Emoji:       feed.Emoji,
+	}
+}
+
+func newEnclosureForm(v *model.Item) *EnclosureForm {
+	if v.EnclosureURL == nil {
+		return nil
+	}
+	return &EnclosureForm{
+		URL:              *v.EnclosureURL,
+		Type:             ptr.From(v.EnclosureType),
+		PlaybackPosition: v.PlaybackPosition,
+		Listened:         ptr.From(v.Listened),
+	}
+}
+
 func (i Item) Get(ctx context.Context, req *ReqItemGet) (*RespItemGet, error) {
 	data, err := i.repo.Get(req.ID)
 	if err != nil {
@@ -75,31 +264,184 @@ func (i Item) Get(ctx context.Context, req *ReqItemGet) (*RespItemGet, error) {
 	}
 
 	return &RespItemGet{
-		ID:        data.ID,
-		GUID:      data.GUID,
-		Title:     data.Title,
-		Link:      data.Link,
-		Content:   data.Content,
-		Unread:    data.Unread,
-		Bookmark:  data.Bookmark,
-		PubDate:   data.PubDate,
-		UpdatedAt: &data.UpdatedAt,
-		Feed: ItemFeed{
-			ID:   data.Feed.ID,
-			Name: data.Feed.Name,
-			Link: data.Feed.Link,
+		ID:          data.ID,
+		GUID:        data.GUID,
+		Title:       data.Title,
+		Link:        data.Link,
+		Content:     data.Content,
+		Unread:      data.Unread,
+		Bookmark:    data.Bookmark,
+		Hidden:      data.Hidden,
+		PubDate:     data.PubDate,
+		UpdatedAt:   &data.UpdatedAt,
+		Language:    data.Language,
+		ReadingTime: readingTime(data.WordCount),
+		WordCount:   data.WordCount,
+		HasVideo:    data.HasVideo,
+		HasAudio:    data.HasAudio,
+		HasGallery:  data.HasGallery,
+		ImageURL:    data.ImageURL,
+		Feed:        newItemFeed(data.Feed),
+		Enclosure:   newEnclosureForm(data),
+		Origin: &ItemOriginForm{
+			FetchedAt: data.CreatedAt,
+			Kind:      data.Origin,
+			SourceURL: data.SourceURL,
 		},
 	}, nil
 }
 
+// Delete deletes the item for good: it isn't fetched again with the next
+// pull.
 func (i Item) Delete(ctx context.Context, req *ReqItemDelete) error {
 	return i.repo.Delete(req.ID)
 }
 
-func (i Item) UpdateUnread(ctx context.Context, req *ReqItemUpdateUnread) error {
-	return i.repo.UpdateUnread(req.IDs, req.Unread)
+func (i Item) BatchDelete(ctx context.Context, req *ReqItemBatchDelete) error {
+	return i.repo.Delete(req.IDs...)
+}
+
+// BatchUpdateHidden hides the items from all lists, or shows them again.
+// Unlike deleted items, hidden ones can still be opened.
+func (i Item) BatchUpdateHidden(ctx context.Context, req *ReqItemBatchUpdateHidden) (*RespItemBatchUpdateHidden, error) {
+	updated, err := i.repo.UpdateHidden(req.IDs, *req.Hidden)
+	if err != nil {
+		return nil, err
+	}
+
+	feedIDs, err := i.repo.FeedIDs(req.IDs)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := i.repo.UnreadCounts(feedIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &RespItemBatchUpdateHidden{Updated: updated, UnreadCounts: counts}, nil
+}
+
+// UpdateUnread sets the unread state of the items and returns how many
+// changed, along with the new unread counts of their feeds, so the client can
+// update the sidebar in place.
+func (i Item) UpdateUnread(ctx context.Context, req *ReqItemUpdateUnread) (*RespItemUpdateUnread, error) {
+	updated, err := i.repo.UpdateUnread(req.IDs, req.Unread)
+	if err != nil {
+		return nil, err
+	}
+
+	feedIDs, err := i.repo.FeedIDs(req.IDs)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := i.repo.UnreadCounts(feedIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &RespItemUpdateUnread{Updated: updated, UnreadCounts: counts}, nil
+}
+
+// Open marks the item as read and returns its link, so the caller can send
+// the user to the original page in one step.
+func (i Item) Open(ctx context.Context, req *ReqItemOpen) (*RespItemOpen, error) {
+	data, err := i.repo.Get(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	unread := false
+	if _, err := i.repo.UpdateUnread([]uint{req.ID}, &unread); err != nil {
+		return nil, err
+	}
+
+	return &RespItemOpen{
+		Link: ptr.From(data.Link),
+	}, nil
 }
 
 func (i Item) UpdateBookmark(ctx context.Context, req *ReqItemUpdateBookmark) error {
-	return i.repo.UpdateBookmark(req.ID, req.Bookmark)
+	_, err := i.repo.UpdateBookmark([]uint{req.ID}, req.Bookmark)
+	return err
+}
+
+// UpdatePlayback records the playback state of the item's enclosure. The
+// client sends it periodically while the enclosure plays, and when it ends.
+func (i Item) UpdatePlayback(ctx context.Context, req *ReqItemUpdatePlayback) error {
+	if req.Position == nil && req.Listened == nil {
+		msg := "Either position or listened is required"
+		return NewBizError(errors.New(msg), http.StatusBadRequest, msg)
+	}
+	return i.repo.UpdatePlayback(req.ID, req.Position, req.Listened)
+}
+
+// BatchUpdateBookmark sets the bookmark state of the items with the given
+// IDs, or else of those matching the filter.
+func (i Item) BatchUpdateBookmark(ctx context.Context, req *ReqItemBatchUpdateBookmark) (*RespItemBatchUpdateBookmark, error) {
+	var updated int
+	var err error
+	switch {
+	case len(req.IDs) > 0:
+		updated, err = i.repo.UpdateBookmark(req.IDs, req.Bookmark)
+	case req.Filter != nil:
+		updated, err = i.repo.UpdateBookmarkByFilter(newItemFilter(req.Filter), req.Bookmark)
+	default:
+		msg := "Either ids or filter is required"
+		return nil, NewBizError(errors.New(msg), http.StatusBadRequest, msg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &RespItemBatchUpdateBookmark{Updated: updated}, nil
+}
+
+func newItemFilter(f *ItemFilterForm) repo.ItemFilter {
+	return repo.ItemFilter{
+		Keyword:  f.Keyword,
+		FeedID:   f.FeedID,
+		GroupID:  f.GroupID,
+		Unread:   f.Unread,
+		Bookmark: f.Bookmark,
+	}
+}
+
+// ToggleUnread flips the unread state of an item and returns the updated row,
+// so that row actions like swipe gestures need a single round-trip.
+func (i Item) ToggleUnread(ctx context.Context, req *ReqItemToggle) (*RespItemToggle, error) {
+	data, err := i.repo.Get(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	unread := !ptr.From(data.Unread)
+	if _, err := i.repo.UpdateUnread([]uint{req.ID}, &unread); err != nil {
+		return nil, err
+	}
+	data.Unread = &unread
+
+	// Send the feed's new unread count along, so the client can reconcile its
+	// optimistic update of the sidebar without fetching the feeds again.
+	counts, err := i.repo.UnreadCounts([]uint{data.FeedID})
+	if err != nil {
+		return nil, err
+	}
+	row := newItemRow(data)
+	row.Feed.UnreadCount = ptr.To(counts[data.FeedID])
+
+	return (*RespItemToggle)(row), nil
+}
+
+// ToggleBookmark flips the bookmark state of an item and returns the updated
+// row.
+func (i Item) ToggleBookmark(ctx context.Context, req *ReqItemToggle) (*RespItemToggle, error) {
+	data, err := i.repo.Get(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmark := !ptr.From(data.Bookmark)
+	if _, err := i.repo.UpdateBookmark([]uint{req.ID}, &bookmark); err != nil {
+		return nil, err
+	}
+	data.Bookmark = &bookmark
+
+	return (*RespItemToggle)(newItemRow(data)), nil
 }
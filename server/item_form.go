@@ -6,6 +6,20 @@ type ItemFeed struct {
 	ID   uint    `json:"id"`
 	Name *string `json:"name"`
 	Link *string `json:"link"`
+	// SiteURL tells where bridged feeds come from, for their favicon.
+	SiteURL *string `json:"site_url,omitempty"`
+	// EmbedVideos tells the client to embed the videos the item links to, see
+	// model.Feed.
+	EmbedVideos bool `json:"embed_videos"`
+	// DisplayMode is how the feed's items are shown, see model.Feed.
+	DisplayMode string `json:"display_mode,omitempty"`
+	// Sanitize is how much of the markup of the content to keep, see
+	// model.Settings.
+	Sanitize string  `json:"sanitize"`
+	Color    *string `json:"color,omitempty"`
+	Emoji    *string `json:"emoji,omitempty"`
+	// UnreadCount is only set in responses to actions that change it.
+	UnreadCount *int `json:"unread_count,omitempty"`
 }
 
 type ItemForm struct {
@@ -16,9 +30,67 @@ type ItemForm struct {
 	Content   *string    `json:"content"`
 	Unread    *bool      `json:"unread"`
 	Bookmark  *bool      `json:"bookmark"`
+	Hidden    *bool      `json:"hidden"`
 	PubDate   *time.Time `json:"pub_date"`
 	UpdatedAt *time.Time `json:"updated_at"`
-	Feed      ItemFeed   `json:"feed"`
+	Language  *string    `json:"language"`
+	// ReadingTime is the estimated reading time in minutes, nil if unknown.
+	ReadingTime *int     `json:"reading_time"`
+	WordCount   *int     `json:"word_count"`
+	HasVideo    bool     `json:"has_video"`
+	HasAudio    bool     `json:"has_audio"`
+	HasGallery  bool     `json:"has_gallery"`
+	ImageURL    *string  `json:"image_url"`
+	Feed        ItemFeed `json:"feed"`
+	// Enclosure is the audio or video file of the item, nil if it has none.
+	Enclosure *EnclosureForm `json:"enclosure"`
+	// Snippet is the beginning of the content as plain text, nil for items
+	// stored before snippets were.
+	Snippet *string `json:"snippet"`
+	// Score is only set in the Highlights view.
+	Score *float64 `json:"score,omitempty"`
+	// Origin is only set when getting a single item.
+	Origin *ItemOriginForm `json:"origin,omitempty"`
+}
+
+// ItemOriginForm tells when and how an item arrived, see model.Item.Origin.
+type ItemOriginForm struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Kind      *string   `json:"kind"`
+	SourceURL *string   `json:"source_url"`
+}
+
+type EnclosureForm struct {
+	URL  string `json:"url"`
+	Type string `json:"type"`
+	// PlaybackPosition is where, in seconds, playing it was left off.
+	PlaybackPosition int  `json:"playback_position"`
+	Listened         bool `json:"listened"`
+}
+
+type ReqItemHighlights struct {
+	Limit int `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type ReqItemSurprise struct {
+	Limit int `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type ReqItemDigest struct {
+	// Since is the start of the user's day, as the server doesn't know the
+	// user's timezone.
+	Since time.Time `query:"since" validate:"required"`
+}
+
+type DigestFeed struct {
+	Feed  ItemFeed    `json:"feed"`
+	Items []*ItemForm `json:"items"`
+}
+
+type RespItemDigest struct {
+	Feeds []*DigestFeed `json:"feeds"`
+	// Truncated is true if there were more unread items than fit in a digest.
+	Truncated bool `json:"truncated"`
 }
 
 type ReqItemList struct {
@@ -28,11 +100,23 @@ type ReqItemList struct {
 	GroupID  *uint   `query:"group_id"`
 	Unread   *bool   `query:"unread"`
 	Bookmark *bool   `query:"bookmark"`
+	Language *string `query:"language"`
+	// MaxReadingTime only lists items that take less than this many minutes
+	// to read.
+	MaxReadingTime *int `query:"max_reading_time" validate:"omitempty,min=1"`
+	// Media only lists items that carry the given kind of media.
+	Media *string `query:"media" validate:"omitempty,oneof=video audio gallery"`
+	// PublishedAfter only lists items published since then, like the start of
+	// the user's day, which the server can't tell.
+	PublishedAfter *time.Time `query:"published_after"`
 }
 
 type RespItemList struct {
-	Total *int        `json:"total"`
-	Items []*ItemForm `json:"items"`
+	Total *int `json:"total"`
+	// PageSize is the page size actually used, which is the instance default if
+	// the request didn't specify one.
+	PageSize int         `json:"page_size"`
+	Items    []*ItemForm `json:"items"`
 }
 
 type ReqItemGet struct {
@@ -45,12 +129,79 @@ type ReqItemDelete struct {
 	ID uint `param:"id" validate:"required"`
 }
 
+type ReqItemBatchDelete struct {
+	IDs []uint `json:"ids" validate:"required"`
+}
+
+type ReqItemBatchUpdateHidden struct {
+	IDs    []uint `json:"ids" validate:"required"`
+	Hidden *bool  `json:"hidden" validate:"required"`
+}
+
+type RespItemBatchUpdateHidden struct {
+	// Updated is the number of items updated.
+	Updated int `json:"updated"`
+	// UnreadCounts maps the ID of each affected feed to its new unread count,
+	// as hiding items marks them as read.
+	UnreadCounts map[uint]int `json:"unread_counts"`
+}
+
 type ReqItemUpdateUnread struct {
 	IDs    []uint `json:"ids" validate:"required"`
 	Unread *bool  `json:"unread" validate:"required"`
 }
 
+type RespItemUpdateUnread struct {
+	// Updated is the number of items whose state changed. Items already in
+	// the requested state aren't counted.
+	Updated int `json:"updated"`
+	// UnreadCounts maps the ID of each affected feed to its new unread count.
+	UnreadCounts map[uint]int `json:"unread_counts"`
+}
+
+type ReqItemOpen struct {
+	ID uint `param:"id" validate:"required"`
+}
+
+type RespItemOpen struct {
+	Link string `json:"link"`
+}
+
+type ReqItemToggle struct {
+	ID uint `param:"id" validate:"required"`
+}
+
+type RespItemToggle ItemForm
+
 type ReqItemUpdateBookmark struct {
 	ID       uint  `param:"id" validate:"required"`
 	Bookmark *bool `json:"bookmark" validate:"required"`
 }
+
+// ReqItemBatchUpdateBookmark selects the items either by IDs or by Filter.
+type ReqItemBatchUpdateBookmark struct {
+	IDs      []uint          `json:"ids" validate:"required_without=Filter,omitempty,min=1"`
+	Filter   *ItemFilterForm `json:"filter" validate:"required_without=IDs"`
+	Bookmark *bool           `json:"bookmark" validate:"required"`
+}
+
+// ItemFilterForm selects items like the filters of ReqItemList. An empty one
+// selects all items.
+type ItemFilterForm struct {
+	Keyword  *string `json:"keyword"`
+	FeedID   *uint   `json:"feed_id"`
+	GroupID  *uint   `json:"group_id"`
+	Unread   *bool   `json:"unread"`
+	Bookmark *bool   `json:"bookmark"`
+}
+
+type RespItemBatchUpdateBookmark struct {
+	// Updated is the number of items updated.
+	Updated int `json:"updated"`
+}
+
+type ReqItemUpdatePlayback struct {
+	ID       uint  `param:"id" validate:"required"`
+	Position *int  `json:"position" validate:"omitnil,min=0"`
+	Listened *bool `json:"listened"`
+}
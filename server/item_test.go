@@ -0,0 +1,177 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/server"
+)
+
+func TestItemUpdatePlayback(t *testing.T) {
+	db := repotest.NewDB(t)
+	feeds := []*model.Feed{{Name: ptr.To("Podcast"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	_, err := repo.NewItem(db).Insert([]*model.Item{{
+		GUID:          ptr.To("ep1"),
+		Title:         ptr.To("Episode 1"),
+		EnclosureURL:  ptr.To("https://example.com/ep1.mp3"),
+		EnclosureType: ptr.To("audio/mpeg"),
+		FeedID:        feeds[0].ID,
+	}})
+	require.NoError(t, err)
+	items, _, err := repo.NewItem(db).List(repo.ItemFilter{}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	id := items[0].ID
+	itemService := server.NewItem(repo.NewItem(db), repo.NewScoreKeyword(db), 10)
+	ctx := context.Background()
+
+	enclosure := func() *server.EnclosureForm {
+		t.Helper()
+		item, err := itemService.Get(ctx, &server.ReqItemGet{ID: id})
+		require.NoError(t, err)
+		require.NotNil(t, item.Enclosure)
+		return item.Enclosure
+	}
+
+	assert.Equal(t, &server.EnclosureForm{
+		URL:  "https://example.com/ep1.mp3",
+		Type: "audio/mpeg",
+	}, enclosure())
+
+	require.NoError(t, itemService.UpdatePlayback(ctx, &server.ReqItemUpdatePlayback{ID: id, Position: ptr.To(95)}))
+	assert.Equal(t, 95, enclosure().PlaybackPosition)
+	assert.False(t, enclosure().Listened)
+
+	require.NoError(t, itemService.UpdatePlayback(ctx, &server.ReqItemUpdatePlayback{ID: id, Listened: ptr.To(true)}))
+	assert.True(t, enclosure().Listened)
+	assert.Zero(t, enclosure().PlaybackPosition, "listened enclosures must restart from the beginning")
+
+	require.NoError(t, itemService.UpdatePlayback(ctx, &server.ReqItemUpdatePlayback{ID: id, Listened: ptr.To(false)}))
+	assert.False(t, enclosure().Listened)
+
+	err = itemService.UpdatePlayback(ctx, &server.ReqItemUpdatePlayback{ID: id})
+	var bizErr server.BizError
+	require.ErrorAs(t, err, &bizErr)
+	assert.EqualValues(t, http.StatusBadRequest, bizErr.HTTPCode)
+}
+
+func TestItemListPublishedAfter(t *testing.T) {
+	db := repotest.NewDB(t)
+	feeds := []*model.Feed{{Name: ptr.To("Blog"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	since := time.Date(2025, 1, 2, 0, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	_, err := repo.NewItem(db).Insert([]*model.Item{
+		{GUID: ptr.To("today"), PubDate: ptr.To(since.Add(time.Hour)), FeedID: feeds[0].ID},
+		// The same day, written in another time zone.
+		{GUID: ptr.To("today-utc"), PubDate: ptr.To(since.Add(time.Minute).UTC()), FeedID: feeds[0].ID},
+		{GUID: ptr.To("yesterday"), PubDate: ptr.To(since.Add(-time.Minute).UTC()), FeedID: feeds[0].ID},
+		// Undated items count as published when they were fetched, now.
+		{GUID: ptr.To("undated"), FeedID: feeds[0].ID},
+	})
+	require.NoError(t, err)
+	itemService := server.NewItem(repo.NewItem(db), repo.NewScoreKeyword(db), 10)
+
+	resp, err := itemService.List(context.Background(), &server.ReqItemList{PublishedAfter: &since})
+	require.NoError(t, err)
+	guids := make([]string, 0, len(resp.Items))
+	for _, v := range resp.Items {
+		guids = append(guids, *v.GUID)
+	}
+	assert.ElementsMatch(t, []string{"today", "today-utc", "undated"}, guids)
+}
+
+func TestItemSurprise(t *testing.T) {
+	db := repotest.NewDB(t)
+	feeds := []*model.Feed{{Name: ptr.To("Blog"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	old := time.Now().AddDate(0, -1, 0)
+	_, err := repo.NewItem(db).Insert([]*model.Item{
+		{GUID: ptr.To("old-unread"), PubDate: &old, Unread: ptr.To(true), FeedID: feeds[0].ID},
+		{GUID: ptr.To("old-bookmarked"), PubDate: &old, Unread: ptr.To(false), Bookmark: ptr.To(true), FeedID: feeds[0].ID},
+		{GUID: ptr.To("old-read"), PubDate: &old, Unread: ptr.To(false), FeedID: feeds[0].ID},
+		{GUID: ptr.To("new-unread"), PubDate: ptr.To(time.Now()), Unread: ptr.To(true), FeedID: feeds[0].ID},
+	})
+	require.NoError(t, err)
+	itemService := server.NewItem(repo.NewItem(db), repo.NewScoreKeyword(db), 10)
+
+	resp, err := itemService.Surprise(context.Background(), &server.ReqItemSurprise{})
+	require.NoError(t, err)
+	guids := make([]string, 0, len(resp.Items))
+	for _, v := range resp.Items {
+		guids = append(guids, *v.GUID)
+	}
+	assert.ElementsMatch(t, []string{"old-unread", "old-bookmarked"}, guids)
+
+	resp, err = itemService.Surprise(context.Background(), &server.ReqItemSurprise{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, resp.Items, 1)
+}
+
+func TestItemBatchUpdateBookmark(t *testing.T) {
+	db := repotest.NewDB(t)
+	feeds := []*model.Feed{
+		{Name: ptr.To("Blog"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))},
+		{Name: ptr.To("News"), Link: ptr.To("https://example.org/feed"), GroupID: ptr.To(uint(1))},
+	}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	_, err := repo.NewItem(db).Insert([]*model.Item{
+		{GUID: ptr.To("blog-unread"), Unread: ptr.To(true), FeedID: feeds[0].ID},
+		{GUID: ptr.To("blog-read"), Unread: ptr.To(false), FeedID: feeds[0].ID},
+		{GUID: ptr.To("news-unread"), Unread: ptr.To(true), FeedID: feeds[1].ID},
+	})
+	require.NoError(t, err)
+	itemService := server.NewItem(repo.NewItem(db), repo.NewScoreKeyword(db), 10)
+	ctx := context.Background()
+	bookmarked := func() []string {
+		t.Helper()
+		resp, err := itemService.List(ctx, &server.ReqItemList{Bookmark: ptr.To(true)})
+		require.NoError(t, err)
+		guids := make([]string, 0, len(resp.Items))
+		for _, v := range resp.Items {
+			guids = append(guids, *v.GUID)
+		}
+		return guids
+	}
+
+	resp, err := itemService.BatchUpdateBookmark(ctx, &server.ReqItemBatchUpdateBookmark{
+		Filter:   &server.ItemFilterForm{FeedID: &feeds[0].ID, Unread: ptr.To(true)},
+		Bookmark: ptr.To(true),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Updated)
+	assert.ElementsMatch(t, []string{"blog-unread"}, bookmarked())
+
+	resp, err = itemService.BatchUpdateBookmark(ctx, &server.ReqItemBatchUpdateBookmark{
+		Filter:   &server.ItemFilterForm{Keyword: ptr.To("nothing matches")},
+		Bookmark: ptr.To(true),
+	})
+	require.NoError(t, err)
+	assert.Zero(t, resp.Updated)
+
+	resp, err = itemService.BatchUpdateBookmark(ctx, &server.ReqItemBatchUpdateBookmark{
+		Filter:   &server.ItemFilterForm{Unread: ptr.To(true)},
+		Bookmark: ptr.To(true),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Updated)
+	assert.ElementsMatch(t, []string{"blog-unread", "news-unread"}, bookmarked())
+
+	var bizErr server.BizError
+	_, err = itemService.BatchUpdateBookmark(ctx, &server.ReqItemBatchUpdateBookmark{
+		IDs:      []uint{},
+		Bookmark: ptr.To(false),
+	})
+	require.ErrorAs(t, err, &bizErr, "empty ids without a filter must not select all items")
+	assert.EqualValues(t, http.StatusBadRequest, bizErr.HTTPCode)
+	assert.ElementsMatch(t, []string{"blog-unread", "news-unread"}, bookmarked())
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/repo"
+)
+
+// JobEnqueuer queues background work, see the job service.
+type JobEnqueuer interface {
+	Enqueue(ctx context.Context, kind string, payload any) error
+}
+
+type JobRepo interface {
+	List(filter repo.JobFilter, page, pageSize int) ([]*model.Job, int, error)
+	Get(id uint) (*model.Job, error)
+	Retry(id uint, now time.Time) error
+	Delete(id uint) error
+}
+
+// Job manages the background jobs.
+type Job struct {
+	repo JobRepo
+}
+
+func NewJob(repo JobRepo) *Job {
+	return &Job{
+		repo: repo,
+	}
+}
+
+func (j Job) List(ctx context.Context, req *ReqJobList) (*RespJobList, error) {
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 50
+	}
+	data, total, err := j.repo.List(repo.JobFilter{Status: req.Status}, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*JobForm, 0, len(data))
+	for _, v := range data {
+		jobs = append(jobs, &JobForm{
+			ID:         v.ID,
+			Kind:       v.Kind,
+			Payload:    json.RawMessage(v.Payload),
+			Status:     v.Status,
+			Attempts:   v.Attempts,
+			Failure:    v.Failure,
+			RunAt:      v.RunAt,
+			CreatedAt:  v.CreatedAt,
+			FinishedAt: v.FinishedAt,
+		})
+	}
+	return &RespJobList{
+		Total: total,
+		Jobs:  jobs,
+	}, nil
+}
+
+// Retry runs a failed job again.
+func (j Job) Retry(ctx context.Context, req *ReqJobRetry) error {
+	job, err := j.repo.Get(req.ID)
+	if err != nil {
+		return err
+	}
+	if job.Status != model.JobFailed {
+		return NewBizError(errors.New("job not failed"), http.StatusBadRequest, "Only failed jobs can be retried")
+	}
+	return j.repo.Retry(req.ID, time.Now())
+}
+
+func (j Job) Delete(ctx context.Context, req *ReqJobDelete) error {
+	job, err := j.repo.Get(req.ID)
+	if err != nil {
+		return err
+	}
+	if job.Status == model.JobRunning {
+		return NewBizError(errors.New("job running"), http.StatusBadRequest, "Running jobs can't be deleted")
+	}
+	return j.repo.Delete(req.ID)
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type JobForm struct {
+	ID        uint            `json:"id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"`
+	Status    string          `json:"status"`
+	Attempts  int             `json:"attempts"`
+	Failure   *string         `json:"failure"`
+	RunAt     time.Time       `json:"run_at"`
+	CreatedAt time.Time       `json:"created_at"`
+	// FinishedAt is when the job succeeded or was given up on.
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+type ReqJobList struct {
+	Paginate
+	Status *string `query:"status" validate:"omitempty,oneof=pending running succeeded failed"`
+}
+
+type RespJobList struct {
+	Total int        `json:"total"`
+	Jobs  []*JobForm `json:"jobs"`
+}
+
+type ReqJobRetry struct {
+	ID uint `param:"id" validate:"required"`
+}
+
+type ReqJobDelete struct {
+	ID uint `param:"id" validate:"required"`
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0x2e/fusion/auth"
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+)
+
+// metricsTokenMessage is signed to authorize scraping the metrics. Search
+// feed keywords can't match it, see searchFeedTokenMessage.
+const metricsTokenMessage = "metrics"
+
+type MetricsFeedRepo interface {
+	List(filter *repo.FeedListFilter) ([]*model.Feed, error)
+}
+
+// Metrics exposes the health of each feed in the Prometheus text format, so
+// that monitoring can alert on feeds that have gone quiet or keep failing.
+type Metrics struct {
+	repo MetricsFeedRepo
+	// passwordHash signs the metrics link, like SearchFeed's links.
+	passwordHash *auth.HashedPassword
+}
+
+func NewMetrics(repo MetricsFeedRepo, passwordHash *auth.HashedPassword) *Metrics {
+	return &Metrics{
+		repo:         repo,
+		passwordHash: passwordHash,
+	}
+}
+
+func (m Metrics) Link(ctx context.Context) (*RespMetricsLink, error) {
+	link := "/api/metrics"
+	if m.passwordHash != nil {
+		link += "?token=" + m.passwordHash.Sign(metricsTokenMessage)
+	}
+	return &RespMetricsLink{Link: link}, nil
+}
+
+// Get returns the metrics. Timestamps are in seconds since the epoch, and 0
+// when the event never happened, e.g. a feed that never had new items.
+func (m Metrics) Get(ctx context.Context, req *ReqMetrics) (string, error) {
+	if m.passwordHash != nil && !m.passwordHash.Verify(metricsTokenMessage, req.Token) {
+		msg := "invalid token"
+		return "", NewBizError(fmt.Errorf("%s for metrics", msg), http.StatusUnauthorized, msg)
+	}
+
+	feeds, err := m.repo.List(nil)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, metric := range []struct {
+		name, help string
+		value      func(f *model.Feed) int64
+	}{
+		{
+			name:  "fusion_feed_last_item_timestamp_seconds",
+			help:  "Last time a fetch of the feed found new items.",
+			value: func(f *model.Feed) int64 { return unixOrZero(f.LastItemAt) },
+		},
+		{
+			name:  "fusion_feed_last_fetch_timestamp_seconds",
+			help:  "Last time the feed was fetched successfully.",
+			value: func(f *model.Feed) int64 { return unixOrZero(f.LastFetchedAt) },
+		},
+		{
+			name:  "fusion_feed_consecutive_failures",
+			help:  "Number of consecutive failed fetches of the feed.",
+			value: func(f *model.Feed) int64 { return int64(f.ConsecutiveFailures) },
+		},
+		{
+			name:  "fusion_feed_unread_items",
+			help:  "Number of unread items of the feed.",
+			value: func(f *model.Feed) int64 { return int64(f.UnreadCount) },
+		},
+	} {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", metric.name, metric.help, metric.name)
+		for _, f := range feeds {
+			fmt.Fprintf(&b, "%s{feed_id=\"%d\",feed=\"%s\",group=\"%s\"} %d\n",
+				metric.name, f.ID, escapeLabel(ptr.From(f.Name)), escapeLabel(ptr.From(f.Group.Name)), metric.value(f))
+		}
+	}
+	return b.String(), nil
+}
+
+func unixOrZero(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value for the Prometheus text format.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,10 @@
+package server
+
+type RespMetricsLink struct {
+	// Link is relative to the server root.
+	Link string `json:"link"`
+}
+
+type ReqMetrics struct {
+	Token string `query:"token"`
+}
@@ -0,0 +1,63 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/auth"
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/server"
+)
+
+func TestMetricsGet(t *testing.T) {
+	db := repotest.NewDB(t)
+	lastItemAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, repo.NewFeed(db).Create([]*model.Feed{
+		{Name: ptr.To(`Say "hi"`), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1)), LastItemAt: &lastItemAt},
+		{Name: ptr.To("Quiet"), Link: ptr.To("https://example.org/feed"), GroupID: ptr.To(uint(1)), ConsecutiveFailures: 3},
+	}))
+
+	body, err := server.NewMetrics(repo.NewFeed(db), nil).Get(context.Background(), &server.ReqMetrics{})
+
+	require.NoError(t, err)
+	assert.Contains(t, body, "# TYPE fusion_feed_last_item_timestamp_seconds gauge\n")
+	assert.Contains(t, body, `fusion_feed_last_item_timestamp_seconds{feed_id="1",feed="Say \"hi\"",group="Default"} 1735787045`+"\n")
+	assert.Contains(t, body, `fusion_feed_last_item_timestamp_seconds{feed_id="2",feed="Quiet",group="Default"} 0`+"\n",
+		"feeds that never had new items must be reported as stale since the epoch")
+	assert.Contains(t, body, `fusion_feed_consecutive_failures{feed_id="2",feed="Quiet",group="Default"} 3`+"\n")
+}
+
+func TestMetricsToken(t *testing.T) {
+	db := repotest.NewDB(t)
+	hash, err := auth.HashPassword("mypassword")
+	require.NoError(t, err)
+	metrics := server.NewMetrics(repo.NewFeed(db), &hash)
+
+	_, err = metrics.Get(context.Background(), &server.ReqMetrics{Token: "wrong"})
+	var bizErr server.BizError
+	require.ErrorAs(t, err, &bizErr)
+	assert.EqualValues(t, http.StatusUnauthorized, bizErr.HTTPCode)
+
+	link, err := metrics.Link(context.Background())
+	require.NoError(t, err)
+	token := link.Link[len("/api/metrics?token="):]
+	_, err = metrics.Get(context.Background(), &server.ReqMetrics{Token: token})
+	assert.NoError(t, err)
+
+	search, err := server.NewSearchFeed(repo.NewItem(db), &hash).Link(context.Background(), &server.ReqSearchFeedLink{Keyword: "metrics"})
+	require.NoError(t, err)
+	u, err := url.Parse(search.Link)
+	require.NoError(t, err)
+	_, err = metrics.Get(context.Background(), &server.ReqMetrics{Token: u.Query().Get("token")})
+	require.ErrorAs(t, err, &bizErr, "a search feed link must not authorize the metrics")
+	assert.EqualValues(t, http.StatusUnauthorized, bizErr.HTTPCode)
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0x2e/fusion/auth"
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/service/pull"
+)
+
+// noteTokenMessage is signed to authorize adding notes.
+const noteTokenMessage = "notes"
+
+type NoteFeedRepo interface {
+	GetByLink(link string) (*model.Feed, error)
+	Create(feed []*model.Feed) error
+}
+
+type NoteItemRepo interface {
+	Insert(items []*model.Item) (int, error)
+}
+
+// Note adds items to the Notes to self feed, so that links and thoughts can
+// be pushed into the reading flow from browser shortcuts or phone share
+// sheets. The feed is created with the first note.
+type Note struct {
+	feedRepo NoteFeedRepo
+	itemRepo NoteItemRepo
+	// passwordHash signs the link notes are sent to, like SearchFeed's links.
+	passwordHash *auth.HashedPassword
+}
+
+func NewNote(feedRepo NoteFeedRepo, itemRepo NoteItemRepo, passwordHash *auth.HashedPassword) *Note {
+	return &Note{
+		feedRepo:     feedRepo,
+		itemRepo:     itemRepo,
+		passwordHash: passwordHash,
+	}
+}
+
+func (n Note) Link(ctx context.Context) (*RespNoteLink, error) {
+	link := "/api/notes"
+	if n.passwordHash != nil {
+		link += "?token=" + n.passwordHash.Sign(noteTokenMessage)
+	}
+	return &RespNoteLink{Link: link}, nil
+}
+
+// Create adds a note with a link, a text, or both. Its title defaults to the
+// link.
+func (n Note) Create(ctx context.Context, req *ReqNoteCreate) (*RespNoteCreate, error) {
+	if n.passwordHash != nil && !n.passwordHash.Verify(noteTokenMessage, req.Token) {
+		msg := "invalid token"
+		return nil, NewBizError(fmt.Errorf("%s for notes", msg), http.StatusUnauthorized, msg)
+	}
+
+	feed, err := n.notesFeed()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	title := strings.TrimSpace(ptr.From(req.Title))
+	if title == "" {
+		title = ptr.From(req.URL)
+	}
+	item := &model.Item{
+		FeedID:  feed.ID,
+		GUID:    ptr.To(fmt.Sprintf("note-%d", now.UnixNano())),
+		Title:   &title,
+		Link:    req.URL,
+		Unread:  ptr.To(true),
+		PubDate: &now,
+		Origin:  ptr.To(model.ItemOriginAPI),
+	}
+	if req.Content != nil && strings.TrimSpace(*req.Content) != "" {
+		item.Content = ptr.To(textToHTML(*req.Content))
+	}
+	inserted, err := n.itemRepo.Insert([]*model.Item{item})
+	if err != nil {
+		return nil, err
+	}
+	if inserted == 0 {
+		msg := "the note was not added, as it exists already"
+		return nil, NewBizError(errors.New(msg), http.StatusConflict, msg)
+	}
+
+	pull.Events.Publish(pull.NewItemsEvent{FeedID: feed.ID, NewItems: 1})
+	return &RespNoteCreate{ID: item.ID, FeedID: feed.ID}, nil
+}
+
+// notesFeed returns the Notes to self feed, creating it if needed.
+func (n Note) notesFeed() (*model.Feed, error) {
+	feed, err := n.feedRepo.GetByLink(model.NotesFeedLink)
+	if !errors.Is(err, repo.ErrNotFound) {
+		return feed, err
+	}
+
+	feed = &model.Feed{
+		Name: ptr.To("Notes to self"),
+		Link: ptr.To(model.NotesFeedLink),
+	}
+	if err := n.feedRepo.Create([]*model.Feed{feed}); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// textToHTML turns plain text into paragraphs, one per block of lines.
+func textToHTML(text string) string {
+	var b strings.Builder
+	for _, p := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(p), "\n", "<br>"))
+		b.WriteString("</p>")
+	}
+	return b.String()
+}
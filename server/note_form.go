@@ -0,0 +1,23 @@
+package server
+
+// ReqNoteCreate adds a note. It takes JSON or a form, as share sheets and
+// shortcuts send either.
+type ReqNoteCreate struct {
+	// Token is read from the query, see api.noteAPI.Create.
+	Token   string  `query:"token"`
+	URL     *string `json:"url" form:"url" validate:"required_without=Content,omitnil,http_url"`
+	Title   *string `json:"title" form:"title" validate:"omitnil,max=500"`
+	Content *string `json:"content" form:"content" validate:"required_without=URL,omitnil,max=100000"`
+}
+
+type RespNoteCreate struct {
+	// ID is the ID of the item of the note.
+	ID uint `json:"id"`
+	// FeedID is the ID of the Notes to self feed.
+	FeedID uint `json:"feed_id"`
+}
+
+type RespNoteLink struct {
+	// Link is relative to the server root.
+	Link string `json:"link"`
+}
@@ -0,0 +1,101 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/auth"
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/server"
+)
+
+func TestNoteCreate(t *testing.T) {
+	db := repotest.NewDB(t)
+	itemRepo := repo.NewItem(db)
+	hash, err := auth.HashPassword("mypassword")
+	require.NoError(t, err)
+	notes := server.NewNote(repo.NewFeed(db), itemRepo, &hash)
+	ctx := context.Background()
+
+	_, err = notes.Create(ctx, &server.ReqNoteCreate{Token: "wrong", URL: ptr.To("https://example.com/post")})
+	var bizErr server.BizError
+	require.ErrorAs(t, err, &bizErr)
+	assert.EqualValues(t, http.StatusUnauthorized, bizErr.HTTPCode)
+
+	link, err := notes.Link(ctx)
+	require.NoError(t, err)
+	token := link.Link[len("/api/notes?token="):]
+	first, err := notes.Create(ctx, &server.ReqNoteCreate{Token: token, URL: ptr.To("https://example.com/post")})
+	require.NoError(t, err)
+	second, err := notes.Create(ctx, &server.ReqNoteCreate{
+		Token:   token,
+		Title:   ptr.To("Idea"),
+		Content: ptr.To("Read <this>\nlater\n\nor not"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, first.FeedID, second.FeedID, "notes must go to the same feed")
+
+	feed, err := repo.NewFeed(db).Get(first.FeedID)
+	require.NoError(t, err)
+	assert.Equal(t, model.NotesFeedLink, *feed.Link)
+	assert.True(t, feed.IsVirtual())
+
+	item, err := itemRepo.Get(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/post", *item.Title, "the title must default to the link")
+	assert.True(t, *item.Unread)
+	item, err = itemRepo.Get(second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Idea", *item.Title)
+	assert.Nil(t, item.Link)
+	assert.Equal(t, "<p>Read &lt;this&gt;<br>later</p><p>or not</p>", *item.Content)
+
+	again, err := notes.Create(ctx, &server.ReqNoteCreate{Token: token, URL: ptr.To("https://example.com/post")})
+	require.NoError(t, err, "the same link may be noted twice")
+	assert.NotZero(t, again.ID)
+	assert.NotEqual(t, first.ID, again.ID)
+}
+
+func TestNoteCreateRejectsSearchFeedToken(t *testing.T) {
+	db := repotest.NewDB(t)
+	hash, err := auth.HashPassword("mypassword")
+	require.NoError(t, err)
+	link, err := server.NewSearchFeed(repo.NewItem(db), &hash).Link(context.Background(), &server.ReqSearchFeedLink{Keyword: "notes"})
+	require.NoError(t, err)
+	u, err := url.Parse(link.Link)
+	require.NoError(t, err)
+	notes := server.NewNote(repo.NewFeed(db), repo.NewItem(db), &hash)
+
+	_, err = notes.Create(context.Background(), &server.ReqNoteCreate{
+		Token: u.Query().Get("token"),
+		URL:   ptr.To("https://example.com/post"),
+	})
+	var bizErr server.BizError
+	require.ErrorAs(t, err, &bizErr, "a search feed link must not authorize adding notes")
+	assert.EqualValues(t, http.StatusUnauthorized, bizErr.HTTPCode)
+}
+
+// droppingItemRepo stores no item, like when the item exists already.
+type droppingItemRepo struct{}
+
+func (droppingItemRepo) Insert(items []*model.Item) (int, error) {
+	return 0, nil
+}
+
+func TestNoteCreateNotInserted(t *testing.T) {
+	db := repotest.NewDB(t)
+	notes := server.NewNote(repo.NewFeed(db), droppingItemRepo{}, nil)
+
+	_, err := notes.Create(context.Background(), &server.ReqNoteCreate{URL: ptr.To("https://example.com/post")})
+	var bizErr server.BizError
+	require.ErrorAs(t, err, &bizErr, "a note that wasn't stored must not be reported as created")
+	assert.EqualValues(t, http.StatusConflict, bizErr.HTTPCode)
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/service/pull"
+)
+
+type OPMLSubscriptionRepo interface {
+	List() ([]*model.OPMLSubscription, error)
+	Create(sub *model.OPMLSubscription) error
+	Delete(id uint) error
+}
+
+type OPMLSyncer interface {
+	SyncOne(ctx context.Context, id uint) error
+}
+
+type OPMLSubscription struct {
+	repo      OPMLSubscriptionRepo
+	groupRepo groupGetter
+	syncer    OPMLSyncer
+	jobs      JobEnqueuer
+}
+
+func NewOPMLSubscription(repo OPMLSubscriptionRepo, groupRepo groupGetter, syncer OPMLSyncer, jobs JobEnqueuer) *OPMLSubscription {
+	return &OPMLSubscription{
+		repo:      repo,
+		groupRepo: groupRepo,
+		syncer:    syncer,
+		jobs:      jobs,
+	}
+}
+
+func (o OPMLSubscription) List(ctx context.Context) (*RespOPMLSubscriptionList, error) {
+	data, err := o.repo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]*OPMLSubscriptionForm, 0, len(data))
+	for _, v := range data {
+		subs = append(subs, &OPMLSubscriptionForm{
+			ID:       v.ID,
+			Link:     v.Link,
+			LastSync: v.LastSync,
+			Failure:  v.Failure,
+			Group:    GroupForm{ID: v.GroupID, Name: v.Group.Name},
+		})
+	}
+	return &RespOPMLSubscriptionList{
+		Subscriptions: subs,
+	}, nil
+}
+
+func (o OPMLSubscription) Create(ctx context.Context, req *ReqOPMLSubscriptionCreate) (*RespOPMLSubscriptionCreate, error) {
+	groupID := req.GroupID
+	if groupID == 0 {
+		groupID = 1
+	} else if err := checkGroupExists(o.groupRepo, groupID, "group_id"); err != nil {
+		return nil, err
+	}
+	sub := &model.OPMLSubscription{
+		Link:    req.Link,
+		GroupID: groupID,
+	}
+	if err := o.repo.Create(sub); err != nil {
+		if errors.Is(err, repo.ErrDuplicatedKey) {
+			err = NewFieldError(err, "link", "already subscribed to this OPML")
+		}
+		return nil, err
+	}
+
+	// Sync right away, so an invalid link is reported instead of being
+	// silently retried in the background.
+	if err := o.syncer.SyncOne(ctx, sub.ID); err != nil {
+		if deleteErr := o.repo.Delete(sub.ID); deleteErr != nil {
+			return nil, deleteErr
+		}
+		return nil, NewFieldError(err, "link", "failed to sync the OPML: "+err.Error())
+	}
+
+	// Pull the new feeds.
+	if err := o.jobs.Enqueue(ctx, pull.JobPullAll, nil); err != nil {
+		return nil, err
+	}
+	return &RespOPMLSubscriptionCreate{ID: sub.ID}, nil
+}
+
+func (o OPMLSubscription) Delete(ctx context.Context, req *ReqOPMLSubscriptionDelete) error {
+	return o.repo.Delete(req.ID)
+}
+
+func (o OPMLSubscription) Sync(ctx context.Context, req *ReqOPMLSubscriptionSync) error {
+	return o.syncer.SyncOne(ctx, req.ID)
+}
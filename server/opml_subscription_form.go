@@ -0,0 +1,34 @@
+package server
+
+import "time"
+
+type OPMLSubscriptionForm struct {
+	ID       uint       `json:"id"`
+	Link     *string    `json:"link"`
+	LastSync *time.Time `json:"last_sync"`
+	Failure  *string    `json:"failure"`
+	Group    GroupForm  `json:"group"`
+}
+
+type RespOPMLSubscriptionList struct {
+	Subscriptions []*OPMLSubscriptionForm `json:"subscriptions"`
+}
+
+type ReqOPMLSubscriptionCreate struct {
+	Link *string `json:"link" validate:"required,http_url"`
+	// GroupID is the group for feeds outside of any OPML folder. Defaults to
+	// the default group.
+	GroupID uint `json:"group_id"`
+}
+
+type RespOPMLSubscriptionCreate struct {
+	ID uint `json:"id"`
+}
+
+type ReqOPMLSubscriptionDelete struct {
+	ID uint `param:"id" validate:"required"`
+}
+
+type ReqOPMLSubscriptionSync struct {
+	ID uint `param:"id" validate:"required"`
+}
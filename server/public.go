@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/repo"
+)
+
+type PublicGroupRepo interface {
+	Get(id uint) (*model.Group, error)
+	ListPublic() ([]*model.Group, error)
+}
+
+type PublicItemRepo interface {
+	List(filter repo.ItemFilter, page, pageSize int) ([]*model.Item, int, error)
+	Get(id uint) (*model.Item, error)
+}
+
+// Public serves the public groups to visitors who aren't logged in.
+type Public struct {
+	groupRepo       PublicGroupRepo
+	itemRepo        PublicItemRepo
+	defaultPageSize int
+}
+
+func NewPublic(groupRepo PublicGroupRepo, itemRepo PublicItemRepo, defaultPageSize int) *Public {
+	return &Public{
+		groupRepo:       groupRepo,
+		itemRepo:        itemRepo,
+		defaultPageSize: defaultPageSize,
+	}
+}
+
+func (p Public) Groups(ctx context.Context) (*RespPublicGroups, error) {
+	data, err := p.groupRepo.ListPublic()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]*PublicGroupForm, 0, len(data))
+	for _, v := range data {
+		groups = append(groups, &PublicGroupForm{ID: v.ID, Name: v.Name})
+	}
+	return &RespPublicGroups{
+		Groups: groups,
+	}, nil
+}
+
+func (p Public) ListItems(ctx context.Context, req *ReqPublicItemList) (*RespPublicItemList, error) {
+	group, err := p.publicGroup(req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = p.defaultPageSize
+	}
+	data, total, err := p.itemRepo.List(repo.ItemFilter{GroupID: &group.ID}, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*PublicItemForm, 0, len(data))
+	for _, v := range data {
+		item := newPublicItem(v)
+		item.Content = nil
+		items = append(items, item)
+	}
+	return &RespPublicItemList{
+		Group:    PublicGroupForm{ID: group.ID, Name: group.Name},
+		Total:    total,
+		PageSize: req.PageSize,
+		Items:    items,
+	}, nil
+}
+
+func (p Public) GetItem(ctx context.Context, req *ReqPublicItemGet) (*RespPublicItemGet, error) {
+	data, err := p.itemRepo.Get(req.ID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			err = NewBizError(err, http.StatusNotFound, "item not found")
+		}
+		return nil, err
+	}
+	// Uncategorized feeds are in no group, so they are never public.
+	if data.Feed.GroupID == nil {
+		return nil, NewBizError(errors.New("feed has no group"), http.StatusNotFound, "item not found")
+	}
+	if _, err := p.publicGroup(*data.Feed.GroupID); err != nil {
+		return nil, NewBizError(err, http.StatusNotFound, "item not found")
+	}
+
+	return (*RespPublicItemGet)(newPublicItem(data)), nil
+}
+
+// publicGroup returns the group with the given ID if it's public. Private
+// groups are reported as missing, so that they can't be told apart.
+func (p Public) publicGroup(id uint) (*model.Group, error) {
+	group, err := p.groupRepo.Get(id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			err = NewBizError(err, http.StatusNotFound, "group not found")
+		}
+		return nil, err
+	}
+	if group.Public == nil || !*group.Public {
+		return nil, NewBizError(errors.New("group is private"), http.StatusNotFound, "group not found")
+	}
+	return group, nil
+}
+
+func newPublicItem(v *model.Item) *PublicItemForm {
+	return &PublicItemForm{
+		ID:          v.ID,
+		Title:       v.Title,
+		Link:        v.Link,
+		Content:     v.Content,
+		PubDate:     v.PubDate,
+		ReadingTime: readingTime(v.WordCount),
+		Feed:        newPublicItemFeed(v.Feed),
+	}
+}
+
+func newPublicItemFeed(feed model.Feed) PublicItemFeed {
+	f := newItemFeed(feed)
+	return PublicItemFeed{
+		ID:          f.ID,
+		Name:        f.Name,
+		SiteURL:     f.SiteURL,
+		EmbedVideos: f.EmbedVideos,
+		Sanitize:    f.Sanitize,
+	}
+}
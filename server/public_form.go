@@ -0,0 +1,53 @@
+package server
+
+import "time"
+
+// The public forms leave out the reading state, which is private.
+
+type PublicGroupForm struct {
+	ID   uint    `json:"id"`
+	Name *string `json:"name"`
+}
+
+type RespPublicGroups struct {
+	Groups []*PublicGroupForm `json:"groups"`
+}
+
+type PublicItemForm struct {
+	ID      uint       `json:"id"`
+	Title   *string    `json:"title"`
+	Link    *string    `json:"link"`
+	Content *string    `json:"content,omitempty"`
+	PubDate *time.Time `json:"pub_date"`
+	// ReadingTime is the estimated reading time in minutes, nil if unknown.
+	ReadingTime *int           `json:"reading_time"`
+	Feed        PublicItemFeed `json:"feed"`
+}
+
+// PublicItemFeed is the part of ItemFeed visitors may see. It leaves out the
+// feed's link, as private feed links often carry an access token.
+type PublicItemFeed struct {
+	ID          uint    `json:"id"`
+	Name        *string `json:"name"`
+	SiteURL     *string `json:"site_url,omitempty"`
+	EmbedVideos bool    `json:"embed_videos"`
+	Sanitize    string  `json:"sanitize"`
+}
+
+type ReqPublicItemList struct {
+	Paginate
+	GroupID uint `param:"id" validate:"required"`
+}
+
+type RespPublicItemList struct {
+	Group    PublicGroupForm   `json:"group"`
+	Total    int               `json:"total"`
+	PageSize int               `json:"page_size"`
+	Items    []*PublicItemForm `json:"items"`
+}
+
+type ReqPublicItemGet struct {
+	ID uint `param:"id" validate:"required"`
+}
+
+type RespPublicItemGet PublicItemForm
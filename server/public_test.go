@@ -0,0 +1,75 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/server"
+)
+
+func TestPublicOnlyShowsPublicGroups(t *testing.T) {
+	db := repotest.NewDB(t)
+	public := &model.Group{Name: ptr.To("Blogroll"), Public: ptr.To(true)}
+	require.NoError(t, repo.NewGroup(db).Create(public))
+	feeds := []*model.Feed{
+		{Name: ptr.To("Public Feed"), Link: ptr.To("https://example.com/feed"), GroupID: &public.ID},
+		{Name: ptr.To("Private Feed"), Link: ptr.To("https://example.org/feed"), GroupID: ptr.To(uint(1))},
+	}
+	require.NoError(t, repo.NewFeed(db).Create(feeds))
+	_, err := repo.NewItem(db).Insert([]*model.Item{
+		{GUID: ptr.To("public"), Title: ptr.To("Public post"), Content: ptr.To("<p>Hello</p>"), FeedID: feeds[0].ID},
+		{GUID: ptr.To("private"), Title: ptr.To("Private post"), FeedID: feeds[1].ID},
+	})
+	require.NoError(t, err)
+	items, _, err := repo.NewItem(db).List(repo.ItemFilter{}, 1, 10)
+	require.NoError(t, err)
+	itemIDs := map[string]uint{}
+	for _, v := range items {
+		itemIDs[*v.GUID] = v.ID
+	}
+	publicService := server.NewPublic(repo.NewGroup(db), repo.NewItem(db), 10)
+	ctx := context.Background()
+
+	groups, err := publicService.Groups(ctx)
+	require.NoError(t, err)
+	require.Len(t, groups.Groups, 1)
+	assert.Equal(t, "Blogroll", *groups.Groups[0].Name)
+
+	list, err := publicService.ListItems(ctx, &server.ReqPublicItemList{GroupID: public.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, list.Total)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "Public post", *list.Items[0].Title)
+	assert.Nil(t, list.Items[0].Content, "lists must leave out the content")
+
+	item, err := publicService.GetItem(ctx, &server.ReqPublicItemGet{ID: itemIDs["public"]})
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Hello</p>", *item.Content)
+	body, err := json.Marshal(item)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "https://example.com/feed", "feed links may carry access tokens")
+
+	for _, err := range []error{
+		func() error {
+			_, err := publicService.ListItems(ctx, &server.ReqPublicItemList{GroupID: 1})
+			return err
+		}(),
+		func() error {
+			_, err := publicService.GetItem(ctx, &server.ReqPublicItemGet{ID: itemIDs["private"]})
+			return err
+		}(),
+	} {
+		var bizErr server.BizError
+		require.ErrorAs(t, err, &bizErr)
+		assert.EqualValues(t, http.StatusNotFound, bizErr.HTTPCode, "private groups must look missing")
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/repo"
+)
+
+type ScoreKeywordRepo interface {
+	All() ([]*model.ScoreKeyword, error)
+	Create(keyword *model.ScoreKeyword) error
+	Delete(id uint) error
+}
+
+type ScoreKeyword struct {
+	repo ScoreKeywordRepo
+}
+
+func NewScoreKeyword(repo ScoreKeywordRepo) *ScoreKeyword {
+	return &ScoreKeyword{
+		repo: repo,
+	}
+}
+
+func (s ScoreKeyword) All(ctx context.Context) (*RespScoreKeywordAll, error) {
+	data, err := s.repo.All()
+	if err != nil {
+		return nil, err
+	}
+
+	keywords := make([]*ScoreKeywordForm, 0, len(data))
+	for _, v := range data {
+		keywords = append(keywords, &ScoreKeywordForm{
+			ID:      v.ID,
+			Keyword: v.Keyword,
+			Weight:  v.Weight,
+		})
+	}
+	return &RespScoreKeywordAll{
+		Keywords: keywords,
+	}, nil
+}
+
+func (s ScoreKeyword) Create(ctx context.Context, req *ReqScoreKeywordCreate) (*RespScoreKeywordCreate, error) {
+	keyword := strings.TrimSpace(*req.Keyword)
+	if keyword == "" {
+		msg := "keyword is required"
+		return nil, NewBizError(errors.New(msg), http.StatusBadRequest, msg)
+	}
+
+	newKeyword := &model.ScoreKeyword{
+		Keyword: &keyword,
+		Weight:  req.Weight,
+	}
+	if err := s.repo.Create(newKeyword); err != nil {
+		if errors.Is(err, repo.ErrDuplicatedKey) {
+			err = NewBizError(err, http.StatusBadRequest, "keyword already exists")
+		}
+		return nil, err
+	}
+	return &RespScoreKeywordCreate{ID: newKeyword.ID}, nil
+}
+
+func (s ScoreKeyword) Delete(ctx context.Context, req *ReqScoreKeywordDelete) error {
+	return s.repo.Delete(req.ID)
+}
@@ -0,0 +1,24 @@
+package server
+
+type ScoreKeywordForm struct {
+	ID      uint    `json:"id"`
+	Keyword *string `json:"keyword"`
+	Weight  int     `json:"weight"`
+}
+
+type RespScoreKeywordAll struct {
+	Keywords []*ScoreKeywordForm `json:"keywords"`
+}
+
+type ReqScoreKeywordCreate struct {
+	Keyword *string `json:"keyword" validate:"required"`
+	Weight  int     `json:"weight" validate:"required"`
+}
+
+type RespScoreKeywordCreate struct {
+	ID uint `json:"id"`
+}
+
+type ReqScoreKeywordDelete struct {
+	ID uint `param:"id" validate:"required"`
+}
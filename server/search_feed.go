@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/0x2e/fusion/auth"
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+)
+
+// searchFeedSize is the number of latest matching items in a search feed.
+const searchFeedSize = 50
+
+// searchFeedTokenMessage is signed to authorize reading the search feed of
+// keyword. The prefix keeps keywords from matching the messages of other
+// links, like noteTokenMessage.
+func searchFeedTokenMessage(keyword string) string {
+	return "search:" + keyword
+}
+
+type SearchFeedRepo interface {
+	List(filter repo.ItemFilter, page, pageSize int) ([]*model.Item, int, error)
+}
+
+// SearchFeed exposes search results as RSS feeds, so that other tools can
+// subscribe to items matching a keyword across all feeds.
+type SearchFeed struct {
+	repo SearchFeedRepo
+	// passwordHash signs the feed links. It's nil if the instance has no
+	// password, in which case links aren't signed.
+	passwordHash *auth.HashedPassword
+}
+
+func NewSearchFeed(repo SearchFeedRepo, passwordHash *auth.HashedPassword) *SearchFeed {
+	return &SearchFeed{
+		repo:         repo,
+		passwordHash: passwordHash,
+	}
+}
+
+func (s SearchFeed) Link(ctx context.Context, req *ReqSearchFeedLink) (*RespSearchFeedLink, error) {
+	query := url.Values{}
+	query.Set("keyword", req.Keyword)
+	if s.passwordHash != nil {
+		query.Set("token", s.passwordHash.Sign(searchFeedTokenMessage(req.Keyword)))
+	}
+	return &RespSearchFeedLink{
+		Link: "/api/search-feed?" + query.Encode(),
+	}, nil
+}
+
+// Get returns the RSS feed of items matching req.Keyword. selfLink is the
+// absolute URL of the web UI, used as the channel link.
+func (s SearchFeed) Get(ctx context.Context, req *ReqSearchFeed, selfLink string) (*RSS, error) {
+	if s.passwordHash != nil && !s.passwordHash.Verify(searchFeedTokenMessage(req.Keyword), req.Token) {
+		msg := "invalid token"
+		return nil, NewBizError(fmt.Errorf("%s for search feed %q", msg, req.Keyword), http.StatusUnauthorized, msg)
+	}
+
+	data, _, err := s.repo.List(repo.ItemFilter{Keyword: &req.Keyword}, 1, searchFeedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*RSSItem, 0, len(data))
+	for _, v := range data {
+		item := &RSSItem{
+			Title:       ptr.From(v.Title),
+			Link:        ptr.From(v.Link),
+			Description: ptr.From(v.Content),
+			GUID: RSSGUID{
+				Value: fmt.Sprintf("%d-%s", v.FeedID, ptr.From(v.GUID)),
+			},
+			Source: ptr.From(v.Feed.Name),
+		}
+		if v.PubDate != nil {
+			item.PubDate = v.PubDate.Format(time.RFC1123Z)
+		}
+		items = append(items, item)
+	}
+
+	query := url.Values{}
+	query.Set("keyword", req.Keyword)
+	return &RSS{
+		Version: "2.0",
+		Channel: RSSChannel{
+			Title:       fmt.Sprintf("Fusion: %s", req.Keyword),
+			Link:        selfLink + "/search?" + query.Encode(),
+			Description: fmt.Sprintf("Items mentioning %q", req.Keyword),
+			Items:       items,
+		},
+	}, nil
+}
@@ -0,0 +1,45 @@
+package server
+
+import "encoding/xml"
+
+type ReqSearchFeedLink struct {
+	Keyword string `query:"keyword" validate:"required"`
+}
+
+type RespSearchFeedLink struct {
+	// Link is relative to the server root.
+	Link string `json:"link"`
+}
+
+type ReqSearchFeed struct {
+	Keyword string `query:"keyword" validate:"required"`
+	Token   string `query:"token"`
+}
+
+// RSS is an RSS 2.0 document.
+type RSS struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Description string     `xml:"description"`
+	Items       []*RSSItem `xml:"item"`
+}
+
+type RSSItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        RSSGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Source      string  `xml:"source,omitempty"`
+}
+
+type RSSGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
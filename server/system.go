@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x2e/fusion/conf"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/service/pull"
+)
+
+// LatestReleaseURL is the GitHub API endpoint describing fusion's latest
+// release.
+const LatestReleaseURL = "https://api.github.com/repos/0x2E/fusion/releases/latest"
+
+// startedAt approximates when the server started.
+var startedAt = time.Now()
+
+type SystemFeedRepo interface {
+	Count() (int, error)
+}
+
+type SystemItemRepo interface {
+	Counts() (*repo.ItemCounts, error)
+}
+
+type SystemSchemaRepo interface {
+	Version() (int, error)
+	Pending() ([]repo.Migration, error)
+}
+
+type SystemParams struct {
+	DBPath           string
+	Settings         []conf.Setting
+	LatestReleaseURL string
+}
+
+type System struct {
+	feedRepo   SystemFeedRepo
+	itemRepo   SystemItemRepo
+	schemaRepo SystemSchemaRepo
+	params     SystemParams
+	client     *http.Client
+}
+
+func NewSystem(feedRepo SystemFeedRepo, itemRepo SystemItemRepo, schemaRepo SystemSchemaRepo, params SystemParams) *System {
+	return &System{
+		feedRepo:   feedRepo,
+		itemRepo:   itemRepo,
+		schemaRepo: schemaRepo,
+		params:     params,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s System) Get(ctx context.Context) (*RespSystem, error) {
+	feeds, err := s.feedRepo.Count()
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.itemRepo.Counts()
+	if err != nil {
+		return nil, err
+	}
+	schemaVersion, err := s.schemaRepo.Version()
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.schemaRepo.Pending()
+	if err != nil {
+		return nil, err
+	}
+	pendingMigrations := make([]RespSystemMigration, 0, len(pending))
+	for _, m := range pending {
+		pendingMigrations = append(pendingMigrations, RespSystemMigration{
+			Version:     m.Version,
+			Description: m.Description,
+		})
+	}
+	dbSize, err := fileSize(s.params.DBPath)
+	if err != nil {
+		return nil, err
+	}
+	walSize, err := fileSize(s.params.DBPath + "-wal")
+	if err != nil {
+		return nil, err
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return &RespSystem{
+		Version:       conf.Version,
+		StartedAt:     startedAt,
+		UptimeSeconds: int64(time.Since(startedAt).Seconds()),
+		DBPath:        s.params.DBPath,
+		DBSize:        dbSize + walSize,
+		Schema: RespSystemSchema{
+			Version:           schemaVersion,
+			LatestVersion:     repo.LatestSchemaVersion(),
+			PendingMigrations: pendingMigrations,
+		},
+		Counts: RespSystemCounts{
+			Feeds:           feeds,
+			Items:           items.Total,
+			UnreadItems:     items.Unread,
+			BookmarkedItems: items.Bookmarked,
+		},
+		Runtime: RespSystemRuntime{
+			GoVersion:  runtime.Version(),
+			OS:         runtime.GOOS,
+			Arch:       runtime.GOARCH,
+			NumCPU:     runtime.NumCPU(),
+			Goroutines: runtime.NumGoroutine(),
+			HeapAlloc:  mem.HeapAlloc,
+			Sys:        mem.Sys,
+			NumGC:      mem.NumGC,
+		},
+		LastPull: newRespFeedRefreshStatus(pull.CurrentProgress()),
+		Config:   s.params.Settings,
+	}, nil
+}
+
+// fileSize returns the size of the file at path, or 0 if it doesn't exist.
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// LatestRelease checks GitHub for a release newer than the running version.
+func (s System) LatestRelease(ctx context.Context) (*RespSystemLatestRelease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.params.LatestReleaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, NewBizError(err, http.StatusBadGateway, "failed to check for updates")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("got status code %d", resp.StatusCode)
+		return nil, NewBizError(err, http.StatusBadGateway, "failed to check for updates: "+err.Error())
+	}
+
+	var release struct {
+		TagName     string    `json:"tag_name"`
+		HTMLURL     string    `json:"html_url"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, NewBizError(err, http.StatusBadGateway, "failed to check for updates")
+	}
+
+	return &RespSystemLatestRelease{
+		CurrentVersion:  conf.Version,
+		LatestVersion:   release.TagName,
+		URL:             release.HTMLURL,
+		PublishedAt:     release.PublishedAt,
+		UpdateAvailable: isNewerVersion(release.TagName, conf.Version),
+	}, nil
+}
+
+// isNewerVersion reports whether version a, like "v1.2.3", is newer than b.
+// Versions that aren't in that form, like development builds, are never
+// older than a release.
+func isNewerVersion(a, b string) bool {
+	av, ok := parseVersion(a)
+	if !ok {
+		return false
+	}
+	bv, ok := parseVersion(b)
+	if !ok {
+		return false
+	}
+	for i := range av {
+		if av[i] != bv[i] {
+			return av[i] > bv[i]
+		}
+	}
+	return false
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var res [3]int
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) != len(res) {
+		return res, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return res, false
+		}
+		res[i] = n
+	}
+	return res, true
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"time"
+
+	"github.com/0x2e/fusion/conf"
+)
+
+type RespSystem struct {
+	Version   string    `json:"version"`
+	StartedAt time.Time `json:"started_at"`
+	// UptimeSeconds is how long the server has been running.
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	DBPath        string `json:"db_path"`
+	// DBSize is the size in bytes of the database file, including its WAL
+	// file if any.
+	DBSize   int64                  `json:"db_size"`
+	Schema   RespSystemSchema       `json:"schema"`
+	Counts   RespSystemCounts       `json:"counts"`
+	Runtime  RespSystemRuntime      `json:"runtime"`
+	LastPull *RespFeedRefreshStatus `json:"last_pull"`
+	Config   []conf.Setting         `json:"config"`
+}
+
+// RespSystemSchema reports the database schema version, see repo.Migration.
+type RespSystemSchema struct {
+	Version           int                   `json:"version"`
+	LatestVersion     int                   `json:"latest_version"`
+	PendingMigrations []RespSystemMigration `json:"pending_migrations"`
+}
+
+type RespSystemMigration struct {
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+}
+
+type RespSystemCounts struct {
+	Feeds           int `json:"feeds"`
+	Items           int `json:"items"`
+	UnreadItems     int `json:"unread_items"`
+	BookmarkedItems int `json:"bookmarked_items"`
+}
+
+type RespSystemRuntime struct {
+	GoVersion  string `json:"go_version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	NumCPU     int    `json:"num_cpu"`
+	Goroutines int    `json:"goroutines"`
+	// HeapAlloc and Sys are in bytes, see runtime.MemStats.
+	HeapAlloc uint64 `json:"heap_alloc"`
+	Sys       uint64 `json:"sys"`
+	NumGC     uint32 `json:"num_gc"`
+}
+
+type RespSystemLatestRelease struct {
+	CurrentVersion  string    `json:"current_version"`
+	LatestVersion   string    `json:"latest_version"`
+	URL             string    `json:"url"`
+	PublishedAt     time.Time `json:"published_at"`
+	UpdateAvailable bool      `json:"update_available"`
+}
@@ -0,0 +1,118 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/conf"
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/server"
+)
+
+func TestSystemGet(t *testing.T) {
+	db := repotest.NewDB(t)
+	feed := &model.Feed{Name: ptr.To("Test Feed"), Link: ptr.To("https://example.com/feed"), GroupID: ptr.To(uint(1))}
+	require.NoError(t, repo.NewFeed(db).Create([]*model.Feed{feed}))
+	_, err := repo.NewItem(db).Insert([]*model.Item{
+		{GUID: ptr.To("first"), FeedID: feed.ID, Unread: ptr.To(true), Bookmark: ptr.To(false)},
+		{GUID: ptr.To("second"), FeedID: feed.ID, Unread: ptr.To(false), Bookmark: ptr.To(true)},
+	})
+	require.NoError(t, err)
+	dbPath := filepath.Join(t.TempDir(), "fusion.db")
+	require.NoError(t, os.WriteFile(dbPath, make([]byte, 100), 0o644))
+	require.NoError(t, os.WriteFile(dbPath+"-wal", make([]byte, 20), 0o644))
+	settings := []conf.Setting{{Name: "PASSWORD", Value: "********"}}
+
+	resp, err := server.NewSystem(repo.NewFeed(db), repo.NewItem(db), repo.NewSchema(db), server.SystemParams{
+		DBPath:   dbPath,
+		Settings: settings,
+	}).Get(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, server.RespSystemCounts{
+		Feeds:           1,
+		Items:           2,
+		UnreadItems:     1,
+		BookmarkedItems: 1,
+	}, resp.Counts)
+	assert.Equal(t, dbPath, resp.DBPath)
+	assert.EqualValues(t, 120, resp.DBSize, "the size must include the WAL file")
+	assert.Equal(t, server.RespSystemSchema{
+		Version:           repo.LatestSchemaVersion(),
+		LatestVersion:     repo.LatestSchemaVersion(),
+		PendingMigrations: []server.RespSystemMigration{},
+	}, resp.Schema)
+	assert.Equal(t, settings, resp.Config)
+	assert.NotEmpty(t, resp.Runtime.GoVersion)
+	assert.NotNil(t, resp.LastPull)
+}
+
+func TestSystemLatestRelease(t *testing.T) {
+	for _, tt := range []struct {
+		description             string
+		currentVersion          string
+		status                  int
+		expectedUpdateAvailable bool
+		expectedErr             bool
+	}{
+		{
+			description:             "older version has an update",
+			currentVersion:          "v0.9.10",
+			status:                  http.StatusOK,
+			expectedUpdateAvailable: true,
+		},
+		{
+			description:    "latest version has no update",
+			currentVersion: "v1.2.0",
+			status:         http.StatusOK,
+		},
+		{
+			description:    "development build has no update",
+			currentVersion: "unknown-version",
+			status:         http.StatusOK,
+		},
+		{
+			description:    "GitHub errors are reported",
+			currentVersion: "v0.9.10",
+			status:         http.StatusForbidden,
+			expectedErr:    true,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"tag_name":"v1.2.0","html_url":"https://github.com/0x2E/fusion/releases/tag/v1.2.0","published_at":"2025-01-01T12:00:00Z"}`))
+			}))
+			defer github.Close()
+			defer func(v string) { conf.Version = v }(conf.Version)
+			conf.Version = tt.currentVersion
+			db := repotest.NewDB(t)
+
+			resp, err := server.NewSystem(repo.NewFeed(db), repo.NewItem(db), repo.NewSchema(db), server.SystemParams{
+				LatestReleaseURL: github.URL,
+			}).LatestRelease(context.Background())
+
+			if tt.expectedErr {
+				var bizErr server.BizError
+				require.ErrorAs(t, err, &bizErr)
+				assert.EqualValues(t, http.StatusBadGateway, bizErr.HTTPCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.currentVersion, resp.CurrentVersion)
+			assert.Equal(t, "v1.2.0", resp.LatestVersion)
+			assert.Equal(t, "https://github.com/0x2E/fusion/releases/tag/v1.2.0", resp.URL)
+			assert.Equal(t, tt.expectedUpdateAvailable, resp.UpdateAvailable)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package integrity repairs, on a schedule, the rows left referencing rows
+// that are gone, see repo.Integrity.
+package integrity
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/0x2e/fusion/pkg/errreport"
+	"github.com/0x2e/fusion/repo"
+)
+
+var interval = 24 * time.Hour
+
+type Repo interface {
+	Check(repair bool) (*repo.IntegrityReport, error)
+}
+
+type Checker struct {
+	repo Repo
+}
+
+func NewChecker(repo Repo) *Checker {
+	return &Checker{
+		repo: repo,
+	}
+}
+
+func (c *Checker) Run() {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.repairScheduled()
+
+		<-ticker.C
+	}
+}
+
+// repairScheduled runs a scheduled repair, recovering from panics so that
+// later runs still happen.
+func (c *Checker) repairScheduled() {
+	defer errreport.Recover(context.Background())
+
+	report, err := c.repo.Check(true)
+	if err != nil {
+		slog.Error("failed to check database integrity", "error", err)
+		return
+	}
+	if report.Total() > 0 {
+		slog.Warn("repaired database integrity",
+			"orphaned_items", report.OrphanedItems,
+			"feeds_without_group", report.FeedsWithoutGroup,
+			"group_rules_without_group", report.GroupRulesWithoutGroup,
+			"opml_subscriptions_without_group", report.OPMLSubscriptionsWithoutGroup,
+			"feeds_without_opml_subscription", report.FeedsWithoutOPMLSubscription)
+	}
+}
@@ -0,0 +1,207 @@
+// Package job runs background work from a queue stored in the database, so
+// that work outlives the request that asked for it and survives restarts.
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/errreport"
+	"github.com/0x2e/fusion/pkg/logctx"
+	"github.com/0x2e/fusion/repo"
+)
+
+var (
+	// pollInterval is how often idle workers look for due jobs.
+	pollInterval = time.Second
+	// workers is the number of jobs run at once.
+	workers = 4
+	// retention is how long succeeded jobs are kept.
+	retention = 7 * 24 * time.Hour
+)
+
+type Repo interface {
+	Create(job *model.Job) error
+	Claim(now time.Time) (*model.Job, error)
+	Finish(id uint, now time.Time) error
+	Fail(id uint, failure string, now time.Time, retryAt *time.Time) error
+	ResetRunning() (int, error)
+	DeleteSucceeded(before time.Time) error
+}
+
+// Handler runs a job of a kind, given the payload it was enqueued with. A
+// returned error fails the attempt.
+type Handler func(ctx context.Context, payload []byte) error
+
+// RetryPolicy decides whether and when a failed job is tried again.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts before a job is given up on.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubling for each of the
+	// following ones.
+	Backoff time.Duration
+}
+
+// DefaultRetryPolicy tries a job 5 times over about 15 minutes.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	Backoff:     time.Minute,
+}
+
+// retryAt returns when to retry a job that failed its attempts-th attempt, or
+// nil if it shouldn't be retried.
+func (p RetryPolicy) retryAt(attempts int, now time.Time) *time.Time {
+	if attempts >= p.MaxAttempts {
+		return nil
+	}
+	t := now.Add(p.Backoff << (attempts - 1))
+	return &t
+}
+
+type kind struct {
+	handle Handler
+	policy RetryPolicy
+}
+
+// Queue enqueues jobs, and runs those of the kinds registered with it.
+type Queue struct {
+	repo Repo
+
+	mu    sync.RWMutex
+	kinds map[string]kind
+}
+
+func NewQueue(repo Repo) *Queue {
+	return &Queue{
+		repo:  repo,
+		kinds: make(map[string]kind),
+	}
+}
+
+// Register sets the handler of the jobs of a kind.
+func (q *Queue) Register(name string, handle Handler, policy RetryPolicy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.kinds[name] = kind{handle: handle, policy: policy}
+}
+
+// Enqueue stores a job of the given kind, to be run as soon as a worker is
+// free. payload is encoded as JSON.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return q.repo.Create(&model.Job{
+		Kind:    kind,
+		Payload: string(data),
+		Status:  model.JobPending,
+		RunAt:   time.Now(),
+	})
+}
+
+// Run runs the due jobs forever.
+func (q *Queue) Run() {
+	// The jobs that were running when the process stopped are lost, as
+	// nothing else runs them.
+	if n, err := q.repo.ResetRunning(); err != nil {
+		slog.Error("failed to reset interrupted jobs", "error", err)
+	} else if n > 0 {
+		slog.Info("requeued interrupted jobs", "count", n)
+	}
+
+	pool := make(chan struct{}, workers)
+	var lastPrune time.Time
+	for {
+		if time.Since(lastPrune) > time.Hour {
+			lastPrune = time.Now()
+			if err := q.repo.DeleteSucceeded(lastPrune.Add(-retention)); err != nil {
+				slog.Error("failed to delete old jobs", "error", err)
+			}
+		}
+
+		pool <- struct{}{}
+		job, err := q.repo.Claim(time.Now())
+		if err != nil {
+			<-pool
+			if !errors.Is(err, repo.ErrNotFound) {
+				slog.Error("failed to claim job", "error", err)
+			}
+			time.Sleep(pollInterval)
+			continue
+		}
+		go func() {
+			defer func() { <-pool }()
+			q.run(context.Background(), job)
+		}()
+	}
+}
+
+// RunDue runs the jobs due now one after another, until there are none left.
+func (q *Queue) RunDue(ctx context.Context) error {
+	for {
+		job, err := q.repo.Claim(time.Now())
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		q.run(ctx, job)
+	}
+}
+
+// run runs a claimed job and records the outcome.
+func (q *Queue) run(ctx context.Context, job *model.Job) {
+	logger := logctx.From(ctx).With("job_id", job.ID, "job_kind", job.Kind, "job_attempt", job.Attempts)
+	ctx = logctx.With(ctx, logger)
+
+	q.mu.RLock()
+	k, ok := q.kinds[job.Kind]
+	q.mu.RUnlock()
+	var err error
+	if ok {
+		err = safeHandle(ctx, job, k.handle)
+	} else {
+		err = fmt.Errorf("unknown job kind %q", job.Kind)
+	}
+
+	now := time.Now()
+	if err == nil {
+		if err := q.repo.Finish(job.ID, now); err != nil {
+			logger.Error("failed to record finished job", "error", err)
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	if ok {
+		retryAt = k.policy.retryAt(job.Attempts, now)
+	}
+	if retryAt != nil {
+		logger.Warn("job failed, retrying later", "error", err, "retry_at", *retryAt)
+	} else {
+		logger.Error("job failed", "error", err)
+	}
+	if err := q.repo.Fail(job.ID, err.Error(), now, retryAt); err != nil {
+		logger.Error("failed to record failed job", "error", err)
+	}
+}
+
+// safeHandle turns a panic of the handler into an error, so that it fails the
+// job instead of the process. The panic is reported as well.
+func safeHandle(ctx context.Context, job *model.Job, handle Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			errreport.CapturePanic(ctx, r, "job_id", job.ID, "job_kind", job.Kind)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handle(ctx, []byte(job.Payload))
+}
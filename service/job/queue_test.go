@@ -0,0 +1,133 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+)
+
+type echoJob struct {
+	Message string `json:"message"`
+}
+
+func newQueue(t *testing.T) (*Queue, *repo.Job) {
+	t.Helper()
+
+	jobRepo := repo.NewJob(repotest.NewDB(t))
+	return NewQueue(jobRepo), jobRepo
+}
+
+func TestQueueRunsJobs(t *testing.T) {
+	q, jobRepo := newQueue(t)
+	var got []string
+	q.Register("echo", func(ctx context.Context, payload []byte) error {
+		got = append(got, string(payload))
+		return nil
+	}, DefaultRetryPolicy)
+	ctx := context.Background()
+
+	require.NoError(t, q.Enqueue(ctx, "echo", echoJob{Message: "first"}))
+	require.NoError(t, q.Enqueue(ctx, "echo", echoJob{Message: "second"}))
+	require.NoError(t, q.RunDue(ctx))
+
+	assert.Equal(t, []string{`{"message":"first"}`, `{"message":"second"}`}, got)
+	jobs, total, err := jobRepo.List(repo.JobFilter{Status: ptr.To(model.JobSucceeded)}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	for _, v := range jobs {
+		assert.Equal(t, 1, v.Attempts)
+		assert.NotNil(t, v.FinishedAt)
+	}
+}
+
+func TestQueueRetriesFailedJobs(t *testing.T) {
+	q, jobRepo := newQueue(t)
+	attempts := 0
+	// Without backoff, the retries are due right away.
+	q.Register("fail", func(ctx context.Context, payload []byte) error {
+		attempts++
+		return errors.New("dummy error")
+	}, RetryPolicy{MaxAttempts: 3})
+	ctx := context.Background()
+	require.NoError(t, q.Enqueue(ctx, "fail", nil))
+
+	require.NoError(t, q.RunDue(ctx))
+
+	assert.Equal(t, 3, attempts)
+	jobs, _, err := jobRepo.List(repo.JobFilter{}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, model.JobFailed, jobs[0].Status, "a job must be given up on after MaxAttempts")
+	assert.Equal(t, 3, jobs[0].Attempts)
+	assert.Equal(t, "dummy error", ptr.From(jobs[0].Failure))
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Minute}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, ptr.To(now.Add(time.Minute)), policy.retryAt(1, now))
+	assert.Equal(t, ptr.To(now.Add(2*time.Minute)), policy.retryAt(2, now))
+	assert.Nil(t, policy.retryAt(3, now))
+}
+
+func TestQueueFailsBrokenJobs(t *testing.T) {
+	for _, tt := range []struct {
+		description     string
+		kind            string
+		expectedFailure string
+	}{
+		{
+			description:     "unknown kinds fail",
+			kind:            "unknown",
+			expectedFailure: `unknown job kind "unknown"`,
+		},
+		{
+			description:     "panics fail the job",
+			kind:            "panic",
+			expectedFailure: "panic: dummy panic",
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			q, jobRepo := newQueue(t)
+			q.Register("panic", func(ctx context.Context, payload []byte) error {
+				panic("dummy panic")
+			}, RetryPolicy{MaxAttempts: 1})
+			ctx := context.Background()
+			require.NoError(t, q.Enqueue(ctx, tt.kind, nil))
+
+			require.NoError(t, q.RunDue(ctx))
+
+			jobs, _, err := jobRepo.List(repo.JobFilter{}, 1, 10)
+			require.NoError(t, err)
+			require.Len(t, jobs, 1)
+			assert.Equal(t, model.JobFailed, jobs[0].Status)
+			assert.Equal(t, tt.expectedFailure, ptr.From(jobs[0].Failure))
+		})
+	}
+}
+
+func TestResetRunning(t *testing.T) {
+	q, jobRepo := newQueue(t)
+	require.NoError(t, q.Enqueue(context.Background(), "echo", nil))
+	claimed, err := jobRepo.Claim(time.Now())
+	require.NoError(t, err)
+	_, err = jobRepo.Claim(time.Now())
+	assert.ErrorIs(t, err, repo.ErrNotFound, "a running job must not be claimed again")
+
+	n, err := jobRepo.ResetRunning()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	again, err := jobRepo.Claim(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, claimed.ID, again.ID, "an interrupted job must be run again")
+}
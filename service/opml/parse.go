@@ -0,0 +1,107 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// Feed is a feed outline in an OPML file.
+type Feed struct {
+	Name    string
+	Link    string
+	SiteURL string
+}
+
+// Group is a folder in an OPML file. Nested folders are flattened using the
+// "a/b/c" naming convention. Feeds outside of any folder are in the group with
+// an empty name.
+type Group struct {
+	Name  string
+	Feeds []Feed
+}
+
+type outline struct {
+	Type     string    `xml:"type,attr"`
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr"`
+	XMLURL   string    `xml:"xmlUrl,attr"`
+	HTMLURL  string    `xml:"htmlUrl,attr"`
+	Outlines []outline `xml:"outline"`
+}
+
+type document struct {
+	Body struct {
+		Outlines []outline `xml:"outline"`
+	} `xml:"body"`
+}
+
+// Parse reads an OPML document. It mirrors the importer in the frontend, but
+// also accepts feed outlines that lack the type attribute.
+func Parse(r io.Reader) ([]Group, error) {
+	var doc document
+	decoder := xml.NewDecoder(r)
+	// OPML files in the wild often declare encodings other than UTF-8, but
+	// they are UTF-8 in practice.
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	groups := []Group{{Name: ""}}
+	index := map[string]int{"": 0}
+	var walk func(parent string, o outline)
+	walk = func(parent string, o outline) {
+		if strings.EqualFold(o.Type, "rss") || (o.XMLURL != "" && len(o.Outlines) == 0) {
+			link := o.XMLURL
+			if link == "" {
+				link = o.HTMLURL
+			}
+			if link == "" {
+				return
+			}
+			name := o.Title
+			if name == "" {
+				name = o.Text
+			}
+			g := &groups[index[parent]]
+			g.Feeds = append(g.Feeds, Feed{
+				Name:    name,
+				Link:    link,
+				SiteURL: o.HTMLURL,
+			})
+			return
+		}
+		if len(o.Outlines) == 0 {
+			return
+		}
+
+		name := o.Text
+		if name == "" {
+			name = o.Title
+		}
+		if parent != "" {
+			name = parent + "/" + name
+		}
+		if _, ok := index[name]; !ok {
+			index[name] = len(groups)
+			groups = append(groups, Group{Name: name})
+		}
+		for _, child := range o.Outlines {
+			walk(name, child)
+		}
+	}
+	for _, o := range doc.Body.Outlines {
+		walk("", o)
+	}
+
+	res := make([]Group, 0, len(groups))
+	for _, g := range groups {
+		if len(g.Feeds) > 0 {
+			res = append(res, g)
+		}
+	}
+	return res, nil
+}
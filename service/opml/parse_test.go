@@ -0,0 +1,93 @@
+package opml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/service/opml"
+)
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		description    string
+		content        string
+		expectedGroups []opml.Group
+		expectedErr    bool
+	}{
+		{
+			description: "feeds outside of folders are ungrouped",
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <body>
+    <outline type="rss" text="Example" xmlUrl="https://example.com/feed.xml" htmlUrl="https://example.com/"/>
+  </body>
+</opml>`,
+			expectedGroups: []opml.Group{
+				{
+					Name: "",
+					Feeds: []opml.Feed{
+						{Name: "Example", Link: "https://example.com/feed.xml", SiteURL: "https://example.com/"},
+					},
+				},
+			},
+		},
+		{
+			description: "nested folders are flattened",
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <body>
+    <outline text="Tech">
+      <outline type="rss" title="A" xmlUrl="https://a.com/feed"/>
+      <outline text="Go">
+        <outline type="rss" title="B" xmlUrl="https://b.com/feed"/>
+      </outline>
+    </outline>
+  </body>
+</opml>`,
+			expectedGroups: []opml.Group{
+				{
+					Name:  "Tech",
+					Feeds: []opml.Feed{{Name: "A", Link: "https://a.com/feed"}},
+				},
+				{
+					Name:  "Tech/Go",
+					Feeds: []opml.Feed{{Name: "B", Link: "https://b.com/feed"}},
+				},
+			},
+		},
+		{
+			description: "feed outlines without type are accepted",
+			content: `<?xml version="1.0" encoding="ISO-8859-1"?>
+<opml version="1.0">
+  <body>
+    <outline text="C" xmlUrl="https://c.com/feed"/>
+    <outline text="Empty folder"/>
+  </body>
+</opml>`,
+			expectedGroups: []opml.Group{
+				{
+					Name:  "",
+					Feeds: []opml.Feed{{Name: "C", Link: "https://c.com/feed"}},
+				},
+			},
+		},
+		{
+			description: "invalid xml",
+			content:     "not xml",
+			expectedErr: true,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			groups, err := opml.Parse(strings.NewReader(tt.content))
+			if tt.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedGroups, groups)
+		})
+	}
+}
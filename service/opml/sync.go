@@ -0,0 +1,238 @@
+package opml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/errreport"
+	"github.com/0x2e/fusion/pkg/httpx"
+	"github.com/0x2e/fusion/pkg/logctx"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+)
+
+var (
+	interval = 12 * time.Hour
+)
+
+type SubscriptionRepo interface {
+	List() ([]*model.OPMLSubscription, error)
+	Get(id uint) (*model.OPMLSubscription, error)
+	Update(id uint, sub *model.OPMLSubscription) error
+}
+
+type FeedRepo interface {
+	List(filter *repo.FeedListFilter) ([]*model.Feed, error)
+	DeletedOPMLLinks(opmlSubscriptionID uint) ([]string, error)
+	Create(feeds []*model.Feed) error
+	Update(id uint, feed *model.Feed) error
+}
+
+type GroupRepo interface {
+	All() ([]*model.Group, error)
+	Create(group *model.Group) error
+}
+
+// Syncer keeps feeds in sync with remote OPML files. New feeds are added and
+// feeds that disappear from the file are flagged, but never deleted. Feeds
+// the user deleted are not added again.
+type Syncer struct {
+	subRepo   SubscriptionRepo
+	feedRepo  FeedRepo
+	groupRepo GroupRepo
+}
+
+func NewSyncer(subRepo SubscriptionRepo, feedRepo FeedRepo, groupRepo GroupRepo) *Syncer {
+	return &Syncer{
+		subRepo:   subRepo,
+		feedRepo:  feedRepo,
+		groupRepo: groupRepo,
+	}
+}
+
+func (s *Syncer) Run() {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.syncAllScheduled()
+
+		<-ticker.C
+	}
+}
+
+// syncAllScheduled runs a scheduled SyncAll, recovering from panics so that
+// later runs still happen.
+func (s *Syncer) syncAllScheduled() {
+	ctx := context.Background()
+	defer errreport.Recover(ctx)
+
+	s.SyncAll(ctx)
+}
+
+func (s *Syncer) SyncAll(ctx context.Context) error {
+	subs, err := s.subRepo.List()
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			err = nil
+		}
+		return err
+	}
+
+	for _, sub := range subs {
+		if err := s.sync(ctx, sub); err != nil {
+			logctx.From(ctx).Error("failed to sync opml", "error", err, "opml_subscription_id", sub.ID, "opml_link", ptr.From(sub.Link))
+		}
+	}
+	return nil
+}
+
+// SyncOne syncs a single subscription. The result is recorded on the
+// subscription, and the error is returned as well.
+func (s *Syncer) SyncOne(ctx context.Context, id uint) error {
+	sub, err := s.subRepo.Get(id)
+	if err != nil {
+		return err
+	}
+
+	return s.sync(ctx, sub)
+}
+
+func (s *Syncer) sync(ctx context.Context, sub *model.OPMLSubscription) error {
+	groups, fetchErr := fetch(ctx, *sub.Link)
+	if fetchErr == nil {
+		fetchErr = s.apply(ctx, sub, groups)
+	}
+
+	record := &model.OPMLSubscription{Failure: ptr.To("")}
+	if fetchErr != nil {
+		record.Failure = ptr.To(fetchErr.Error())
+	} else {
+		record.LastSync = ptr.To(time.Now())
+	}
+	if err := s.subRepo.Update(sub.ID, record); err != nil {
+		return err
+	}
+	return fetchErr
+}
+
+func fetch(ctx context.Context, link string) ([]Group, error) {
+	resp, err := httpx.FusionRequest(ctx, link, model.FeedRequestOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got status code %d", resp.StatusCode)
+	}
+	return Parse(resp.Body)
+}
+
+// apply adds the feeds in groups that don't exist yet, and flags the feeds of
+// the subscription that are no longer listed.
+func (s *Syncer) apply(ctx context.Context, sub *model.OPMLSubscription, groups []Group) error {
+	existingGroups, err := s.groupRepo.All()
+	if err != nil {
+		return err
+	}
+	groupIDs := make(map[string]uint, len(existingGroups))
+	for _, g := range existingGroups {
+		groupIDs[*g.Name] = g.ID
+	}
+
+	existingFeeds, err := s.feedRepo.List(nil)
+	if err != nil {
+		return err
+	}
+	feedsByLink := make(map[string]*model.Feed, len(existingFeeds))
+	for _, f := range existingFeeds {
+		feedsByLink[*f.Link] = f
+	}
+	deletedLinks, err := s.feedRepo.DeletedOPMLLinks(sub.ID)
+	if err != nil {
+		return err
+	}
+	deleted := make(map[string]bool, len(deletedLinks))
+	for _, link := range deletedLinks {
+		deleted[link] = true
+	}
+
+	listed := make(map[string]bool)
+	newFeeds := make([]*model.Feed, 0)
+	for _, g := range groups {
+		groupID := sub.GroupID
+		if g.Name != "" {
+			id, ok := groupIDs[g.Name]
+			if !ok {
+				newGroup := &model.Group{Name: ptr.To(g.Name)}
+				if err := s.groupRepo.Create(newGroup); err != nil {
+					return err
+				}
+				id = newGroup.ID
+				groupIDs[g.Name] = id
+			}
+			groupID = id
+		}
+
+		for _, f := range g.Feeds {
+			if listed[f.Link] {
+				continue
+			}
+			listed[f.Link] = true
+
+			if existing, ok := feedsByLink[f.Link]; ok {
+				// it's listed again after being removed
+				if existing.RemovedFromOPML != nil && *existing.RemovedFromOPML {
+					if err := s.feedRepo.Update(existing.ID, &model.Feed{RemovedFromOPML: ptr.To(false)}); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			// the user unsubscribed from it
+			if deleted[f.Link] {
+				continue
+			}
+
+			name := f.Name
+			if name == "" {
+				name = f.Link
+			}
+			feed := &model.Feed{
+				Name:               ptr.To(name),
+				Link:               ptr.To(f.Link),
+				GroupID:            ptr.To(groupID),
+				OPMLSubscriptionID: ptr.To(sub.ID),
+			}
+			if f.SiteURL != "" {
+				feed.SiteURL = ptr.To(f.SiteURL)
+			}
+			newFeeds = append(newFeeds, feed)
+		}
+	}
+
+	if len(newFeeds) > 0 {
+		if err := s.feedRepo.Create(newFeeds); err != nil {
+			return err
+		}
+		logctx.From(ctx).Info("added feeds from opml", "feeds", len(newFeeds), "opml_subscription_id", sub.ID)
+	}
+
+	for _, f := range existingFeeds {
+		if f.OPMLSubscriptionID == nil || *f.OPMLSubscriptionID != sub.ID || listed[*f.Link] {
+			continue
+		}
+		if f.RemovedFromOPML != nil && *f.RemovedFromOPML {
+			continue
+		}
+		if err := s.feedRepo.Update(f.ID, &model.Feed{RemovedFromOPML: ptr.To(true)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
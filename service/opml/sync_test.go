@@ -0,0 +1,60 @@
+package opml_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/service/opml"
+)
+
+const syncedOPML = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <body>
+    <outline type="rss" text="One" xmlUrl="https://one.example.com/feed.xml"/>
+    <outline type="rss" text="Two" xmlUrl="https://two.example.com/feed.xml"/>
+  </body>
+</opml>`
+
+func TestSyncSkipsDeletedFeeds(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(syncedOPML))
+	}))
+	defer srv.Close()
+
+	db := repotest.NewDB(t)
+	subRepo := repo.NewOPMLSubscription(db)
+	feedRepo := repo.NewFeed(db)
+	sub := &model.OPMLSubscription{Link: ptr.To(srv.URL), GroupID: 1}
+	require.NoError(t, subRepo.Create(sub))
+	syncer := opml.NewSyncer(subRepo, feedRepo, repo.NewGroup(db))
+	ctx := context.Background()
+
+	require.NoError(t, syncer.SyncOne(ctx, sub.ID))
+	feeds, err := feedRepo.List(nil)
+	require.NoError(t, err)
+	require.Len(t, feeds, 2)
+
+	var deleted *model.Feed
+	for _, f := range feeds {
+		if *f.Link == "https://one.example.com/feed.xml" {
+			deleted = f
+		}
+	}
+	require.NotNil(t, deleted)
+	require.NoError(t, feedRepo.Delete(deleted.ID))
+
+	require.NoError(t, syncer.SyncOne(ctx, sub.ID))
+	feeds, err = feedRepo.List(nil)
+	require.NoError(t, err)
+	require.Len(t, feeds, 1, "a deleted feed must not be added again")
+	assert.Equal(t, "https://two.example.com/feed.xml", *feeds[0].Link)
+}
@@ -0,0 +1,134 @@
+package pull
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// breakerThreshold is the number of consecutive unreachable fetches from
+	// a host that trip its circuit.
+	breakerThreshold = 3
+	// breakerCooldown is how long a tripped circuit skips the host's feeds
+	// before one of them is tried again.
+	breakerCooldown = 10 * time.Minute
+)
+
+// circuits tracks the hosts that stopped responding, across all their feeds.
+var circuits = newBreaker()
+
+type hostCircuit struct {
+	failures int
+	// openUntil is when the host may be tried again, if the circuit is
+	// tripped.
+	openUntil time.Time
+	// probing is set while a fetch tries the host after openUntil. Other
+	// feeds of the host still wait for its outcome.
+	probing bool
+}
+
+// breaker is a circuit breaker per host, so that a dead host with many feeds
+// doesn't use up PullAll's deadline waiting for each of them to time out.
+type breaker struct {
+	mu    sync.Mutex
+	hosts map[string]*hostCircuit
+}
+
+func newBreaker() *breaker {
+	return &breaker{hosts: make(map[string]*hostCircuit)}
+}
+
+// allow reports whether a feed of host may be fetched at now. If not, it
+// returns when the host may be tried again.
+func (b *breaker) allow(host string, now time.Time) (bool, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.hosts[host]
+	if !ok || c.failures < breakerThreshold {
+		return true, time.Time{}
+	}
+	if now.Before(c.openUntil) || c.probing {
+		return false, c.openUntil
+	}
+	c.probing = true
+	return true, time.Time{}
+}
+
+// record updates the circuit of host with the outcome of a fetch, which
+// failed with err.
+func (b *breaker) record(host string, err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !isUnreachable(err) {
+		delete(b.hosts, host)
+		return
+	}
+	c, ok := b.hosts[host]
+	if !ok {
+		c = &hostCircuit{}
+		b.hosts[host] = c
+	}
+	c.failures++
+	c.probing = false
+	if c.failures >= breakerThreshold {
+		c.openUntil = now.Add(breakerCooldown)
+	}
+}
+
+// release ends the probe of host, if any. It's for fetches that end without
+// a record, like those cut short by the caller or by a panic, so that the
+// host's other feeds don't wait for the probe forever.
+func (b *breaker) release(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.hosts[host]; ok {
+		c.probing = false
+	}
+}
+
+// isUnreachable reports whether err means the host didn't answer at all, as
+// opposed to answering with an error or slowly.
+func isUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	// Connecting failed, whatever the reason.
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	if errors.As(err, &dnsErr) || (errors.As(err, &opErr) && opErr.Op == "dial") {
+		return true
+	}
+	// The caller's deadline says nothing about the host, which may just be
+	// slow to send a large feed.
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.As(err, &opErr)
+}
+
+// feedHost returns the host, with the port if any, a feed is fetched from, or
+// "" if its link can't be parsed. Fediverse accounts, acct:user@host, are
+// fetched from their host.
+func feedHost(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	if u.Scheme == "acct" {
+		_, host, _ := strings.Cut(u.Opaque, "@")
+		return host
+	}
+	return u.Host
+}
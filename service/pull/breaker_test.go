@@ -0,0 +1,103 @@
+package pull
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/service/pull/client"
+)
+
+func TestBreaker(t *testing.T) {
+	b := newBreaker()
+	now := time.Now()
+	unreachable := fmt.Errorf("fetch: %w", &net.OpError{Op: "dial", Err: errors.New("i/o timeout")})
+
+	for range breakerThreshold - 1 {
+		b.record("example.com", unreachable, now)
+	}
+	allowed, _ := b.allow("example.com", now)
+	assert.True(t, allowed, "the circuit must not trip before the threshold")
+
+	b.record("example.com", unreachable, now)
+	allowed, retryAt := b.allow("example.com", now)
+	assert.False(t, allowed)
+	assert.Equal(t, now.Add(breakerCooldown), retryAt)
+	allowed, _ = b.allow("example.org", now)
+	assert.True(t, allowed, "other hosts must not be affected")
+
+	later := now.Add(breakerCooldown)
+	allowed, _ = b.allow("example.com", later)
+	assert.True(t, allowed, "one fetch must try the host after the cooldown")
+	allowed, _ = b.allow("example.com", later)
+	assert.False(t, allowed, "other feeds must wait for the outcome of the probe")
+
+	b.record("example.com", unreachable, later)
+	allowed, retryAt = b.allow("example.com", later)
+	assert.False(t, allowed, "a failed probe must trip the circuit again")
+	assert.Equal(t, later.Add(breakerCooldown), retryAt)
+
+	b.record("example.com", errors.New("got status code 500"), later)
+	allowed, _ = b.allow("example.com", later)
+	assert.True(t, allowed, "a response from the host must reset the circuit")
+}
+
+// nopFeedRepo serves no feeds and ignores updates.
+type nopFeedRepo struct{}
+
+func (nopFeedRepo) List(filter *repo.FeedListFilter) ([]*model.Feed, error) {
+	return nil, nil
+}
+
+func (nopFeedRepo) Get(id uint) (*model.Feed, error) {
+	return nil, repo.ErrNotFound
+}
+
+func (nopFeedRepo) Update(id uint, feed *model.Feed) error {
+	return nil
+}
+
+func TestBreakerReleasesCancelledProbe(t *testing.T) {
+	defer func(saved *breaker) { circuits = saved }(circuits)
+	circuits = newBreaker()
+	unreachable := fmt.Errorf("fetch: %w", &net.OpError{Op: "dial", Err: errors.New("i/o timeout")})
+	trippedAt := time.Now().Add(-breakerCooldown - time.Second)
+	for range breakerThreshold {
+		circuits.record("example.com", unreachable, trippedAt)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	feed := &model.Feed{ID: 42, Name: ptr.To("Feed"), Link: ptr.To("https://example.com/feed")}
+	_, _ = NewPuller(nopFeedRepo{}, nil, client.NewFeedClient("")).do(ctx, feed, false)
+
+	allowed, _ := circuits.allow("example.com", time.Now())
+	assert.True(t, allowed, "a probe cut short by the caller must let another fetch try the host")
+}
+
+func TestIsUnreachable(t *testing.T) {
+	for _, tt := range []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("got status code 503"), false},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), false},
+		{&url.Error{Op: "Get", URL: "https://example.com/feed", Err: context.DeadlineExceeded}, false},
+		{&net.OpError{Op: "read", Err: context.DeadlineExceeded}, false},
+		{&net.OpError{Op: "dial", Err: context.DeadlineExceeded}, true},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{&net.DNSError{Err: "no such host", Name: "example.invalid"}, true},
+	} {
+		assert.Equal(t, tt.expected, isUnreachable(tt.err), "%v", tt.err)
+	}
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/httpx"
+	"github.com/0x2e/fusion/pkg/lang"
+)
+
+// activityPubAccept asks for ActivityStreams documents, which servers like
+// Mastodon only serve to those who ask, instead of the web page.
+const activityPubAccept = `application/activity+json, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+
+// maxActivityTitleLength is the length in runes of the titles made up for
+// posts, which don't have any.
+const maxActivityTitleLength = 80
+
+// maxActivityPubBodySize caps the documents read from ActivityPub servers.
+const maxActivityPubBodySize = 4 * 1024 * 1024
+
+// ActivityPubLink returns the link a fediverse account, given as
+// @user@host, user@host or acct:user@host, is subscribed to, and whether s is
+// one.
+func ActivityPubLink(s string) (string, bool) {
+	handle := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "acct:"), "@")
+	user, host, ok := strings.Cut(handle, "@")
+	if !ok || user == "" || host == "" ||
+		strings.ContainsAny(user, "/?#: ") || strings.ContainsAny(host, "/?#@ ") {
+		return "", false
+	}
+	return "acct:" + user + "@" + host, true
+}
+
+// IsActivityPubLink tells whether link is a fediverse account, whose posts
+// are fetched from its ActivityPub outbox.
+func IsActivityPubLink(link string) bool {
+	return strings.HasPrefix(link, "acct:")
+}
+
+// apObject is the part of ActivityStreams objects that makes feeds. Most
+// properties may be a link or the object itself, see apRef.
+type apObject struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	Name         string          `json:"name"`
+	Summary      string          `json:"summary"`
+	Content      string          `json:"content"`
+	URL          json.RawMessage `json:"url"`
+	Published    string          `json:"published"`
+	Updated      string          `json:"updated"`
+	Sensitive    bool            `json:"sensitive"`
+	Outbox       string          `json:"outbox"`
+	Object       json.RawMessage `json:"object"`
+	First        json.RawMessage `json:"first"`
+	OrderedItems []apObject      `json:"orderedItems"`
+	Items        []apObject      `json:"items"`
+	Attachment   []struct {
+		Type      string `json:"type"`
+		MediaType string `json:"mediaType"`
+		URL       string `json:"url"`
+	} `json:"attachment"`
+}
+
+// apRef unmarshals properties that are either the id of an object or the
+// object itself. Ids are returned in link.
+func apRef(raw json.RawMessage) (obj *apObject, link string) {
+	if len(raw) == 0 {
+		return nil, ""
+	}
+	if err := json.Unmarshal(raw, &link); err == nil {
+		return nil, link
+	}
+	var o apObject
+	if err := json.Unmarshal(raw, &o); err != nil {
+		return nil, ""
+	}
+	return &o, o.ID
+}
+
+// apURL returns the web page of an object. url may be a string, a Link
+// object, or a list of them, of which the first HTML one wins.
+func apURL(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	type link struct {
+		Href      string `json:"href"`
+		MediaType string `json:"mediaType"`
+	}
+	var links []link
+	if err := json.Unmarshal(raw, &links); err != nil {
+		var l link
+		if err := json.Unmarshal(raw, &l); err != nil {
+			return ""
+		}
+		links = []link{l}
+	}
+	for _, l := range links {
+		if l.MediaType == "" || l.MediaType == "text/html" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// fetchActivityPub fetches the recent posts of a fediverse account: it finds
+// the actor with WebFinger, then reads the first page of its outbox. The
+// result is made a feed, so it goes through the same steps as the others.
+func (c FeedClient) fetchActivityPub(ctx context.Context, link string, options model.FeedRequestOptions) (fetchedFeed, error) {
+	fetched := fetchedFeed{finalURL: link}
+	handle := strings.TrimPrefix(link, "acct:")
+	_, host, _ := strings.Cut(handle, "@")
+
+	webfinger := fmt.Sprintf("https://%s/.well-known/webfinger?resource=%s", host, url.QueryEscape(link))
+	var jrd struct {
+		Links []struct {
+			Rel  string `json:"rel"`
+			Type string `json:"type"`
+			Href string `json:"href"`
+		} `json:"links"`
+	}
+	if err := c.getJSON(ctx, webfinger, options, &fetched, &jrd); err != nil {
+		return fetched, fmt.Errorf("webfinger: %w", err)
+	}
+	var actorURL string
+	for _, l := range jrd.Links {
+		if l.Rel == "self" && (l.Type == "application/activity+json" || strings.HasPrefix(l.Type, "application/ld+json")) {
+			actorURL = l.Href
+			break
+		}
+	}
+	if actorURL == "" {
+		return fetched, errors.New("webfinger: no ActivityPub actor for " + handle)
+	}
+
+	var actor apObject
+	if err := c.getJSON(ctx, actorURL, options, &fetched, &actor); err != nil {
+		return fetched, fmt.Errorf("actor: %w", err)
+	}
+	if actor.Outbox == "" {
+		return fetched, errors.New("actor has no outbox")
+	}
+	var outbox apObject
+	if err := c.getJSON(ctx, actor.Outbox, options, &fetched, &outbox); err != nil {
+		return fetched, fmt.Errorf("outbox: %w", err)
+	}
+	// Large outboxes list their activities on pages, the first one holding
+	// the latest.
+	page := outbox
+	if first, firstURL := apRef(outbox.First); first != nil {
+		page = *first
+	} else if firstURL != "" {
+		if err := c.getJSON(ctx, firstURL, options, &fetched, &page); err != nil {
+			return fetched, fmt.Errorf("outbox: %w", err)
+		}
+	}
+
+	fetched.feed = &gofeed.Feed{
+		Title:       actor.Name,
+		Description: actor.Summary,
+		Link:        apURL(actor.URL),
+	}
+	if fetched.feed.Title == "" {
+		fetched.feed.Title = handle
+	}
+	activities := page.OrderedItems
+	if len(activities) == 0 {
+		activities = page.Items
+	}
+	for _, activity := range activities {
+		// Boosts, announced as links to posts of other accounts, would take
+		// one more request each; only the account's own posts are kept.
+		if activity.Type != "Create" {
+			continue
+		}
+		post, _ := apRef(activity.Object)
+		if post == nil {
+			continue
+		}
+		fetched.feed.Items = append(fetched.feed.Items, activityItem(post))
+	}
+	return fetched, nil
+}
+
+// activityItem makes a feed item of a post.
+func activityItem(post *apObject) *gofeed.Item {
+	item := &gofeed.Item{
+		GUID:    post.ID,
+		Link:    apURL(post.URL),
+		Title:   post.Name,
+		Content: post.Content,
+	}
+	if item.Link == "" {
+		item.Link = post.ID
+	}
+	// Posts with a content warning put it in summary.
+	if post.Sensitive && post.Summary != "" {
+		item.Content = "<p><strong>" + html.EscapeString(post.Summary) + "</strong></p>" + item.Content
+	}
+	if item.Title == "" {
+		item.Title = lang.Snippet(item.Content, maxActivityTitleLength)
+	}
+	if t, err := time.Parse(time.RFC3339, post.Published); err == nil {
+		item.PublishedParsed = &t
+	}
+	if t, err := time.Parse(time.RFC3339, post.Updated); err == nil {
+		item.UpdatedParsed = &t
+	}
+	for _, a := range post.Attachment {
+		if a.URL == "" {
+			continue
+		}
+		item.Enclosures = append(item.Enclosures, &gofeed.Enclosure{URL: a.URL, Type: a.MediaType})
+		if strings.HasPrefix(a.MediaType, "image/") {
+			item.Content += fmt.Sprintf(`<p><img src="%s"></p>`, html.EscapeString(a.URL))
+		}
+	}
+	return item
+}
+
+// getJSON requests an ActivityPub document at link and decodes it into v.
+// fetched records the status and, if enabled, the response.
+func (c FeedClient) getJSON(ctx context.Context, link string, options model.FeedRequestOptions, fetched *fetchedFeed, v any) error {
+	resp, err := c.httpRequestFn(httpx.WithAccept(ctx, activityPubAccept), link, options)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	fetched.statusCode = resp.StatusCode
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxActivityPubBodySize))
+	if options.IsCapturingResponse() {
+		fetched.rawResponse = dumpResponse(resp, data, len(data) < maxActivityPubBodySize)
+	}
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got status code %d", resp.StatusCode)
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,127 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/service/pull/client"
+)
+
+func TestActivityPubLink(t *testing.T) {
+	for _, tt := range []struct {
+		input    string
+		expected string
+	}{
+		{"@alice@example.social", "acct:alice@example.social"},
+		{"alice@example.social", "acct:alice@example.social"},
+		{"acct:alice@example.social", "acct:alice@example.social"},
+		{" @alice@example.social:8443 ", "acct:alice@example.social:8443"},
+		{"https://example.social/@alice", ""},
+		{"https://alice@example.social/feed", ""},
+		{"@alice", ""},
+		{"alice@", ""},
+	} {
+		t.Run(tt.input, func(t *testing.T) {
+			link, ok := client.ActivityPubLink(tt.input)
+			assert.Equal(t, tt.expected != "", ok)
+			assert.Equal(t, tt.expected, link)
+		})
+	}
+}
+
+func TestFeedClientFetchItemsActivityPub(t *testing.T) {
+	responses := map[string]string{
+		"https://example.social/.well-known/webfinger?resource=acct%3Aalice%40example.social": `{
+			"subject": "acct:alice@example.social",
+			"links": [
+				{"rel": "http://webfinger.net/rel/profile-page", "type": "text/html", "href": "https://example.social/@alice"},
+				{"rel": "self", "type": "application/activity+json", "href": "https://example.social/users/alice"}
+			]
+		}`,
+		"https://example.social/users/alice": `{
+			"id": "https://example.social/users/alice",
+			"type": "Person",
+			"name": "Alice",
+			"summary": "<p>Writing about <b>things</b></p>",
+			"url": "https://example.social/@alice",
+			"outbox": "https://example.social/users/alice/outbox"
+		}`,
+		"https://example.social/users/alice/outbox": `{
+			"type": "OrderedCollection",
+			"first": "https://example.social/users/alice/outbox?page=true"
+		}`,
+		"https://example.social/users/alice/outbox?page=true": `{
+			"type": "OrderedCollectionPage",
+			"orderedItems": [
+				{
+					"type": "Create",
+					"object": {
+						"id": "https://example.social/users/alice/statuses/2",
+						"type": "Note",
+						"url": "https://example.social/@alice/2",
+						"published": "2025-01-02T10:00:00Z",
+						"content": "<p>Hello, fediverse!</p>",
+						"attachment": [{"type": "Document", "mediaType": "image/png", "url": "https://example.social/media/cat.png"}]
+					}
+				},
+				{
+					"type": "Announce",
+					"object": "https://other.social/users/bob/statuses/1"
+				},
+				{
+					"type": "Create",
+					"object": {
+						"id": "https://example.social/users/alice/statuses/1",
+						"type": "Note",
+						"url": "https://example.social/@alice/1",
+						"published": "2025-01-01T10:00:00Z",
+						"sensitive": true,
+						"summary": "Spoilers",
+						"content": "<p>The end</p>"
+					}
+				}
+			]
+		}`,
+	}
+	requestFn := func(ctx context.Context, link string, options model.FeedRequestOptions) (*http.Response, error) {
+		body, ok := responses[link]
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+	c := client.NewFeedClientWithRequestFn(requestFn)
+
+	title, err := c.FetchTitle(context.Background(), "acct:alice@example.social", model.FeedRequestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", title)
+
+	result, err := c.FetchItems(context.Background(), "acct:alice@example.social", model.FeedRequestOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.social/@alice", result.SiteURL)
+	assert.Equal(t, "acct:alice@example.social", result.CanonicalURL)
+	assert.Equal(t, "Writing about things", result.Description)
+	require.Len(t, result.Items, 2)
+
+	first := result.Items[0]
+	assert.Equal(t, "Hello, fediverse!", *first.Title)
+	assert.Equal(t, "https://example.social/users/alice/statuses/2", *first.GUID)
+	assert.Equal(t, "https://example.social/@alice/2", *first.Link)
+	assert.Equal(t, `<p>Hello, fediverse!</p><p><img src="https://example.social/media/cat.png"></p>`, *first.Content)
+	assert.Equal(t, time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC), first.PubDate.UTC())
+
+	second := result.Items[1]
+	assert.Equal(t, "Spoilers The end", *second.Title)
+	assert.Equal(t, "<p><strong>Spoilers</strong></p><p>The end</p>", *second.Content)
+
+	_, err = c.FetchItems(context.Background(), "acct:bob@example.social", model.FeedRequestOptions{})
+	assert.ErrorContains(t, err, "webfinger: got status code 404")
+}
@@ -1,18 +1,31 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/mmcdole/gofeed"
 
 	"github.com/0x2e/fusion/model"
 	"github.com/0x2e/fusion/pkg/httpx"
+	"github.com/0x2e/fusion/pkg/lang"
 )
 
+// maxCapturedBodySize is the number of bytes of the response body kept when a
+// feed has response capture enabled.
+const maxCapturedBodySize = 16 * 1024
+
+// maxDescriptionLength is the length in runes the description of a feed is
+// cut to, as some feeds put whole articles there.
+const maxDescriptionLength = 500
+
 type HttpRequestFn func(ctx context.Context, link string, options model.FeedRequestOptions) (*http.Response, error)
 
 // FeedClient retrieves a feed given a feed URL and parses the result.
@@ -20,9 +33,11 @@ type FeedClient struct {
 	httpRequestFn HttpRequestFn
 }
 
-// NewFeedClient creates a feed client with the default options.
-func NewFeedClient() FeedClient {
-	return NewFeedClientWithRequestFn(httpx.FusionRequest)
+// NewFeedClient creates a feed client with the default options. Requests
+// blocked by an anti-bot challenge are retried through the FlareSolverr
+// instance at flareSolverrURL, unless it's empty.
+func NewFeedClient(flareSolverrURL string) FeedClient {
+	return NewFeedClientWithRequestFn(httpx.FusionRequestWithFlareSolverr(flareSolverrURL))
 }
 
 // NewFeedClientWithRequestFn creates a feed client that uses a custom
@@ -34,60 +49,159 @@ func NewFeedClientWithRequestFn(httpRequestFn HttpRequestFn) FeedClient {
 }
 
 func (c FeedClient) FetchTitle(ctx context.Context, feedURL string, options model.FeedRequestOptions) (string, error) {
-	feed, err := c.fetchFeed(ctx, feedURL, options)
+	fetched, err := c.fetchFeed(ctx, feedURL, options)
 	if err != nil {
 		return "", err
 	}
 
-	return feed.Title, nil
+	return fetched.feed.Title, nil
 }
 
 // FetchDeclaredLink retrieves the feed link declared within the feed content
 func (c FeedClient) FetchDeclaredLink(ctx context.Context, feedURL string, options model.FeedRequestOptions) (string, error) {
-	feed, err := c.fetchFeed(ctx, feedURL, options)
+	fetched, err := c.fetchFeed(ctx, feedURL, options)
 	if err != nil {
 		return "", err
 	}
 
-	if feed.FeedLink != "" {
-		return feed.FeedLink, nil
+	if fetched.feed.FeedLink != "" {
+		return fetched.feed.FeedLink, nil
 	}
 
-	return feed.Link, nil
+	return fetched.feed.Link, nil
 }
 
 type FetchItemsResult struct {
 	LastBuild *time.Time
-	Items     []*model.Item
+	// SiteURL is the website link declared in the feed. It may be empty.
+	SiteURL string
+	Items   []*model.Item
+	// CanonicalURL is the URL the feed goes by, see canonicalURL.
+	CanonicalURL string
+	// URL is where the feed was fetched from in the end, after redirects.
+	URL string
+	// Description is the description declared in the feed, as plain text. It
+	// may be empty.
+	Description string
+	// RawResponse is a dump of the response headers and the beginning of the
+	// body. It's only populated when the feed has response capture enabled, and
+	// it's populated even if the fetch fails.
+	RawResponse *string
+	// StatusCode is the HTTP status of the response, or 0 if the request
+	// failed before getting one. It's populated even if the fetch fails.
+	StatusCode int
 }
 
 func (c FeedClient) FetchItems(ctx context.Context, feedURL string, options model.FeedRequestOptions) (FetchItemsResult, error) {
-	feed, err := c.fetchFeed(ctx, feedURL, options)
+	fetched, err := c.fetchFeed(ctx, feedURL, options)
 	if err != nil {
-		return FetchItemsResult{}, err
+		return FetchItemsResult{RawResponse: fetched.rawResponse, StatusCode: fetched.statusCode}, err
 	}
 
 	return FetchItemsResult{
-		LastBuild: feed.UpdatedParsed,
-		Items:     ParseGoFeedItems(feedURL, feed.Items),
+		LastBuild:    fetched.feed.UpdatedParsed,
+		SiteURL:      fetched.feed.Link,
+		CanonicalURL: canonicalURL(fetched.finalURL, fetched.feed.FeedLink),
+		URL:          fetched.finalURL,
+		Description:  lang.Snippet(fetched.feed.Description, maxDescriptionLength),
+		Items:        ParseGoFeedItems(feedURL, fetched.feed.Items, time.Now()),
+		RawResponse:  fetched.rawResponse,
+		StatusCode:   fetched.statusCode,
 	}, nil
 }
 
-func (c FeedClient) fetchFeed(ctx context.Context, feedURL string, options model.FeedRequestOptions) (*gofeed.Feed, error) {
+// fetchedFeed is the outcome of fetchFeed. When fetching fails, only
+// statusCode and rawResponse may be set.
+type fetchedFeed struct {
+	feed *gofeed.Feed
+	// statusCode is the HTTP status of the response.
+	statusCode int
+	// rawResponse is the captured response, if enabled.
+	rawResponse *string
+	// finalURL is where the feed was fetched from in the end, after
+	// redirects.
+	finalURL string
+}
+
+// fetchFeed requests and parses a feed, or the outbox of a fediverse account.
+func (c FeedClient) fetchFeed(ctx context.Context, feedURL string, options model.FeedRequestOptions) (fetchedFeed, error) {
+	if IsActivityPubLink(feedURL) {
+		return c.fetchActivityPub(ctx, feedURL, options)
+	}
+
 	resp, err := c.httpRequestFn(ctx, feedURL, options)
 	if err != nil {
-		return nil, err
+		return fetchedFeed{}, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("got status code %d", resp.StatusCode)
+	blocked := httpx.IsBlocked(resp)
+	if blocked || resp.StatusCode != http.StatusOK {
+		var rawResponse *string
+		if options.IsCapturingResponse() {
+			// Best effort: we still want the headers even if the body is unreadable.
+			// The extra byte tells whether the body was cut.
+			data, _ := io.ReadAll(io.LimitReader(resp.Body, maxCapturedBodySize+1))
+			rawResponse = dumpResponse(resp, data, false)
+		}
+		fetched := fetchedFeed{statusCode: resp.StatusCode, rawResponse: rawResponse}
+		if blocked {
+			return fetched, fmt.Errorf("%w (got status code %d)", httpx.ErrBlocked, resp.StatusCode)
+		}
+		return fetched, fmt.Errorf("got status code %d", resp.StatusCode)
 	}
 
+	fetched := fetchedFeed{statusCode: resp.StatusCode, finalURL: feedURL}
+	if resp.Request != nil && resp.Request.URL != nil {
+		fetched.finalURL = resp.Request.URL.String()
+	}
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return fetched, err
+	}
+
+	if options.IsCapturingResponse() {
+		fetched.rawResponse = dumpResponse(resp, data, true)
+	}
+
+	fetched.feed, err = gofeed.NewParser().ParseString(string(data))
+	return fetched, err
+}
+
+// canonicalURL returns the URL a feed goes by: the self link it declares, or
+// else finalURL, where it was fetched from after redirects. Self links to the
+// local machine, left over from the setup of the site's author, are ignored.
+func canonicalURL(finalURL, selfLink string) string {
+	u, err := url.Parse(strings.TrimSpace(selfLink))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return finalURL
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		(ip != nil && (ip.IsLoopback() || ip.IsUnspecified())) {
+		return finalURL
 	}
+	return u.String()
+}
 
-	return gofeed.NewParser().ParseString(string(data))
+// dumpResponse formats the status line, headers, and the first
+// maxCapturedBodySize bytes of the body in a human-readable form. complete
+// tells whether body is all of it, so its size can be reported when it's cut.
+func dumpResponse(resp *http.Response, body []byte, complete bool) *string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s %d %s\r\n", resp.Proto, resp.StatusCode, http.StatusText(resp.StatusCode))
+	resp.Header.Write(&b)
+	b.WriteString("\r\n")
+	if len(body) > maxCapturedBodySize {
+		b.Write(body[:maxCapturedBodySize])
+		if complete {
+			fmt.Fprintf(&b, "\n... (truncated, %d bytes total)", len(body))
+		} else {
+			b.WriteString("\n... (truncated)")
+		}
+	} else {
+		b.Write(body)
+	}
+	s := b.String()
+	return &s
 }
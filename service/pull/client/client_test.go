@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/feedtest"
 	"github.com/0x2e/fusion/pkg/ptr"
 	"github.com/0x2e/fusion/service/pull/client"
 	"github.com/stretchr/testify/assert"
@@ -423,6 +425,35 @@ func TestFeedClientFetchItems(t *testing.T) {
 			},
 			expectedErrMsg: "",
 		},
+		{
+			description: "fetch succeeds and populates SiteURL from channel link",
+			feedURL:     "https://example.com/feed.xml",
+			options:     model.FeedRequestOptions{},
+			httpRespBody: `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Test Feed</title>
+    <link>https://example.com/</link>
+    <item>
+      <title>Test Item</title>
+      <link>https://example.com/item</link>
+    </item>
+  </channel>
+</rss>`,
+			httpStatusCode:     http.StatusOK,
+			httpErr:            nil,
+			httpBodyReadErrMsg: "",
+			expectedResult: client.FetchItemsResult{
+				SiteURL: "https://example.com/",
+				Items: []*model.Item{
+					{
+						Title: ptr.To("Test Item"),
+						Link:  ptr.To("https://example.com/item"),
+					},
+				},
+			},
+			expectedErrMsg: "",
+		},
 		{
 			description: "fetch succeeds and populates LastBuild from Atom updated",
 			feedURL:     "https://example.com/feed.xml",
@@ -590,6 +621,17 @@ func TestFeedClientFetchItems(t *testing.T) {
 			expectedResult:     client.FetchItemsResult{},
 			expectedErrMsg:     "got status code 404",
 		},
+		{
+			description:        "fetch fails when HTTP response is an anti-bot challenge",
+			feedURL:            "https://example.com/feed.xml",
+			options:            model.FeedRequestOptions{},
+			httpRespBody:       "<html><head><title>Just a moment...</title></head></html>",
+			httpStatusCode:     http.StatusServiceUnavailable,
+			httpErr:            nil,
+			httpBodyReadErrMsg: "",
+			expectedResult:     client.FetchItemsResult{},
+			expectedErrMsg:     "blocked by anti-bot protection",
+		},
 		{
 			description:        "fetch fails when HTTP response body cannot be read",
 			feedURL:            "https://example.com/feed.xml",
@@ -648,6 +690,7 @@ func TestFeedClientFetchItems(t *testing.T) {
 			} else {
 				assert.Nil(t, actualResult.LastBuild, "LastBuild should be nil")
 			}
+			assert.Equal(t, tt.httpStatusCode, actualResult.StatusCode)
 			assert.Equal(t, len(tt.expectedResult.Items), len(actualResult.Items))
 
 			if len(tt.expectedResult.Items) > 0 {
@@ -670,6 +713,198 @@ func TestFeedClientFetchItems(t *testing.T) {
 	}
 }
 
+func TestFeedClientFetchItemsCapturesResponse(t *testing.T) {
+	for _, tt := range []struct {
+		description         string
+		options             model.FeedRequestOptions
+		httpRespBody        string
+		httpStatusCode      int
+		expectedRawResponse *string
+		expectedErrMsg      string
+	}{
+		{
+			description:         "does not capture response when capture is disabled",
+			options:             model.FeedRequestOptions{},
+			httpRespBody:        "<html>Just a moment...</html>",
+			httpStatusCode:      http.StatusOK,
+			expectedRawResponse: nil,
+			expectedErrMsg:      "Failed to detect feed type",
+		},
+		{
+			description: "captures response when body is not a feed",
+			options: model.FeedRequestOptions{
+				CaptureResponse: ptr.To(true),
+			},
+			httpRespBody:        "<html>Just a moment...</html>",
+			httpStatusCode:      http.StatusOK,
+			expectedRawResponse: ptr.To("HTTP/1.1 200 OK\r\nContent-Type: text/html\r\n\r\n<html>Just a moment...</html>"),
+			expectedErrMsg:      "Failed to detect feed type",
+		},
+		{
+			description: "captures response when status code is not 200",
+			options: model.FeedRequestOptions{
+				CaptureResponse: ptr.To(true),
+			},
+			httpRespBody:        "<html>Forbidden</html>",
+			httpStatusCode:      http.StatusForbidden,
+			expectedRawResponse: ptr.To("HTTP/1.1 403 Forbidden\r\nContent-Type: text/html\r\n\r\n<html>Forbidden</html>"),
+			expectedErrMsg:      "got status code 403",
+		},
+		{
+			description: "truncates large response bodies",
+			options: model.FeedRequestOptions{
+				CaptureResponse: ptr.To(true),
+			},
+			httpRespBody:        strings.Repeat("a", 16*1024+1),
+			httpStatusCode:      http.StatusOK,
+			expectedRawResponse: ptr.To("HTTP/1.1 200 OK\r\nContent-Type: text/html\r\n\r\n" + strings.Repeat("a", 16*1024) + "\n... (truncated, 16385 bytes total)"),
+			expectedErrMsg:      "Failed to detect feed type",
+		},
+		{
+			description: "truncates large response bodies when status code is not 200",
+			options: model.FeedRequestOptions{
+				CaptureResponse: ptr.To(true),
+			},
+			httpRespBody:        strings.Repeat("a", 16*1024+1),
+			httpStatusCode:      http.StatusForbidden,
+			expectedRawResponse: ptr.To("HTTP/1.1 403 Forbidden\r\nContent-Type: text/html\r\n\r\n" + strings.Repeat("a", 16*1024) + "\n... (truncated)"),
+			expectedErrMsg:      "got status code 403",
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			httpClient := &mockHTTPClient{
+				resp: &http.Response{
+					Proto:      "HTTP/1.1",
+					StatusCode: tt.httpStatusCode,
+					Status:     http.StatusText(tt.httpStatusCode),
+					Header:     http.Header{"Content-Type": []string{"text/html"}},
+					Body:       &mockReadCloser{result: tt.httpRespBody},
+				},
+			}
+
+			actualResult, actualErr := client.NewFeedClientWithRequestFn(httpClient.Get).FetchItems(context.Background(), "https://example.com/feed.xml", tt.options)
+
+			require.Error(t, actualErr)
+			assert.Contains(t, actualErr.Error(), tt.expectedErrMsg)
+			assert.Equal(t, tt.expectedRawResponse, actualResult.RawResponse)
+		})
+	}
+}
+
+func TestFeedClientFetchItemsOverHTTP(t *testing.T) {
+	feed := feedtest.Feed{
+		Title:       "Test Feed",
+		SiteURL:     "https://example.com",
+		Description: "All about <b>testing</b>",
+		Updated:     *mustParseTime("2025-01-02T00:00:00Z"),
+		Items: []feedtest.Item{
+			{
+				Title:   "First post",
+				Link:    "https://example.com/first",
+				GUID:    "first",
+				Content: "Content 1",
+			},
+		},
+	}
+	for _, format := range []feedtest.Format{feedtest.FormatRSS, feedtest.FormatAtom, feedtest.FormatJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			s := feedtest.NewServer(t, feed)
+			s.SetFormat(format)
+
+			result, err := client.NewFeedClient("").FetchItems(context.Background(), s.FeedURL(), model.FeedRequestOptions{})
+			require.NoError(t, err)
+
+			assert.Equal(t, "https://example.com", result.SiteURL)
+			assert.Equal(t, "All about testing", result.Description)
+			require.Len(t, result.Items, 1)
+			assert.Equal(t, "First post", *result.Items[0].Title)
+			assert.Equal(t, "first", *result.Items[0].GUID)
+			assert.Equal(t, "https://example.com/first", *result.Items[0].Link)
+			assert.Equal(t, "Content 1", *result.Items[0].Content)
+		})
+	}
+
+	t.Run("error status", func(t *testing.T) {
+		s := feedtest.NewServer(t, feed)
+		s.SetStatus(http.StatusBadGateway)
+
+		_, err := client.NewFeedClient("").FetchItems(context.Background(), s.FeedURL(), model.FeedRequestOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "got status code 502")
+	})
+
+	t.Run("malformed feed", func(t *testing.T) {
+		s := feedtest.NewServer(t, feed)
+		s.SetBody("<html>not a feed</html>")
+
+		_, err := client.NewFeedClient("").FetchItems(context.Background(), s.FeedURL(), model.FeedRequestOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Failed to detect feed type")
+	})
+
+	t.Run("slow server", func(t *testing.T) {
+		s := feedtest.NewServer(t, feed)
+		s.SetDelay(time.Minute)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.NewFeedClient("").FetchItems(ctx, s.FeedURL(), model.FeedRequestOptions{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestFeedClientFetchItemsCanonicalURL(t *testing.T) {
+	withSelfLink := func(link string) string {
+		return `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel>` +
+			`<title>Test Feed</title><atom:link href="` + link + `" rel="self" type="application/rss+xml"/>` +
+			`</channel></rss>`
+	}
+	for _, tt := range []struct {
+		description string
+		body        string
+		redirect    bool
+		expected    func(s *feedtest.Server) string
+	}{
+		{
+			description: "the fetched URL",
+			expected:    func(s *feedtest.Server) string { return s.FeedURL() },
+		},
+		{
+			description: "the URL after redirects",
+			redirect:    true,
+			expected:    func(s *feedtest.Server) string { return s.FeedURL() },
+		},
+		{
+			description: "the declared self link",
+			body:        withSelfLink("https://example.com/feed.xml"),
+			redirect:    true,
+			expected:    func(s *feedtest.Server) string { return "https://example.com/feed.xml" },
+		},
+		{
+			description: "not a self link to the local machine",
+			body:        withSelfLink("http://localhost:1313/index.xml"),
+			expected:    func(s *feedtest.Server) string { return s.FeedURL() },
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			s := feedtest.NewServer(t, feedtest.Feed{Title: "Test Feed"})
+			if tt.body != "" {
+				s.SetBody(tt.body)
+			}
+			link := s.FeedURL()
+			if tt.redirect {
+				old := httptest.NewServer(http.RedirectHandler(s.FeedURL(), http.StatusMovedPermanently))
+				t.Cleanup(old.Close)
+				link = old.URL + "/old.xml"
+			}
+
+			result, err := client.NewFeedClient("").FetchItems(context.Background(), link, model.FeedRequestOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected(s), result.CanonicalURL)
+		})
+	}
+}
+
 // Helper function to parse ISO8601 string to time.Time.
 func mustParseTime(iso8601 string) *time.Time {
 	t, err := time.Parse(time.RFC3339, iso8601)
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// extraDateFormats are layouts found in feeds that gofeed doesn't parse.
+var extraDateFormats = []string{
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"02.01.2006",
+	// The format of JavaScript's Date.toString.
+	"Mon Jan 02 2006 15:04:05 GMT-0700",
+}
+
+// pubDate returns when item was published. Dates gofeed couldn't parse are
+// tried again with parseDate. Items without a date get fetchedAt, and so do
+// items dated in the future, which would otherwise stay at the top of the
+// lists until then.
+func pubDate(item *gofeed.Item, fetchedAt time.Time) *time.Time {
+	date := item.PublishedParsed
+	if date == nil {
+		date = parseDate(item.Published)
+	}
+	if date == nil {
+		date = item.UpdatedParsed
+	}
+	if date == nil {
+		date = parseDate(item.Updated)
+	}
+	if date == nil || date.After(fetchedAt) {
+		return &fetchedAt
+	}
+	return date
+}
+
+// parseDate parses s as a Unix timestamp, in seconds or milliseconds, or in
+// one of extraDateFormats. It returns nil if s is none of them.
+func parseDate(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		var t time.Time
+		switch len(s) {
+		case 9, 10:
+			t = time.Unix(n, 0).UTC()
+		case 13:
+			t = time.UnixMilli(n).UTC()
+		default:
+			return nil
+		}
+		return &t
+	}
+
+	// A comment after the date, like "(CET)" or "(Central European Standard
+	// Time)", repeats the zone.
+	if i := strings.LastIndex(s, " ("); i > 0 && strings.HasSuffix(s, ")") {
+		s = s[:i]
+	}
+	// Some feeds leave out the space after the day of the week.
+	if i := strings.IndexByte(s, ','); i > 0 && i+1 < len(s) && s[i+1] != ' ' {
+		s = s[:i+1] + " " + s[i+1:]
+	}
+	for _, layout := range extraDateFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// minGalleryImages is the number of images an item needs to be considered an
+// image gallery.
+const minGalleryImages = 3
+
+// videoEmbedHosts are the hosts of common video players that are embedded with
+// an iframe.
+var videoEmbedHosts = []string{
+	"youtube.com/embed/",
+	"youtube-nocookie.com/embed/",
+	"player.vimeo.com/",
+	"dailymotion.com/embed/",
+	"player.twitch.tv/",
+}
+
+// playableEnclosure returns the first audio or video enclosure of the item,
+// or nil if it has none.
+func playableEnclosure(item *gofeed.Item) *gofeed.Enclosure {
+	for _, enclosure := range item.Enclosures {
+		if enclosure == nil || enclosure.URL == "" {
+			continue
+		}
+		if strings.HasPrefix(enclosure.Type, "audio/") || strings.HasPrefix(enclosure.Type, "video/") {
+			return enclosure
+		}
+	}
+	return nil
+}
+
+// imgSrc matches the source of the images in HTML content.
+var imgSrc = regexp.MustCompile(`(?i)<img\s[^>]*?\bsrc\s*=\s*["']([^"']+)["']`)
+
+// maxLeadImageCandidates is how many images of the content are tried as the
+// lead image, in case the first ones are unusable, like inline data.
+const maxLeadImageCandidates = 5
+
+// leadImage returns the image that stands for the item in lists: the one the
+// feed gives it, else its first image enclosure, else the first image of its
+// content. Relative links are resolved against base, the item's link. It's
+// "" if the item has none.
+func leadImage(base string, item *gofeed.Item, content string) string {
+	var candidates []string
+	if item.Image != nil {
+		candidates = append(candidates, item.Image.URL)
+	}
+	for _, enclosure := range item.Enclosures {
+		if enclosure != nil && strings.HasPrefix(enclosure.Type, "image/") {
+			candidates = append(candidates, enclosure.URL)
+		}
+	}
+	for _, m := range imgSrc.FindAllStringSubmatch(content, maxLeadImageCandidates) {
+		candidates = append(candidates, html.UnescapeString(m[1]))
+	}
+	for _, c := range candidates {
+		if image := absoluteImageURL(base, c); image != "" {
+			return image
+		}
+	}
+	return ""
+}
+
+// absoluteImageURL resolves link against base, and returns it if it's a
+// web image.
+func absoluteImageURL(base, link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return ""
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	if b, err := url.Parse(base); err == nil {
+		u = b.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return u.String()
+}
+
+type mediaFlags struct {
+	video   bool
+	audio   bool
+	gallery bool
+}
+
+// detectMedia derives what kind of media an item carries from its enclosures
+// and content.
+func detectMedia(item *gofeed.Item, content string) mediaFlags {
+	var flags mediaFlags
+	images := 0
+	for _, enclosure := range item.Enclosures {
+		if enclosure == nil {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(enclosure.Type, "audio/"):
+			flags.audio = true
+		case strings.HasPrefix(enclosure.Type, "video/"):
+			flags.video = true
+		case strings.HasPrefix(enclosure.Type, "image/"):
+			images++
+		}
+	}
+
+	lower := strings.ToLower(content)
+	if strings.Contains(lower, "<video") {
+		flags.video = true
+	}
+	if strings.Contains(lower, "<iframe") {
+		for _, host := range videoEmbedHosts {
+			if strings.Contains(lower, host) {
+				flags.video = true
+				break
+			}
+		}
+	}
+	if strings.Contains(lower, "<audio") {
+		flags.audio = true
+	}
+	images += strings.Count(lower, "<img")
+	flags.gallery = images >= minGalleryImages
+
+	return flags
+}
@@ -1,16 +1,31 @@
 package client
 
 import (
+	"cmp"
 	"net/url"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/lang"
 	"github.com/0x2e/fusion/pkg/ptr"
 
 	"github.com/mmcdole/gofeed"
 )
 
-func ParseGoFeedItems(feedURL string, gfItems []*gofeed.Item) []*model.Item {
+// ParseGoFeedItems converts the items of a feed fetched at fetchedAt, which
+// stands in for the dates that are missing or in the future.
+func ParseGoFeedItems(feedURL string, gfItems []*gofeed.Item, fetchedAt time.Time) []*model.Item {
+	// Links shared by several items, like the website of a podcast that all
+	// its episodes link to, can't stand in for their GUIDs.
+	links := make(map[string]int, len(gfItems))
+	for _, item := range gfItems {
+		if item != nil {
+			links[item.Link]++
+		}
+	}
+
 	items := make([]*model.Item, 0, len(gfItems))
 	for _, item := range gfItems {
 		if item == nil {
@@ -22,27 +37,61 @@ func ParseGoFeedItems(feedURL string, gfItems []*gofeed.Item) []*model.Item {
 		if content == "" {
 			content = item.Description
 		}
-		guid := item.GUID
-		if guid == "" {
-			guid = item.Link
+		guid := itemGUID(feedURL, item, links[item.Link] == 1)
+		var language *string
+		if l := lang.Detect(item.Title + "\n" + content); l != "" {
+			language = &l
+		}
+		media := detectMedia(item, content)
+		link := parseLink(feedURL, item.Link)
+		var imageURL *string
+		if image := leadImage(cmp.Or(link, feedURL), item, content); image != "" {
+			imageURL = &image
 		}
-		pubDate := item.PublishedParsed
-		if pubDate == nil {
-			pubDate = item.UpdatedParsed
+		var enclosureURL, enclosureType *string
+		if enclosure := playableEnclosure(item); enclosure != nil {
+			enclosureURL = ptr.To(parseLink(feedURL, enclosure.URL))
+			enclosureType = &enclosure.Type
 		}
 		items = append(items, &model.Item{
-			Title:   &item.Title,
-			GUID:    &guid,
-			Link:    ptr.To(parseLink(feedURL, item.Link)),
-			Content: &content,
-			PubDate: pubDate,
-			Unread:  &unread,
+			Title:      &item.Title,
+			GUID:       &guid,
+			Link:       &link,
+			Content:    &content,
+			PubDate:    pubDate(item, fetchedAt),
+			Unread:     &unread,
+			Language:   language,
+			WordCount:  ptr.To(lang.WordCount(content)),
+			HasVideo:   media.video,
+			HasAudio:   media.audio,
+			HasGallery: media.gallery,
+			ImageURL:   imageURL,
+
+			EnclosureURL:  enclosureURL,
+			EnclosureType: enclosureType,
 		})
 	}
 
 	return items
 }
 
+// opaqueGUID matches GUIDs that are hashes or UUIDs. Some feeds make them up
+// anew when an item is edited, or when they switch to another generator,
+// which duplicates the items.
+var opaqueGUID = regexp.MustCompile(`(?i)^(urn:uuid:)?([0-9a-f]{32,}|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})$`)
+
+// itemGUID returns the GUID identifying item within its feed. Items without a
+// GUID, or with an opaque one, are identified by their link instead, unless
+// uniqueLink says other items share it. Relative GUIDs are resolved like
+// links.
+func itemGUID(feedURL string, item *gofeed.Item, uniqueLink bool) string {
+	guid := strings.TrimSpace(item.GUID)
+	if item.Link != "" && (guid == "" || (uniqueLink && opaqueGUID.MatchString(guid))) {
+		return item.Link
+	}
+	return parseLink(feedURL, guid)
+}
+
 func parseLink(feedURL string, linkURL string) string {
 	// If the link URL is not a relative path, treat it as a full URL.
 	if !strings.HasPrefix(linkURL, "/") {
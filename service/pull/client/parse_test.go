@@ -2,9 +2,11 @@ package client_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/mmcdole/gofeed"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/0x2e/fusion/model"
 	"github.com/0x2e/fusion/pkg/ptr"
@@ -12,6 +14,7 @@ import (
 )
 
 func TestParseGoFeedItems(t *testing.T) {
+	fetchedAt := *mustParseTime("2025-02-01T00:00:00Z")
 	for _, tt := range []struct {
 		description string
 		feedURL     string
@@ -33,12 +36,14 @@ func TestParseGoFeedItems(t *testing.T) {
 			},
 			expected: []*model.Item{
 				{
-					Title:   ptr.To("Test Item"),
-					GUID:    ptr.To("https://example.com/guid"),
-					Link:    ptr.To("https://example.com/link"),
-					Content: ptr.To("<p>This is the content</p>"),
-					PubDate: mustParseTime("2025-01-01T12:00:00Z"),
-					Unread:  ptr.To(true),
+					Title:     ptr.To("Test Item"),
+					GUID:      ptr.To("https://example.com/guid"),
+					Link:      ptr.To("https://example.com/link"),
+					Content:   ptr.To("<p>This is the content</p>"),
+					PubDate:   mustParseTime("2025-01-01T12:00:00Z"),
+					Unread:    ptr.To(true),
+					Language:  ptr.To("en"),
+					WordCount: ptr.To(4),
 				},
 			},
 		},
@@ -57,12 +62,14 @@ func TestParseGoFeedItems(t *testing.T) {
 			},
 			expected: []*model.Item{
 				{
-					Title:   ptr.To("Test Item with Relative Path"),
-					Link:    ptr.To("https://example.com/link"),
-					GUID:    ptr.To("guid"),
-					Content: ptr.To("<p>This is the content</p>"),
-					PubDate: mustParseTime("2025-01-01T12:00:00Z"),
-					Unread:  ptr.To(true),
+					Title:     ptr.To("Test Item with Relative Path"),
+					Link:      ptr.To("https://example.com/link"),
+					GUID:      ptr.To("guid"),
+					Content:   ptr.To("<p>This is the content</p>"),
+					PubDate:   mustParseTime("2025-01-01T12:00:00Z"),
+					Unread:    ptr.To(true),
+					Language:  ptr.To("en"),
+					WordCount: ptr.To(4),
 				},
 			},
 		},
@@ -81,12 +88,14 @@ func TestParseGoFeedItems(t *testing.T) {
 			},
 			expected: []*model.Item{
 				{
-					Title:   ptr.To("Test Item"),
-					GUID:    ptr.To("https://example.com/guid"),
-					Link:    ptr.To("https://example.com/link"),
-					Content: ptr.To("This is the description"), // Should use description
-					PubDate: mustParseTime("2025-01-01T12:00:00Z"),
-					Unread:  ptr.To(true),
+					Title:     ptr.To("Test Item"),
+					GUID:      ptr.To("https://example.com/guid"),
+					Link:      ptr.To("https://example.com/link"),
+					Content:   ptr.To("This is the description"), // Should use description
+					PubDate:   mustParseTime("2025-01-01T12:00:00Z"),
+					Unread:    ptr.To(true),
+					Language:  ptr.To("en"),
+					WordCount: ptr.To(4),
 				},
 			},
 		},
@@ -105,12 +114,14 @@ func TestParseGoFeedItems(t *testing.T) {
 			},
 			expected: []*model.Item{
 				{
-					Title:   ptr.To("Test Item"),
-					GUID:    ptr.To("https://example.com/link"), // Should use link
-					Link:    ptr.To("https://example.com/link"),
-					Content: ptr.To("<p>This is the content</p>"),
-					PubDate: mustParseTime("2025-01-01T12:00:00Z"),
-					Unread:  ptr.To(true),
+					Title:     ptr.To("Test Item"),
+					GUID:      ptr.To("https://example.com/link"), // Should use link
+					Link:      ptr.To("https://example.com/link"),
+					Content:   ptr.To("<p>This is the content</p>"),
+					PubDate:   mustParseTime("2025-01-01T12:00:00Z"),
+					Unread:    ptr.To(true),
+					Language:  ptr.To("en"),
+					WordCount: ptr.To(4),
 				},
 			},
 		},
@@ -129,12 +140,14 @@ func TestParseGoFeedItems(t *testing.T) {
 			},
 			expected: []*model.Item{
 				{
-					Title:   ptr.To("Test Item"),
-					GUID:    ptr.To("https://example.com/link"), // Should use link
-					Link:    ptr.To("https://example.com/link"),
-					Content: ptr.To("This is the description"), // Should use description
-					PubDate: mustParseTime("2025-01-01T12:00:00Z"),
-					Unread:  ptr.To(true),
+					Title:     ptr.To("Test Item"),
+					GUID:      ptr.To("https://example.com/link"), // Should use link
+					Link:      ptr.To("https://example.com/link"),
+					Content:   ptr.To("This is the description"), // Should use description
+					PubDate:   mustParseTime("2025-01-01T12:00:00Z"),
+					Unread:    ptr.To(true),
+					Language:  ptr.To("en"),
+					WordCount: ptr.To(4),
 				},
 			},
 		},
@@ -161,20 +174,22 @@ func TestParseGoFeedItems(t *testing.T) {
 			},
 			expected: []*model.Item{
 				{
-					Title:   ptr.To("Item 1"),
-					GUID:    ptr.To("guid1"),
-					Link:    ptr.To("link1"),
-					Content: ptr.To("content1"),
-					PubDate: mustParseTime("2025-01-01T12:00:00Z"),
-					Unread:  ptr.To(true),
+					Title:     ptr.To("Item 1"),
+					GUID:      ptr.To("guid1"),
+					Link:      ptr.To("link1"),
+					Content:   ptr.To("content1"),
+					PubDate:   mustParseTime("2025-01-01T12:00:00Z"),
+					Unread:    ptr.To(true),
+					WordCount: ptr.To(1),
 				},
 				{
-					Title:   ptr.To("Item 2"),
-					GUID:    ptr.To("guid2"),
-					Link:    ptr.To("link2"),
-					Content: ptr.To("content2"),
-					PubDate: mustParseTime("2025-01-01T12:00:00Z"),
-					Unread:  ptr.To(true),
+					Title:     ptr.To("Item 2"),
+					GUID:      ptr.To("guid2"),
+					Link:      ptr.To("link2"),
+					Content:   ptr.To("content2"),
+					PubDate:   mustParseTime("2025-01-01T12:00:00Z"),
+					Unread:    ptr.To(true),
+					WordCount: ptr.To(1),
 				},
 			},
 		},
@@ -206,27 +221,345 @@ func TestParseGoFeedItems(t *testing.T) {
 			},
 			expected: []*model.Item{
 				{
-					Title:   ptr.To("Valid Item"),
-					GUID:    ptr.To("valid-guid"),
-					Link:    ptr.To("https://example.com/valid"),
-					Content: ptr.To("valid content"),
-					PubDate: mustParseTime("2025-01-01T12:00:00Z"),
-					Unread:  ptr.To(true),
+					Title:     ptr.To("Valid Item"),
+					GUID:      ptr.To("valid-guid"),
+					Link:      ptr.To("https://example.com/valid"),
+					Content:   ptr.To("valid content"),
+					PubDate:   mustParseTime("2025-01-01T12:00:00Z"),
+					Unread:    ptr.To(true),
+					WordCount: ptr.To(2),
 				},
 				{
-					Title:   ptr.To("Another Valid Item"),
-					GUID:    ptr.To("another-guid"),
-					Link:    ptr.To("https://example.com/another"),
-					Content: ptr.To("another content"),
-					PubDate: mustParseTime("2025-01-01T12:00:00Z"),
-					Unread:  ptr.To(true),
+					Title:     ptr.To("Another Valid Item"),
+					GUID:      ptr.To("another-guid"),
+					Link:      ptr.To("https://example.com/another"),
+					Content:   ptr.To("another content"),
+					PubDate:   mustParseTime("2025-01-01T12:00:00Z"),
+					Unread:    ptr.To(true),
+					WordCount: ptr.To(2),
+				},
+			},
+		},
+		{
+			description: "flags podcast episodes from audio enclosures",
+			feedURL:     "https://example.com/feed",
+			gfItems: []*gofeed.Item{
+				{
+					Title:      "Episode 1",
+					GUID:       "ep1",
+					Link:       "https://example.com/ep1",
+					Content:    "show notes",
+					Enclosures: []*gofeed.Enclosure{{URL: "https://example.com/ep1.mp3", Type: "audio/mpeg"}},
+				},
+			},
+			expected: []*model.Item{
+				{
+					Title:     ptr.To("Episode 1"),
+					GUID:      ptr.To("ep1"),
+					Link:      ptr.To("https://example.com/ep1"),
+					Content:   ptr.To("show notes"),
+					Unread:    ptr.To(true),
+					PubDate:   &fetchedAt,
+					WordCount: ptr.To(2),
+					HasAudio:  true,
+
+					EnclosureURL:  ptr.To("https://example.com/ep1.mp3"),
+					EnclosureType: ptr.To("audio/mpeg"),
+				},
+			},
+		},
+		{
+			description: "keeps the first playable enclosure, resolving relative links",
+			feedURL:     "https://example.com/feed",
+			gfItems: []*gofeed.Item{
+				{
+					Title:   "Episode 2",
+					GUID:    "ep2",
+					Link:    "https://example.com/ep2",
+					Content: "show notes",
+					Enclosures: []*gofeed.Enclosure{
+						{URL: "/cover.jpg", Type: "image/jpeg"},
+						{URL: "/ep2.mp4", Type: "video/mp4"},
+						{URL: "/ep2.mp3", Type: "audio/mpeg"},
+					},
+				},
+			},
+			expected: []*model.Item{
+				{
+					Title:     ptr.To("Episode 2"),
+					GUID:      ptr.To("ep2"),
+					Link:      ptr.To("https://example.com/ep2"),
+					Content:   ptr.To("show notes"),
+					Unread:    ptr.To(true),
+					PubDate:   &fetchedAt,
+					WordCount: ptr.To(2),
+					HasVideo:  true,
+					HasAudio:  true,
+					ImageURL:  ptr.To("https://example.com/cover.jpg"),
+
+					EnclosureURL:  ptr.To("https://example.com/ep2.mp4"),
+					EnclosureType: ptr.To("video/mp4"),
+				},
+			},
+		},
+		{
+			description: "flags embedded videos and image galleries",
+			feedURL:     "https://example.com/feed",
+			gfItems: []*gofeed.Item{
+				{
+					Title:   "Video",
+					GUID:    "video",
+					Link:    "https://example.com/video",
+					Content: `<iframe src="https://www.youtube.com/embed/abc"></iframe>`,
+				},
+				{
+					Title:   "Photos",
+					GUID:    "photos",
+					Link:    "https://example.com/photos",
+					Content: `<img src="1.jpg"><img src="2.jpg"><IMG src="3.jpg">`,
+				},
+				{
+					Title:   "Two photos",
+					GUID:    "two-photos",
+					Link:    "https://example.com/two-photos",
+					Content: `<img src="1.jpg"><img src="2.jpg">`,
+				},
+			},
+			expected: []*model.Item{
+				{
+					Title:     ptr.To("Video"),
+					GUID:      ptr.To("video"),
+					Link:      ptr.To("https://example.com/video"),
+					Content:   ptr.To(`<iframe src="https://www.youtube.com/embed/abc"></iframe>`),
+					Unread:    ptr.To(true),
+					PubDate:   &fetchedAt,
+					WordCount: ptr.To(0),
+					HasVideo:  true,
+				},
+				{
+					Title:      ptr.To("Photos"),
+					GUID:       ptr.To("photos"),
+					Link:       ptr.To("https://example.com/photos"),
+					Content:    ptr.To(`<img src="1.jpg"><img src="2.jpg"><IMG src="3.jpg">`),
+					Unread:     ptr.To(true),
+					PubDate:    &fetchedAt,
+					WordCount:  ptr.To(0),
+					HasGallery: true,
+					ImageURL:   ptr.To("https://example.com/1.jpg"),
+				},
+				{
+					Title:     ptr.To("Two photos"),
+					GUID:      ptr.To("two-photos"),
+					Link:      ptr.To("https://example.com/two-photos"),
+					Content:   ptr.To(`<img src="1.jpg"><img src="2.jpg">`),
+					Unread:    ptr.To(true),
+					PubDate:   &fetchedAt,
+					WordCount: ptr.To(0),
+					ImageURL:  ptr.To("https://example.com/1.jpg"),
 				},
 			},
 		},
 	} {
 		t.Run(tt.description, func(t *testing.T) {
-			result := client.ParseGoFeedItems(tt.feedURL, tt.gfItems)
+			result := client.ParseGoFeedItems(tt.feedURL, tt.gfItems, fetchedAt)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
+
+func TestParseGoFeedItemsPubDate(t *testing.T) {
+	fetchedAt := *mustParseTime("2025-02-01T00:00:00Z")
+	for _, tt := range []struct {
+		description string
+		item        gofeed.Item
+		expected    *time.Time
+	}{
+		{
+			description: "keeps the date parsed by gofeed",
+			item:        gofeed.Item{PublishedParsed: mustParseTime("2025-01-01T12:00:00Z")},
+			expected:    mustParseTime("2025-01-01T12:00:00Z"),
+		},
+		{
+			description: "falls back to the update date",
+			item:        gofeed.Item{UpdatedParsed: mustParseTime("2025-01-01T12:00:00Z")},
+			expected:    mustParseTime("2025-01-01T12:00:00Z"),
+		},
+		{
+			description: "prefers an unparsed publication date to the update date",
+			item: gofeed.Item{
+				Published:     "2025/01/01 12:00:00",
+				UpdatedParsed: mustParseTime("2025-01-02T12:00:00Z"),
+			},
+			expected: mustParseTime("2025-01-01T12:00:00Z"),
+		},
+		{
+			description: "parses Unix timestamps in seconds",
+			item:        gofeed.Item{Published: "1735732800"},
+			expected:    mustParseTime("2025-01-01T12:00:00Z"),
+		},
+		{
+			description: "parses Unix timestamps in milliseconds",
+			item:        gofeed.Item{Published: "1735732800000"},
+			expected:    mustParseTime("2025-01-01T12:00:00Z"),
+		},
+		{
+			description: "ignores a comment repeating the zone",
+			item:        gofeed.Item{Published: "Wed, 1 Jan 2025 13:00:00 +0100 (CET)"},
+			expected:    mustParseTime("2025-01-01T12:00:00Z"),
+		},
+		{
+			description: "tolerates a missing space after the day of the week",
+			item:        gofeed.Item{Published: "Wed,01 Jan 2025 12:00:00 GMT"},
+			expected:    mustParseTime("2025-01-01T12:00:00Z"),
+		},
+		{
+			description: "parses dotted dates",
+			item:        gofeed.Item{Published: "01.01.2025"},
+			expected:    mustParseTime("2025-01-01T00:00:00Z"),
+		},
+		{
+			description: "parses JavaScript dates",
+			item:        gofeed.Item{Published: "Wed Jan 01 2025 13:00:00 GMT+0100 (Central European Standard Time)"},
+			expected:    mustParseTime("2025-01-01T12:00:00Z"),
+		},
+		{
+			description: "clamps future dates to the fetch time",
+			item:        gofeed.Item{PublishedParsed: mustParseTime("2030-01-01T00:00:00Z")},
+			expected:    &fetchedAt,
+		},
+		{
+			description: "uses the fetch time for unparseable dates",
+			item:        gofeed.Item{Published: "sometime last week"},
+			expected:    &fetchedAt,
+		},
+		{
+			description: "uses the fetch time for items without a date",
+			item:        gofeed.Item{},
+			expected:    &fetchedAt,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			items := client.ParseGoFeedItems("https://example.com/feed", []*gofeed.Item{&tt.item}, fetchedAt)
+			require.Len(t, items, 1)
+			require.NotNil(t, items[0].PubDate)
+			assert.True(t, tt.expected.Equal(*items[0].PubDate), "expected %v, got %v", tt.expected, items[0].PubDate)
+		})
+	}
+}
+
+func TestParseGoFeedItemsGUID(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		feedURL     string
+		gfItems     []*gofeed.Item
+		expected    []string
+	}{
+		{
+			description: "keeps stable GUIDs",
+			feedURL:     "https://example.com/feed",
+			gfItems: []*gofeed.Item{
+				{GUID: "https://example.com/?p=123", Link: "https://example.com/hello-world/"},
+				{GUID: "tag:example.com,2025:post-1", Link: "https://example.com/post-1"},
+			},
+			expected: []string{"https://example.com/?p=123", "tag:example.com,2025:post-1"},
+		},
+		{
+			// Static site generators that hash the rendered content change the
+			// GUID whenever a post is edited.
+			description: "prefers the link to content hashes",
+			feedURL:     "https://example.com/index.xml",
+			gfItems: []*gofeed.Item{
+				{GUID: "5d41402abc4b2a76b9719d911017c592", Link: "https://example.com/posts/hello/"},
+				{GUID: "AAF4C61DDCC5E8A2DABEDE0F3B482CD9AEA9434D", Link: "https://example.com/posts/world/"},
+			},
+			expected: []string{"https://example.com/posts/hello/", "https://example.com/posts/world/"},
+		},
+		{
+			description: "prefers the link to UUIDs",
+			feedURL:     "https://example.com/atom.xml",
+			gfItems: []*gofeed.Item{
+				{GUID: "urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a", Link: "https://example.com/2025/01/a"},
+			},
+			expected: []string{"https://example.com/2025/01/a"},
+		},
+		{
+			description: "keeps opaque GUIDs of items sharing a link",
+			feedURL:     "https://example.com/podcast.xml",
+			gfItems: []*gofeed.Item{
+				{GUID: "0b4c2a3e-5f8b-4d0e-9a61-0a4f9f6a1c11", Link: "https://example.com/podcast"},
+				{GUID: "7e1f9c44-2b6d-4a3a-8f0e-3c5d2e1b9a22", Link: "https://example.com/podcast"},
+			},
+			expected: []string{"0b4c2a3e-5f8b-4d0e-9a61-0a4f9f6a1c11", "7e1f9c44-2b6d-4a3a-8f0e-3c5d2e1b9a22"},
+		},
+		{
+			description: "resolves relative GUIDs",
+			feedURL:     "https://example.com/blog/feed.xml",
+			gfItems: []*gofeed.Item{
+				{GUID: " /2025/01/01/new-year ", Link: "/2025/01/01/new-year.html"},
+			},
+			expected: []string{"https://example.com/2025/01/01/new-year"},
+		},
+		{
+			description: "falls back to the link",
+			feedURL:     "https://example.com/feed",
+			gfItems: []*gofeed.Item{
+				{Link: "https://example.com/no-guid"},
+			},
+			expected: []string{"https://example.com/no-guid"},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			items := client.ParseGoFeedItems(tt.feedURL, tt.gfItems, time.Now())
+			guids := make([]string, 0, len(items))
+			for _, item := range items {
+				guids = append(guids, *item.GUID)
+			}
+			assert.Equal(t, tt.expected, guids)
+		})
+	}
+}
+
+func TestParseGoFeedItemsImage(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		item        gofeed.Item
+		expected    string
+	}{
+		{
+			description: "prefers the image of the item",
+			item: gofeed.Item{
+				Link:       "https://example.com/post",
+				Image:      &gofeed.Image{URL: "https://cdn.example.com/thumb.jpg"},
+				Enclosures: []*gofeed.Enclosure{{URL: "https://example.com/cover.jpg", Type: "image/jpeg"}},
+				Content:    `<img src="inline.jpg">`,
+			},
+			expected: "https://cdn.example.com/thumb.jpg",
+		},
+		{
+			description: "resolves images of the content against the link",
+			item: gofeed.Item{
+				Link:    "https://example.com/blog/post",
+				Content: `<p>Hi</p><img alt="" src='photo.jpg?w=1&amp;h=2'>`,
+			},
+			expected: "https://example.com/blog/photo.jpg?w=1&h=2",
+		},
+		{
+			description: "skips inline images",
+			item: gofeed.Item{
+				Link:    "https://example.com/post",
+				Content: `<img src="data:image/gif;base64,R0lGOD"><img src="/real.png">`,
+			},
+			expected: "https://example.com/real.png",
+		},
+		{
+			description: "has none without images",
+			item:        gofeed.Item{Link: "https://example.com/post", Content: "<p>text</p>"},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			items := client.ParseGoFeedItems("https://example.com/feed", []*gofeed.Item{&tt.item}, time.Now())
+			require.Len(t, items, 1)
+			assert.Equal(t, tt.expected, ptr.From(items[0].ImageURL))
+		})
+	}
+}
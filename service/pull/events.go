@@ -0,0 +1,12 @@
+package pull
+
+import "github.com/0x2e/fusion/pkg/pubsub"
+
+// NewItemsEvent reports that a pull stored new items of a feed.
+type NewItemsEvent struct {
+	FeedID   uint `json:"feed_id"`
+	NewItems int  `json:"new_items"`
+}
+
+// Events publishes a NewItemsEvent whenever a pull stores new items.
+var Events = pubsub.NewBroker[NewItemsEvent]()
@@ -2,30 +2,36 @@ package pull
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/logctx"
 	"github.com/0x2e/fusion/pkg/ptr"
-	"github.com/0x2e/fusion/service/pull/client"
 )
 
-func (p *Puller) do(ctx context.Context, f *model.Feed, force bool) error {
-	logger := slog.With("feed_id", f.ID, "feed_link", ptr.From(f.Link))
+// feedLogger returns the logger carried by ctx, with the fields identifying f.
+func feedLogger(ctx context.Context, f *model.Feed) *slog.Logger {
+	return logctx.From(ctx).With("feed_id", f.ID, "feed_link", ptr.From(f.Link))
+}
+
+func (p *Puller) do(ctx context.Context, f *model.Feed, force bool) (PullResult, error) {
+	logger := feedLogger(ctx, f)
+	parentCtx := ctx
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	updateAction, skipReason := DecideFeedUpdateAction(f, time.Now())
-	if skipReason == &SkipReasonSuspended {
-		logger.Info(fmt.Sprintf("skip: %s", skipReason))
-		return nil
+	if skipReason == &SkipReasonSuspended || skipReason == &SkipReasonVirtual {
+		logger.Info("skipped feed", "skip_reason", skipReason.Code())
+		return PullResult{SkipReason: skipReason}, nil
 	}
 	if !force {
 		switch updateAction {
 		case ActionSkipUpdate:
-			logger.Info(fmt.Sprintf("skip: %s", skipReason))
-			return nil
+			nextFetchAt := NextFetchAt(f)
+			logger.Info("skipped feed", "skip_reason", skipReason.Code(), "next_fetch_at", nextFetchAt, "consecutive_failures", f.ConsecutiveFailures)
+			return PullResult{SkipReason: skipReason, NextFetchAt: nextFetchAt}, nil
 		case ActionFetchUpdate:
 			// Proceed to perform the fetch.
 		default:
@@ -33,12 +39,25 @@ func (p *Puller) do(ctx context.Context, f *model.Feed, force bool) error {
 		}
 	}
 
-	repo := defaultSingleFeedRepo{
-		feedID:   f.ID,
-		feedRepo: p.feedRepo,
-		itemRepo: p.itemRepo,
+	// Forced pulls try the host anyway, as someone asked for them.
+	host := feedHost(ptr.From(f.Link))
+	allowed, retryAt := circuits.allow(host, time.Now())
+	if !allowed && !force {
+		logger.Info("skipped feed", "skip_reason", SkipReasonHostUnavailable.Code(), "next_fetch_at", retryAt)
+		return PullResult{SkipReason: &SkipReasonHostUnavailable, NextFetchAt: &retryAt}, nil
+	}
+	if allowed {
+		defer circuits.release(host)
+	}
+
+	repo := NewSingleFeedRepo(f.ID, p.feedRepo, p.itemRepo)
+	result, err := NewSingleFeedPuller(p.feedClient.FetchItems, repo).Pull(ctx, f)
+	// A fetch cut short by the caller, e.g. at the end of PullAll's run, says
+	// nothing about the host.
+	if parentCtx.Err() == nil {
+		circuits.record(host, result.FetchErr, time.Now())
 	}
-	return NewSingleFeedPuller(client.NewFeedClient().FetchItems, &repo).Pull(ctx, f)
+	return result, err
 }
 
 // FeedUpdateAction represents the action to take when considering checking a
@@ -52,6 +71,7 @@ const (
 
 // FeedSkipReason represents a reason for skipping a feed update.
 type FeedSkipReason struct {
+	code   string
 	reason string
 }
 
@@ -59,24 +79,59 @@ func (r FeedSkipReason) String() string {
 	return r.reason
 }
 
+// Code identifies the reason for API clients.
+func (r FeedSkipReason) Code() string {
+	return r.code
+}
+
 var (
-	SkipReasonSuspended  = FeedSkipReason{"user suspended feed updates"}
-	SkipReasonCoolingOff = FeedSkipReason{"slowing down requests due to past failures to update feed"}
-	SkipReasonTooSoon    = FeedSkipReason{"feed was updated too recently"}
+	SkipReasonSuspended  = FeedSkipReason{"suspended", "user suspended feed updates"}
+	SkipReasonCoolingOff = FeedSkipReason{"cooling_off", "slowing down requests due to past failures to update feed"}
+	SkipReasonTooSoon    = FeedSkipReason{"too_soon", "feed was updated too recently"}
+	// SkipReasonHostUnavailable is for feeds whose host stopped responding to
+	// the fetches of any of its feeds, see breaker.
+	SkipReasonHostUnavailable = FeedSkipReason{"host_unavailable", "the feed's host is not responding"}
+	// SkipReasonVirtual is for the feeds there's nothing to fetch of, see
+	// model.Feed.IsVirtual.
+	SkipReasonVirtual = FeedSkipReason{"virtual", "the feed's items are added through the API"}
 )
 
 func DecideFeedUpdateAction(f *model.Feed, now time.Time) (FeedUpdateAction, *FeedSkipReason) {
+	if f.IsVirtual() {
+		return ActionSkipUpdate, &SkipReasonVirtual
+	}
 	if f.IsSuspended() {
 		return ActionSkipUpdate, &SkipReasonSuspended
-	} else if f.ConsecutiveFailures > 0 {
-		backoffTime := CalculateBackoffTime(f.ConsecutiveFailures)
-		timeSinceUpdate := now.Sub(f.UpdatedAt)
-		if timeSinceUpdate < backoffTime {
-			slog.Info(fmt.Sprintf("%d consecutive feed update failures, so next attempt is after %v", f.ConsecutiveFailures, f.UpdatedAt.Add(backoffTime).Format(time.RFC3339)), "feed_id", f.ID, "feed_link", ptr.From(f.Link))
-			return ActionSkipUpdate, &SkipReasonCoolingOff
-		}
-	} else if now.Sub(f.UpdatedAt) < interval {
-		return ActionSkipUpdate, &SkipReasonTooSoon
 	}
-	return ActionFetchUpdate, nil
+
+	nextFetchAt := NextFetchAt(f)
+	if nextFetchAt == nil || !now.Before(*nextFetchAt) {
+		return ActionFetchUpdate, nil
+	}
+
+	if f.ConsecutiveFailures > 0 {
+		return ActionSkipUpdate, &SkipReasonCoolingOff
+	}
+	return ActionSkipUpdate, &SkipReasonTooSoon
+}
+
+// NextFetchAt returns when the feed is due for a fetch, or nil if it's due
+// right away.
+func NextFetchAt(f *model.Feed) *time.Time {
+	if f.NextFetchAt == nil && f.LastFetchedAt != nil {
+		// The feed was last fetched before NextFetchAt was recorded.
+		return ptr.To(f.LastFetchedAt.Add(RefreshInterval(f)))
+	}
+	return f.NextFetchAt
+}
+
+// RefreshInterval returns the time between fetches of the feed, as set for it
+// or its group, see model.Feed.Settings. Feeds aren't fetched more often than
+// the scheduler runs.
+func RefreshInterval(f *model.Feed) time.Duration {
+	minutes := f.Settings().RefreshInterval
+	if minutes == nil {
+		return interval
+	}
+	return max(time.Duration(*minutes)*time.Minute, interval)
 }
@@ -1,7 +1,6 @@
 package pull_test
 
 import (
-	"math"
 	"testing"
 	"time"
 
@@ -39,13 +38,22 @@ func TestDecideFeedUpdateAction(t *testing.T) {
 			expectedAction:     pull.ActionSkipUpdate,
 			expectedSkipReason: &pull.SkipReasonSuspended,
 		},
+		{
+			description: "virtual feed should skip update",
+			currentTime: parseTime("2025-01-01T12:00:00Z"),
+			feed: model.Feed{
+				Link: ptr.To(model.NotesFeedLink),
+			},
+			expectedAction:     pull.ActionSkipUpdate,
+			expectedSkipReason: &pull.SkipReasonVirtual,
+		},
 		{
 			description: "feed should be updated when conditions are met",
 			currentTime: parseTime("2025-01-01T12:00:00Z"),
 			feed: model.Feed{
-				Failure:   ptr.To(""),
-				Suspended: ptr.To(false),
-				UpdatedAt: parseTime("2025-01-01T11:15:00Z"), // 45 minutes before current time
+				Failure:       ptr.To(""),
+				Suspended:     ptr.To(false),
+				LastFetchedAt: ptr.To(parseTime("2025-01-01T11:15:00Z")), // 45 minutes before current time
 			},
 			expectedAction:     pull.ActionFetchUpdate,
 			expectedSkipReason: nil,
@@ -54,9 +62,9 @@ func TestDecideFeedUpdateAction(t *testing.T) {
 			description: "feed with nil failure should be updated",
 			currentTime: parseTime("2025-01-01T12:00:00Z"),
 			feed: model.Feed{
-				Failure:   nil,
-				Suspended: ptr.To(false),
-				UpdatedAt: parseTime("2025-01-01T11:15:00Z"), // 45 minutes before current time
+				Failure:       nil,
+				Suspended:     ptr.To(false),
+				LastFetchedAt: ptr.To(parseTime("2025-01-01T11:15:00Z")), // 45 minutes before current time
 			},
 			expectedAction:     pull.ActionFetchUpdate,
 			expectedSkipReason: nil,
@@ -65,81 +73,104 @@ func TestDecideFeedUpdateAction(t *testing.T) {
 			description: "feed with nil suspended should be updated",
 			currentTime: parseTime("2025-01-01T12:00:00Z"),
 			feed: model.Feed{
-				Failure:   ptr.To(""),
-				Suspended: nil,
-				UpdatedAt: parseTime("2025-01-01T11:15:00Z"), // 45 minutes before current time
+				Failure:       ptr.To(""),
+				Suspended:     nil,
+				LastFetchedAt: ptr.To(parseTime("2025-01-01T11:15:00Z")), // 45 minutes before current time
 			},
 			expectedAction:     pull.ActionFetchUpdate,
 			expectedSkipReason: nil,
 		},
 		{
-			description: "failed feed with 1 consecutive failure should skip update before 54 minutes",
+			description: "feed fetched too recently should skip update without a next fetch time",
 			currentTime: parseTime("2025-01-01T12:00:00Z"),
 			feed: model.Feed{
-				Failure:             ptr.To("dummy previous error"),
-				Suspended:           ptr.To(false),
-				UpdatedAt:           parseTime("2025-01-01T11:15:00Z"), // 45 minutes before current time
-				ConsecutiveFailures: 1,
+				Failure:       ptr.To(""),
+				Suspended:     ptr.To(false),
+				LastFetchedAt: ptr.To(parseTime("2025-01-01T11:50:00Z")), // 10 minutes before current time
 			},
 			expectedAction:     pull.ActionSkipUpdate,
-			expectedSkipReason: &pull.SkipReasonCoolingOff,
+			expectedSkipReason: &pull.SkipReasonTooSoon,
 		},
 		{
-			description: "failed feed with 1 consecutive failure should be updated after 54 minutes",
+			description: "recently edited feed should be updated if not fetched recently",
 			currentTime: parseTime("2025-01-01T12:00:00Z"),
 			feed: model.Feed{
-				Failure:             ptr.To("dummy previous error"),
-				Suspended:           ptr.To(false),
-				UpdatedAt:           parseTime("2025-01-01T11:06:00Z"), // 54 minutes before current time
-				ConsecutiveFailures: 1,
+				Failure:       ptr.To(""),
+				Suspended:     ptr.To(false),
+				UpdatedAt:     parseTime("2025-01-01T11:59:00Z"),         // 1 minute before current time
+				LastFetchedAt: ptr.To(parseTime("2025-01-01T11:15:00Z")), // 45 minutes before current time
 			},
 			expectedAction:     pull.ActionFetchUpdate,
 			expectedSkipReason: nil,
 		},
 		{
-			description: "failed feed with 3 consecutive failures should skip update for 174 minutes",
+			description: "feed never fetched should be updated",
 			currentTime: parseTime("2025-01-01T12:00:00Z"),
 			feed: model.Feed{
-				Failure:             ptr.To("dummy previous error"),
-				Suspended:           ptr.To(false),
-				UpdatedAt:           parseTime("2025-01-01T09:10:00Z"), // 170 minutes before current time
-				ConsecutiveFailures: 3,
+				Failure:   ptr.To(""),
+				Suspended: ptr.To(false),
+				UpdatedAt: parseTime("2025-01-01T11:59:00Z"), // 1 minute before current time
+			},
+			expectedAction:     pull.ActionFetchUpdate,
+			expectedSkipReason: nil,
+		},
+		{
+			description: "feed should skip update before its next fetch time",
+			currentTime: parseTime("2025-01-01T12:00:00Z"),
+			feed: model.Feed{
+				Failure:       ptr.To(""),
+				Suspended:     ptr.To(false),
+				LastFetchedAt: ptr.To(parseTime("2025-01-01T11:15:00Z")),
+				NextFetchAt:   ptr.To(parseTime("2025-01-01T12:05:00Z")),
 			},
 			expectedAction:     pull.ActionSkipUpdate,
-			expectedSkipReason: &pull.SkipReasonCoolingOff,
+			expectedSkipReason: &pull.SkipReasonTooSoon,
 		},
 		{
-			description: "failed feed with 3 consecutive failures should be updated after 174 minutes",
+			description: "feed should be updated at its next fetch time",
 			currentTime: parseTime("2025-01-01T12:00:00Z"),
 			feed: model.Feed{
-				Failure:             ptr.To("dummy previous error"),
-				Suspended:           ptr.To(false),
-				UpdatedAt:           parseTime("2025-01-01T09:06:00Z"), // 174 minutes before current time
-				ConsecutiveFailures: 3,
+				Failure:       ptr.To(""),
+				Suspended:     ptr.To(false),
+				LastFetchedAt: ptr.To(parseTime("2025-01-01T11:59:00Z")),
+				NextFetchAt:   ptr.To(parseTime("2025-01-01T12:00:00Z")),
 			},
 			expectedAction:     pull.ActionFetchUpdate,
 			expectedSkipReason: nil,
 		},
 		{
-			description: "failed feed with many consecutive failures should not exceed maximum wait time of 7 days",
+			description: "failed feed should skip update before its next fetch time",
 			currentTime: parseTime("2025-01-01T12:00:00Z"),
 			feed: model.Feed{
 				Failure:             ptr.To("dummy previous error"),
 				Suspended:           ptr.To(false),
-				UpdatedAt:           parseTime("2024-12-30T12:00:00Z"), // 2 days before current time
-				ConsecutiveFailures: 10,
+				NextFetchAt:         ptr.To(parseTime("2025-01-01T12:54:00Z")),
+				ConsecutiveFailures: 1,
 			},
 			expectedAction:     pull.ActionSkipUpdate,
 			expectedSkipReason: &pull.SkipReasonCoolingOff,
 		},
 		{
-			description: "failed feed with many consecutive failures should be updated after maximum wait time of 7 days",
+			description: "failed feed should be updated after its next fetch time",
 			currentTime: parseTime("2025-01-01T12:00:00Z"),
 			feed: model.Feed{
 				Failure:             ptr.To("dummy previous error"),
 				Suspended:           ptr.To(false),
-				UpdatedAt:           parseTime("2024-12-25T12:00:00Z"), // 7 days before current time
-				ConsecutiveFailures: math.MaxUint,
+				NextFetchAt:         ptr.To(parseTime("2025-01-01T11:54:00Z")),
+				ConsecutiveFailures: 3,
+			},
+			expectedAction:     pull.ActionFetchUpdate,
+			expectedSkipReason: nil,
+		},
+		{
+			description: "editing a failed feed should not delay its next fetch",
+			currentTime: parseTime("2025-01-01T12:00:00Z"),
+			feed: model.Feed{
+				Failure:             ptr.To("dummy previous error"),
+				Suspended:           ptr.To(false),
+				UpdatedAt:           parseTime("2025-01-01T11:59:00Z"), // 1 minute before current time
+				NextFetchAt:         ptr.To(parseTime("2025-01-01T11:54:00Z")),
+				ConsecutiveFailures: 1,
 			},
 			expectedAction:     pull.ActionFetchUpdate,
 			expectedSkipReason: nil,
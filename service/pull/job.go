@@ -0,0 +1,46 @@
+package pull
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/service/job"
+)
+
+// Kinds of the jobs run by the Puller, see RegisterJobs.
+const (
+	// JobPullFeed pulls the feed of a PullFeedJob.
+	JobPullFeed = "pull_feed"
+	// JobPullAll pulls every feed due for an update.
+	JobPullAll = "pull_all"
+)
+
+type PullFeedJob struct {
+	FeedID uint `json:"feed_id"`
+}
+
+// RegisterJobs registers the handlers of the pull jobs with q.
+func (p *Puller) RegisterJobs(q *job.Queue) {
+	q.Register(JobPullFeed, func(ctx context.Context, payload []byte) error {
+		var req PullFeedJob
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return err
+		}
+		// Feeds are only queued to be pulled once subscribed to.
+		_, err := p.PullOne(WithOrigin(ctx, model.ItemOriginImport), req.FeedID, false)
+		// The feed was deleted since.
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil
+		}
+		return err
+	}, job.DefaultRetryPolicy)
+
+	// A run in progress may have listed the feeds before the job was
+	// enqueued, so the job is retried until it gets its own run.
+	q.Register(JobPullAll, func(ctx context.Context, payload []byte) error {
+		return p.PullAll(WithOrigin(ctx, model.ItemOriginScheduled), false)
+	}, job.DefaultRetryPolicy)
+}
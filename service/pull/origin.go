@@ -0,0 +1,19 @@
+package pull
+
+import "context"
+
+type originKey struct{}
+
+// WithOrigin returns a copy of ctx with which pulls record the items they
+// find as arrived by origin, one of the model.ItemOrigin values.
+func WithOrigin(ctx context.Context, origin string) context.Context {
+	return context.WithValue(ctx, originKey{}, origin)
+}
+
+// originFrom returns the origin carried by ctx, or nil if there's none.
+func originFrom(ctx context.Context) *string {
+	if origin, ok := ctx.Value(originKey{}).(string); ok {
+		return &origin
+	}
+	return nil
+}
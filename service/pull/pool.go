@@ -0,0 +1,86 @@
+package pull
+
+import (
+	"context"
+	"sync"
+)
+
+// priority orders the pulls waiting for a worker.
+type priority int
+
+const (
+	// priorityScheduled is for the feeds pulled by PullAll.
+	priorityScheduled priority = iota
+	// priorityUser is for the feeds someone is waiting for, which go ahead
+	// of all the scheduled ones.
+	priorityUser
+)
+
+// workers limits how many feeds are pulled at once, across PullAll and
+// PullOne.
+var workers = newPool(10)
+
+// pool is a semaphore whose waiters are served by priority, then in order.
+type pool struct {
+	mu   sync.Mutex
+	free int
+	// waiting holds a channel per waiter, by priority. It's closed to hand
+	// over a worker.
+	waiting [priorityUser + 1][]chan struct{}
+}
+
+func newPool(size int) *pool {
+	return &pool{free: size}
+}
+
+// acquire waits for a worker, which must be given back with release. It
+// returns ctx's error if ctx is done first.
+func (p *pool) acquire(ctx context.Context, prio priority) error {
+	p.mu.Lock()
+	if p.free > 0 {
+		p.free--
+		p.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	p.waiting[prio] = append(p.waiting[prio], ready)
+	p.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		select {
+		case <-ready:
+			// The worker was handed over meanwhile, pass it on.
+			p.releaseLocked()
+		default:
+			for i, c := range p.waiting[prio] {
+				if c == ready {
+					p.waiting[prio] = append(p.waiting[prio][:i], p.waiting[prio][i+1:]...)
+					break
+				}
+			}
+		}
+		return ctx.Err()
+	}
+}
+
+func (p *pool) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.releaseLocked()
+}
+
+func (p *pool) releaseLocked() {
+	for prio := len(p.waiting) - 1; prio >= 0; prio-- {
+		if len(p.waiting[prio]) > 0 {
+			close(p.waiting[prio][0])
+			p.waiting[prio] = p.waiting[prio][1:]
+			return
+		}
+	}
+	p.free++
+}
@@ -0,0 +1,60 @@
+package pull
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolServesUserPullsFirst(t *testing.T) {
+	p := newPool(1)
+	require.NoError(t, p.acquire(context.Background(), priorityScheduled))
+
+	order := make(chan string, 2)
+	var wg sync.WaitGroup
+	wait := func(name string, prio priority) {
+		defer wg.Done()
+		assert.NoError(t, p.acquire(context.Background(), prio))
+		order <- name
+		p.release()
+	}
+	wg.Add(2)
+	go wait("scheduled", priorityScheduled)
+	waitForWaiters(t, p, priorityScheduled, 1)
+	go wait("user", priorityUser)
+	waitForWaiters(t, p, priorityUser, 1)
+
+	p.release()
+	assert.Equal(t, "user", <-order)
+	assert.Equal(t, "scheduled", <-order)
+	wg.Wait()
+	assert.Equal(t, 1, p.free)
+}
+
+func TestPoolAcquireCanceled(t *testing.T) {
+	p := newPool(1)
+	require.NoError(t, p.acquire(context.Background(), priorityScheduled))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.acquire(ctx, priorityUser), context.DeadlineExceeded)
+
+	p.release()
+	assert.Equal(t, 1, p.free, "canceled waiters must not keep a worker")
+	assert.Empty(t, p.waiting[priorityUser])
+}
+
+// waitForWaiters waits until n pulls of priority prio wait for a worker.
+func waitForWaiters(t *testing.T, p *pool, prio priority, n int) {
+	t.Helper()
+
+	assert.Eventually(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return len(p.waiting[prio]) == n
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,125 @@
+package pull
+
+import (
+	"sync"
+	"time"
+)
+
+// Progress reports how far the latest PullAll got, and when the scheduler
+// runs the next one.
+type Progress struct {
+	// ID identifies the PullAll run. It grows with each run, so clients can
+	// tell a run they started from an earlier one.
+	ID      uint `json:"id"`
+	Running bool `json:"running"`
+	Total   int  `json:"total"`
+	// Completed is the number of feeds done so far, including the Failed
+	// ones.
+	Completed int `json:"completed"`
+	// Fetched is the number of feeds the run tried to fetch, the others were
+	// skipped.
+	Fetched    int        `json:"fetched"`
+	Failed     int        `json:"failed"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	// NextRunAt is when the scheduler starts the next run. It's nil until the
+	// scheduler has started.
+	NextRunAt *time.Time `json:"next_run_at"`
+	// Outage is set when the last finished run failed to fetch most feeds,
+	// see isOutage. It's kept while the next run is going.
+	Outage bool `json:"outage"`
+}
+
+// outageThreshold is the percentage of the fetched feeds that must fail for a
+// run to be an outage, 0 to never report one. SetOutageThreshold configures
+// it.
+var outageThreshold = 50
+
+// SetOutageThreshold configures the percentage of failed feeds past which a
+// run of PullAll is reported as an outage.
+func SetOutageThreshold(percent int) {
+	outageThreshold = percent
+}
+
+// outageMinFeeds is the number of feeds a run must fetch to be an outage, as
+// a few failing feeds say little about the network.
+const outageMinFeeds = 5
+
+// isOutage tells whether so many of the feeds fetched failed that the cause is
+// likely shared, like the network or a proxy being down, rather than the
+// feeds.
+func (p Progress) isOutage() bool {
+	return outageThreshold > 0 && p.Fetched >= outageMinFeeds &&
+		p.Failed*100 > p.Fetched*outageThreshold
+}
+
+type progressTracker struct {
+	mu       sync.Mutex
+	progress Progress
+}
+
+var progress progressTracker
+
+// CurrentProgress returns the progress of the running PullAll, or of the last
+// one if none is running.
+func CurrentProgress() Progress {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+	return progress.progress
+}
+
+// start resets the progress for a new run and returns its ID. The updates of
+// an earlier run still in flight are ignored from then on.
+func (t *progressTracker) start(total int) uint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.progress = Progress{
+		ID:        t.progress.ID + 1,
+		Running:   true,
+		Total:     total,
+		StartedAt: &now,
+		NextRunAt: t.progress.NextRunAt,
+		Outage:    t.progress.Outage,
+	}
+	return t.progress.ID
+}
+
+func (t *progressTracker) scheduleNext(at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progress.NextRunAt = &at
+}
+
+func (t *progressTracker) done(id uint, fetched, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.progress.ID != id {
+		return
+	}
+	t.progress.Completed++
+	if fetched {
+		t.progress.Fetched++
+	}
+	if failed {
+		t.progress.Failed++
+	}
+}
+
+// finish ends the run and returns its final progress.
+func (t *progressTracker) finish(id uint) Progress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.progress.ID != id {
+		return Progress{}
+	}
+	now := time.Now()
+	t.progress.Running = false
+	t.progress.FinishedAt = &now
+	t.progress.Outage = t.progress.isOutage()
+	return t.progress
+}
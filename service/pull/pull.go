@@ -8,14 +8,24 @@ import (
 	"time"
 
 	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/errreport"
 	"github.com/0x2e/fusion/pkg/ptr"
 	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/service/pull/client"
 )
 
 var (
 	interval = 30 * time.Minute
 )
 
+// ErrPullAllRunning is returned by PullAll when an earlier run hasn't
+// finished yet.
+var ErrPullAllRunning = errors.New("already pulling all feeds")
+
+// pullAllMu keeps the scheduled and the manual runs of PullAll from
+// overlapping.
+var pullAllMu sync.Mutex
+
 type FeedRepo interface {
 	List(filter *repo.FeedListFilter) ([]*model.Feed, error)
 	Get(id uint) (*model.Feed, error)
@@ -23,20 +33,40 @@ type FeedRepo interface {
 }
 
 type ItemRepo interface {
-	Insert(items []*model.Item) error
+	Insert(items []*model.Item) (int, error)
+}
+
+// PullResult describes the outcome of pulling a feed.
+type PullResult struct {
+	// NewItems is the number of items stored by the pull.
+	NewItems int
+	// SkipReason is set if the feed wasn't fetched.
+	SkipReason *FeedSkipReason
+	// NextFetchAt is when the feed is due, if it was skipped for being fetched
+	// too recently or for cooling off after failures.
+	NextFetchAt *time.Time
+	Duration    time.Duration
+	// StatusCode is the HTTP status of the feed response, or 0 if there was
+	// none.
+	StatusCode int
+	// FetchErr is the error of fetching the feed. Pulls record it in the store
+	// rather than returning it.
+	FetchErr error
 }
 
 type Puller struct {
-	feedRepo FeedRepo
-	itemRepo ItemRepo
+	feedRepo   FeedRepo
+	itemRepo   ItemRepo
+	feedClient client.FeedClient
 }
 
 // TODO: cache favicon
 
-func NewPuller(feedRepo FeedRepo, itemRepo ItemRepo) *Puller {
+func NewPuller(feedRepo FeedRepo, itemRepo ItemRepo, feedClient client.FeedClient) *Puller {
 	return &Puller{
-		feedRepo: feedRepo,
-		itemRepo: itemRepo,
+		feedRepo:   feedRepo,
+		itemRepo:   itemRepo,
+		feedClient: feedClient,
 	}
 }
 
@@ -45,17 +75,46 @@ func (p *Puller) Run() {
 	defer ticker.Stop()
 
 	for {
-		p.PullAll(context.Background(), false)
+		progress.scheduleNext(time.Now().Add(interval))
+		p.pullAllScheduled()
 
 		<-ticker.C
 	}
 }
 
+// pullAllScheduled runs a scheduled PullAll, recovering from panics so that
+// later runs still happen.
+func (p *Puller) pullAllScheduled() {
+	ctx := WithOrigin(context.Background(), model.ItemOriginScheduled)
+	defer errreport.Recover(ctx)
+
+	if err := p.PullAll(ctx, false); err != nil {
+		slog.Error("failed to pull all feeds", "error", err)
+	}
+}
+
+// PullAll pulls every feed due for an update, or every feed if force is set.
+// It returns ErrPullAllRunning without pulling anything if another run is in
+// progress.
 func (p *Puller) PullAll(ctx context.Context, force bool) error {
+	if !pullAllMu.TryLock() {
+		return ErrPullAllRunning
+	}
+	defer pullAllMu.Unlock()
+
 	ctx, cancel := context.WithTimeout(ctx, interval/2)
 	defer cancel()
 
 	feeds, err := p.feedRepo.List(nil)
+	// Track the run even if it fails early, so that clients waiting for it
+	// see it finish.
+	runID := progress.start(len(feeds))
+	defer func() {
+		if run := progress.finish(runID); run.Outage {
+			slog.Warn("most feeds failed to refresh, the network or proxy may be down",
+				"failed", run.Failed, "fetched", run.Fetched)
+		}
+	}()
 	if err != nil {
 		if errors.Is(err, repo.ErrNotFound) {
 			err = nil
@@ -66,20 +125,28 @@ func (p *Puller) PullAll(ctx context.Context, force bool) error {
 		return nil
 	}
 
-	routinePool := make(chan struct{}, 10)
-	defer close(routinePool)
 	wg := sync.WaitGroup{}
 	for _, f := range feeds {
-		routinePool <- struct{}{}
+		if err := workers.acquire(ctx, priorityScheduled); err != nil {
+			// The run timed out, the remaining feeds wait for the next one.
+			break
+		}
 		wg.Add(1)
 		go func(f *model.Feed) {
 			defer func() {
 				wg.Done()
-				<-routinePool
+				workers.release()
 			}()
-
-			if err := p.do(ctx, f, force); err != nil {
-				slog.Error("failed to pull feed", "error", err, "feed_id", f.ID, "feed_link", ptr.From(f.Link))
+			defer errreport.Recover(ctx, "feed_id", f.ID, "feed_link", ptr.From(f.Link))
+
+			result, err := p.do(ctx, f, force)
+			progress.done(runID, result.SkipReason == nil, err != nil || result.FetchErr != nil)
+			if err != nil {
+				msg := "failed to pull feed"
+				if errors.Is(err, ErrStore) {
+					msg = "failed to store pulled feed"
+				}
+				feedLogger(ctx, f).Error(msg, "error", err)
 			}
 		}(f)
 	}
@@ -87,11 +154,18 @@ func (p *Puller) PullAll(ctx context.Context, force bool) error {
 	return nil
 }
 
-func (p *Puller) PullOne(ctx context.Context, id uint) error {
+// PullOne pulls a feed if it's due, or regardless of its schedule if force is
+// set. Suspended feeds are never pulled. As the caller waits for the result,
+// the feed goes ahead of those queued by a running PullAll.
+func (p *Puller) PullOne(ctx context.Context, id uint, force bool) (PullResult, error) {
 	f, err := p.feedRepo.Get(id)
 	if err != nil {
-		return err
+		return PullResult{}, err
 	}
 
-	return p.do(ctx, f, true)
+	if err := workers.acquire(ctx, priorityUser); err != nil {
+		return PullResult{}, err
+	}
+	defer workers.release()
+	return p.do(ctx, f, force)
 }
@@ -0,0 +1,222 @@
+package pull_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/feedtest"
+	"github.com/0x2e/fusion/pkg/logctx"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
+	"github.com/0x2e/fusion/service/pull"
+	"github.com/0x2e/fusion/service/pull/client"
+)
+
+// withTestLogger returns a context whose logger writes JSON records to the
+// returned buffer, tagged with a request_id.
+func withTestLogger() (context.Context, *bytes.Buffer) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil)).With("request_id", "test-request")
+	return logctx.With(context.Background(), logger), &logs
+}
+
+// findLogRecord returns the first JSON log record with the given message.
+func findLogRecord(t *testing.T, logs *bytes.Buffer, msg string) map[string]any {
+	t.Helper()
+
+	dec := json.NewDecoder(logs)
+	for dec.More() {
+		var record map[string]any
+		require.NoError(t, dec.Decode(&record))
+		if record["msg"] == msg {
+			return record
+		}
+	}
+	require.Fail(t, "log record not found", "msg %q", msg)
+	return nil
+}
+
+// blockingFeedRepo lists no feeds, but only once release is closed.
+type blockingFeedRepo struct {
+	listing chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingFeedRepo) List(filter *repo.FeedListFilter) ([]*model.Feed, error) {
+	close(r.listing)
+	<-r.release
+	return nil, nil
+}
+
+func (r *blockingFeedRepo) Get(id uint) (*model.Feed, error) {
+	return nil, repo.ErrNotFound
+}
+
+func (r *blockingFeedRepo) Update(id uint, feed *model.Feed) error {
+	return nil
+}
+
+func TestPullAllDoesNotOverlap(t *testing.T) {
+	feedRepo := &blockingFeedRepo{
+		listing: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	puller := pull.NewPuller(feedRepo, nil, client.NewFeedClient(""))
+
+	firstRun := make(chan error)
+	go func() {
+		firstRun <- puller.PullAll(context.Background(), true)
+	}()
+	<-feedRepo.listing
+
+	assert.ErrorIs(t, puller.PullAll(context.Background(), true), pull.ErrPullAllRunning)
+
+	close(feedRepo.release)
+	require.NoError(t, <-firstRun)
+	assert.False(t, pull.CurrentProgress().Running)
+}
+
+// stubFeedRepo serves a single feed.
+type stubFeedRepo struct {
+	feed *model.Feed
+}
+
+func (r stubFeedRepo) List(filter *repo.FeedListFilter) ([]*model.Feed, error) {
+	return []*model.Feed{r.feed}, nil
+}
+
+func (r stubFeedRepo) Get(id uint) (*model.Feed, error) {
+	return r.feed, nil
+}
+
+func (r stubFeedRepo) Update(id uint, feed *model.Feed) error {
+	return nil
+}
+
+func TestPullOneSkips(t *testing.T) {
+	nextFetchAt := time.Now().Add(10 * time.Minute)
+	for _, tt := range []struct {
+		description         string
+		feed                model.Feed
+		force               bool
+		expectedSkipReason  *pull.FeedSkipReason
+		expectedNextFetchAt *time.Time
+	}{
+		{
+			description:         "feed fetched recently is skipped",
+			feed:                model.Feed{NextFetchAt: &nextFetchAt},
+			expectedSkipReason:  &pull.SkipReasonTooSoon,
+			expectedNextFetchAt: &nextFetchAt,
+		},
+		{
+			description:         "failed feed is skipped while cooling off",
+			feed:                model.Feed{NextFetchAt: &nextFetchAt, ConsecutiveFailures: 1},
+			expectedSkipReason:  &pull.SkipReasonCoolingOff,
+			expectedNextFetchAt: &nextFetchAt,
+		},
+		{
+			description:        "suspended feed is skipped even if forced",
+			feed:               model.Feed{Suspended: ptr.To(true)},
+			force:              true,
+			expectedSkipReason: &pull.SkipReasonSuspended,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			tt.feed.Link = ptr.To("https://example.com/feed")
+			puller := pull.NewPuller(stubFeedRepo{feed: &tt.feed}, nil, client.NewFeedClient(""))
+
+			result, err := puller.PullOne(context.Background(), 42, tt.force)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSkipReason, result.SkipReason)
+			assert.Equal(t, tt.expectedNextFetchAt, result.NextFetchAt)
+		})
+	}
+}
+
+// failingItemRepo fails to store any item.
+type failingItemRepo struct{}
+
+func (failingItemRepo) Insert(items []*model.Item) (int, error) {
+	return 0, errors.New("dummy store error")
+}
+
+func TestPullAllLogsFailures(t *testing.T) {
+	feedServer := feedtest.NewServer(t, feedtest.Feed{
+		Title: "Test Feed",
+		Items: []feedtest.Item{
+			{Title: "First post", Link: "https://example.com/first", GUID: "first"},
+		},
+	})
+	feed := &model.Feed{ID: 42, Link: ptr.To(feedServer.FeedURL())}
+
+	ctx, logs := withTestLogger()
+	require.NoError(t, pull.NewPuller(stubFeedRepo{feed: feed}, failingItemRepo{}, client.NewFeedClient("")).PullAll(ctx, true))
+
+	record := findLogRecord(t, logs, "failed to store pulled feed")
+	assert.Equal(t, "ERROR", record["level"])
+	assert.EqualValues(t, 42, record["feed_id"])
+	assert.Equal(t, feedServer.FeedURL(), record["feed_link"])
+	assert.Contains(t, record["error"], "dummy store error")
+	assert.Equal(t, "test-request", record["request_id"], "the context logger must be used")
+}
+
+// listFeedRepo serves the feeds, and ignores updates.
+type listFeedRepo struct {
+	feeds []*model.Feed
+}
+
+func (r listFeedRepo) List(filter *repo.FeedListFilter) ([]*model.Feed, error) {
+	return r.feeds, nil
+}
+
+func (r listFeedRepo) Get(id uint) (*model.Feed, error) {
+	for _, f := range r.feeds {
+		if f.ID == id {
+			return f, nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+func (r listFeedRepo) Update(id uint, feed *model.Feed) error {
+	return nil
+}
+
+func TestPullAllReportsOutage(t *testing.T) {
+	feedServer := feedtest.NewServer(t, feedtest.Feed{Title: "Test Feed"})
+	feedServer.SetStatus(http.StatusBadGateway)
+	feedRepo := listFeedRepo{}
+	for i := range 5 {
+		feedRepo.feeds = append(feedRepo.feeds, &model.Feed{
+			ID:   uint(i + 1),
+			Link: ptr.To(fmt.Sprintf("%s?feed=%d", feedServer.FeedURL(), i)),
+		})
+	}
+	puller := pull.NewPuller(feedRepo, repo.NewItem(repotest.NewDB(t)), client.NewFeedClient(""))
+
+	ctx, logs := withTestLogger()
+	require.NoError(t, puller.PullAll(ctx, true))
+	progress := pull.CurrentProgress()
+	assert.Equal(t, 5, progress.Fetched)
+	assert.Equal(t, 5, progress.Failed)
+	assert.True(t, progress.Outage)
+	record := findLogRecord(t, logs, "most feeds failed to refresh, the network or proxy may be down")
+	assert.Equal(t, "WARN", record["level"])
+
+	feedServer.SetStatus(http.StatusOK)
+	require.NoError(t, puller.PullAll(context.Background(), true))
+	assert.False(t, pull.CurrentProgress().Outage)
+}
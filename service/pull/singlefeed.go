@@ -2,11 +2,14 @@ package pull
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/httpx"
+	"github.com/0x2e/fusion/pkg/lang"
 	"github.com/0x2e/fusion/pkg/ptr"
 	"github.com/0x2e/fusion/service/pull/client"
 )
@@ -16,17 +19,46 @@ import (
 // is for request errors (e.g. HTTP errors).
 type ReadFeedItemsFn func(ctx context.Context, feedURL string, options model.FeedRequestOptions) (client.FetchItemsResult, error)
 
-// UpdateFeedInStoreFn is responsible for saving the result of a feed fetch to a data
-// store. If the fetch failed, it records that in the data store. If the fetch
-// succeeds, it stores the latest build time in the data store and adds any new
-// feed items to the datastore.
-type UpdateFeedInStoreFn func(feedID uint, items []*model.Item, lastBuild *time.Time, requestError error) error
+// ErrStore wraps errors of saving a fetched feed, to tell them apart from
+// errors of fetching it.
+var ErrStore = errors.New("failed to store feed")
+
+// FeedMetadata is what a successful fetch tells about the feed itself. Nil
+// fields weren't declared by the feed.
+type FeedMetadata struct {
+	LastBuild     *time.Time
+	SiteURL       *string
+	CanonicalLink *string
+	Description   *string
+}
 
 // SingleFeedRepo represents a datastore for storing information about a feed.
 type SingleFeedRepo interface {
-	InsertItems(items []*model.Item) error
-	RecordSuccess(lastBuild *time.Time) error
+	// InsertItems stores the new items and returns how many there were.
+	InsertItems(items []*model.Item) (int, error)
+	// RecordSuccess records a successful fetch, which found newItems items.
+	// The feed is due again after refresh.
+	RecordSuccess(meta FeedMetadata, newItems int, refresh time.Duration) error
 	RecordFailure(readErr error) error
+	RecordResponse(rawResponse string) error
+}
+
+// unwantedLanguages lists the ISO 639-1 codes of languages whose items are
+// stored as read. It's empty unless configured with SetUnwantedLanguages.
+var unwantedLanguages []string
+
+// SetUnwantedLanguages configures the languages whose new items skip Unread.
+func SetUnwantedLanguages(languages []string) {
+	unwantedLanguages = languages
+}
+
+// snippetLength is the length in runes of the plain-text snippets stored with
+// new items, 0 to store none. SetSnippetLength configures it.
+var snippetLength = 200
+
+// SetSnippetLength configures the length of the snippets of new items.
+func SetSnippetLength(n int) {
+	snippetLength = n
 }
 
 type SingleFeedPuller struct {
@@ -49,7 +81,17 @@ type defaultSingleFeedRepo struct {
 	itemRepo ItemRepo
 }
 
-func (r *defaultSingleFeedRepo) InsertItems(items []*model.Item) error {
+// NewSingleFeedRepo creates a SingleFeedRepo that stores the results of the
+// given feed in feedRepo and itemRepo.
+func NewSingleFeedRepo(feedID uint, feedRepo FeedRepo, itemRepo ItemRepo) SingleFeedRepo {
+	return &defaultSingleFeedRepo{
+		feedID:   feedID,
+		feedRepo: feedRepo,
+		itemRepo: itemRepo,
+	}
+}
+
+func (r *defaultSingleFeedRepo) InsertItems(items []*model.Item) (int, error) {
 	// Set the correct feed ID for all items.
 	for _, item := range items {
 		item.FeedID = r.feedID
@@ -57,12 +99,23 @@ func (r *defaultSingleFeedRepo) InsertItems(items []*model.Item) error {
 	return r.itemRepo.Insert(items)
 }
 
-func (r *defaultSingleFeedRepo) RecordSuccess(lastBuild *time.Time) error {
-	return r.feedRepo.Update(r.feedID, &model.Feed{
-		LastBuild:           lastBuild,
+func (r *defaultSingleFeedRepo) RecordSuccess(meta FeedMetadata, newItems int, refresh time.Duration) error {
+	now := time.Now()
+	data := &model.Feed{
+		LastBuild:           meta.LastBuild,
+		LastFetchedAt:       &now,
+		NextFetchAt:         ptr.To(now.Add(refresh)),
+		SiteURL:             meta.SiteURL,
+		CanonicalLink:       meta.CanonicalLink,
+		Description:         meta.Description,
 		Failure:             ptr.To(""),
+		Blocked:             ptr.To(false),
 		ConsecutiveFailures: 0,
-	})
+	}
+	if newItems > 0 {
+		data.LastItemAt = &now
+	}
+	return r.feedRepo.Update(r.feedID, data)
 }
 
 func (r *defaultSingleFeedRepo) RecordFailure(readErr error) error {
@@ -71,37 +124,105 @@ func (r *defaultSingleFeedRepo) RecordFailure(readErr error) error {
 		return err
 	}
 
+	failures := feed.ConsecutiveFailures + 1
 	return r.feedRepo.Update(r.feedID, &model.Feed{
 		Failure:             ptr.To(readErr.Error()),
-		ConsecutiveFailures: feed.ConsecutiveFailures + 1,
+		Blocked:             ptr.To(errors.Is(readErr, httpx.ErrBlocked)),
+		ConsecutiveFailures: failures,
+		NextFetchAt:         ptr.To(time.Now().Add(CalculateBackoffTime(failures))),
 	})
 }
 
-func (p SingleFeedPuller) Pull(ctx context.Context, feed *model.Feed) error {
-	logger := slog.With("feed_id", feed.ID, "feed_link", ptr.From(feed.Link))
+func (r *defaultSingleFeedRepo) RecordResponse(rawResponse string) error {
+	return r.feedRepo.Update(r.feedID, &model.Feed{
+		LastResponse: &rawResponse,
+	})
+}
+
+func (p SingleFeedPuller) Pull(ctx context.Context, feed *model.Feed) (PullResult, error) {
+	logger := feedLogger(ctx, feed)
+	start := time.Now()
+	settings := feed.Settings()
+	options := feed.FeedRequestOptions
+	options.ReqProxy = settings.ReqProxy
 
 	// We don't exit on error, as we want to record any error in the data store.
-	fetchResult, readErr := p.readFeed(ctx, *feed.Link, feed.FeedRequestOptions)
+	fetchResult, readErr := p.readFeed(ctx, *feed.Link, options)
 	if readErr == nil {
-		logger.Info(fmt.Sprintf("fetched %d items", len(fetchResult.Items)))
+		logger.Info("fetched feed", "items", len(fetchResult.Items), "status_code", fetchResult.StatusCode)
 	} else {
-		logger.Warn("failed to fetch feed", "error", readErr)
+		logger.Warn("failed to fetch feed", "error", readErr, "status_code", fetchResult.StatusCode)
+	}
+
+	origin := originFrom(ctx)
+	sourceURL := nonEmpty(fetchResult.URL)
+	for i, item := range fetchResult.Items {
+		item.Origin = origin
+		item.SourceURL = sourceURL
+		item.Position = ptr.To(i)
+		if settings.IsMonitorOnly() || (item.Language != nil && slices.Contains(unwantedLanguages, *item.Language)) {
+			item.Unread = ptr.To(false)
+		}
+		if item.Content != nil {
+			if snippet := lang.Snippet(*item.Content, snippetLength); snippet != "" {
+				item.Snippet = &snippet
+			}
+		}
 	}
 
-	return p.updateFeedInStore(feed.ID, fetchResult.Items, fetchResult.LastBuild, readErr)
+	if fetchResult.RawResponse != nil {
+		if err := p.repo.RecordResponse(*fetchResult.RawResponse); err != nil {
+			logger.Warn("failed to record raw response", "error", err)
+		}
+	}
+
+	meta := FeedMetadata{
+		LastBuild:     fetchResult.LastBuild,
+		SiteURL:       nonEmpty(fetchResult.SiteURL),
+		CanonicalLink: nonEmpty(fetchResult.CanonicalURL),
+		Description:   nonEmpty(fetchResult.Description),
+	}
+	inserted, err := p.updateFeedInStore(feed.ID, fetchResult.Items, meta, RefreshInterval(feed), readErr)
+	return PullResult{
+		NewItems:   inserted,
+		Duration:   time.Since(start),
+		StatusCode: fetchResult.StatusCode,
+		FetchErr:   readErr,
+	}, err
 }
 
 // updateFeedInStore saves the result of a feed fetch to the data store.
 // If the fetch failed, it records that in the data store.
-// If the fetch succeeds, it stores the latest build time and adds any new feed items.
-func (p SingleFeedPuller) updateFeedInStore(feedID uint, items []*model.Item, lastBuild *time.Time, requestError error) error {
+// If the fetch succeeds, it stores the metadata of the feed, adds any new
+// feed items, and returns how many there were. The feed is due again after
+// refresh.
+func (p SingleFeedPuller) updateFeedInStore(feedID uint, items []*model.Item, meta FeedMetadata, refresh time.Duration, requestError error) (int, error) {
 	if requestError != nil {
-		return p.repo.RecordFailure(requestError)
+		if err := p.repo.RecordFailure(requestError); err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrStore, err)
+		}
+		return 0, nil
 	}
 
-	if err := p.repo.InsertItems(items); err != nil {
-		return err
+	inserted, err := p.repo.InsertItems(items)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	if err := p.repo.RecordSuccess(meta, inserted, refresh); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrStore, err)
 	}
 
-	return p.repo.RecordSuccess(lastBuild)
+	if inserted > 0 {
+		Events.Publish(NewItemsEvent{FeedID: feedID, NewItems: inserted})
+	}
+	return inserted, nil
+}
+
+// nonEmpty returns nil for an empty s, which the feed didn't declare.
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
 }
@@ -3,6 +3,7 @@ package pull_test
 import (
 	"context"
 	"errors"
+	"sort"
 	"testing"
 	"time"
 
@@ -11,6 +12,8 @@ import (
 
 	"github.com/0x2e/fusion/model"
 	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/repo"
+	"github.com/0x2e/fusion/repo/repotest"
 	"github.com/0x2e/fusion/service/pull"
 	"github.com/0x2e/fusion/service/pull/client"
 )
@@ -30,49 +33,34 @@ func (m *mockFeedReader) Read(ctx context.Context, feedURL string, options model
 	return m.result, m.err
 }
 
-// mockSingleFeedRepo is a mock implementation of the SingleFeedRepo interface
-type mockSingleFeedRepo struct {
-	err          error
-	items        []*model.Item
-	lastBuild    *time.Time
-	requestError error
-}
-
-func (m *mockSingleFeedRepo) InsertItems(items []*model.Item) error {
-	if m.err != nil {
-		return m.err
-	}
-	m.items = items
-	return nil
-}
-
-func (m *mockSingleFeedRepo) RecordSuccess(lastBuild *time.Time) error {
-	if m.err != nil {
-		return m.err
-	}
-	m.lastBuild = lastBuild
-	m.requestError = nil
-	return nil
-}
-
-func (m *mockSingleFeedRepo) RecordFailure(readErr error) error {
-	if m.err != nil {
-		return m.err
-	}
-	m.requestError = readErr
-	return nil
+// storedItem holds the item fields that the puller is responsible for.
+type storedItem struct {
+	Title    *string
+	GUID     *string
+	Link     *string
+	Content  *string
+	Snippet  *string
+	Unread   *bool
+	Language *string
 }
 
 func TestSingleFeedPullerPull(t *testing.T) {
 	for _, tt := range []struct {
-		description                string
-		feed                       model.Feed
-		mockFeedReader             *mockFeedReader
-		mockDbErr                  error
-		expectedErrMsg             string
-		expectedStoredItems        []*model.Item
-		expectedStoredLastBuild    *time.Time
-		expectedStoredRequestError error
+		description               string
+		feed                      model.Feed
+		unwantedLanguages         []string
+		mockFeedReader            *mockFeedReader
+		dropItemsTable            bool
+		expectedErrMsg            string
+		expectedErrIs             error
+		expectedStoredItems       []storedItem
+		expectedStoredLastBuild   *time.Time
+		expectedStoredSiteURL     *string
+		expectedStoredCanonical   *string
+		expectedStoredDescription *string
+		expectedStoredFailure     string
+		expectedStoredRawResponse *string
+		expectedNewItemsEvent     *pull.NewItemsEvent
 	}{
 		{
 			description: "successful pull with no errors",
@@ -86,45 +74,153 @@ func TestSingleFeedPullerPull(t *testing.T) {
 			},
 			mockFeedReader: &mockFeedReader{
 				result: client.FetchItemsResult{
-					LastBuild: mustParseTime("2025-01-01T12:00:00Z"),
+					LastBuild:    mustParseTime("2025-01-01T12:00:00Z"),
+					SiteURL:      "https://example.com",
+					CanonicalURL: "https://example.com/feed.xml",
+					Description:  "A test feed",
 					Items: []*model.Item{
 						{
 							Title:   ptr.To("Test Item 1"),
 							GUID:    ptr.To("guid1"),
 							Link:    ptr.To("https://example.com/item1"),
 							Content: ptr.To("Content 1"),
-							FeedID:  42,
 						},
 						{
 							Title:   ptr.To("Test Item 2"),
 							GUID:    ptr.To("guid2"),
 							Link:    ptr.To("https://example.com/item2"),
 							Content: ptr.To("Content 2"),
-							FeedID:  42,
 						},
 					},
 				},
 				err: nil,
 			},
-			mockDbErr: nil,
-			expectedStoredItems: []*model.Item{
+			expectedStoredItems: []storedItem{
 				{
 					Title:   ptr.To("Test Item 1"),
 					GUID:    ptr.To("guid1"),
 					Link:    ptr.To("https://example.com/item1"),
 					Content: ptr.To("Content 1"),
-					FeedID:  42,
+					Snippet: ptr.To("Content 1"),
+					Unread:  ptr.To(true),
 				},
 				{
 					Title:   ptr.To("Test Item 2"),
 					GUID:    ptr.To("guid2"),
 					Link:    ptr.To("https://example.com/item2"),
 					Content: ptr.To("Content 2"),
-					FeedID:  42,
+					Snippet: ptr.To("Content 2"),
+					Unread:  ptr.To(true),
+				},
+			},
+			expectedStoredLastBuild:   mustParseTime("2025-01-01T12:00:00Z"),
+			expectedStoredSiteURL:     ptr.To("https://example.com"),
+			expectedStoredCanonical:   ptr.To("https://example.com/feed.xml"),
+			expectedStoredDescription: ptr.To("A test feed"),
+			expectedNewItemsEvent:     &pull.NewItemsEvent{FeedID: 42, NewItems: 2},
+		},
+		{
+			description: "items that are already stored are not duplicated",
+			feed: model.Feed{
+				ID:   42,
+				Name: ptr.To("Test Feed"),
+				Link: ptr.To("https://example.com/feed.xml"),
+			},
+			mockFeedReader: &mockFeedReader{
+				result: client.FetchItemsResult{
+					LastBuild: mustParseTime("2025-01-01T12:00:00Z"),
+					Items: []*model.Item{
+						{
+							Title: ptr.To("Test Item 1"),
+							GUID:  ptr.To("guid1"),
+						},
+						{
+							Title: ptr.To("Test Item 1 again"),
+							GUID:  ptr.To("guid1"),
+						},
+					},
+				},
+			},
+			expectedStoredItems: []storedItem{
+				{
+					Title:  ptr.To("Test Item 1"),
+					GUID:   ptr.To("guid1"),
+					Unread: ptr.To(true),
 				},
 			},
-			expectedStoredLastBuild:    mustParseTime("2025-01-01T12:00:00Z"),
-			expectedStoredRequestError: nil,
+			expectedStoredLastBuild: mustParseTime("2025-01-01T12:00:00Z"),
+			expectedNewItemsEvent:   &pull.NewItemsEvent{FeedID: 42, NewItems: 1},
+		},
+		{
+			description: "items of monitor-only feeds are stored as read",
+			feed: model.Feed{
+				ID:          42,
+				Name:        ptr.To("Test Feed"),
+				Link:        ptr.To("https://example.com/feed.xml"),
+				MonitorOnly: ptr.To(true),
+			},
+			mockFeedReader: &mockFeedReader{
+				result: client.FetchItemsResult{
+					LastBuild: mustParseTime("2025-01-01T12:00:00Z"),
+					Items: []*model.Item{
+						{
+							Title: ptr.To("Test Item 1"),
+							GUID:  ptr.To("guid1"),
+						},
+					},
+				},
+			},
+			expectedStoredItems: []storedItem{
+				{
+					Title:  ptr.To("Test Item 1"),
+					GUID:   ptr.To("guid1"),
+					Unread: ptr.To(false),
+				},
+			},
+			expectedStoredLastBuild: mustParseTime("2025-01-01T12:00:00Z"),
+			expectedNewItemsEvent:   &pull.NewItemsEvent{FeedID: 42, NewItems: 1},
+		},
+		{
+			description: "items in unwanted languages are stored as read",
+			feed: model.Feed{
+				ID:   42,
+				Name: ptr.To("Test Feed"),
+				Link: ptr.To("https://example.com/feed.xml"),
+			},
+			unwantedLanguages: []string{"de"},
+			mockFeedReader: &mockFeedReader{
+				result: client.FetchItemsResult{
+					LastBuild: mustParseTime("2025-01-01T12:00:00Z"),
+					Items: []*model.Item{
+						{
+							Title:    ptr.To("Test Item 1"),
+							GUID:     ptr.To("guid1"),
+							Language: ptr.To("de"),
+						},
+						{
+							Title:    ptr.To("Test Item 2"),
+							GUID:     ptr.To("guid2"),
+							Language: ptr.To("en"),
+						},
+					},
+				},
+			},
+			expectedStoredItems: []storedItem{
+				{
+					Title:    ptr.To("Test Item 1"),
+					GUID:     ptr.To("guid1"),
+					Language: ptr.To("de"),
+					Unread:   ptr.To(false),
+				},
+				{
+					Title:    ptr.To("Test Item 2"),
+					GUID:     ptr.To("guid2"),
+					Language: ptr.To("en"),
+					Unread:   ptr.To(true),
+				},
+			},
+			expectedStoredLastBuild: mustParseTime("2025-01-01T12:00:00Z"),
+			expectedNewItemsEvent:   &pull.NewItemsEvent{FeedID: 42, NewItems: 2},
 		},
 		{
 			description: "readFeed returns error",
@@ -136,10 +232,32 @@ func TestSingleFeedPullerPull(t *testing.T) {
 			mockFeedReader: &mockFeedReader{
 				err: errors.New("dummy feed read error"),
 			},
-			expectedErrMsg:             "",
-			expectedStoredItems:        nil,
-			expectedStoredLastBuild:    nil,
-			expectedStoredRequestError: errors.New("dummy feed read error"),
+			expectedErrMsg:          "",
+			expectedStoredItems:     []storedItem{},
+			expectedStoredLastBuild: nil,
+			expectedStoredFailure:   "dummy feed read error",
+		},
+		{
+			description: "raw response is recorded even when readFeed returns error",
+			feed: model.Feed{
+				ID:   42,
+				Name: ptr.To("Test Feed"),
+				Link: ptr.To("https://example.com/feed.xml"),
+				FeedRequestOptions: model.FeedRequestOptions{
+					CaptureResponse: ptr.To(true),
+				},
+			},
+			mockFeedReader: &mockFeedReader{
+				result: client.FetchItemsResult{
+					RawResponse: ptr.To("HTTP/1.1 200 OK\r\n\r\n<html>Just a moment...</html>"),
+				},
+				err: errors.New("Failed to detect feed type"),
+			},
+			expectedErrMsg:            "",
+			expectedStoredItems:       []storedItem{},
+			expectedStoredLastBuild:   nil,
+			expectedStoredFailure:     "Failed to detect feed type",
+			expectedStoredRawResponse: ptr.To("HTTP/1.1 200 OK\r\n\r\n<html>Just a moment...</html>"),
 		},
 		{
 			description: "readFeed succeeds but updateFeedInStore fails",
@@ -157,29 +275,44 @@ func TestSingleFeedPullerPull(t *testing.T) {
 							GUID:    ptr.To("guid1"),
 							Link:    ptr.To("https://example.com/item1"),
 							Content: ptr.To("Content 1"),
-							FeedID:  42,
 						},
 					},
 				},
 				err: nil,
 			},
-			mockDbErr:                  errors.New("dummy database error"),
-			expectedErrMsg:             "dummy database error",
-			expectedStoredItems:        nil,
-			expectedStoredLastBuild:    nil,
-			expectedStoredRequestError: nil,
+			dropItemsTable:          true,
+			expectedErrMsg:          "no such table: items",
+			expectedErrIs:           pull.ErrStore,
+			expectedStoredItems:     nil,
+			expectedStoredLastBuild: nil,
 		},
 	} {
 		t.Run(tt.description, func(t *testing.T) {
-			mockRepo := &mockSingleFeedRepo{
-				err: tt.mockDbErr,
+			db := repotest.NewDB(t)
+			feedRepo := repo.NewFeed(db)
+			itemRepo := repo.NewItem(db)
+
+			stored := tt.feed
+			stored.GroupID = ptr.To(uint(1))
+			require.NoError(t, feedRepo.Create([]*model.Feed{&stored}))
+			if tt.dropItemsTable {
+				require.NoError(t, db.Migrator().DropTable(&model.Item{}))
 			}
+			pull.SetUnwantedLanguages(tt.unwantedLanguages)
+			t.Cleanup(func() { pull.SetUnwantedLanguages(nil) })
 
-			err := pull.NewSingleFeedPuller(tt.mockFeedReader.Read, mockRepo).Pull(context.Background(), &tt.feed)
+			events, unsubscribe := pull.Events.Subscribe()
+			defer unsubscribe()
+
+			singleFeedRepo := pull.NewSingleFeedRepo(tt.feed.ID, feedRepo, itemRepo)
+			result, err := pull.NewSingleFeedPuller(tt.mockFeedReader.Read, singleFeedRepo).Pull(context.Background(), &tt.feed)
 
 			if tt.expectedErrMsg != "" {
 				require.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedErrMsg)
+				if tt.expectedErrIs != nil {
+					assert.ErrorIs(t, err, tt.expectedErrIs)
+				}
 			} else {
 				require.NoError(t, err)
 			}
@@ -187,11 +320,187 @@ func TestSingleFeedPullerPull(t *testing.T) {
 			assert.Equal(t, *tt.feed.Link, tt.mockFeedReader.lastFeedURL)
 			assert.Equal(t, tt.feed.FeedRequestOptions, tt.mockFeedReader.lastOptions)
 
-			assert.Equal(t, tt.expectedStoredRequestError, mockRepo.requestError)
-			assert.Equal(t, tt.expectedStoredItems, mockRepo.items)
-			assert.Equal(t, tt.expectedStoredLastBuild, mockRepo.lastBuild)
+			if !tt.dropItemsTable {
+				assert.Equal(t, tt.expectedStoredItems, listStoredItems(t, itemRepo, tt.feed.ID))
+			}
+
+			feed, err := feedRepo.Get(tt.feed.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStoredFailure, ptr.From(feed.Failure))
+			if tt.expectedStoredLastBuild == nil {
+				assert.Nil(t, feed.LastBuild)
+			} else if assert.NotNil(t, feed.LastBuild) {
+				assert.True(t, tt.expectedStoredLastBuild.Equal(*feed.LastBuild), "last build %v", feed.LastBuild)
+			}
+			fetched := tt.expectedErrMsg == "" && tt.expectedStoredFailure == ""
+			assert.Equal(t, fetched, feed.LastFetchedAt != nil, "last fetched at %v", feed.LastFetchedAt)
+			assert.Equal(t, result.NewItems > 0, feed.LastItemAt != nil, "last item at %v", feed.LastItemAt)
+			switch {
+			case fetched:
+				if assert.NotNil(t, feed.NextFetchAt) {
+					assert.WithinDuration(t, time.Now().Add(30*time.Minute), *feed.NextFetchAt, time.Minute)
+				}
+			case tt.expectedStoredFailure != "":
+				if assert.NotNil(t, feed.NextFetchAt) {
+					assert.WithinDuration(t, time.Now().Add(pull.CalculateBackoffTime(1)), *feed.NextFetchAt, time.Minute)
+				}
+			default:
+				assert.Nil(t, feed.NextFetchAt)
+			}
+			assert.Equal(t, tt.expectedStoredSiteURL, feed.SiteURL)
+			assert.Equal(t, tt.expectedStoredCanonical, feed.CanonicalLink)
+			assert.Equal(t, tt.expectedStoredDescription, feed.Description)
+			assert.Equal(t, tt.expectedStoredRawResponse, feed.LastResponse)
+
+			expectedNewItems := 0
+			if tt.expectedNewItemsEvent != nil {
+				expectedNewItems = tt.expectedNewItemsEvent.NewItems
+			}
+			assert.Equal(t, expectedNewItems, result.NewItems)
+			assert.Equal(t, tt.expectedStoredFailure != "", result.FetchErr != nil, "fetch error %v", result.FetchErr)
+
+			select {
+			case event := <-events:
+				assert.Equal(t, tt.expectedNewItemsEvent, &event)
+			default:
+				assert.Nil(t, tt.expectedNewItemsEvent, "expected a new items event")
+			}
+		})
+	}
+}
+
+func TestSingleFeedPullerPullLogsFetchFailures(t *testing.T) {
+	db := repotest.NewDB(t)
+	feed := model.Feed{
+		ID:      42,
+		Name:    ptr.To("Test Feed"),
+		Link:    ptr.To("https://example.com/feed.xml"),
+		GroupID: ptr.To(uint(1)),
+	}
+	require.NoError(t, repo.NewFeed(db).Create([]*model.Feed{&feed}))
+	reader := &mockFeedReader{
+		result: client.FetchItemsResult{StatusCode: 500},
+		err:    errors.New("got status code 500"),
+	}
+	singleFeedRepo := pull.NewSingleFeedRepo(feed.ID, repo.NewFeed(db), repo.NewItem(db))
+
+	ctx, logs := withTestLogger()
+	_, err := pull.NewSingleFeedPuller(reader.Read, singleFeedRepo).Pull(ctx, &feed)
+	require.NoError(t, err)
+
+	record := findLogRecord(t, logs, "failed to fetch feed")
+	assert.Equal(t, "WARN", record["level"])
+	assert.EqualValues(t, 42, record["feed_id"])
+	assert.Equal(t, "https://example.com/feed.xml", record["feed_link"])
+	assert.EqualValues(t, 500, record["status_code"])
+	assert.Equal(t, "got status code 500", record["error"])
+	assert.Equal(t, "test-request", record["request_id"], "the context logger must be used")
+}
+
+func TestSingleFeedPullerPullUsesGroupSettings(t *testing.T) {
+	db := repotest.NewDB(t)
+	groupRepo := repo.NewGroup(db)
+	feedRepo := repo.NewFeed(db)
+	itemRepo := repo.NewItem(db)
+	group := &model.Group{Name: ptr.To("Behind a proxy")}
+	require.NoError(t, groupRepo.Create(group))
+	require.NoError(t, groupRepo.UpdateSettings(group.ID, model.Settings{
+		ReqProxy:        ptr.To("http://proxy.example.com"),
+		RefreshInterval: ptr.To(120),
+		MonitorOnly:     ptr.To(true),
+	}))
+	feeds := []*model.Feed{
+		{Name: ptr.To("Inherits"), Link: ptr.To("https://example.com/feed.xml"), GroupID: &group.ID},
+		{Name: ptr.To("Overrides"), Link: ptr.To("https://example.org/feed.xml"), GroupID: &group.ID},
+	}
+	require.NoError(t, feedRepo.Create(feeds))
+	require.NoError(t, feedRepo.UpdateSettings(feeds[1].ID, model.Settings{
+		ReqProxy:    ptr.To("http://other-proxy.example.com"),
+		MonitorOnly: ptr.To(false),
+	}))
+
+	for _, tt := range []struct {
+		feedID          uint
+		expectedProxy   string
+		expectedUnread  bool
+		expectedRefresh time.Duration
+	}{
+		{feeds[0].ID, "http://proxy.example.com", false, 2 * time.Hour},
+		{feeds[1].ID, "http://other-proxy.example.com", true, 2 * time.Hour},
+	} {
+		feed, err := feedRepo.Get(tt.feedID)
+		require.NoError(t, err)
+		reader := &mockFeedReader{
+			result: client.FetchItemsResult{
+				Items: []*model.Item{{Title: ptr.To("Item"), GUID: ptr.To("guid")}},
+			},
+		}
+		singleFeedRepo := pull.NewSingleFeedRepo(feed.ID, feedRepo, itemRepo)
+		_, err = pull.NewSingleFeedPuller(reader.Read, singleFeedRepo).Pull(context.Background(), feed)
+		require.NoError(t, err)
+
+		assert.Equal(t, tt.expectedProxy, ptr.From(reader.lastOptions.ReqProxy), *feed.Name)
+		stored := listStoredItems(t, itemRepo, feed.ID)
+		require.Len(t, stored, 1)
+		assert.Equal(t, tt.expectedUnread, *stored[0].Unread, *feed.Name)
+		feed, err = feedRepo.Get(tt.feedID)
+		require.NoError(t, err)
+		if assert.NotNil(t, feed.NextFetchAt) {
+			assert.WithinDuration(t, time.Now().Add(tt.expectedRefresh), *feed.NextFetchAt, time.Minute, *feed.Name)
+		}
+	}
+}
+
+func TestSingleFeedPullerPullRecordsOrigin(t *testing.T) {
+	db := repotest.NewDB(t)
+	feedRepo := repo.NewFeed(db)
+	itemRepo := repo.NewItem(db)
+	feed := &model.Feed{Name: ptr.To("Moved"), Link: ptr.To("https://example.com/feed.xml")}
+	require.NoError(t, feedRepo.Create([]*model.Feed{feed}))
+	reader := &mockFeedReader{
+		result: client.FetchItemsResult{
+			URL:   "https://example.org/feed.xml",
+			Items: []*model.Item{{Title: ptr.To("Item"), GUID: ptr.To("guid")}},
+		},
+	}
+
+	ctx := pull.WithOrigin(context.Background(), model.ItemOriginRefresh)
+	singleFeedRepo := pull.NewSingleFeedRepo(feed.ID, feedRepo, itemRepo)
+	_, err := pull.NewSingleFeedPuller(reader.Read, singleFeedRepo).Pull(ctx, feed)
+	require.NoError(t, err)
+
+	items, _, err := itemRepo.List(repo.ItemFilter{FeedID: &feed.ID}, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	item, err := itemRepo.Get(items[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ItemOriginRefresh, ptr.From(item.Origin))
+	assert.Equal(t, "https://example.org/feed.xml", ptr.From(item.SourceURL))
+}
+
+// listStoredItems returns the items of the feed ordered by GUID.
+func listStoredItems(t *testing.T, itemRepo *repo.Item, feedID uint) []storedItem {
+	t.Helper()
+
+	items, _, err := itemRepo.List(repo.ItemFilter{FeedID: &feedID}, 1, 100)
+	require.NoError(t, err)
+	sort.Slice(items, func(i, j int) bool {
+		return *items[i].GUID < *items[j].GUID
+	})
+
+	res := make([]storedItem, 0, len(items))
+	for _, v := range items {
+		res = append(res, storedItem{
+			Title:    v.Title,
+			GUID:     v.GUID,
+			Link:     v.Link,
+			Content:  v.Content,
+			Snippet:  v.Snippet,
+			Unread:   v.Unread,
+			Language: v.Language,
 		})
 	}
+	return res
 }
 
 func mustParseTime(iso8601 string) *time.Time {
@@ -0,0 +1,39 @@
+// Package score ranks items for the Highlights view.
+package score
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+)
+
+// halfLife is how long it takes for an item's score to halve.
+const halfLife = 24 * time.Hour
+
+// Score rates item by its feed's weight and the keywords it mentions, decayed
+// by its age. An item with no boosts scores 1 when it's brand new. Negative
+// weights can push the score below zero.
+func Score(item *model.Item, keywords []*model.ScoreKeyword, now time.Time) float64 {
+	base := 1 + float64(ptr.From(item.Feed.Weight))
+
+	text := strings.ToLower(ptr.From(item.Title) + "\n" + ptr.From(item.Content))
+	for _, k := range keywords {
+		keyword := strings.ToLower(ptr.From(k.Keyword))
+		if keyword != "" && strings.Contains(text, keyword) {
+			base += float64(k.Weight)
+		}
+	}
+
+	published := item.CreatedAt
+	if item.PubDate != nil && !item.PubDate.After(now) {
+		published = *item.PubDate
+	}
+	age := now.Sub(published)
+	if age < 0 {
+		age = 0
+	}
+	return base * math.Pow(0.5, float64(age)/float64(halfLife))
+}
@@ -0,0 +1,74 @@
+package score_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/0x2e/fusion/model"
+	"github.com/0x2e/fusion/pkg/ptr"
+	"github.com/0x2e/fusion/service/score"
+)
+
+func TestScore(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	keywords := []*model.ScoreKeyword{
+		{Keyword: ptr.To("Golang"), Weight: 2},
+		{Keyword: ptr.To("crypto"), Weight: -3},
+	}
+
+	for _, tt := range []struct {
+		description   string
+		item          *model.Item
+		expectedScore float64
+	}{
+		{
+			description: "new item without boosts",
+			item: &model.Item{
+				Title:   ptr.To("Hello"),
+				PubDate: ptr.To(now),
+			},
+			expectedScore: 1,
+		},
+		{
+			description: "score halves after a day",
+			item: &model.Item{
+				Title:   ptr.To("Hello"),
+				PubDate: ptr.To(now.Add(-24 * time.Hour)),
+			},
+			expectedScore: 0.5,
+		},
+		{
+			description: "feed weight and keywords are case-insensitive boosts",
+			item: &model.Item{
+				Title:   ptr.To("Why I like golang"),
+				PubDate: ptr.To(now),
+				Feed:    model.Feed{Weight: ptr.To(1)},
+			},
+			expectedScore: 4,
+		},
+		{
+			description: "negative keywords bury items",
+			item: &model.Item{
+				Title:   ptr.To("News"),
+				Content: ptr.To("All about crypto"),
+				PubDate: ptr.To(now),
+			},
+			expectedScore: -2,
+		},
+		{
+			description: "future pub date falls back to creation time",
+			item: &model.Item{
+				Title:     ptr.To("Hello"),
+				PubDate:   ptr.To(now.Add(time.Hour)),
+				CreatedAt: now.Add(-24 * time.Hour),
+			},
+			expectedScore: 0.5,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.InDelta(t, tt.expectedScore, score.Score(tt.item, keywords, now), 1e-9)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Package tombstone expires, on a schedule, the tombstones of deleted items
+// that their feed no longer lists, see repo.Item.Delete.
+package tombstone
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/0x2e/fusion/pkg/errreport"
+)
+
+var (
+	interval = 24 * time.Hour
+	// retention is how long a tombstone is kept after its feed last listed
+	// the item. Feeds that bring items back after that are rare.
+	retention = 30 * 24 * time.Hour
+)
+
+type Repo interface {
+	ExpireTombstones(before time.Time) (int, error)
+}
+
+type Expirer struct {
+	repo Repo
+}
+
+func NewExpirer(repo Repo) *Expirer {
+	return &Expirer{
+		repo: repo,
+	}
+}
+
+func (e *Expirer) Run() {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.expireScheduled()
+
+		<-ticker.C
+	}
+}
+
+// expireScheduled runs a scheduled expiry, recovering from panics so that
+// later runs still happen.
+func (e *Expirer) expireScheduled() {
+	defer errreport.Recover(context.Background())
+
+	expired, err := e.repo.ExpireTombstones(time.Now().Add(-retention))
+	if err != nil {
+		slog.Error("failed to expire item tombstones", "error", err)
+		return
+	}
+	if expired > 0 {
+		slog.Info("expired item tombstones", "count", expired)
+	}
+}